@@ -95,6 +95,7 @@ require (
 	k8s.io/apimachinery v0.22.0
 	k8s.io/cli-runtime v0.19.4
 	k8s.io/client-go v0.22.0
+	k8s.io/kubectl v0.19.4
 	k8s.io/kubernetes v1.13.0
 	rsc.io/letsencrypt v0.0.3 // indirect
 )
@@ -104,6 +105,7 @@ replace (
 	k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.19.4
 	k8s.io/apimachinery => k8s.io/apimachinery v0.19.4
 	k8s.io/client-go => k8s.io/client-go v0.19.4
+	k8s.io/kubectl => k8s.io/kubectl v0.19.4
 	sigs.k8s.io/controller-runtime => sigs.k8s.io/controller-runtime v0.7.0
 )
 
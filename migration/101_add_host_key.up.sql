@@ -0,0 +1,14 @@
+ALTER TABLE
+    `ko`.`ko_host`
+ADD
+    COLUMN `host_key` TEXT NULL
+AFTER
+    `architecture`,
+ADD
+    COLUMN `host_key_status` VARCHAR(64) NULL
+AFTER
+    `host_key`,
+ADD
+    COLUMN `pending_host_key` TEXT NULL
+AFTER
+    `host_key_status`;
\ No newline at end of file
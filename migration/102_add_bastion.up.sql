@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `ko_bastion`
+(
+    `created_at`    datetime     DEFAULT NULL,
+    `updated_at`    datetime     DEFAULT NULL,
+    `id`            varchar(64)  NOT NULL,
+    `name`          varchar(256) NOT NULL,
+    `ip`            varchar(128) NOT NULL,
+    `port`          int(64)      DEFAULT NULL,
+    `credential_id` varchar(64)  DEFAULT NULL,
+    `host_key`      text,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name` (`name`)
+);
+
+ALTER TABLE `ko`.`ko_host` ADD COLUMN `bastion_id` VARCHAR(64) NULL AFTER `zone_id`;
+ALTER TABLE `ko`.`ko_zone` ADD COLUMN `bastion_id` VARCHAR(64) NULL AFTER `ip_pool_id`;
+ALTER TABLE `ko`.`ko_project` ADD COLUMN `bastion_id` VARCHAR(64) NULL AFTER `description`;
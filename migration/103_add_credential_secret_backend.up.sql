@@ -0,0 +1,7 @@
+ALTER TABLE
+    `ko`.`ko_credential`
+ADD
+    COLUMN `secret_backend` VARCHAR(64) NULL
+AFTER
+    `type`;
+UPDATE `ko`.`ko_credential` SET `secret_backend` = 'DB';
@@ -0,0 +1,33 @@
+ALTER TABLE `ko`.`ko_host` ADD COLUMN `maintenance` tinyint(1) DEFAULT 0 AFTER `pending_host_key`;
+
+CREATE TABLE IF NOT EXISTS `ko_host_maintenance`
+(
+    `created_at` datetime    DEFAULT NULL,
+    `updated_at` datetime    DEFAULT NULL,
+    `id`         varchar(64) NOT NULL,
+    `host_id`    varchar(64) DEFAULT NULL,
+    `cluster_id` varchar(64) DEFAULT NULL,
+    `node_name`  varchar(256) DEFAULT NULL,
+    `patch`      tinyint(1)  DEFAULT 0,
+    `reboot`     tinyint(1)  DEFAULT 0,
+    `status`     varchar(64) DEFAULT NULL,
+    `message`    text,
+    `start_time` datetime    DEFAULT NULL,
+    `end_time`   datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_host_maintenance_step`
+(
+    `created_at`     datetime    DEFAULT NULL,
+    `updated_at`     datetime    DEFAULT NULL,
+    `id`             varchar(64) NOT NULL,
+    `maintenance_id` varchar(64) DEFAULT NULL,
+    `name`           varchar(64) DEFAULT NULL,
+    `status`         varchar(64) DEFAULT NULL,
+    `message`        text,
+    `order_num`      int(11)     DEFAULT NULL,
+    `start_time`     datetime    DEFAULT NULL,
+    `end_time`       datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`)
+);
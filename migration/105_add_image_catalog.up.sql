@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `ko_image`
+(
+    `created_at`   datetime     DEFAULT NULL,
+    `updated_at`   datetime     DEFAULT NULL,
+    `id`           varchar(64)  NOT NULL,
+    `name`         varchar(256) NOT NULL,
+    `os_family`    varchar(64)  DEFAULT NULL,
+    `os_version`   varchar(64)  DEFAULT NULL,
+    `architecture` varchar(64)  DEFAULT NULL,
+    `checksum`     varchar(256) DEFAULT NULL,
+    `artifacts`    text,
+    `builtin`      tinyint(1)   DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name` (`name`)
+);
+
+INSERT INTO `ko`.`ko_image`(`id`, `name`, `os_family`, `os_version`, `architecture`, `checksum`, `artifacts`, `builtin`, `created_at`, `updated_at`) VALUES (
+    UUID(), 'kubeoperator_centos_7.6.1810', 'CentOS', '7.6', 'x86_64', '', '{\"vSphere\":{\"ovf\":\"/repository/oss-proxy/terraform/images/vsphere/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810.ovf\",\"vmdk\":\"/repository/oss-proxy/terraform/images/vsphere/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810-1.vmdk\"},\"OpenStack\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/openstack/kubeoperator_centos_7.6.1810-1.qcow2\"},\"FusionCompute\":{\"ovf\":\"/repository/oss-proxy/terraform/images/fusioncompute/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810.ovf\",\"vhd\":\"/repository/oss-proxy/terraform/images/fusioncompute/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810-vda.vhd\"},\"Proxmox\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/openstack/kubeoperator_centos_7.6.1810-1.qcow2\"},\"Libvirt\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/openstack/kubeoperator_centos_7.6.1810-1.qcow2\"}}', 1, date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR));
+
+ALTER TABLE `ko`.`ko_plan` ADD COLUMN `image_id` varchar(64) DEFAULT NULL AFTER `deploy_template`;
+ALTER TABLE `ko`.`ko_vm_config` ADD COLUMN `image_id` varchar(64) DEFAULT NULL AFTER `provider`;
+ALTER TABLE `ko`.`ko_host` ADD COLUMN `image` varchar(256) DEFAULT NULL AFTER `architecture`;
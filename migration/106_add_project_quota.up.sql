@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `ko_project_quota`
+(
+    `created_at`   datetime    DEFAULT NULL,
+    `updated_at`   datetime    DEFAULT NULL,
+    `id`           varchar(64) NOT NULL,
+    `project_id`   varchar(64) NOT NULL,
+    `max_clusters` int(11)     DEFAULT 0,
+    `max_nodes`    int(11)     DEFAULT 0,
+    `max_cpu`      int(11)     DEFAULT 0,
+    `max_memory`   int(11)     DEFAULT 0,
+    `max_ips`      int(11)     DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `project_id` (`project_id`)
+);
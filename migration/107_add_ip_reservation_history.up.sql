@@ -0,0 +1,25 @@
+ALTER TABLE `ko`.`ko_ip`
+    ADD COLUMN `reserved_for` varchar(255) NULL AFTER `cluster_id`,
+    ADD COLUMN `host_name` varchar(255) NULL AFTER `reserved_for`,
+    ADD COLUMN `mac` varchar(64) NULL AFTER `host_name`,
+    ADD COLUMN `conflict` tinyint(1) DEFAULT 0 AFTER `mac`;
+
+ALTER TABLE `ko`.`ko_ip_pool`
+    ADD COLUMN `exclusions` text NULL AFTER `subnet`;
+
+CREATE TABLE IF NOT EXISTS `ko_ip_history`
+(
+    `created_at`   datetime     DEFAULT NULL,
+    `updated_at`   datetime     DEFAULT NULL,
+    `id`           varchar(64)  NOT NULL,
+    `ip_id`        varchar(64)  DEFAULT NULL,
+    `address`      varchar(255) DEFAULT NULL,
+    `ip_pool_id`   varchar(64)  DEFAULT NULL,
+    `cluster_id`   varchar(64)  DEFAULT NULL,
+    `cluster_name` varchar(255) DEFAULT NULL,
+    `host_name`    varchar(255) DEFAULT NULL,
+    `action`       varchar(64)  DEFAULT NULL,
+    `message`      varchar(255) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `address` (`address`)
+);
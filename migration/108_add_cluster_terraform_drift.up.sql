@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_terraform_state`
+(
+    `created_at` datetime    DEFAULT NULL,
+    `updated_at` datetime    DEFAULT NULL,
+    `id`         varchar(64) NOT NULL,
+    `cluster_id` varchar(64) NOT NULL,
+    `action`     varchar(64) DEFAULT NULL,
+    `success`    tinyint(1)  DEFAULT 0,
+    `added`      int(11)     DEFAULT 0,
+    `changed`    int(11)     DEFAULT 0,
+    `destroyed`  int(11)     DEFAULT 0,
+    `hosts`      text        NULL,
+    `message`    text        NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `cluster_id` (`cluster_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_cluster_drift`
+(
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    `id`         varchar(64)  NOT NULL,
+    `cluster_id` varchar(64)  NOT NULL,
+    `host_name`  varchar(255) DEFAULT NULL,
+    `type`       varchar(64)  DEFAULT NULL,
+    `expected`   varchar(255) DEFAULT NULL,
+    `actual`     varchar(255) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `cluster_id` (`cluster_id`)
+);
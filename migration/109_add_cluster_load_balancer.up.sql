@@ -0,0 +1,21 @@
+ALTER TABLE `ko`.`ko_f5_setting` ADD COLUMN `password` varchar(256) NULL AFTER `user`;
+ALTER TABLE `ko`.`ko_f5_setting` ADD COLUMN `message` text NULL AFTER `status`;
+
+CREATE TABLE IF NOT EXISTS `ko_cluster_load_balancer`
+(
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    `id`         varchar(64)  NOT NULL,
+    `cluster_id` varchar(64)  NOT NULL,
+    `provider`   varchar(64)  DEFAULT NULL,
+    `vip`        varchar(64)  DEFAULT NULL,
+    `port`       int(11)      DEFAULT 0,
+    `hosts`      varchar(1024) DEFAULT NULL,
+    `vars`       text         NULL,
+    `password`   varchar(256) DEFAULT NULL,
+    `members`    varchar(1024) DEFAULT NULL,
+    `status`     varchar(64)  DEFAULT NULL,
+    `message`    text         NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `cluster_id` (`cluster_id`)
+);
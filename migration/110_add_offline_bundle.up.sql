@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `ko_offline_bundle`
+(
+    `created_at`   datetime      DEFAULT NULL,
+    `updated_at`   datetime      DEFAULT NULL,
+    `id`           varchar(64)   NOT NULL,
+    `name`         varchar(255)  NOT NULL,
+    `architecture` varchar(64)   DEFAULT NULL,
+    `images`       int(11)       DEFAULT 0,
+    `charts`       int(11)       DEFAULT 0,
+    `binaries`     int(11)       DEFAULT 0,
+    `manifests`    varchar(1024) DEFAULT NULL,
+    `status`       varchar(64)   DEFAULT NULL,
+    `message`      text          NULL,
+    PRIMARY KEY (`id`)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_addon`
+(
+    `created_at`   datetime     DEFAULT NULL,
+    `updated_at`   datetime     DEFAULT NULL,
+    `id`           varchar(64)  NOT NULL,
+    `name`         varchar(64)  NOT NULL,
+    `version`      varchar(64)  DEFAULT NULL,
+    `describe`     varchar(255) DEFAULT NULL,
+    `logo`         varchar(255) DEFAULT NULL,
+    `frame`        tinyint(1)   DEFAULT 0,
+    `url`          varchar(255) DEFAULT NULL,
+    `proxy_type`   varchar(64)  DEFAULT NULL,
+    `architecture` varchar(64)  DEFAULT NULL,
+    `values`       text         NULL,
+    `ready_kind`   varchar(64)  DEFAULT NULL,
+    `ready_name`   varchar(255) DEFAULT NULL,
+    `service_name` varchar(255) DEFAULT NULL,
+    `service_port` int(11)      DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `name` (`name`)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE `ko`.`ko_cluster_secret` ADD COLUMN `alert_token` text NULL AFTER `kubernetes_token`;
+
+UPDATE ko_cluster_tool_detail SET vars = JSON_SET(vars, '$.alertmanager_image_name', 'prom/alertmanager', '$.alertmanager_image_tag', 'v0.20.0') WHERE name = 'prometheus' AND version = 'v2.18.1';
+UPDATE ko_cluster_tool_detail SET vars = JSON_SET(vars, '$.alertmanager_image_name', 'prom/alertmanager', '$.alertmanager_image_tag', 'v0.21.0') WHERE name = 'prometheus' AND version = 'v2.20.1';
@@ -0,0 +1 @@
+ALTER TABLE `ko`.`ko_cluster_tool` ADD COLUMN `release_revision` int(11) DEFAULT 0 AFTER `proxy_type`;
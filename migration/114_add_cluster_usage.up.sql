@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_usage`
+(
+    `created_at`         datetime     DEFAULT NULL,
+    `updated_at`         datetime     DEFAULT NULL,
+    `id`                 varchar(64)  NOT NULL,
+    `date`               varchar(10)  NOT NULL,
+    `cluster_id`         varchar(64)  NOT NULL,
+    `cluster_name`       varchar(255) DEFAULT NULL,
+    `scope`              varchar(64)  NOT NULL,
+    `name`               varchar(255) NOT NULL,
+    `cpu_requested`      bigint(20)   DEFAULT 0,
+    `cpu_allocatable`    bigint(20)   DEFAULT 0,
+    `cpu_used`           bigint(20)   DEFAULT 0,
+    `cpu_used_peak`      bigint(20)   DEFAULT 0,
+    `memory_requested`   bigint(20)   DEFAULT 0,
+    `memory_allocatable` bigint(20)   DEFAULT 0,
+    `memory_used`        bigint(20)   DEFAULT 0,
+    `memory_used_peak`   bigint(20)   DEFAULT 0,
+    `samples`            int(11)      DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `usage` (`date`, `cluster_id`, `scope`, `name`),
+    KEY `cluster_id` (`cluster_id`)
+);
@@ -0,0 +1,21 @@
+ALTER TABLE `ko`.`ko_cluster_usage`
+    ADD COLUMN `storage_class` varchar(255) NOT NULL DEFAULT '' AFTER `name`,
+    ADD COLUMN `storage_requested` bigint(20) DEFAULT 0 AFTER `memory_used_peak`,
+    DROP INDEX `usage`,
+    ADD UNIQUE KEY `usage` (`date`, `cluster_id`, `scope`, `name`, `storage_class`);
+
+CREATE TABLE IF NOT EXISTS `ko_cost_rate`
+(
+    `created_at`     datetime       DEFAULT NULL,
+    `updated_at`     datetime       DEFAULT NULL,
+    `id`             varchar(64)    NOT NULL,
+    `type`           varchar(64)    NOT NULL,
+    `name`           varchar(255)   NOT NULL DEFAULT '',
+    `cpu_month`      decimal(12, 4) DEFAULT 0,
+    `memory_month`   decimal(12, 4) DEFAULT 0,
+    `gpu_month`      decimal(12, 4) DEFAULT 0,
+    `storage_month`  decimal(12, 4) DEFAULT 0,
+    `instance_month` decimal(12, 4) DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `rate` (`type`, `name`)
+);
@@ -0,0 +1,23 @@
+ALTER TABLE `ko`.`ko_cluster_istio`
+    ADD COLUMN `revision` varchar(64) NOT NULL DEFAULT '' AFTER `version`,
+    ADD COLUMN `canary_version` varchar(64) NOT NULL DEFAULT '' AFTER `revision`,
+    ADD COLUMN `canary_revision` varchar(64) NOT NULL DEFAULT '' AFTER `canary_version`;
+
+CREATE TABLE IF NOT EXISTS `ko_cluster_istio_detail`
+(
+    `created_at`    datetime     DEFAULT NULL,
+    `updated_at`    datetime     DEFAULT NULL,
+    `id`            varchar(64)  NOT NULL,
+    `name`          varchar(255) NOT NULL,
+    `version`       varchar(255) NOT NULL,
+    `chart_version` varchar(255) DEFAULT NULL,
+    `vars`          mediumtext,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `detail` (`name`, `version`)
+);
+
+INSERT INTO `ko`.`ko_cluster_istio_detail`(`id`, `name`, `version`, `chart_version`, `vars`, `created_at`, `updated_at`) VALUES
+    (UUID(), 'base', 'v1.8.0', '1.8.0', '{}', date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR)),
+    (UUID(), 'pilot', 'v1.8.0', '1.8.0', '{\"pilot_image_name\":\"istio/pilot\",\"pilot_image_tag\":\"1.8.0\",\"proxy_image_name\":\"istio/proxyv2\",\"proxy_image_tag\":\"1.8.0\"}', date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR)),
+    (UUID(), 'ingress', 'v1.8.0', '1.8.0', '{\"proxy_image_name\":\"istio/proxyv2\",\"proxy_image_tag\":\"1.8.0\"}', date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR)),
+    (UUID(), 'egress', 'v1.8.0', '1.8.0', '{\"proxy_image_name\":\"istio/proxyv2\",\"proxy_image_tag\":\"1.8.0\"}', date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR));
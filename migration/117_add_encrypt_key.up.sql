@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `ko_encrypt_key`
+(
+    `created_at` datetime   DEFAULT NULL,
+    `updated_at` datetime   DEFAULT NULL,
+    `version`    int(11)    NOT NULL,
+    `key`        text       NOT NULL,
+    `active`     tinyint(1) NOT NULL DEFAULT 0,
+    PRIMARY KEY (`version`)
+);
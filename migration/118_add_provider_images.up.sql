@@ -0,0 +1 @@
+UPDATE `ko`.`ko_image` SET `artifacts` = '{\"vSphere\":{\"ovf\":\"/repository/oss-proxy/terraform/images/vsphere/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810.ovf\",\"vmdk\":\"/repository/oss-proxy/terraform/images/vsphere/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810-1.vmdk\"},\"OpenStack\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/openstack/kubeoperator_centos_7.6.1810-1.qcow2\"},\"FusionCompute\":{\"ovf\":\"/repository/oss-proxy/terraform/images/fusioncompute/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810.ovf\",\"vhd\":\"/repository/oss-proxy/terraform/images/fusioncompute/kubeoperator_centos_7.6.1810/kubeoperator_centos_7.6.1810-vda.vhd\"},\"Proxmox\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/proxmox/kubeoperator_centos_7.6.1810-1.qcow2\"},\"Libvirt\":{\"qcow2\":\"/repository/oss-proxy/terraform/images/libvirt/kubeoperator_centos_7.6.1810-1.qcow2\"}}', `updated_at` = date_add(now(), interval 8 HOUR) WHERE `name` = 'kubeoperator_centos_7.6.1810' AND `builtin` = 1;
@@ -0,0 +1 @@
+ALTER TABLE `ko`.`ko_cluster_load_balancer` ADD COLUMN `insecure_skip_verify` tinyint(1) DEFAULT 0 AFTER `port`;
@@ -0,0 +1,6 @@
+ALTER TABLE `ko`.`ko_cluster_usage`
+    ADD COLUMN `host_name` varchar(255) NOT NULL DEFAULT '' AFTER `storage_class`,
+    ADD COLUMN `vm_config` varchar(255) NOT NULL DEFAULT '' AFTER `host_name`,
+    ADD COLUMN `cpu_core` int(64) DEFAULT 0 AFTER `vm_config`,
+    ADD COLUMN `memory` int(64) DEFAULT 0 AFTER `cpu_core`,
+    ADD COLUMN `gpu_num` int(64) DEFAULT 0 AFTER `memory`;
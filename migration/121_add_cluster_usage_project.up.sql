@@ -0,0 +1,6 @@
+ALTER TABLE `ko`.`ko_cluster_usage` ADD COLUMN `project_name` varchar(255) NOT NULL DEFAULT '' AFTER `cluster_name`;
+
+UPDATE ko_cluster_usage
+JOIN ko_project_resource ON ko_project_resource.resource_id = ko_cluster_usage.cluster_id AND ko_project_resource.resource_type = 'CLUSTER'
+JOIN ko_project ON ko_project.ID = ko_project_resource.project_id
+SET ko_cluster_usage.project_name = ko_project.name;
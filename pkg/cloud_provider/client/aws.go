@@ -0,0 +1,534 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	AwsImageUploadNotSupported = "AWS_IMAGE_UPLOAD_NOT_SUPPORTED"
+)
+
+const awsDefaultRegion = "us-east-1"
+
+// awsNodePorts are opened to the allowed cidrs, ssh for ansible and the apiserver for kubectl.
+var awsNodePorts = []int64{22, 6443}
+
+type awsClient struct {
+	Vars map[string]interface{}
+}
+
+func NewAwsClient(vars map[string]interface{}) *awsClient {
+	return &awsClient{
+		Vars: vars,
+	}
+}
+
+func (a *awsClient) ListDatacenter() ([]string, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.DescribeRegions(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, r := range out.Regions {
+		result = append(result, aws.StringValue(r.RegionName))
+	}
+	return result, nil
+}
+
+// ListClusters returns the availability zones of the region with the subnets, security groups and key pairs usable in them.
+func (a *awsClient) ListClusters() ([]interface{}, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	zones, err := c.DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := c.DescribeSubnets(&ec2.DescribeSubnetsInput{})
+	if err != nil {
+		return nil, err
+	}
+	groups, err := c.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{})
+	if err != nil {
+		return nil, err
+	}
+	keyPairs, err := c.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		return nil, err
+	}
+	var keyNames []string
+	for _, k := range keyPairs.KeyPairs {
+		keyNames = append(keyNames, aws.StringValue(k.KeyName))
+	}
+	var result []interface{}
+	for _, z := range zones.AvailabilityZones {
+		zoneName := aws.StringValue(z.ZoneName)
+		zoneData := make(map[string]interface{})
+		zoneData["cluster"] = zoneName
+		var zoneSubnets []map[string]interface{}
+		vpcs := map[string]bool{}
+		for _, s := range subnets.Subnets {
+			if aws.StringValue(s.AvailabilityZone) != zoneName {
+				continue
+			}
+			vpcs[aws.StringValue(s.VpcId)] = true
+			zoneSubnets = append(zoneSubnets, map[string]interface{}{
+				"id":   aws.StringValue(s.SubnetId),
+				"name": awsTagName(s.Tags),
+				"vpc":  aws.StringValue(s.VpcId),
+				"cidr": aws.StringValue(s.CidrBlock),
+			})
+		}
+		zoneData["subnets"] = zoneSubnets
+		var securityGroups []map[string]interface{}
+		for _, g := range groups.SecurityGroups {
+			if !vpcs[aws.StringValue(g.VpcId)] {
+				continue
+			}
+			securityGroups = append(securityGroups, map[string]interface{}{
+				"id":   aws.StringValue(g.GroupId),
+				"name": aws.StringValue(g.GroupName),
+				"vpc":  aws.StringValue(g.VpcId),
+			})
+		}
+		zoneData["securityGroups"] = securityGroups
+		zoneData["keyPairs"] = keyNames
+		result = append(result, zoneData)
+	}
+	return result, nil
+}
+
+// ListTemplates returns the images owned by the account, public images are referenced by id in the zone vars.
+func (a *awsClient) ListTemplates() ([]interface{}, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: []*string{aws.String("self")},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, i := range out.Images {
+		template := make(map[string]interface{})
+		template["imageName"] = aws.StringValue(i.Name)
+		template["imageId"] = aws.StringValue(i.ImageId)
+		result = append(result, template)
+	}
+	return result, nil
+}
+
+// ListFlavors maps instance types to vm configs, the same way openstack flavors are offered to plans.
+func (a *awsClient) ListFlavors() ([]interface{}, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	err = c.DescribeInstanceTypesPages(&ec2.DescribeInstanceTypesInput{}, func(out *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
+		for _, t := range out.InstanceTypes {
+			if t.MemoryInfo == nil || t.VCpuInfo == nil || aws.Int64Value(t.MemoryInfo.SizeInMiB) <= 1024 {
+				continue
+			}
+			vmConfig := make(map[string]interface{})
+			vmConfig["name"] = aws.StringValue(t.InstanceType)
+			config := make(map[string]interface{})
+			config["cpu"] = int(aws.Int64Value(t.VCpuInfo.DefaultVCpus))
+			config["memory"] = int(aws.Int64Value(t.MemoryInfo.SizeInMiB) / 1024)
+			vmConfig["config"] = config
+			result = append(result, vmConfig)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetIpInUsed returns the private addresses of every network interface in the subnet.
+func (a *awsClient) GetIpInUsed(subnet string) ([]string, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	input := &ec2.DescribeNetworkInterfacesInput{}
+	if subnet != "" {
+		input.Filters = []*ec2.Filter{
+			{Name: aws.String("subnet-id"), Values: []*string{aws.String(subnet)}},
+		}
+	}
+	var results []string
+	err = c.DescribeNetworkInterfacesPages(input, func(out *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+		for _, n := range out.NetworkInterfaces {
+			for _, addr := range n.PrivateIpAddresses {
+				results = append(results, aws.StringValue(addr.PrivateIpAddress))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ListVms returns the instances of the region named by their Name tag, terminated ones left out.
+func (a *awsClient) ListVms() ([]VmResult, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	var instances []*ec2.Instance
+	err = c.DescribeInstancesPages(&ec2.DescribeInstancesInput{}, func(out *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, r := range out.Reservations {
+			for _, i := range r.Instances {
+				if i.State != nil && aws.StringValue(i.State.Name) == ec2.InstanceStateNameTerminated {
+					continue
+				}
+				instances = append(instances, i)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+	typeNames := map[string]bool{}
+	var instanceTypes []*string
+	for _, i := range instances {
+		if !typeNames[aws.StringValue(i.InstanceType)] {
+			typeNames[aws.StringValue(i.InstanceType)] = true
+			instanceTypes = append(instanceTypes, i.InstanceType)
+		}
+	}
+	sizes := map[string]*ec2.InstanceTypeInfo{}
+	types, err := c.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{InstanceTypes: instanceTypes})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types.InstanceTypes {
+		sizes[aws.StringValue(t.InstanceType)] = t
+	}
+	var results []VmResult
+	for _, i := range instances {
+		result := VmResult{
+			Name:    awsTagName(i.Tags),
+			PowerOn: i.State != nil && aws.StringValue(i.State.Name) == ec2.InstanceStateNameRunning,
+		}
+		if ip := aws.StringValue(i.PrivateIpAddress); ip != "" {
+			result.Ips = append(result.Ips, ip)
+		}
+		if t := sizes[aws.StringValue(i.InstanceType)]; t != nil {
+			if t.VCpuInfo != nil {
+				result.Cpu = int(aws.Int64Value(t.VCpuInfo.DefaultVCpus))
+			}
+			if t.MemoryInfo != nil {
+				result.Memory = int(aws.Int64Value(t.MemoryInfo.SizeInMiB))
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (a *awsClient) UploadImage() error {
+	return errors.New(AwsImageUploadNotSupported)
+}
+
+// DefaultImageExist looks for an image with the default name imported into the account beforehand.
+func (a *awsClient) DefaultImageExist() (bool, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return false, err
+	}
+	out, err := c.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: []*string{aws.String("self")},
+		Filters: []*ec2.Filter{
+			{Name: aws.String("name"), Values: []*string{aws.String(imageName(a.Vars, constant.AwsImageName))}},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(out.Images) > 0, nil
+}
+
+func (a *awsClient) CreateDefaultFolder() error {
+	return nil
+}
+
+func (a *awsClient) ListDatastores() ([]DatastoreResult, error) {
+	return nil, nil
+}
+
+// GetCompute is left to the account limits of aws, instances are not placed on a known pool of hosts.
+func (a *awsClient) GetCompute() (*ComputeResult, error) {
+	return nil, nil
+}
+
+func (a *awsClient) GetSubnet(subnetID string) (*SubnetResult, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		SubnetIds: []*string{aws.String(subnetID)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Subnets) == 0 {
+		return nil, fmt.Errorf("subnet %s not found", subnetID)
+	}
+	s := out.Subnets[0]
+	_, cidr, err := net.ParseCIDR(aws.StringValue(s.CidrBlock))
+	if err != nil {
+		return nil, err
+	}
+	// the vpc router and the amazon provided dns always take the first two hosts of the subnet
+	network := cidr.IP.To4()
+	gateway := net.IPv4(network[0], network[1], network[2], network[3]+1)
+	dns := net.IPv4(network[0], network[1], network[2], network[3]+2)
+	return &SubnetResult{
+		ID:               aws.StringValue(s.SubnetId),
+		VpcID:            aws.StringValue(s.VpcId),
+		AvailabilityZone: aws.StringValue(s.AvailabilityZone),
+		Cidr:             cidr.String(),
+		Gateway:          gateway.String(),
+		DNS:              dns.String(),
+		ReservedHead:     4,
+		ReservedTail:     1,
+	}, nil
+}
+
+// ImportKeyPair registers the public key under the name. An existing key pair with the same name is
+// kept when it holds the same key and replaced otherwise, so a rotated credential is not left with
+// a stale key pair.
+func (a *awsClient) ImportKeyPair(name string, publicKey string) error {
+	c, err := a.GetConnect()
+	if err != nil {
+		return err
+	}
+	input := &ec2.ImportKeyPairInput{
+		KeyName:           aws.String(name),
+		PublicKeyMaterial: []byte(publicKey),
+	}
+	_, err = c.ImportKeyPair(input)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "InvalidKeyPair.Duplicate" {
+		return err
+	}
+	out, err := c.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{KeyNames: []*string{aws.String(name)}})
+	if err != nil {
+		return err
+	}
+	if fingerprint, err := awsKeyFingerprint(publicKey); err == nil && len(out.KeyPairs) > 0 &&
+		aws.StringValue(out.KeyPairs[0].KeyFingerprint) == fingerprint {
+		return nil
+	}
+	if _, err := c.DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyName: aws.String(name)}); err != nil {
+		return err
+	}
+	_, err = c.ImportKeyPair(input)
+	return err
+}
+
+// CreateSecurityGroup creates a group allowing ssh and the apiserver from the allowed cidrs and everything
+// between its members. The ssh and apiserver rules of an existing group are brought in line with the cidrs.
+func (a *awsClient) CreateSecurityGroup(vpcID string, name string, allowedCidrs []string) (string, error) {
+	c, err := a.GetConnect()
+	if err != nil {
+		return "", err
+	}
+	if len(allowedCidrs) == 0 {
+		return "", errors.New("at least one cidr has to be allowed to reach the nodes")
+	}
+	existing, err := c.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{aws.String(vpcID)}},
+			{Name: aws.String("group-name"), Values: []*string{aws.String(name)}},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(existing.SecurityGroups) > 0 {
+		group := existing.SecurityGroups[0]
+		return aws.StringValue(group.GroupId), a.syncNodePorts(c, group, allowedCidrs)
+	}
+	out, err := c.CreateSecurityGroup(&ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(name),
+		Description: aws.String("KubeOperator cluster nodes"),
+		VpcId:       aws.String(vpcID),
+	})
+	if err != nil {
+		return "", err
+	}
+	groupID := aws.StringValue(out.GroupId)
+	var permissions []*ec2.IpPermission
+	for _, port := range awsNodePorts {
+		permissions = append(permissions, awsPortPermission(port, allowedCidrs))
+	}
+	permissions = append(permissions, &ec2.IpPermission{
+		IpProtocol:       aws.String("-1"),
+		UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String(groupID)}},
+	})
+	if _, err := c.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:       aws.String(groupID),
+		IpPermissions: permissions,
+	}); err != nil {
+		return "", err
+	}
+	return groupID, nil
+}
+
+// syncNodePorts revokes the ssh and apiserver ranges of the group that are not allowed and adds the missing ones.
+func (a *awsClient) syncNodePorts(c *ec2.EC2, group *ec2.SecurityGroup, allowedCidrs []string) error {
+	var revoke, authorize []*ec2.IpPermission
+	for _, port := range awsNodePorts {
+		current := map[string]bool{}
+		for _, p := range group.IpPermissions {
+			if aws.StringValue(p.IpProtocol) != "tcp" || aws.Int64Value(p.FromPort) != port || aws.Int64Value(p.ToPort) != port {
+				continue
+			}
+			for _, r := range p.IpRanges {
+				current[aws.StringValue(r.CidrIp)] = true
+			}
+		}
+		var stale, missing []string
+		for cidr := range current {
+			if !containsCidr(allowedCidrs, cidr) {
+				stale = append(stale, cidr)
+			}
+		}
+		for _, cidr := range allowedCidrs {
+			if !current[cidr] {
+				missing = append(missing, cidr)
+			}
+		}
+		if len(stale) > 0 {
+			revoke = append(revoke, awsPortPermission(port, stale))
+		}
+		if len(missing) > 0 {
+			authorize = append(authorize, awsPortPermission(port, missing))
+		}
+	}
+	if len(revoke) > 0 {
+		if _, err := c.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       group.GroupId,
+			IpPermissions: revoke,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(authorize) > 0 {
+		if _, err := c.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       group.GroupId,
+			IpPermissions: authorize,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func awsPortPermission(port int64, cidrs []string) *ec2.IpPermission {
+	var ranges []*ec2.IpRange
+	for _, cidr := range cidrs {
+		ranges = append(ranges, &ec2.IpRange{CidrIp: aws.String(cidr)})
+	}
+	return &ec2.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(port),
+		ToPort:     aws.Int64(port),
+		IpRanges:   ranges,
+	}
+}
+
+func containsCidr(cidrs []string, cidr string) bool {
+	for _, c := range cidrs {
+		if c == cidr {
+			return true
+		}
+	}
+	return false
+}
+
+// awsKeyFingerprint is the fingerprint ec2 reports for an imported rsa key, the md5 of the der encoded public key.
+func awsKeyFingerprint(authorizedKey string) (string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return "", err
+	}
+	cryptoKey, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported key type %s", key.Type())
+	}
+	rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported key type %s", key.Type())
+	}
+	der, err := x509.MarshalPKIXPublicKey(rsaKey)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// GetConnect uses the datacenter as aws region, endpoint points the client to a compatible api or a local stand-in.
+func (a *awsClient) GetConnect() (*ec2.EC2, error) {
+	region := awsDefaultRegion
+	if a.Vars["datacenter"] != nil && a.Vars["datacenter"].(string) != "" {
+		region = a.Vars["datacenter"].(string)
+	}
+	config := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(a.Vars["accessKey"].(string), a.Vars["secretKey"].(string), ""),
+		Region:      aws.String(region),
+	}
+	if a.Vars["endpoint"] != nil && a.Vars["endpoint"].(string) != "" {
+		config.Endpoint = aws.String(a.Vars["endpoint"].(string))
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return ec2.New(sess), nil
+}
+
+func awsTagName(tags []*ec2.Tag) string {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == "Name" {
+			return aws.StringValue(t.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newEC2StandIn answers the ec2 query api with canned responses keyed by action.
+func newEC2StandIn(t *testing.T, responses map[string]string) *httptest.Server {
+	return newEC2Recorder(t, responses, nil, nil)
+}
+
+// newEC2Recorder is newEC2StandIn keeping the form of every call, failures answer the first call of an
+// action with the error code mapped to it.
+func newEC2Recorder(t *testing.T, responses map[string]string, failures map[string]string, calls *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		action := r.Form.Get("Action")
+		if calls != nil {
+			*calls = append(*calls, r.Form)
+		}
+		if code, ok := failures[action]; ok {
+			delete(failures, action)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors></Response>`, code, action)
+			return
+		}
+		body, ok := responses[action]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `<Response><Errors><Error><Code>InvalidAction</Code><Message>%s</Message></Error></Errors></Response>`, action)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprintf(w, `<%sResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId>%s</%sResponse>`, action, body, action)
+	}))
+}
+
+func TestAwsClient(t *testing.T) {
+	server := newEC2StandIn(t, map[string]string{
+		"DescribeSubnets": `<subnetSet><item><subnetId>subnet-1</subnetId><vpcId>vpc-1</vpcId>` +
+			`<cidrBlock>10.0.16.0/20</cidrBlock><availabilityZone>us-east-1a</availabilityZone></item></subnetSet>`,
+		"DescribeNetworkInterfaces": `<networkInterfaceSet><item><privateIpAddressesSet>` +
+			`<item><privateIpAddress>10.0.16.4</privateIpAddress></item>` +
+			`<item><privateIpAddress>10.0.16.9</privateIpAddress></item>` +
+			`</privateIpAddressesSet></item></networkInterfaceSet>`,
+		"DescribeInstances": `<reservationSet><item><instancesSet>` +
+			`<item><instanceId>i-1</instanceId><instanceType>t3.large</instanceType><privateIpAddress>10.0.16.4</privateIpAddress>` +
+			`<instanceState><code>16</code><name>running</name></instanceState>` +
+			`<tagSet><item><key>Name</key><value>demo-master-1</value></item></tagSet></item>` +
+			`<item><instanceId>i-2</instanceId><instanceType>t3.large</instanceType>` +
+			`<instanceState><code>48</code><name>terminated</name></instanceState></item>` +
+			`</instancesSet></item></reservationSet>`,
+		"DescribeInstanceTypes": `<instanceTypeSet><item><instanceType>t3.large</instanceType>` +
+			`<vCpuInfo><defaultVCpus>2</defaultVCpus></vCpuInfo><memoryInfo><sizeInMiB>8192</sizeInMiB></memoryInfo></item></instanceTypeSet>`,
+	})
+	defer server.Close()
+
+	c := NewAwsClient(map[string]interface{}{
+		"accessKey":  "ak",
+		"secretKey":  "sk",
+		"datacenter": "us-east-1",
+		"endpoint":   server.URL,
+	})
+
+	subnet, err := c.GetSubnet("subnet-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.VpcID != "vpc-1" || subnet.Cidr != "10.0.16.0/20" {
+		t.Fatalf("unexpected subnet %+v", subnet)
+	}
+	if subnet.Gateway != "10.0.16.1" || subnet.DNS != "10.0.16.2" {
+		t.Fatalf("unexpected gateway %s or dns %s", subnet.Gateway, subnet.DNS)
+	}
+
+	ips, err := c.GetIpInUsed("subnet-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 2 || ips[0] != "10.0.16.4" || ips[1] != "10.0.16.9" {
+		t.Fatalf("unexpected ips %v", ips)
+	}
+
+	vms, err := c.ListVms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vms) != 1 || vms[0].Name != "demo-master-1" || vms[0].Cpu != 2 || vms[0].Memory != 8192 || !vms[0].PowerOn {
+		t.Fatalf("unexpected vms %+v", vms)
+	}
+
+	if _, err := c.ListDatacenter(); err == nil {
+		t.Fatal("expected the stand-in to reject DescribeRegions")
+	}
+}
+
+func actions(calls []url.Values) []string {
+	var result []string
+	for _, c := range calls {
+		result = append(result, c.Get("Action"))
+	}
+	return result
+}
+
+func TestAwsImportKeyPair(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizedKey := string(ssh.MarshalAuthorizedKey(publicKey))
+	fingerprint, err := awsKeyFingerprint(authorizedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name        string
+		fingerprint string
+		expected    []string
+	}{
+		{name: "same key", fingerprint: fingerprint, expected: []string{"ImportKeyPair", "DescribeKeyPairs"}},
+		{name: "stale key", fingerprint: "00:11", expected: []string{"ImportKeyPair", "DescribeKeyPairs", "DeleteKeyPair", "ImportKeyPair"}},
+	} {
+		var calls []url.Values
+		server := newEC2Recorder(t, map[string]string{
+			"ImportKeyPair":    `<keyName>kubeoperator-demo</keyName>`,
+			"DescribeKeyPairs": fmt.Sprintf(`<keySet><item><keyName>kubeoperator-demo</keyName><keyFingerprint>%s</keyFingerprint></item></keySet>`, c.fingerprint),
+			"DeleteKeyPair":    `<return>true</return>`,
+		}, map[string]string{"ImportKeyPair": "InvalidKeyPair.Duplicate"}, &calls)
+		client := NewAwsClient(map[string]interface{}{"accessKey": "ak", "secretKey": "sk", "endpoint": server.URL})
+		if err := client.ImportKeyPair("kubeoperator-demo", authorizedKey); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		server.Close()
+		if got := actions(calls); fmt.Sprint(got) != fmt.Sprint(c.expected) {
+			t.Fatalf("%s: expected calls %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestAwsCreateSecurityGroup(t *testing.T) {
+	var calls []url.Values
+	server := newEC2Recorder(t, map[string]string{
+		"DescribeSecurityGroups":        `<securityGroupInfo></securityGroupInfo>`,
+		"CreateSecurityGroup":           `<return>true</return><groupId>sg-1</groupId>`,
+		"AuthorizeSecurityGroupIngress": `<return>true</return>`,
+	}, nil, &calls)
+	defer server.Close()
+	c := NewAwsClient(map[string]interface{}{"accessKey": "ak", "secretKey": "sk", "endpoint": server.URL})
+
+	if _, err := c.CreateSecurityGroup("vpc-1", "kubeoperator-demo", nil); err == nil {
+		t.Fatal("expected a group without allowed cidrs to be refused")
+	}
+	groupID, err := c.CreateSecurityGroup("vpc-1", "kubeoperator-demo", []string{"10.0.16.0/20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groupID != "sg-1" {
+		t.Fatalf("unexpected group %s", groupID)
+	}
+	authorize := calls[len(calls)-1]
+	for _, i := range []string{"1", "2"} {
+		if cidr := authorize.Get("IpPermissions." + i + ".IpRanges.1.CidrIp"); cidr != "10.0.16.0/20" {
+			t.Fatalf("expected the ports open to the subnet only, got %v", authorize)
+		}
+	}
+}
+
+func TestAwsCreateSecurityGroupExisting(t *testing.T) {
+	var calls []url.Values
+	server := newEC2Recorder(t, map[string]string{
+		"DescribeSecurityGroups": `<securityGroupInfo><item><groupId>sg-1</groupId><ipPermissions>` +
+			`<item><ipProtocol>tcp</ipProtocol><fromPort>22</fromPort><toPort>22</toPort><ipRanges><item><cidrIp>0.0.0.0/0</cidrIp></item></ipRanges></item>` +
+			`<item><ipProtocol>tcp</ipProtocol><fromPort>6443</fromPort><toPort>6443</toPort><ipRanges><item><cidrIp>10.0.16.0/20</cidrIp></item></ipRanges></item>` +
+			`</ipPermissions></item></securityGroupInfo>`,
+		"RevokeSecurityGroupIngress":    `<return>true</return>`,
+		"AuthorizeSecurityGroupIngress": `<return>true</return>`,
+	}, nil, &calls)
+	defer server.Close()
+	c := NewAwsClient(map[string]interface{}{"accessKey": "ak", "secretKey": "sk", "endpoint": server.URL})
+
+	if _, err := c.CreateSecurityGroup("vpc-1", "kubeoperator-demo", []string{"10.0.16.0/20"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := actions(calls); fmt.Sprint(got) != "[DescribeSecurityGroups RevokeSecurityGroupIngress AuthorizeSecurityGroupIngress]" {
+		t.Fatalf("unexpected calls %v", got)
+	}
+	revoke, authorize := calls[1], calls[2]
+	if revoke.Get("IpPermissions.1.FromPort") != "22" || revoke.Get("IpPermissions.1.IpRanges.1.CidrIp") != "0.0.0.0/0" || revoke.Get("IpPermissions.2.FromPort") != "" {
+		t.Fatalf("expected only the open ssh range revoked, got %v", revoke)
+	}
+	if authorize.Get("IpPermissions.1.FromPort") != "22" || authorize.Get("IpPermissions.1.IpRanges.1.CidrIp") != "10.0.16.0/20" || authorize.Get("IpPermissions.2.FromPort") != "" {
+		t.Fatalf("expected only ssh opened to the subnet, got %v", authorize)
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+// ComputeResult is the cpu and memory capacity of a zone, memory in GB.
+// Providers which can not tell return nil and the capacity check is skipped.
+type ComputeResult struct {
+	CpuTotal    int `json:"cpuTotal"`
+	CpuFree     int `json:"cpuFree"`
+	MemoryTotal int `json:"memoryTotal"`
+	MemoryFree  int `json:"memoryFree"`
+}
+
+// freeCapacity is what is left of total after allocated in the given unit, an overcommitted
+// zone has nothing left.
+func freeCapacity(total, allocated, unit int64) int {
+	if allocated >= total {
+		return 0
+	}
+	return int((total - allocated) / unit)
+}
@@ -1,7 +1,26 @@
 package client
 
+import "fmt"
+
 type DatastoreResult struct {
 	Name      string `json:"name"`
 	Capacity  int    `json:"capacity"`
 	FreeSpace int    `json:"freeSpace"`
 }
+
+// datastoreName accepts the zone datastore either as a single name or as the candidate list of the zone.
+func datastoreName(v interface{}) string {
+	switch d := v.(type) {
+	case string:
+		return d
+	case []interface{}:
+		if len(d) > 0 {
+			return fmt.Sprintf("%v", d[0])
+		}
+	case []string:
+		if len(d) > 0 {
+			return d[0]
+		}
+	}
+	return ""
+}
@@ -172,6 +172,55 @@ func (f *fusionComputeClient) GetIpInUsed(network string) ([]string, error) {
 	return result, nil
 }
 
+// ListVms returns the vms of the site, templates left out.
+func (f *fusionComputeClient) ListVms() ([]VmResult, error) {
+	siteName := f.Vars["datacenter"].(string)
+	c := f.newFusionComputeClient()
+	if err := c.Connect(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.DisConnect(); err != nil {
+			logger.Log.Errorf("fusionComputeClient DisConnect failed, error: %s", err.Error())
+		}
+	}()
+	sm := site.NewManager(c)
+	ss, err := sm.ListSite()
+	if err != nil {
+		return nil, err
+	}
+	siteUri := ""
+	for _, s := range ss {
+		if s.Name == siteName {
+			siteUri = s.Uri
+		}
+	}
+	vmm := vm.NewManager(c, siteUri)
+	vms, err := vmm.ListVm(false)
+	if err != nil {
+		return nil, err
+	}
+	var results []VmResult
+	for _, v := range vms {
+		if v.IsTemplate {
+			continue
+		}
+		result := VmResult{
+			Name:    v.Name,
+			Cpu:     v.VmConfig.Cpu.Quantity,
+			Memory:  v.VmConfig.Memory.QuantityMB,
+			PowerOn: v.Status == "running",
+		}
+		for _, n := range v.VmConfig.Nics {
+			if n.Ip != "" {
+				result.Ips = append(result.Ips, n.Ip)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 func (f *fusionComputeClient) UploadImage() error {
 	siteName := f.Vars["datacenter"].(string)
 	clusterName := f.Vars["cluster"].(string)
@@ -240,7 +289,7 @@ func (f *fusionComputeClient) UploadImage() error {
 	}
 	vmm := vm.NewManager(c, siteUri)
 	res, err := vmm.UploadImage(siteUri+"/vms", vm.ImportTemplateRequest{
-		Name:        constant.FusionComputeImageName,
+		Name:        imageName(f.Vars, constant.FusionComputeImageName),
 		Description: "KubeOperator默认模版",
 		VmConfig: vm.Config{
 			Cpu:    vm.Cpu{Quantity: 4, Reservation: 0},
@@ -324,7 +373,7 @@ func (f *fusionComputeClient) DefaultImageExist() (bool, error) {
 	}
 	result := false
 	for _, tem := range vms {
-		if tem.Name == constant.VSphereImageName {
+		if tem.Name == imageName(f.Vars, constant.FusionComputeImageName) {
 			result = true
 			break
 		}
@@ -383,3 +432,7 @@ func (f *fusionComputeClient) ListDatastores() ([]DatastoreResult, error) {
 	}
 	return results, nil
 }
+
+func (f *fusionComputeClient) GetCompute() (*ComputeResult, error) {
+	return nil, nil
+}
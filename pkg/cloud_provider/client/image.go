@@ -0,0 +1,51 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// imageName returns the template name of the catalog image to work on, falling back to the provider default.
+func imageName(vars map[string]interface{}, fallback string) string {
+	if name, ok := vars["imageName"].(string); ok && name != "" {
+		return name
+	}
+	return fallback
+}
+
+// imageChecksum splits the "algorithm:sum" checksum of the catalog image, an empty algorithm means no verification.
+func imageChecksum(vars map[string]interface{}) (string, string) {
+	checksum, _ := vars["checksum"].(string)
+	parts := strings.SplitN(checksum, ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return strings.ToLower(parts[0]), strings.ToLower(parts[1])
+}
+
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %s", algorithm)
+}
+
+func verifyChecksum(h hash.Hash, expected string) error {
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("image checksum mismatch, expected %s got %s", expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+package client
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
+)
+
+// libvirtRunner runs a command on the kvm host, *ssh.SSH in production.
+type libvirtRunner interface {
+	CombinedOutput(cmd ...string) ([]byte, error)
+}
+
+// libvirtClient drives a plain KVM host through virsh over ssh, the host itself is the only "cluster".
+type libvirtClient struct {
+	Vars   map[string]interface{}
+	Client libvirtRunner
+}
+
+func NewLibvirtClient(vars map[string]interface{}) *libvirtClient {
+	return &libvirtClient{
+		Vars: vars,
+	}
+}
+
+func (l *libvirtClient) ListDatacenter() ([]string, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	hostname, err := l.virsh("hostname")
+	if err != nil {
+		return nil, err
+	}
+	return []string{strings.TrimSpace(hostname)}, nil
+}
+
+func (l *libvirtClient) ListClusters() ([]interface{}, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	hostname, err := l.virsh("hostname")
+	if err != nil {
+		return nil, err
+	}
+	pools, err := l.listPools()
+	if err != nil {
+		return nil, err
+	}
+	out, err := l.virsh("net-list", "--name")
+	if err != nil {
+		return nil, err
+	}
+	var templates []string
+	for _, pool := range pools {
+		volumes, err := l.listVolumes(pool)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, volumes...)
+	}
+	clusterData := make(map[string]interface{})
+	clusterData["cluster"] = strings.TrimSpace(hostname)
+	clusterData["datastores"] = pools
+	clusterData["networks"] = splitLines(out)
+	clusterData["templates"] = templates
+	return []interface{}{clusterData}, nil
+}
+
+func (l *libvirtClient) ListTemplates() ([]interface{}, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	pools, err := l.listPools()
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, pool := range pools {
+		volumes, err := l.listVolumes(pool)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range volumes {
+			template := make(map[string]interface{})
+			template["imageName"] = v
+			template["pool"] = pool
+			result = append(result, template)
+		}
+	}
+	return result, nil
+}
+
+func (l *libvirtClient) ListFlavors() ([]interface{}, error) {
+	return nil, nil
+}
+
+// GetIpInUsed merges the dhcp leases of the network with the arp table entries of every running domain.
+func (l *libvirtClient) GetIpInUsed(network string) ([]string, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	var results []string
+	if network != "" {
+		// bridged networks are not managed by libvirt and have no leases
+		if out, err := l.virsh("net-dhcp-leases", network); err == nil {
+			for _, fields := range parseTable(out) {
+				for _, f := range fields {
+					if strings.Contains(f, ".") && strings.Contains(f, "/") {
+						results = append(results, strings.Split(f, "/")[0])
+					}
+				}
+			}
+		}
+	}
+	out, err := l.virsh("list", "--name")
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range splitLines(out) {
+		out, err := l.virsh("domifaddr", domain, "--source", "arp")
+		if err != nil {
+			continue
+		}
+		for _, fields := range parseTable(out) {
+			if len(fields) >= 4 && fields[2] == "ipv4" {
+				results = append(results, strings.Split(fields[3], "/")[0])
+			}
+		}
+	}
+	return results, nil
+}
+
+// ListVms returns every domain of the kvm host with the size dominfo reports.
+func (l *libvirtClient) ListVms() ([]VmResult, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	out, err := l.virsh("list", "--all", "--name")
+	if err != nil {
+		return nil, err
+	}
+	var results []VmResult
+	for _, domain := range splitLines(out) {
+		out, err := l.virsh("dominfo", domain)
+		if err != nil {
+			return nil, err
+		}
+		info := parseColonValues(out)
+		cpus, _ := strconv.Atoi(strings.Fields(info["CPU(s)"] + " 0")[0])
+		kib, _ := strconv.ParseInt(strings.Fields(info["Max memory"] + " 0")[0], 10, 64)
+		result := VmResult{
+			Name:    domain,
+			Cpu:     cpus,
+			Memory:  int(kib / 1024),
+			PowerOn: info["State"] == "running",
+		}
+		if result.PowerOn {
+			if out, err := l.virsh("domifaddr", domain, "--source", "arp"); err == nil {
+				for _, fields := range parseTable(out) {
+					if len(fields) >= 4 && fields[2] == "ipv4" {
+						result.Ips = append(result.Ips, strings.Split(fields[3], "/")[0])
+					}
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// UploadImage lets the kvm host fetch the qcow2 image into the pool directory and refreshes the pool.
+func (l *libvirtClient) UploadImage() error {
+	if err := l.GetConnect(); err != nil {
+		return err
+	}
+	pool := datastoreName(l.Vars["datastore"])
+	imagePath, err := requiredVar(l.Vars, "imagePath")
+	if err != nil {
+		return err
+	}
+	out, err := l.virsh("pool-dumpxml", pool)
+	if err != nil {
+		return err
+	}
+	var poolXml struct {
+		Target struct {
+			Path string `xml:"path"`
+		} `xml:"target"`
+	}
+	if err := xml.Unmarshal([]byte(out), &poolXml); err != nil {
+		return err
+	}
+	if poolXml.Target.Path == "" {
+		return fmt.Errorf("pool %s has no target path", pool)
+	}
+	target := poolXml.Target.Path + "/" + imageName(l.Vars, constant.LibvirtImageName)
+	cmd := fmt.Sprintf("curl -fsSL -o %s %s", shellQuote(target), shellQuote(imagePath))
+	if _, err := l.Client.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("download image %s failed: %s", imagePath, err.Error())
+	}
+	if algorithm, sum := imageChecksum(l.Vars); algorithm != "" {
+		cmd := fmt.Sprintf("%ssum %s", algorithm, shellQuote(target))
+		output, err := l.Client.CombinedOutput(cmd)
+		if err != nil {
+			return fmt.Errorf("checksum image %s failed: %s", target, err.Error())
+		}
+		if fields := strings.Fields(string(output)); len(fields) == 0 || strings.ToLower(fields[0]) != sum {
+			_, _ = l.Client.CombinedOutput(fmt.Sprintf("rm -f %s", shellQuote(target)))
+			return fmt.Errorf("image checksum mismatch, expected %s got %s", sum, string(output))
+		}
+	}
+	_, err = l.virsh("pool-refresh", pool)
+	return err
+}
+
+func (l *libvirtClient) DefaultImageExist() (bool, error) {
+	if err := l.GetConnect(); err != nil {
+		return false, err
+	}
+	pools, err := l.listPools()
+	if err != nil {
+		return false, err
+	}
+	for _, pool := range pools {
+		if l.Vars["datastore"] != nil && datastoreName(l.Vars["datastore"]) != pool {
+			continue
+		}
+		volumes, err := l.listVolumes(pool)
+		if err != nil {
+			return false, err
+		}
+		for _, v := range volumes {
+			if v == imageName(l.Vars, constant.LibvirtImageName) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (l *libvirtClient) CreateDefaultFolder() error {
+	return nil
+}
+
+func (l *libvirtClient) ListDatastores() ([]DatastoreResult, error) {
+	var result []DatastoreResult
+	if err := l.GetConnect(); err != nil {
+		return result, err
+	}
+	pools, err := l.listPools()
+	if err != nil {
+		return result, err
+	}
+	for _, pool := range pools {
+		out, err := l.virsh("pool-info", pool, "--bytes")
+		if err != nil {
+			return result, err
+		}
+		info := map[string]int64{}
+		for _, line := range splitLines(out) {
+			kv := strings.SplitN(line, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			value, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+			if err != nil {
+				continue
+			}
+			info[strings.TrimSpace(kv[0])] = value
+		}
+		result = append(result, DatastoreResult{
+			Name:      pool,
+			Capacity:  int(info["Capacity"] / (1024 * 1024 * 1024)),
+			FreeSpace: int(info["Available"] / (1024 * 1024 * 1024)),
+		})
+	}
+	return result, nil
+}
+
+// GetCompute compares the cpus and memory allocated to every domain, running or not, with the
+// totals of nodeinfo.
+func (l *libvirtClient) GetCompute() (*ComputeResult, error) {
+	if err := l.GetConnect(); err != nil {
+		return nil, err
+	}
+	out, err := l.virsh("nodeinfo")
+	if err != nil {
+		return nil, err
+	}
+	info := parseColonValues(out)
+	cpus, _ := strconv.Atoi(strings.Fields(info["CPU(s)"] + " 0")[0])
+	memory, _ := strconv.ParseInt(strings.Fields(info["Memory size"] + " 0")[0], 10, 64)
+	out, err = l.virsh("list", "--all", "--name")
+	if err != nil {
+		return nil, err
+	}
+	var allocatedCpus, allocatedMemory int64
+	for _, domain := range splitLines(out) {
+		out, err := l.virsh("dominfo", domain)
+		if err != nil {
+			return nil, err
+		}
+		info := parseColonValues(out)
+		domainCpus, _ := strconv.ParseInt(strings.Fields(info["CPU(s)"] + " 0")[0], 10, 64)
+		kib, _ := strconv.ParseInt(strings.Fields(info["Max memory"] + " 0")[0], 10, 64)
+		allocatedCpus += domainCpus
+		allocatedMemory += kib
+	}
+	return &ComputeResult{
+		CpuTotal:    cpus,
+		CpuFree:     freeCapacity(int64(cpus), allocatedCpus, 1),
+		MemoryTotal: int(memory / (1024 * 1024)),
+		MemoryFree:  freeCapacity(memory, allocatedMemory, 1024*1024),
+	}, nil
+}
+
+func (l *libvirtClient) GetConnect() error {
+	if l.Client != nil {
+		return nil
+	}
+	config, err := LibvirtSSHConfig(l.Vars)
+	if err != nil {
+		return err
+	}
+	client, err := ssh.New(config)
+	if err != nil {
+		return err
+	}
+	l.Client = client
+	return nil
+}
+
+// LibvirtSSHConfig is the ssh config of the kvm host of the region vars, the connection is pinned to
+// the key in the hostKey var.
+func LibvirtSSHConfig(vars map[string]interface{}) (*ssh.Config, error) {
+	port := 22
+	switch v := vars["port"].(type) {
+	case float64:
+		port = int(v)
+	case string:
+		port, _ = strconv.Atoi(v)
+	}
+	username, _ := vars["username"].(string)
+	host, _ := vars["host"].(string)
+	if username == "" || host == "" {
+		return nil, errors.New("host and username of the libvirt host are required")
+	}
+	config := &ssh.Config{
+		User:        username,
+		Host:        host,
+		Port:        port,
+		DialTimeOut: 5 * time.Second,
+		Retry:       3,
+	}
+	config.HostKey, _ = vars["hostKey"].(string)
+	if password, ok := vars["password"].(string); ok {
+		config.Password = password
+	}
+	if privateKey, ok := vars["privateKey"].(string); ok {
+		config.PrivateKey = []byte(privateKey)
+	}
+	return config, nil
+}
+
+func (l *libvirtClient) listPools() ([]string, error) {
+	out, err := l.virsh("pool-list", "--name")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+func (l *libvirtClient) listVolumes(pool string) ([]string, error) {
+	out, err := l.virsh("vol-list", pool)
+	if err != nil {
+		return nil, err
+	}
+	var volumes []string
+	for _, fields := range parseTable(out) {
+		volumes = append(volumes, fields[0])
+	}
+	return volumes, nil
+}
+
+func (l *libvirtClient) virsh(args ...string) (string, error) {
+	cmd := "virsh -c qemu:///system"
+	for _, a := range args {
+		cmd += " " + shellQuote(a)
+	}
+	out, err := l.Client.CombinedOutput(cmd)
+	if err != nil {
+		// the output is empty on errors, the stderr of virsh is part of err
+		return "", fmt.Errorf("%s: %s", cmd, err.Error())
+	}
+	return string(out), nil
+}
+
+func splitLines(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseColonValues reads the "key: value" lines virsh prints for node information.
+func parseColonValues(out string) map[string]string {
+	values := map[string]string{}
+	for _, line := range splitLines(out) {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) == 2 {
+			values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return values
+}
+
+// parseTable skips the header and the dash separator of virsh table output.
+func parseTable(out string) [][]string {
+	var rows [][]string
+	started := false
+	for _, line := range splitLines(out) {
+		if strings.HasPrefix(line, "---") {
+			started = true
+			continue
+		}
+		if started {
+			rows = append(rows, strings.Fields(line))
+		}
+	}
+	return rows
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
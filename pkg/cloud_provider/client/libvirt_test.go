@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// libvirtStandIn answers the commands run on the kvm host with canned output, the way
+// *ssh.SSH does: no output and the stderr in the error when the command fails.
+type libvirtStandIn struct {
+	outputs map[string]string
+	failed  map[string]string
+	ran     []string
+}
+
+func (s *libvirtStandIn) CombinedOutput(cmd ...string) ([]byte, error) {
+	c := strings.Join(cmd, " ")
+	s.ran = append(s.ran, c)
+	for k, stderr := range s.failed {
+		if strings.Contains(c, k) {
+			return nil, errors.New("exit error 1:" + stderr)
+		}
+	}
+	for k, out := range s.outputs {
+		if strings.HasSuffix(c, k) {
+			return []byte(out), nil
+		}
+	}
+	return nil, errors.New("exit error 127:command not found")
+}
+
+func TestLibvirtClient(t *testing.T) {
+	standIn := &libvirtStandIn{outputs: map[string]string{
+		"'hostname'":                                   "kvm1\n",
+		"'pool-list' '--name'":                         "default\n\n",
+		"'net-list' '--name'":                          "default\n",
+		"'list' '--name'":                              "demo-master-1\n",
+		"'list' '--all' '--name'":                      "demo-master-1\ndemo-worker-1\n",
+		"'net-dhcp-leases' 'default'":                  " Expiry Time   MAC address   Protocol   IP address   Hostname   Client ID or DUID\n---------\n 2021-10-01 10:00:00   52:54:00:aa:bb:cc   ipv4   192.168.122.15/24   demo   -\n",
+		"'domifaddr' 'demo-master-1' '--source' 'arp'": " Name   MAC address   Protocol   Address\n-------\n vnet0   52:54:00:aa:bb:cd   ipv4   192.168.122.11/0\n",
+		"'vol-list' 'default'":                         " Name   Path\n------\n kubeoperator_centos_7.6.1810.qcow2   /var/lib/libvirt/images/kubeoperator_centos_7.6.1810.qcow2\n",
+		"'dominfo' 'demo-master-1'":                    "Name:           demo-master-1\nState:          running\nCPU(s):         4\nMax memory:     8388608 KiB\n",
+		"'dominfo' 'demo-worker-1'":                    "Name:           demo-worker-1\nState:          shut off\nCPU(s):         2\nMax memory:     4194304 KiB\n",
+		"'pool-info' 'default' '--bytes'":              "Name:           default\nCapacity:       107374182400\nAllocation:     53687091200\nAvailable:      53687091200\n",
+		"'nodeinfo'":                                   "CPU model:           x86_64\nCPU(s):              8\nMemory size:         16777216 KiB\n",
+	}}
+	c := &libvirtClient{Vars: map[string]interface{}{}, Client: standIn}
+
+	datacenters, err := c.ListDatacenter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datacenters) != 1 || datacenters[0] != "kvm1" {
+		t.Fatalf("unexpected datacenters %v", datacenters)
+	}
+
+	ips, err := c.GetIpInUsed("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 2 || ips[0] != "192.168.122.15" || ips[1] != "192.168.122.11" {
+		t.Fatalf("unexpected ips %v", ips)
+	}
+
+	vms, err := c.ListVms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vms) != 2 || vms[0].Cpu != 4 || vms[0].Memory != 8192 || !vms[0].PowerOn || len(vms[0].Ips) != 1 || vms[1].PowerOn {
+		t.Fatalf("unexpected vms %+v", vms)
+	}
+
+	exist, err := c.DefaultImageExist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exist {
+		t.Fatal("expected the default image to exist")
+	}
+
+	datastores, err := c.ListDatastores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datastores) != 1 || datastores[0].Capacity != 100 || datastores[0].FreeSpace != 50 {
+		t.Fatalf("unexpected datastores %+v", datastores)
+	}
+
+	compute, err := c.GetCompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compute.CpuTotal != 8 || compute.CpuFree != 2 || compute.MemoryTotal != 16 || compute.MemoryFree != 4 {
+		t.Fatalf("unexpected compute %+v", compute)
+	}
+}
+
+func TestLibvirtClientErrors(t *testing.T) {
+	standIn := &libvirtStandIn{
+		outputs: map[string]string{
+			"'pool-dumpxml' 'default'": "<pool><target><path>/var/lib/libvirt/images</path></target></pool>",
+		},
+		failed: map[string]string{
+			"'hostname'": "error: failed to connect to the hypervisor",
+			"curl":       "curl: (22) The requested URL returned error: 404",
+		},
+	}
+	c := &libvirtClient{Vars: map[string]interface{}{"datastore": "default", "imagePath": "http://repo/image.qcow2"}, Client: standIn}
+
+	if _, err := c.ListDatacenter(); err == nil || !strings.Contains(err.Error(), "failed to connect to the hypervisor") {
+		t.Fatalf("expected the stderr of virsh in the error, got %v", err)
+	}
+	if err := c.UploadImage(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected the stderr of curl in the error, got %v", err)
+	}
+
+	c.Vars = map[string]interface{}{"datastore": "default", "imagePath": 1}
+	if err := c.UploadImage(); err == nil {
+		t.Fatal("expected a mistyped imagePath to be refused")
+	}
+	if _, err := LibvirtSSHConfig(map[string]interface{}{"host": "10.1.0.3", "username": 1}); err == nil {
+		t.Fatal("expected a mistyped username to be refused")
+	}
+}
@@ -3,13 +3,18 @@ package client
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"hash"
+
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
 	"github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumetypes"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/availabilityzones"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/limits"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/secgroups"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imagedata"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imageimport"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
@@ -325,6 +330,61 @@ func (v *openStackClient) ListFlavors() ([]interface{}, error) {
 func (v *openStackClient) GetIpInUsed(network string) ([]string, error) {
 	return []string{}, nil
 }
+
+// ListVms returns the servers of the project with the size of their flavor.
+func (v *openStackClient) ListVms() ([]VmResult, error) {
+	provider, err := v.GetAuth()
+	if err != nil {
+		return nil, err
+	}
+	client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{
+		Region: v.Vars["datacenter"].(string),
+	})
+	if err != nil {
+		return nil, err
+	}
+	flavorPages, err := flavors.ListDetail(client, flavors.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allFlavors, err := flavors.ExtractFlavors(flavorPages)
+	if err != nil {
+		return nil, err
+	}
+	flavorByID := map[string]flavors.Flavor{}
+	for _, f := range allFlavors {
+		flavorByID[f.ID] = f
+	}
+	serverPages, err := servers.List(client, servers.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allServers, err := servers.ExtractServers(serverPages)
+	if err != nil {
+		return nil, err
+	}
+	var results []VmResult
+	for _, s := range allServers {
+		f := flavorByID[fmt.Sprintf("%v", s.Flavor["id"])]
+		result := VmResult{
+			Name:    s.Name,
+			Cpu:     f.VCPUs,
+			Memory:  f.RAM,
+			PowerOn: s.Status == "ACTIVE",
+		}
+		for _, addresses := range s.Addresses {
+			list, _ := addresses.([]interface{})
+			for _, a := range list {
+				if addr, ok := a.(map[string]interface{}); ok {
+					result.Ips = append(result.Ips, ipv4Only([]string{fmt.Sprintf("%v", addr["addr"])})...)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 func (v *openStackClient) UploadImage() error {
 
 	provider, err := v.GetAuth()
@@ -348,9 +408,14 @@ func (v *openStackClient) UploadImage() error {
 		return err
 	}
 
+	name := imageName(v.Vars, constant.OpenStackImageName)
+	localPath := constant.OpenStackImageLocalPath
+	if name != constant.OpenStackImageName {
+		localPath = fmt.Sprintf("/opt/%s.%s", name, constant.OpenStackImageDiskFormat)
+	}
 	exist := false
 	for _, p := range allPages {
-		if p.Name == constant.OpenStackImageName {
+		if p.Name == name {
 			exist = true
 			break
 		}
@@ -362,17 +427,34 @@ func (v *openStackClient) UploadImage() error {
 		if err != nil {
 			return err
 		}
-		f, err := os.Create(constant.OpenStackImageLocalPath)
+		defer res.Body.Close()
+		f, err := os.Create(localPath)
 		if err != nil {
 			return err
 		}
-		_, err = io.Copy(f, res.Body)
+		var writer io.Writer = f
+		algorithm, sum := imageChecksum(v.Vars)
+		var h hash.Hash
+		if algorithm != "" {
+			h, err = newChecksumHash(algorithm)
+			if err != nil {
+				return err
+			}
+			writer = io.MultiWriter(f, h)
+		}
+		_, err = io.Copy(writer, res.Body)
+		f.Close()
 		if err != nil {
 			return err
 		}
+		if h != nil {
+			if err := verifyChecksum(h, sum); err != nil {
+				return err
+			}
+		}
 
 		create := images.Create(client, images.CreateOpts{
-			Name:            constant.OpenStackImageName,
+			Name:            name,
 			DiskFormat:      constant.OpenStackImageDiskFormat,
 			ContainerFormat: "bare",
 			ID:              imageId,
@@ -381,7 +463,7 @@ func (v *openStackClient) UploadImage() error {
 			return create.Err
 		}
 
-		imageData, err := os.Open(constant.OpenStackImageLocalPath)
+		imageData, err := os.Open(localPath)
 		if err != nil {
 			return err
 		}
@@ -414,3 +496,30 @@ func (v *openStackClient) ListDatastores() ([]DatastoreResult, error) {
 	var results []DatastoreResult
 	return results, nil
 }
+
+// GetCompute reports the compute quota of the project, unlimited quotas are left out of the check.
+func (v *openStackClient) GetCompute() (*ComputeResult, error) {
+	provider, err := v.GetAuth()
+	if err != nil {
+		return nil, err
+	}
+	client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{
+		Region: v.Vars["datacenter"].(string),
+	})
+	if err != nil {
+		return nil, err
+	}
+	l, err := limits.Get(client, nil).Extract()
+	if err != nil {
+		return nil, err
+	}
+	if l.Absolute.MaxTotalCores < 0 || l.Absolute.MaxTotalRAMSize < 0 {
+		return nil, nil
+	}
+	return &ComputeResult{
+		CpuTotal:    l.Absolute.MaxTotalCores,
+		CpuFree:     l.Absolute.MaxTotalCores - l.Absolute.TotalCoresUsed,
+		MemoryTotal: l.Absolute.MaxTotalRAMSize / 1024,
+		MemoryFree:  (l.Absolute.MaxTotalRAMSize - l.Absolute.TotalRAMUsed) / 1024,
+	}, nil
+}
@@ -0,0 +1,599 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	// proxmoxTaskInterval and proxmoxTaskTimeout bound the wait for the tasks of the api, an image
+	// download is the longest of them
+	proxmoxTaskInterval = 5 * time.Second
+	proxmoxTaskTimeout  = 30 * time.Minute
+)
+
+type proxmoxClient struct {
+	Vars map[string]interface{}
+
+	httpClient *http.Client
+	ticket     string
+	csrfToken  string
+}
+
+type proxmoxResource struct {
+	VmID     int    `json:"vmid"`
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Template int    `json:"template"`
+	MaxCpu   int    `json:"maxcpu"`
+	MaxMem   int64  `json:"maxmem"`
+}
+
+type proxmoxStorage struct {
+	Storage string `json:"storage"`
+	Total   int64  `json:"total"`
+	Avail   int64  `json:"avail"`
+	Active  int    `json:"active"`
+}
+
+type proxmoxInterface struct {
+	Name        string `json:"name"`
+	IpAddresses []struct {
+		IpAddress     string `json:"ip-address"`
+		IpAddressType string `json:"ip-address-type"`
+	} `json:"ip-addresses"`
+}
+
+func NewProxmoxClient(vars map[string]interface{}) *proxmoxClient {
+	return &proxmoxClient{
+		Vars: vars,
+	}
+}
+
+// ListDatacenter returns the name of the Proxmox cluster, standalone nodes are their own datacenter.
+func (p *proxmoxClient) ListDatacenter() ([]string, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	var status []struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := p.get("/cluster/status", &status); err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, s := range status {
+		if s.Type == "cluster" {
+			return []string{s.Name}, nil
+		}
+	}
+	for _, s := range status {
+		if s.Type == "node" {
+			result = append(result, s.Name)
+		}
+	}
+	return result, nil
+}
+
+// ListClusters returns every online node of the datacenter with its storages, bridges and templates.
+func (p *proxmoxClient) ListClusters() ([]interface{}, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	nodes, err := p.listNodes()
+	if err != nil {
+		return nil, err
+	}
+	resources, err := p.listVms()
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, node := range nodes {
+		nodeData := make(map[string]interface{})
+		nodeData["cluster"] = node
+		storages, err := p.listStorages(node)
+		if err != nil {
+			return nil, err
+		}
+		var datastores []string
+		for _, s := range storages {
+			datastores = append(datastores, s.Storage)
+		}
+		nodeData["datastores"] = datastores
+		var bridges []struct {
+			Iface string `json:"iface"`
+		}
+		if err := p.get(fmt.Sprintf("/nodes/%s/network?type=any_bridge", node), &bridges); err != nil {
+			return nil, err
+		}
+		var networks []string
+		for _, b := range bridges {
+			networks = append(networks, b.Iface)
+		}
+		nodeData["networks"] = networks
+		var templates []string
+		for _, r := range resources {
+			if r.Template == 1 && r.Node == node {
+				templates = append(templates, r.Name)
+			}
+		}
+		nodeData["templates"] = templates
+		result = append(result, nodeData)
+	}
+	return result, nil
+}
+
+func (p *proxmoxClient) ListTemplates() ([]interface{}, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	resources, err := p.listVms()
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, r := range resources {
+		if r.Template != 1 {
+			continue
+		}
+		template := make(map[string]interface{})
+		template["imageName"] = r.Name
+		template["vmid"] = r.VmID
+		template["node"] = r.Node
+		result = append(result, template)
+	}
+	return result, nil
+}
+
+func (p *proxmoxClient) ListFlavors() ([]interface{}, error) {
+	return nil, nil
+}
+
+// GetIpInUsed asks the guest agent of every running vm attached to the bridge for its addresses.
+func (p *proxmoxClient) GetIpInUsed(network string) ([]string, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	resources, err := p.listVms()
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, r := range resources {
+		if r.Template == 1 || r.Status != "running" {
+			continue
+		}
+		if network != "" {
+			config := map[string]interface{}{}
+			if err := p.get(fmt.Sprintf("/nodes/%s/qemu/%d/config", r.Node, r.VmID), &config); err != nil {
+				return nil, err
+			}
+			attached := false
+			for k, v := range config {
+				if strings.HasPrefix(k, "net") && strings.Contains(fmt.Sprintf("%v", v), "bridge="+network) {
+					attached = true
+					break
+				}
+			}
+			if !attached {
+				continue
+			}
+		}
+		var agent struct {
+			Result []proxmoxInterface `json:"result"`
+		}
+		// vms without a running guest agent can not report their addresses
+		if err := p.get(fmt.Sprintf("/nodes/%s/qemu/%d/agent/network-get-interfaces", r.Node, r.VmID), &agent); err != nil {
+			continue
+		}
+		for _, i := range agent.Result {
+			if i.Name == "lo" {
+				continue
+			}
+			for _, addr := range i.IpAddresses {
+				if addr.IpAddressType == "ipv4" {
+					results = append(results, addr.IpAddress)
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// ListVms returns the qemu vms of every node, the addresses come from the guest agent of the running ones.
+func (p *proxmoxClient) ListVms() ([]VmResult, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	resources, err := p.listVms()
+	if err != nil {
+		return nil, err
+	}
+	var results []VmResult
+	for _, r := range resources {
+		if r.Template == 1 {
+			continue
+		}
+		result := VmResult{
+			Name:    r.Name,
+			Cpu:     r.MaxCpu,
+			Memory:  int(r.MaxMem / (1024 * 1024)),
+			PowerOn: r.Status == "running",
+		}
+		if result.PowerOn {
+			var agent struct {
+				Result []proxmoxInterface `json:"result"`
+			}
+			if err := p.get(fmt.Sprintf("/nodes/%s/qemu/%d/agent/network-get-interfaces", r.Node, r.VmID), &agent); err == nil {
+				for _, i := range agent.Result {
+					if i.Name == "lo" {
+						continue
+					}
+					for _, addr := range i.IpAddresses {
+						if addr.IpAddressType == "ipv4" {
+							result.Ips = append(result.Ips, addr.IpAddress)
+						}
+					}
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// UploadImage downloads the qcow2 image into the storage of the node and turns it into the default template.
+func (p *proxmoxClient) UploadImage() error {
+	if err := p.GetConnect(); err != nil {
+		return err
+	}
+	node, err := requiredVar(p.Vars, "cluster")
+	if err != nil {
+		return err
+	}
+	imagePath, err := requiredVar(p.Vars, "imagePath")
+	if err != nil {
+		return err
+	}
+	storage := datastoreName(p.Vars["datastore"])
+	bridge := "vmbr0"
+	if network := stringVar(p.Vars, "network"); network != "" {
+		bridge = network
+	}
+	fileName := path.Base(imagePath)
+
+	download := url.Values{
+		"url":      {imagePath},
+		"content":  {"import"},
+		"filename": {fileName},
+	}
+	if algorithm, sum := imageChecksum(p.Vars); algorithm != "" {
+		download.Set("checksum-algorithm", algorithm)
+		download.Set("checksum", sum)
+	}
+	var upid string
+	if err := p.post(fmt.Sprintf("/nodes/%s/storage/%s/download-url", node, storage), download, &upid); err != nil {
+		return err
+	}
+	if err := p.waitTask(node, upid); err != nil {
+		return err
+	}
+
+	var nextID string
+	if err := p.get("/cluster/nextid", &nextID); err != nil {
+		return err
+	}
+	if err := p.post(fmt.Sprintf("/nodes/%s/qemu", node), url.Values{
+		"vmid":        {nextID},
+		"name":        {imageName(p.Vars, constant.ProxmoxImageName)},
+		"description": {"KubeOperator默认模版"},
+		"ostype":      {"l26"},
+		"cores":       {"4"},
+		"memory":      {"4096"},
+		"agent":       {"1"},
+		"scsihw":      {"virtio-scsi-pci"},
+		"scsi0":       {fmt.Sprintf("%s:0,import-from=%s:import/%s", storage, storage, fileName)},
+		"ide2":        {fmt.Sprintf("%s:cloudinit", storage)},
+		"boot":        {"order=scsi0"},
+		"serial0":     {"socket"},
+		"net0":        {fmt.Sprintf("virtio,bridge=%s", bridge)},
+	}, &upid); err != nil {
+		return err
+	}
+	if err := p.waitTask(node, upid); err != nil {
+		return err
+	}
+	upid = ""
+	if err := p.post(fmt.Sprintf("/nodes/%s/qemu/%s/template", node, nextID), url.Values{}, &upid); err != nil {
+		return err
+	}
+	if upid != "" {
+		return p.waitTask(node, upid)
+	}
+	return nil
+}
+
+func (p *proxmoxClient) DefaultImageExist() (bool, error) {
+	if err := p.GetConnect(); err != nil {
+		return false, err
+	}
+	resources, err := p.listVms()
+	if err != nil {
+		return false, err
+	}
+	for _, r := range resources {
+		if r.Template == 1 && r.Name == imageName(p.Vars, constant.ProxmoxImageName) {
+			if node := stringVar(p.Vars, "cluster"); node == "" || r.Node == node {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (p *proxmoxClient) CreateDefaultFolder() error {
+	return nil
+}
+
+func (p *proxmoxClient) ListDatastores() ([]DatastoreResult, error) {
+	var result []DatastoreResult
+	if err := p.GetConnect(); err != nil {
+		return result, err
+	}
+	node, err := requiredVar(p.Vars, "cluster")
+	if err != nil {
+		return result, err
+	}
+	storages, err := p.listStorages(node)
+	if err != nil {
+		return result, err
+	}
+	for i := range storages {
+		result = append(result, DatastoreResult{
+			Name:      storages[i].Storage,
+			Capacity:  int(storages[i].Total / (1024 * 1024 * 1024)),
+			FreeSpace: int(storages[i].Avail / (1024 * 1024 * 1024)),
+		})
+	}
+	return result, nil
+}
+
+// GetCompute compares the cpus and memory allocated to the vms of the node the zone is bound to
+// with the totals of the node, stopped vms keep their share while templates have none.
+func (p *proxmoxClient) GetCompute() (*ComputeResult, error) {
+	if err := p.GetConnect(); err != nil {
+		return nil, err
+	}
+	var status struct {
+		CpuInfo struct {
+			Cpus int `json:"cpus"`
+		} `json:"cpuinfo"`
+		Memory struct {
+			Total int64 `json:"total"`
+		} `json:"memory"`
+	}
+	node, err := requiredVar(p.Vars, "cluster")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.get(fmt.Sprintf("/nodes/%s/status", node), &status); err != nil {
+		return nil, err
+	}
+	// the node lists the cpus of a vm as cpus, older releases as maxcpu like the cluster resources
+	var vms []struct {
+		Cpus     int   `json:"cpus"`
+		MaxCpu   int   `json:"maxcpu"`
+		MaxMem   int64 `json:"maxmem"`
+		Template int   `json:"template"`
+	}
+	if err := p.get(fmt.Sprintf("/nodes/%s/qemu", node), &vms); err != nil {
+		return nil, err
+	}
+	var allocatedCpus int
+	var allocatedMemory int64
+	for _, vm := range vms {
+		if vm.Template == 1 {
+			continue
+		}
+		if vm.Cpus == 0 {
+			vm.Cpus = vm.MaxCpu
+		}
+		allocatedCpus += vm.Cpus
+		allocatedMemory += vm.MaxMem
+	}
+	return &ComputeResult{
+		CpuTotal:    status.CpuInfo.Cpus,
+		CpuFree:     freeCapacity(int64(status.CpuInfo.Cpus), int64(allocatedCpus), 1),
+		MemoryTotal: int(status.Memory.Total / (1024 * 1024 * 1024)),
+		MemoryFree:  freeCapacity(status.Memory.Total, allocatedMemory, 1024*1024*1024),
+	}, nil
+}
+
+// GetConnect logs in with the api token when one is configured, otherwise with username and password.
+func (p *proxmoxClient) GetConnect() error {
+	if p.httpClient != nil {
+		return nil
+	}
+	if _, err := requiredVar(p.Vars, "host"); err != nil {
+		return err
+	}
+	username, err := requiredVar(p.Vars, "username")
+	if err != nil {
+		return err
+	}
+	p.httpClient = &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	if stringVar(p.Vars, "tokenId") != "" {
+		return nil
+	}
+	var ticket struct {
+		Ticket    string `json:"ticket"`
+		CSRFToken string `json:"CSRFPreventionToken"`
+	}
+	if err := p.do(http.MethodPost, "/access/ticket", url.Values{
+		"username": {username},
+		"password": {stringVar(p.Vars, "password")},
+	}, &ticket); err != nil {
+		p.httpClient = nil
+		return err
+	}
+	p.ticket = ticket.Ticket
+	p.csrfToken = ticket.CSRFToken
+	return nil
+}
+
+func (p *proxmoxClient) listNodes() ([]string, error) {
+	var nodes []struct {
+		Node   string `json:"node"`
+		Status string `json:"status"`
+	}
+	if err := p.get("/nodes", &nodes); err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, n := range nodes {
+		if n.Status == "online" {
+			result = append(result, n.Node)
+		}
+	}
+	return result, nil
+}
+
+func (p *proxmoxClient) listVms() ([]proxmoxResource, error) {
+	var resources []proxmoxResource
+	if err := p.get("/cluster/resources?type=vm", &resources); err != nil {
+		return nil, err
+	}
+	var result []proxmoxResource
+	for _, r := range resources {
+		if r.Type == "qemu" {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (p *proxmoxClient) listStorages(node string) ([]proxmoxStorage, error) {
+	var storages []proxmoxStorage
+	if err := p.get(fmt.Sprintf("/nodes/%s/storage?content=images", node), &storages); err != nil {
+		return nil, err
+	}
+	var result []proxmoxStorage
+	for _, s := range storages {
+		if s.Active == 1 {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (p *proxmoxClient) waitTask(node, upid string) error {
+	var taskErr error
+	err := wait.Poll(proxmoxTaskInterval, proxmoxTaskTimeout, func() (bool, error) {
+		var status struct {
+			Status     string `json:"status"`
+			ExitStatus string `json:"exitstatus"`
+		}
+		if err := p.get(fmt.Sprintf("/nodes/%s/tasks/%s/status", node, url.PathEscape(upid)), &status); err != nil {
+			return false, err
+		}
+		if status.Status == "running" {
+			return false, nil
+		}
+		if status.ExitStatus != "OK" {
+			taskErr = errors.New(status.ExitStatus)
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("proxmox task %s did not finish in %s", upid, proxmoxTaskTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	return taskErr
+}
+
+func (p *proxmoxClient) get(uri string, result interface{}) error {
+	return p.do(http.MethodGet, uri, nil, result)
+}
+
+func (p *proxmoxClient) post(uri string, form url.Values, result interface{}) error {
+	return p.do(http.MethodPost, uri, form, result)
+}
+
+func (p *proxmoxClient) do(method, uri string, form url.Values, result interface{}) error {
+	port := 8006
+	if p.Vars["port"] != nil {
+		switch v := p.Vars["port"].(type) {
+		case float64:
+			port = int(v)
+		case string:
+			port, _ = strconv.Atoi(v)
+		}
+	}
+	endpoint := fmt.Sprintf("https://%s:%d/api2/json%s", stringVar(p.Vars, "host"), port, uri)
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if tokenID := stringVar(p.Vars, "tokenId"); tokenID != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s!%s=%s", stringVar(p.Vars, "username"), tokenID, stringVar(p.Vars, "tokenSecret")))
+	} else if p.ticket != "" {
+		req.AddCookie(&http.Cookie{Name: "PVEAuthCookie", Value: p.ticket})
+		if method != http.MethodGet {
+			req.Header.Set("CSRFPreventionToken", p.csrfToken)
+		}
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxmox %s %s failed: %s %s", method, uri, resp.Status, string(data))
+	}
+	envelope := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	if result == nil || len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, result)
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newProxmoxStandIn answers the proxmox api with canned data envelopes keyed by method and path.
+func newProxmoxStandIn(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.RequestURI()
+		body, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = fmt.Fprintf(w, `{"data":null,"errors":"%s"}`, key)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/api2/json/access/ticket" {
+			if cookie, err := r.Cookie("PVEAuthCookie"); err != nil || cookie.Value != "ticket-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":%s}`, body)
+	}))
+}
+
+func newProxmoxTestClient(t *testing.T, server *httptest.Server) *proxmoxClient {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProxmoxClient(map[string]interface{}{
+		"host":     u.Hostname(),
+		"port":     u.Port(),
+		"username": "root@pam",
+		"password": "secret",
+		"cluster":  "pve1",
+	})
+}
+
+func TestProxmoxClient(t *testing.T) {
+	server := newProxmoxStandIn(t, map[string]string{
+		"POST /api2/json/access/ticket": `{"ticket":"ticket-1","CSRFPreventionToken":"csrf-1"}`,
+		"GET /api2/json/cluster/status": `[{"type":"cluster","name":"lab"},{"type":"node","name":"pve1"}]`,
+		"GET /api2/json/cluster/resources?type=vm": `[` +
+			`{"vmid":100,"name":"kubeoperator-centos-7.6.1810","node":"pve1","type":"qemu","status":"stopped","template":1},` +
+			`{"vmid":101,"name":"demo-master-1","node":"pve1","type":"qemu","status":"running","maxcpu":4,"maxmem":8589934592},` +
+			`{"vmid":102,"name":"demo-worker-1","node":"pve1","type":"qemu","status":"stopped","maxcpu":2,"maxmem":4294967296},` +
+			`{"vmid":200,"name":"ct","node":"pve1","type":"lxc","status":"running"}]`,
+		"GET /api2/json/nodes/pve1/qemu/101/config":                       `{"net0":"virtio=AA:BB:CC:DD:EE:FF,bridge=vmbr0"}`,
+		"GET /api2/json/nodes/pve1/qemu/101/agent/network-get-interfaces": `{"result":[{"name":"lo","ip-addresses":[{"ip-address":"127.0.0.1","ip-address-type":"ipv4"}]},{"name":"eth0","ip-addresses":[{"ip-address":"10.1.0.11","ip-address-type":"ipv4"},{"ip-address":"fe80::1","ip-address-type":"ipv6"}]}]}`,
+		"GET /api2/json/nodes/pve1/storage?content=images":                `[{"storage":"local-lvm","total":107374182400,"avail":53687091200,"active":1},{"storage":"nfs","active":0}]`,
+		"GET /api2/json/nodes/pve1/status":                                `{"cpu":0.25,"cpuinfo":{"cpus":8},"memory":{"total":34359738368,"used":8589934592}}`,
+		"GET /api2/json/nodes/pve1/qemu":                                  `[{"vmid":100,"template":1,"cpus":2,"maxmem":4294967296},{"vmid":101,"status":"running","cpus":4,"maxmem":8589934592},{"vmid":102,"status":"stopped","maxcpu":2,"maxmem":4294967296}]`,
+	})
+	defer server.Close()
+	c := newProxmoxTestClient(t, server)
+
+	datacenters, err := c.ListDatacenter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datacenters) != 1 || datacenters[0] != "lab" {
+		t.Fatalf("unexpected datacenters %v", datacenters)
+	}
+
+	ips, err := c.GetIpInUsed("vmbr0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || ips[0] != "10.1.0.11" {
+		t.Fatalf("unexpected ips %v", ips)
+	}
+
+	vms, err := c.ListVms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vms) != 2 || vms[0].Name != "demo-master-1" || vms[0].Cpu != 4 || vms[0].Memory != 8192 || !vms[0].PowerOn || len(vms[0].Ips) != 1 {
+		t.Fatalf("unexpected vms %+v", vms)
+	}
+
+	exist, err := c.DefaultImageExist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exist {
+		t.Fatal("expected the default template to exist")
+	}
+
+	datastores, err := c.ListDatastores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datastores) != 1 || datastores[0].Name != "local-lvm" || datastores[0].Capacity != 100 || datastores[0].FreeSpace != 50 {
+		t.Fatalf("unexpected datastores %+v", datastores)
+	}
+
+	compute, err := c.GetCompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compute.CpuTotal != 8 || compute.CpuFree != 2 || compute.MemoryTotal != 32 || compute.MemoryFree != 20 {
+		t.Fatalf("unexpected compute %+v", compute)
+	}
+}
+
+func TestProxmoxClientRequiredVars(t *testing.T) {
+	c := NewProxmoxClient(map[string]interface{}{"host": "10.1.0.2", "username": 1})
+	if err := c.GetConnect(); err == nil {
+		t.Fatal("expected a mistyped username to be refused")
+	}
+}
+
+func TestProxmoxWaitTask(t *testing.T) {
+	interval, timeout := proxmoxTaskInterval, proxmoxTaskTimeout
+	proxmoxTaskInterval, proxmoxTaskTimeout = 10*time.Millisecond, 100*time.Millisecond
+	defer func() {
+		proxmoxTaskInterval, proxmoxTaskTimeout = interval, timeout
+	}()
+	server := newProxmoxStandIn(t, map[string]string{
+		"POST /api2/json/access/ticket":                       `{"ticket":"ticket-1","CSRFPreventionToken":"csrf-1"}`,
+		"GET /api2/json/nodes/pve1/tasks/UPID:ok/status":      `{"status":"stopped","exitstatus":"OK"}`,
+		"GET /api2/json/nodes/pve1/tasks/UPID:failed/status":  `{"status":"stopped","exitstatus":"download failed"}`,
+		"GET /api2/json/nodes/pve1/tasks/UPID:running/status": `{"status":"running"}`,
+	})
+	defer server.Close()
+	c := newProxmoxTestClient(t, server)
+	if err := c.GetConnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.waitTask("pve1", "UPID:ok"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.waitTask("pve1", "UPID:failed"); err == nil || err.Error() != "download failed" {
+		t.Fatalf("expected the exit status as error, got %v", err)
+	}
+	if err := c.waitTask("pve1", "UPID:running"); err == nil {
+		t.Fatal("expected a task that never stops to time out")
+	}
+}
@@ -0,0 +1,13 @@
+package client
+
+type SubnetResult struct {
+	ID               string `json:"id"`
+	VpcID            string `json:"vpcId"`
+	AvailabilityZone string `json:"availabilityZone"`
+	Cidr             string `json:"cidr"`
+	Gateway          string `json:"gateway"`
+	DNS              string `json:"dns"`
+	// ReservedHead and ReservedTail are the addresses at both ends of the cidr the cloud keeps for itself.
+	ReservedHead int `json:"reservedHead"`
+	ReservedTail int `json:"reservedTail"`
+}
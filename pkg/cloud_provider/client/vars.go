@@ -0,0 +1,17 @@
+package client
+
+import "fmt"
+
+// stringVar reads an optional string var of the region or zone, a missing or mistyped one is empty.
+func stringVar(vars map[string]interface{}, key string) string {
+	v, _ := vars[key].(string)
+	return v
+}
+
+// requiredVar reads a string var the provider can not work without.
+func requiredVar(vars map[string]interface{}, key string) (string, error) {
+	if v := stringVar(vars, key); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s is required", key)
+}
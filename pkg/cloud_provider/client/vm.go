@@ -0,0 +1,23 @@
+package client
+
+import "strings"
+
+// VmResult is a virtual machine the way the provider sees it, memory in MB.
+type VmResult struct {
+	Name    string   `json:"name"`
+	Ips     []string `json:"ips"`
+	Cpu     int      `json:"cpu"`
+	Memory  int      `json:"memory"`
+	PowerOn bool     `json:"powerOn"`
+}
+
+// ipv4Only drops the ipv6 and link local addresses guest tools report next to the ipv4 ones.
+func ipv4Only(ips []string) []string {
+	var results []string
+	for _, ip := range ips {
+		if strings.Contains(ip, ".") && !strings.HasPrefix(ip, "169.254.") {
+			results = append(results, ip)
+		}
+	}
+	return results
+}
@@ -261,6 +261,48 @@ func (v *vSphereClient) GetIpInUsed(network string) ([]string, error) {
 	return results, nil
 }
 
+// ListVms returns every virtual machine of the datacenter, templates left out.
+func (v *vSphereClient) ListVms() ([]VmResult, error) {
+	if err := v.GetConnect(); err != nil {
+		return nil, err
+	}
+	c := v.Client.Client
+	ctx := context.TODO()
+	m := view.NewManager(c)
+	vi, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := vi.Destroy(ctx); err != nil {
+			logger.Log.Errorf("vSphereClient Destroy failed, error: %s", err.Error())
+		}
+	}()
+	var vms []mo.VirtualMachine
+	if err := vi.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name", "summary", "guest"}, &vms); err != nil {
+		return nil, err
+	}
+	var results []VmResult
+	for _, vm := range vms {
+		if vm.Summary.Config.Template {
+			continue
+		}
+		result := VmResult{
+			Name:    vm.Name,
+			Cpu:     int(vm.Summary.Config.NumCpu),
+			Memory:  int(vm.Summary.Config.MemorySizeMB),
+			PowerOn: vm.Summary.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn,
+		}
+		if vm.Guest != nil {
+			for _, n := range vm.Guest.Net {
+				result.Ips = append(result.Ips, ipv4Only(n.IpAddress)...)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 func (v *vSphereClient) GetConnect() error {
 	u, err := soap.ParseURL(v.Vars["host"].(string) + ":" + strconv.FormatFloat(v.Vars["port"].(float64), 'G', -1, 64))
 	if err != nil {
@@ -353,7 +395,7 @@ func (v *vSphereClient) UploadImage() error {
 		}
 	}
 
-	vm, _ := f.VirtualMachine(ctx, constant.VSphereImageName)
+	vm, _ := f.VirtualMachine(ctx, imageName(v.Vars, constant.VSphereImageName))
 	if vm != nil {
 		return nil
 	}
@@ -371,6 +413,7 @@ func (v *vSphereClient) UploadImage() error {
 
 	cisp := types.OvfCreateImportSpecParams{
 		NetworkMapping: nmap,
+		EntityName:     imageName(v.Vars, constant.VSphereImageName),
 	}
 	ovfClient := ovf.NewManager(client)
 	spec, err := ovfClient.CreateImportSpec(ctx, string(o), resourcePool, datastore, cisp)
@@ -409,7 +452,7 @@ func (v *vSphereClient) UploadImage() error {
 		return err
 	}
 
-	template, err := f.VirtualMachine(ctx, constant.VSphereImageName)
+	template, err := f.VirtualMachine(ctx, imageName(v.Vars, constant.VSphereImageName))
 	if err != nil {
 		return err
 	}
@@ -446,7 +489,7 @@ func (v *vSphereClient) DefaultImageExist() (bool, error) {
 	}
 	f.SetDatacenter(datacenter)
 
-	vm, err := f.VirtualMachine(ctx, constant.VSphereImageName)
+	vm, err := f.VirtualMachine(ctx, imageName(v.Vars, constant.VSphereImageName))
 	if err != nil {
 		return false, nil
 	}
@@ -487,6 +530,10 @@ func (v *vSphereClient) CreateDefaultFolder() error {
 func (v *vSphereClient) ListDatastores() ([]DatastoreResult, error) {
 
 	var result []DatastoreResult
+	clusterName, err := requiredVar(v.Vars, "cluster")
+	if err != nil {
+		return result, err
+	}
 	if err := v.GetConnect(); err != nil {
 		return result, err
 	}
@@ -510,7 +557,7 @@ func (v *vSphereClient) ListDatastores() ([]DatastoreResult, error) {
 	}
 	var dss []mo.Datastore
 	for _, d := range clusters {
-		if d.Name == v.Vars["cluster"].(string) {
+		if d.Name == clusterName {
 			pc := property.DefaultCollector(v.Client.Client)
 			err := pc.Retrieve(ctx, d.ComputeResource.Datastore, []string{"summary", "name"}, &dss)
 			if err != nil {
@@ -529,3 +576,90 @@ func (v *vSphereClient) ListDatastores() ([]DatastoreResult, error) {
 
 	return result, nil
 }
+
+// GetCompute sums the hosts of the zone cluster, or only the host when the zone is bound to a single host.
+// The free cpu and memory are what the powered on vms have not been allocated, not the instantaneous usage.
+func (v *vSphereClient) GetCompute() (*ComputeResult, error) {
+	clusterName, err := requiredVar(v.Vars, "cluster")
+	if err != nil {
+		return nil, err
+	}
+	if err := v.GetConnect(); err != nil {
+		return nil, err
+	}
+	client := v.Client.Client
+	ctx := context.TODO()
+	m := view.NewManager(client)
+
+	vi, err := m.CreateContainerView(ctx, client.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := vi.Destroy(ctx); err != nil {
+			logger.Log.Errorf("vSphereClient Destroy failed, error: %s", err.Error())
+		}
+	}()
+	var clusters []mo.ClusterComputeResource
+	err = vi.Retrieve(ctx, []string{"ClusterComputeResource"}, []string{"name", "host"}, &clusters)
+	if err != nil {
+		return nil, err
+	}
+	pc := property.DefaultCollector(v.Client.Client)
+	var hosts []mo.HostSystem
+	for _, d := range clusters {
+		if d.Name == clusterName && len(d.Host) > 0 {
+			if err := pc.Retrieve(ctx, d.Host, []string{"name", "summary", "vm"}, &hosts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	hostSystem := ""
+	if stringVar(v.Vars, "resourceType") == "host" {
+		hostSystem = stringVar(v.Vars, "hostSystem")
+	}
+	result := &ComputeResult{}
+	var vmRefs []types.ManagedObjectReference
+	for _, h := range hosts {
+		if hostSystem != "" && h.Name != hostSystem {
+			continue
+		}
+		if h.Summary.Hardware == nil {
+			continue
+		}
+		result.CpuTotal += int(h.Summary.Hardware.NumCpuCores)
+		result.MemoryTotal += int(h.Summary.Hardware.MemorySize / (1024 * 1024 * 1024))
+		vmRefs = append(vmRefs, h.Vm...)
+	}
+	var vms []mo.VirtualMachine
+	if len(vmRefs) > 0 {
+		if err := pc.Retrieve(ctx, vmRefs, []string{"summary"}, &vms); err != nil {
+			return nil, err
+		}
+	}
+	allocatedCpu, allocatedMemory := allocatedCompute(vms)
+	result.CpuFree = nonNegative(result.CpuTotal - allocatedCpu)
+	result.MemoryFree = nonNegative(result.MemoryTotal - allocatedMemory)
+	return result, nil
+}
+
+// allocatedCompute sums the vcpus and the memory in GB allocated to the powered on vms, templates excluded.
+func allocatedCompute(vms []mo.VirtualMachine) (int, int) {
+	cpu, memoryMB := 0, 0
+	for _, vm := range vms {
+		if vm.Summary.Config.Template || vm.Summary.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			continue
+		}
+		cpu += int(vm.Summary.Config.NumCpu)
+		memoryMB += int(vm.Summary.Config.MemorySizeMB)
+	}
+	return cpu, (memoryMB + 1023) / 1024
+}
+
+func nonNegative(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"strconv"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// newVSphereStandIn runs a simulated vCenter whose cluster DC0_C0 has two hosts running vms.
+func newVSphereStandIn(t *testing.T) *simulator.Server {
+	model := simulator.VPX()
+	model.ClusterHost = 2
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	model.Service.TLS = new(tls.Config)
+	server := model.Service.NewServer()
+	t.Cleanup(func() {
+		server.Close()
+		model.Remove()
+	})
+	return server
+}
+
+func newVSphereTestClient(t *testing.T, server *simulator.Server, vars map[string]interface{}) *vSphereClient {
+	port, err := strconv.ParseFloat(server.URL.Port(), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := server.URL.User.Password()
+	client := NewVSphereClient(map[string]interface{}{
+		"host":     server.URL.Hostname(),
+		"port":     port,
+		"username": server.URL.User.Username(),
+		"password": password,
+	})
+	for k, v := range vars {
+		client.Vars[k] = v
+	}
+	return client
+}
+
+// clusterHosts reads the hosts of the cluster straight from the simulator.
+func clusterHosts(t *testing.T, client *vSphereClient, cluster string) []mo.HostSystem {
+	f := find.NewFinder(client.Client.Client, true)
+	refs, err := f.HostSystemList(context.TODO(), "/DC0/host/"+cluster+"/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hosts []mo.HostSystem
+	for _, r := range refs {
+		var h mo.HostSystem
+		if err := r.Properties(context.TODO(), r.Reference(), []string{"name", "summary", "vm"}, &h); err != nil {
+			t.Fatal(err)
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+func TestVSphereGetCompute(t *testing.T) {
+	server := newVSphereStandIn(t)
+	client := newVSphereTestClient(t, server, map[string]interface{}{"cluster": "DC0_C0"})
+
+	result, err := client.GetCompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := clusterHosts(t, client, "DC0_C0")
+	if len(hosts) != 2 {
+		t.Fatalf("expect 2 hosts in the cluster, got %d", len(hosts))
+	}
+	cores, vcpus := 0, 0
+	for _, h := range hosts {
+		cores += int(h.Summary.Hardware.NumCpuCores)
+		for _, ref := range h.Vm {
+			var vm mo.VirtualMachine
+			if err := client.Client.RetrieveOne(context.TODO(), ref, []string{"summary"}, &vm); err != nil {
+				t.Fatal(err)
+			}
+			if vm.Summary.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+				vcpus += int(vm.Summary.Config.NumCpu)
+			}
+		}
+	}
+	if vcpus == 0 {
+		t.Fatal("expect powered on vms in the cluster")
+	}
+	if result.CpuTotal != cores {
+		t.Errorf("expect %d cores, got %d", cores, result.CpuTotal)
+	}
+	if result.CpuFree != nonNegative(cores-vcpus) {
+		t.Errorf("expect %d free cores after %d allocated vcpus, got %d", nonNegative(cores-vcpus), vcpus, result.CpuFree)
+	}
+
+	single := newVSphereTestClient(t, server, map[string]interface{}{
+		"cluster":      "DC0_C0",
+		"resourceType": "host",
+		"hostSystem":   hosts[0].Name,
+	})
+	result, err = single.GetCompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CpuTotal != int(hosts[0].Summary.Hardware.NumCpuCores) {
+		t.Errorf("expect only host %s to be counted, got %d cores", hosts[0].Name, result.CpuTotal)
+	}
+}
+
+func TestVSphereRequiredCluster(t *testing.T) {
+	client := NewVSphereClient(map[string]interface{}{"cluster": 1})
+	if _, err := client.GetCompute(); err == nil || err.Error() != "cluster is required" {
+		t.Errorf("expect cluster is required, got %v", err)
+	}
+	if _, err := client.ListDatastores(); err == nil || err.Error() != "cluster is required" {
+		t.Errorf("expect cluster is required, got %v", err)
+	}
+}
+
+func TestAllocatedCompute(t *testing.T) {
+	vm := func(cpu, memoryMB int32, state types.VirtualMachinePowerState, template bool) mo.VirtualMachine {
+		var m mo.VirtualMachine
+		m.Summary.Config.NumCpu = cpu
+		m.Summary.Config.MemorySizeMB = memoryMB
+		m.Summary.Config.Template = template
+		m.Summary.Runtime.PowerState = state
+		return m
+	}
+	cpu, memory := allocatedCompute([]mo.VirtualMachine{
+		vm(4, 8192, types.VirtualMachinePowerStatePoweredOn, false),
+		vm(2, 1536, types.VirtualMachinePowerStatePoweredOn, false),
+		vm(8, 16384, types.VirtualMachinePowerStatePoweredOff, false),
+		vm(2, 4096, types.VirtualMachinePowerStatePoweredOff, true),
+	})
+	if cpu != 6 || memory != 10 {
+		t.Errorf("expect 6 vcpus and 10GB, got %d and %dGB", cpu, memory)
+	}
+	if nonNegative(4-cpu) != 0 {
+		t.Errorf("expect overcommitted hosts to have no free cpu")
+	}
+}
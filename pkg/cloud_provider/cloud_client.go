@@ -15,6 +15,20 @@ type CloudClient interface {
 	DefaultImageExist() (bool, error)
 	CreateDefaultFolder() error
 	ListDatastores() ([]client.DatastoreResult, error)
+	GetCompute() (*client.ComputeResult, error)
+	ListVms() ([]client.VmResult, error)
+}
+
+// PublicCloudClient is implemented by providers whose zones live in a vpc subnet instead of an ip pool.
+type PublicCloudClient interface {
+	CloudClient
+	GetSubnet(subnetID string) (*client.SubnetResult, error)
+	ImportKeyPair(name string, publicKey string) error
+	CreateSecurityGroup(vpcID string, name string, allowedCidrs []string) (string, error)
+}
+
+func IsPublicCloud(provider string) bool {
+	return provider == constant.AWS
 }
 
 func NewCloudClient(vars map[string]interface{}) CloudClient {
@@ -25,6 +39,12 @@ func NewCloudClient(vars map[string]interface{}) CloudClient {
 		return client.NewVSphereClient(vars)
 	case constant.FusionCompute:
 		return client.NewFusionComputeClient(vars)
+	case constant.Proxmox:
+		return client.NewProxmoxClient(vars)
+	case constant.Libvirt:
+		return client.NewLibvirtClient(vars)
+	case constant.AWS:
+		return client.NewAwsClient(vars)
 	}
 	return nil
 }
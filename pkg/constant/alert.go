@@ -0,0 +1,15 @@
+package constant
+
+// address of KubeOperator reachable from the clusters, e.g. http://10.1.1.10:8080, alertmanager
+// posts the alerts of a cluster to AlertWebhookPath of it. Stored in system setting ALERT_WEBHOOK_ENDPOINT
+const (
+	AlertWebhookEndpointKey = "ALERT_WEBHOOK_ENDPOINT"
+	AlertWebhookPath        = "/api/v1/alerts/webhook/"
+	AlertReceiverName       = "kubeoperator"
+)
+
+// alert status as sent by alertmanager
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
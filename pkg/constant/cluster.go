@@ -16,6 +16,7 @@ const (
 	ClusterLogTypeBackup     = "CLUSTER_BACKUP"
 	ClusterLogTypeRestore    = "CLUSTER_RESTORE"
 	ClusterLogTypeUpgrade    = "CLUSTER_UPGRADE"
+	ClusterLogTypeMaintain   = "CLUSTER_MAINTENANCE"
 
 	ClusterLogStatusSuccess = "SUCCESS"
 	ClusterLogStatusFailed  = "FAILED"
@@ -25,6 +26,10 @@ const (
 	// 表示创建资源
 	ClusterCreating      = "Creating"
 	ClusterSynchronizing = "Synchronizing"
+	// 按计划修复资源漂移
+	ClusterReconciling = "Reconciling"
+	// 工具已部署，工作负载尚未全部就绪
+	ClusterProgressing = "Progressing"
 
 	ClusterSourceLocal      = "local"
 	ClusterNotReady         = "NotReady"
@@ -39,6 +44,7 @@ const (
 	NodeRoleNameMaster = "master"
 	NodeRoleNameWorker = "worker"
 	LbModeInternal     = "internal"
+	LbModeExternal     = "external"
 
 	ClusterProviderBareMetal = "bareMetal"
 	ClusterProviderPlan      = "plan"
@@ -42,4 +42,7 @@ var (
 	DefaultAnsibleLogDir = path.Join(DefaultDataDir, "ansible")
 	BackupDir            = path.Join(DefaultDataDir, "backup")
 	DefaultRepositoryDir = path.Join(DefaultDataDir, "git")
+	DefaultKnownHostsDir = path.Join(DefaultDataDir, "known_hosts")
+	DefaultBastionKeyDir = path.Join(DefaultDataDir, "bastion")
+	DefaultBundleDir     = path.Join(DefaultDataDir, "bundles")
 )
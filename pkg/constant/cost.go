@@ -0,0 +1,16 @@
+package constant
+
+// types of the cost rates
+const (
+	CostRateHost         = "host"
+	CostRateVmConfig     = "vm_config"
+	CostRateStorageClass = "storage_class"
+)
+
+// CostCurrencyKey is the system setting of the currency of the cost rates, CNY when not set.
+const (
+	CostCurrencyKey     = "COST_CURRENCY"
+	DefaultCostCurrency = "CNY"
+	// CostIdleNamespace holds the cost of the resources of a cluster no namespace requested or used.
+	CostIdleNamespace = "(idle)"
+)
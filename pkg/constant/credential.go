@@ -3,4 +3,10 @@ package constant
 const (
 	Password   = "password"
 	PrivateKey = "privateKey"
+
+	SecretBackendDB    = "DB"
+	SecretBackendVault = "VAULT"
+
+	CredentialRotateSuccess = "SUCCESS"
+	CredentialRotateFailed  = "FAILED"
 )
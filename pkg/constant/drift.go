@@ -0,0 +1,11 @@
+package constant
+
+const (
+	DriftMissing    = "MISSING"
+	DriftExtra      = "EXTRA"
+	DriftResized    = "RESIZED"
+	DriftPoweredOff = "POWERED_OFF"
+
+	TerraformActionInit  = "init"
+	TerraformActionApply = "apply"
+)
@@ -0,0 +1,42 @@
+package constant
+
+const (
+	HostKeyStatusTrusted = "Trusted"
+	HostKeyStatusPending = "Pending"
+	HostKeyStatusChanged = "Changed"
+)
+
+// host key policy, stored in system setting HOST_KEY_POLICY
+const (
+	HostKeyPolicyKey      = "HOST_KEY_POLICY"
+	HostKeyPolicyTOFU     = "TOFU"
+	HostKeyPolicyApproval = "APPROVAL"
+)
+
+// known_hosts files of bastions share the dir with hosts
+const BastionFilePrefix = "bastion-"
+
+// the password of a bastion is read by sshpass from a file next to the keys of bastions
+const BastionPasswordFileSuffix = ".password"
+
+// host maintenance steps, run in this order
+const (
+	HostMaintenanceStepCordon   = "Cordon"
+	HostMaintenanceStepDrain    = "Drain"
+	HostMaintenanceStepPatch    = "Patch"
+	HostMaintenanceStepWait     = "WaitReady"
+	HostMaintenanceStepUncordon = "Uncordon"
+
+	// HostMaintenanceGroup is the inventory group the patch and reboot modules run on
+	HostMaintenanceGroup = "maintenance"
+	// HostMaintenancePatchCommand upgrades every package with the package manager of the host
+	HostMaintenancePatchCommand = "if command -v dnf >/dev/null 2>&1; then dnf -y upgrade; " +
+		"elif command -v yum >/dev/null 2>&1; then yum -y update; " +
+		"else apt-get update && DEBIAN_FRONTEND=noninteractive apt-get -y upgrade; fi"
+	// HostRebootTimeout in seconds
+	HostRebootTimeout = 600
+	// DefaultDrainTimeout in minutes
+	DefaultDrainTimeout = 10
+	// DefaultNodeReadyTimeout in minutes
+	DefaultNodeReadyTimeout = 15
+)
@@ -5,4 +5,22 @@ const (
 	IpAvailable = "IP_AVAILABLE"
 	IpLock      = "IP_LOCK"
 	IpReachable = "IP_REACHABLE"
+	IpReserved  = "IP_RESERVED"
+)
+
+const (
+	IpOperationLock      = "LOCK"
+	IpOperationUnlock    = "UNLOCK"
+	IpOperationReserve   = "RESERVE"
+	IpOperationUnreserve = "UNRESERVE"
+)
+
+const (
+	IpActionAllocate  = "ALLOCATE"
+	IpActionRelease   = "RELEASE"
+	IpActionLock      = "LOCK"
+	IpActionUnlock    = "UNLOCK"
+	IpActionReserve   = "RESERVE"
+	IpActionUnreserve = "UNRESERVE"
+	IpActionConflict  = "CONFLICT"
 )
@@ -13,3 +13,13 @@ const (
 	RemoteChartName   = "nexus/istiod-remote"
 	CorednsChartName  = "nexus/istiocoredns"
 )
+
+const (
+	DefaultIstioVersion = "v1.8.0"
+
+	IstioRevisionLabel  = "istio.io/rev"
+	IstioInjectionLabel = "istio-injection"
+	// IstioMeshConfigVar is the var of the pilot holding the mesh settings of KubeOperator, it is
+	// translated into chart values and never passed to the chart as is.
+	IstioMeshConfigVar = "meshConfig"
+)
@@ -0,0 +1,7 @@
+package constant
+
+const (
+	LbProviderF5      = "f5"
+	LbProviderHaproxy = "haproxy"
+	LbProviderOctavia = "octavia"
+)
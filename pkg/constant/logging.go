@@ -0,0 +1,27 @@
+package constant
+
+// LogConfigVar is the var of the logging and loki tools holding the log settings of KubeOperator,
+// it is translated into chart values and never passed to the chart as is.
+const LogConfigVar = "logConfig"
+
+// log shipping targets, logs stay in the cluster without target
+const (
+	LogTargetElasticsearch = "elasticsearch"
+	LogTargetLoki          = "loki"
+	LogTargetSyslog        = "syslog"
+)
+
+// DefaultLogTargetSecretName holds the user and password of the external log target, fluentd
+// reads them from the environment and promtail from the mounted file.
+const DefaultLogTargetSecretName = "kubeoperator-log-target"
+
+// DefaultLokiTenant is the tenant of the logs which are not routed, it is the one loki uses
+// without auth so the logs pushed before routes were set stay readable.
+const DefaultLokiTenant = "fake"
+
+const (
+	DefaultLogIlmPolicy    = "kubeoperator-logs"
+	DefaultLogIndexPrefix  = "logstash"
+	DefaultLogHotMaxSize   = "50gb"
+	DefaultLokiIndexPeriod = 7
+)
@@ -30,6 +30,8 @@ const (
 	ClusterRestore      = "CLUSTER_RESTORE"
 	ClusterBackup       = "CLUSTER_BACKUP"
 	ClusterEventWarning = "CLUSTER_EVENT_WARNING"
+	HostKeyChanged      = "HOST_KEY_CHANGED"
+	ClusterAlert        = "CLUSTER_ALERT"
 )
 
 //message level
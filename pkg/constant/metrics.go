@@ -0,0 +1,88 @@
+package constant
+
+// central metrics store the prometheus of every cluster remote-writes to. The clusters push to the
+// address in system setting METRICS_REMOTE_WRITE_URL, e.g. http://10.1.1.10:8428/api/v1/write, the
+// shared grafana queries the prometheus api at METRICS_QUERY_URL, which defaults to the write address
+// without /api/v1/write for stores serving both on one address.
+// METRICS_REMOTE_WRITE_AUTH is none, basic or bearer, the password is the bearer token of bearer.
+const (
+	MetricsRemoteWriteUrlKey      = "METRICS_REMOTE_WRITE_URL"
+	MetricsQueryUrlKey            = "METRICS_QUERY_URL"
+	MetricsRemoteWriteAuthKey     = "METRICS_REMOTE_WRITE_AUTH"
+	MetricsRemoteWriteUserKey     = "METRICS_REMOTE_WRITE_USER"
+	MetricsRemoteWritePasswordKey = "METRICS_REMOTE_WRITE_PASSWORD"
+	MetricsRemoteWriteSecretName  = "kubeoperator-remote-write"
+	MetricsAuthNone               = "none"
+	MetricsAuthBasic              = "basic"
+	MetricsAuthBearer             = "bearer"
+	MetricsClusterLabel           = "kubeoperator_cluster"
+	MetricsDataSourceName         = "KubeOperator-Metrics"
+	MetricsDashboardUid           = "kubeoperator-clusters"
+)
+
+const DefaultClusterCompareDashboardTemplate = `{
+  "annotations": {"list": []},
+  "editable": true,
+  "links": [],
+  "panels": [
+    {
+      "type": "graph", "title": "CPU usage", "id": 1,
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+      "yaxes": [{"format": "percent", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "100 * (1 - avg by (kubeoperator_cluster) (rate(node_cpu_seconds_total{mode=\"idle\",kubeoperator_cluster=~\"$cluster\"}[5m])))"}]
+    },
+    {
+      "type": "graph", "title": "Memory usage", "id": 2,
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0},
+      "yaxes": [{"format": "percent", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "100 * (1 - sum by (kubeoperator_cluster) (node_memory_MemAvailable_bytes{kubeoperator_cluster=~\"$cluster\"}) / sum by (kubeoperator_cluster) (node_memory_MemTotal_bytes{kubeoperator_cluster=~\"$cluster\"}))"}]
+    },
+    {
+      "type": "graph", "title": "Ready nodes", "id": 3,
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8},
+      "yaxes": [{"format": "short", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "sum by (kubeoperator_cluster) (kube_node_status_condition{condition=\"Ready\",status=\"true\",kubeoperator_cluster=~\"$cluster\"})"}]
+    },
+    {
+      "type": "graph", "title": "Running pods", "id": 4,
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 8},
+      "yaxes": [{"format": "short", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "sum by (kubeoperator_cluster) (kube_pod_status_phase{phase=\"Running\",kubeoperator_cluster=~\"$cluster\"})"}]
+    },
+    {
+      "type": "graph", "title": "Apiserver requests", "id": 5,
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 16},
+      "yaxes": [{"format": "reqps", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "sum by (kubeoperator_cluster) (rate(apiserver_request_total{kubeoperator_cluster=~\"$cluster\"}[5m]))"}]
+    },
+    {
+      "type": "graph", "title": "Network received", "id": 6,
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 16},
+      "yaxes": [{"format": "Bps", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "legendFormat": "{{kubeoperator_cluster}}",
+        "expr": "sum by (kubeoperator_cluster) (rate(node_network_receive_bytes_total{device!~\"lo|veth.*|cali.*|flannel.*|cni.*|docker.*\",kubeoperator_cluster=~\"$cluster\"}[5m]))"}]
+    }
+  ],
+  "schemaVersion": 22,
+  "tags": ["kubeoperator"],
+  "templating": {
+    "list": [
+      {
+        "name": "cluster", "label": "Cluster", "type": "query",
+        "query": "label_values(up, kubeoperator_cluster)",
+        "multi": true, "includeAll": true, "refresh": 2,
+        "current": {"text": "All", "value": "$__all"}
+      }
+    ]
+  },
+  "time": {"from": "now-6h", "to": "now"},
+  "timepicker": {},
+  "timezone": "browser",
+  "title": "Clusters",
+  "version": 0
+}`
@@ -67,6 +67,7 @@ var Roles = loader.AdvancedRules{
 			"/api/v1/clusters/{**}/{**}/{**}/{**}/{**}/{**}",
 			"/api/v1/users/change/password",
 			"/api/v1/logs",
+			"/api/v1/logsearch/search",
 			"/api/v1/message/{**}",
 			"/api/v1/message/{**}/check/{**}",
 			"/api/v1/message/{**}/{**}",
@@ -126,8 +127,11 @@ var Roles = loader.AdvancedRules{
 			"/api/v1/plans/{**}/{**}",
 			"/api/v1/vmconfigs",
 			"/api/v1/vmconfigs/{**}",
+			"/api/v1/images",
+			"/api/v1/images/{**}",
 			"/api/v1/hosts",
 			"/api/v1/hosts/{**}",
+			"/api/v1/hosts/maintenance/{**}",
 			"/api/v1/backupaccounts",
 			"/api/v1/backupaccounts/{**}",
 			"/api/v1/projects/{**}/{resources,members}",
@@ -155,6 +159,7 @@ var Roles = loader.AdvancedRules{
 		Path: []string{
 			"/api/v1/plans/search",
 			"/api/v1/vmconfigs/search",
+			"/api/v1/images/search",
 			"/api/v1/hosts/search",
 			"/api/v1/backupaccounts/search",
 		},
@@ -177,8 +182,11 @@ var Roles = loader.AdvancedRules{
 			"/api/v1/ippools/{**}",
 			"/api/v1/ippools/{**}/{**}",
 			"/api/v1/ippools/{**}/{**}/{**}",
+			"/api/v1/ippools/{**}/{**}/{**}/{**}",
 			"/api/v1/credentials",
 			"/api/v1/credentials/{**}",
+			"/api/v1/bastions",
+			"/api/v1/bastions/{**}",
 		},
 		Method: []string{"GET", "POST", "DELETE", "PUT", "PATCH"},
 		Permission: &grbac.Permission{
@@ -218,6 +226,14 @@ var Roles = loader.AdvancedRules{
 		Path: []string{
 			"/api/v1/users",
 			"/api/v1/users/{**}",
+			"/api/v1/bundles",
+			"/api/v1/bundles/{**}",
+			"/api/v1/addons",
+			"/api/v1/addons/{**}",
+			"/api/v1/monitor",
+			"/api/v1/costs/rates",
+			"/api/v1/reports/{**}",
+			"/api/v1/reports/{**}/{**}",
 		},
 		Method: []string{"GET"},
 		Permission: &grbac.Permission{
@@ -235,8 +251,19 @@ var Roles = loader.AdvancedRules{
 			"/api/v1/hosts",
 			"/api/v1/hosts/load",
 			"/api/v1/hosts/{sync,upload}",
+			"/api/v1/hosts/hostkey/{accept,reset}/{**}",
+			"/api/v1/hosts/maintenance/{**}",
+			"/api/v1/hosts/maintenance/exit/{**}",
+			"/api/v1/encrypt/keys/rotate",
+			"/api/v1/bundles",
+			"/api/v1/bundles/import",
+			"/api/v1/addons",
+			"/api/v1/monitor/sync",
+			"/api/v1/costs/rates",
 			"/api/v1/plans",
 			"/api/v1/vmconfigs",
+			"/api/v1/images",
+			"/api/v1/images/batch",
 			"/api/v1/backupaccounts",
 			"/api/v1/backupaccounts/buckets",
 			"/api/v1/projects/{**}/{resources,members}",
@@ -254,7 +281,10 @@ var Roles = loader.AdvancedRules{
 			"/api/v1/hosts/{**}",
 			"/api/v1/plans/{**}",
 			"/api/v1/vmconfigs/{**}",
+			"/api/v1/images/{**}",
 			"/api/v1/backupaccounts/{**}",
+			"/api/v1/addons/{**}",
+			"/api/v1/costs/rates/{**}",
 			"/api/v1/projects/{**}/{resources,members}/{**}",
 		},
 		Method: []string{"DELETE"},
@@ -267,6 +297,7 @@ var Roles = loader.AdvancedRules{
 		Host: []string{"*"},
 		Path: []string{
 			"/api/v1/vmconfigs/{**}",
+			"/api/v1/images/{**}",
 			"/api/v1/manifests/{**}",
 			"/api/v1/backupaccounts/{**}",
 			"/api/v1/plans/{**}",
@@ -38,8 +38,16 @@ const (
 	HEALTH_CHECK    = "集群健康检查|Health check"
 	HEALTH_RECOVER  = "集群健康恢复|Health recover"
 
+	RECONCILE_CLUSTER_DRIFT = "修复集群虚拟机漂移|Reconcile cluster drift"
+	ADOPT_CLUSTER_DRIFT     = "接受集群虚拟机漂移|Adopt cluster drift"
+
+	CREATE_CLUSTER_LOAD_BALANCER = "配置集群负载均衡|Configure cluster load balancer"
+	DELETE_CLUSTER_LOAD_BALANCER = "删除集群负载均衡|Delete cluster load balancer"
+	SYNC_CLUSTER_LOAD_BALANCER   = "同步集群负载均衡|Sync cluster load balancer"
+
 	CREATE_CLUSTER_NODE = "添加集群节点|Create cluster node"
 	DELETE_CLUSTER_NODE = "删除集群节点|Delete cluster node"
+	MAINTAIN_CLUSTER    = "集群滚动维护|Rolling cluster maintenance"
 
 	CREATE_CLUSTER_STORAGE_SUPPLIER = "添加集群存储供应商|Create cluster storage vendor"
 	DELETE_CLUSTER_STORAGE_SUPPLIER = "删除集群存储供应商|Delete cluster storage vendor"
@@ -50,11 +58,19 @@ const (
 	ENABLE_CLUSTER_NPD  = "启用NPD|Enable cluster NPD"
 	DISABLE_CLUSTER_NPD = "关闭NPD|Disable cluster NPD"
 
-	ENABLE_CLUSTER_TOOL   = "启用集群工具|Enable cluster tools"
-	UPGRADE_CLUSTER_TOOL  = "升级集群工具|Upgrade cluster tools"
-	DISABLE_CLUSTER_TOOL  = "禁用集群工具|Disable cluster tools"
-	ENABLE_CLUSTER_ISTIO  = "启用/修改集群 Istio|Enable/Update cluster Istio"
-	DISABLE_CLUSTER_ISTIO = "禁用集群 Istio|Disable cluster Istio"
+	ENABLE_CLUSTER_TOOL        = "启用集群工具|Enable cluster tools"
+	UPGRADE_CLUSTER_TOOL       = "升级集群工具|Upgrade cluster tools"
+	DISABLE_CLUSTER_TOOL       = "禁用集群工具|Disable cluster tools"
+	UPDATE_CLUSTER_TOOL_VALUES = "修改集群工具配置|Update cluster tool values"
+	ROLLBACK_CLUSTER_TOOL      = "回滚集群工具|Rollback cluster tools"
+	CREATE_CLUSTER_ADDON       = "注册集群插件|Register cluster add-on"
+	DELETE_CLUSTER_ADDON       = "删除集群插件|Delete cluster add-on"
+	ENABLE_CLUSTER_ISTIO       = "启用/修改集群 Istio|Enable/Update cluster Istio"
+	DISABLE_CLUSTER_ISTIO      = "禁用集群 Istio|Disable cluster Istio"
+	UPGRADE_CLUSTER_ISTIO      = "升级集群 Istio|Upgrade cluster Istio"
+	PROMOTE_CLUSTER_ISTIO      = "提升集群 Istio 金丝雀版本|Promote cluster Istio canary"
+	ABORT_CLUSTER_ISTIO        = "回退集群 Istio 金丝雀版本|Abort cluster Istio canary"
+	UPDATE_CLUSTER_ISTIO_MESH  = "修改集群 Istio 网格配置|Update cluster Istio mesh config"
 
 	CREATE_CLUSTER_STORAGE_CLASS   = "添加存储类|Create storage class"
 	DELETE_CLUSTER_STORAGE_CLASS   = "删除存储类|Delete storage class"
@@ -69,9 +85,13 @@ const (
 	DELETE_CLUSTER_CIS_SCAN_RESULT = "删除集群CIS扫描结果|Delete cluster CIS scan results"
 
 	// 主机
-	CREATE_HOST    = "添加主机|Create host"
-	SYNC_HOST_LIST = "主机同步|Sync host"
-	DELETE_HOST    = "删除主机|Delete host"
+	CREATE_HOST     = "添加主机|Create host"
+	SYNC_HOST_LIST  = "主机同步|Sync host"
+	DELETE_HOST     = "删除主机|Delete host"
+	ACCEPT_HOST_KEY = "信任主机密钥|Accept host key"
+	RESET_HOST_KEY  = "重置主机密钥|Reset host key"
+	MAINTAIN_HOST   = "主机维护|Host maintenance"
+	EXIT_MAINTAIN   = "主机退出维护|Exit host maintenance"
 
 	// 自动模式
 	CREATE_REGION        = "添加区域|Create region"
@@ -85,6 +105,9 @@ const (
 	CREATE_VM_CONFIG     = "添加虚拟机配置|Create virtual machine configuration"
 	UPDATE_VM_CONFIG     = "修改虚拟机配置信息|Update virtual machine configuration information"
 	DELETE_VM_CONFIG     = "删除虚拟机配置|Delete virtual machine configuration"
+	CREATE_IMAGE         = "添加镜像|Create image"
+	UPDATE_IMAGE         = "修改镜像信息|Update image information"
+	DELETE_IMAGE         = "删除镜像|Delete image"
 	CREATE_IP_POOL       = "添加IP池|Create Ip pool"
 	BACTH_DELETE_IP_POOL = "批量删除IP池|Batch delete IP pool"
 	DELETE_IP_POOL       = "删除IP池|Delete IP Pool"
@@ -106,13 +129,22 @@ const (
 	CREATE_CREDENTIALS    = "添加凭证|Create credentials"
 	UPDATE_CREDENTIALS    = "修改凭证信息|Update credential information"
 	DELETE_CREDENTIALS    = "删除凭证|Delete credentials"
+	ROTATE_CREDENTIALS    = "轮换凭证密钥|Rotate credential key"
+	CREATE_BASTION        = "添加跳板机|Create bastion"
+	UPDATE_BASTION        = "修改跳板机信息|Update bastion information"
+	DELETE_BASTION        = "删除跳板机|Delete bastion"
 	CREATE_REGISTRY       = "添加仓库信息|Create registry"
 	UPDATE_REGISTRY       = "更新仓库信息|Delete registry"
 	UPDATE_NEXUS_PASSWORD = "更新 Nexus 仓库密码|Update nexus password"
+	ROTATE_ENCRYPT_KEY    = "轮换主加密密钥|Rotate master encryption key"
 	DELETE_REGISTRY       = "删除仓库信息|Delete registry"
 	CREATE_BACKUP_ACCOUNT = "添加备份账号|Create backup account"
 	UPDATE_BACKUP_ACCOUNT = "修改备份账号信息|Update backup account information"
 	DELETE_BACKUP_ACCOUNT = "删除备份账号|Delete backup account"
 	CREATE_EMAIL          = "设置系统配置|Set system config"
 	IMPORT_LICENCE        = "导入许可证书|import licence"
+	IMPORT_OFFLINE_BUNDLE = "导入离线包|Import offline bundle"
+	SYNC_CLUSTER_MONITOR  = "配置多集群监控|Configure multi-cluster monitoring"
+	CREATE_COST_RATE      = "设置成本费率|Set cost rate"
+	DELETE_COST_RATE      = "删除成本费率|Delete cost rate"
 )
@@ -3,6 +3,9 @@ package constant
 const (
 	ArchAMD64           = "amd64"
 	ArchARM64           = "arm64"
+	ArchAll             = "all"
 	ArchitectureOfAMD64 = "x86_64"
 	ArchitectureOfARM64 = "aarch64"
+
+	NodeArchLabel = "kubernetes.io/arch"
 )
@@ -0,0 +1,10 @@
+package constant
+
+// scopes of the usage of a cluster, usage by project is only reported
+const (
+	UsageScopeCluster   = "cluster"
+	UsageScopeNode      = "node"
+	UsageScopeNamespace = "namespace"
+	UsageScopeStorage   = "storage"
+	UsageScopeProject   = "project"
+)
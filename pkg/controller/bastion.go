@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type BastionController struct {
+	Ctx            context.Context
+	BastionService service.BastionService
+}
+
+func NewBastionController() *BastionController {
+	return &BastionController{
+		BastionService: service.NewBastionService(),
+	}
+}
+
+// List Bastion
+// @Tags bastions
+// @Summary Show all bastions
+// @Description 获取跳板机列表
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /bastions/ [get]
+func (b BastionController) Get() (*page.Page, error) {
+	var p page.Page
+	items, err := b.BastionService.List()
+	if err != nil {
+		return &p, err
+	}
+	p.Items = items
+	p.Total = len(items)
+	return &p, nil
+}
+
+// Get Bastion
+// @Tags bastions
+// @Summary Show a bastion
+// @Description 获取单个跳板机
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [get]
+func (b BastionController) GetBy(name string) (*dto.Bastion, error) {
+	return b.BastionService.Get(name)
+}
+
+// Create Bastion
+// @Tags bastions
+// @Summary Create a bastion
+// @Description 创建跳板机，记录其主机密钥
+// @Accept  json
+// @Produce  json
+// @Param request body dto.BastionCreate true "request"
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/ [post]
+func (b BastionController) Post() (*dto.Bastion, error) {
+	var req dto.BastionCreate
+	err := b.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_BASTION, req.Name)
+
+	return b.BastionService.Create(req)
+}
+
+// Update Bastion
+// @Tags bastions
+// @Summary Update a bastion
+// @Description 更新跳板机，重新记录其主机密钥
+// @Accept  json
+// @Produce  json
+// @Param request body dto.BastionUpdate true "request"
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [patch]
+func (b BastionController) PatchBy(name string) (*dto.Bastion, error) {
+	var req dto.BastionUpdate
+	err := b.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_BASTION, name)
+
+	return b.BastionService.Update(name, req)
+}
+
+// Delete Bastion
+// @Tags bastions
+// @Summary Delete a bastion
+// @Description 删除跳板机
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [delete]
+func (b BastionController) DeleteBy(name string) error {
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_BASTION, name)
+	return b.BastionService.Delete(name)
+}
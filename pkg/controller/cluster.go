@@ -14,6 +14,7 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
 	"github.com/KubeOperator/KubeOperator/pkg/service"
 	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
+	"github.com/go-playground/validator/v10"
 	"github.com/kataras/iris/v12/context"
 )
 
@@ -30,6 +31,9 @@ type ClusterController struct {
 	ClusterUpgradeService            service.ClusterUpgradeService
 	ClusterHealthService             service.ClusterHealthService
 	BackupAccountService             service.BackupAccountService
+	HostMaintenanceService           service.HostMaintenanceService
+	ClusterDriftService              service.ClusterDriftService
+	ClusterLoadBalancerService       service.ClusterLoadBalancerService
 }
 
 func NewClusterController() *ClusterController {
@@ -45,6 +49,9 @@ func NewClusterController() *ClusterController {
 		ClusterUpgradeService:            service.NewClusterUpgradeService(),
 		ClusterHealthService:             service.NewClusterHealthService(),
 		BackupAccountService:             service.NewBackupAccountService(),
+		HostMaintenanceService:           service.NewHostMaintenanceService(),
+		ClusterDriftService:              service.NewClusterDriftService(),
+		ClusterLoadBalancerService:       service.NewClusterLoadBalancerService(),
 	}
 }
 
@@ -410,6 +417,60 @@ func (c ClusterController) PostToolDisableBy(clusterName string) (*dto.ClusterTo
 	return &cts, nil
 }
 
+func (c ClusterController) GetToolValuesBy(clusterName, toolName string) (*dto.ClusterToolValuesPlan, error) {
+	return c.ClusterToolService.GetValues(clusterName, toolName)
+}
+
+func (c ClusterController) PostToolValuesPlanBy(clusterName, toolName string) (*dto.ClusterToolValuesPlan, error) {
+	var req dto.ClusterToolValues
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	return c.ClusterToolService.PlanValues(clusterName, toolName, req.Vars)
+}
+
+func (c ClusterController) PostToolValuesBy(clusterName, toolName string) (*dto.ClusterToolValuesPlan, error) {
+	var req dto.ClusterToolValues
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	plan, err := c.ClusterToolService.UpdateValues(clusterName, toolName, req.Vars)
+	if err != nil {
+		logger.Log.Info(fmt.Sprintf("%+v", err))
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_CLUSTER_TOOL_VALUES, clusterName+"-"+toolName)
+
+	return plan, nil
+}
+
+func (c ClusterController) GetToolHistoryBy(clusterName, toolName string) ([]dto.ClusterToolRevision, error) {
+	return c.ClusterToolService.History(clusterName, toolName)
+}
+
+func (c ClusterController) PostToolRollbackBy(clusterName, toolName string) (*dto.ClusterTool, error) {
+	var req dto.ClusterToolRollback
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	ct, err := c.ClusterToolService.Rollback(clusterName, toolName, req.Revision)
+	if err != nil {
+		logger.Log.Info(fmt.Sprintf("%+v", err))
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ROLLBACK_CLUSTER_TOOL, fmt.Sprintf("%s-%s-%d", clusterName, toolName, req.Revision))
+
+	return &ct, nil
+}
+
 // Delete Cluster
 // @Tags clusters
 // @Summary Delete a cluster
@@ -547,6 +608,26 @@ func (c ClusterController) PostNodeBatchBy(clusterName string) error {
 	return nil
 }
 
+// Rolling Cluster Maintenance
+// @Tags clusters
+// @Summary Rolling maintenance of cluster nodes
+// @Description 按批次滚动维护集群节点，保持指定数量的节点可用
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ClusterMaintenanceRequest true "request"
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/maintenance/{clusterName} [post]
+func (c ClusterController) PostMaintenanceBy(clusterName string) error {
+	var req dto.ClusterMaintenanceRequest
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.MAINTAIN_CLUSTER, clusterName)
+	return c.HostMaintenanceService.Rolling(clusterName, req)
+}
+
 func (c ClusterController) GetWebkubectlBy(clusterName string) (*dto.WebkubectlToken, error) {
 	tk, err := c.ClusterService.GetWebkubectlToken(clusterName)
 	if err != nil {
@@ -682,6 +763,161 @@ func (c *ClusterController) PostRecoverBy(clusterName string) ([]dto.ClusterReco
 	return c.ClusterHealthService.Recover(clusterName, req)
 }
 
+// Get Cluster Terraform State
+// @Tags clusters
+// @Summary Show the last terraform run of a cluster
+// @Description 获取集群的 terraform 状态
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Success 200 {object} dto.ClusterTerraformState
+// @Security ApiKeyAuth
+// @Router /clusters/terraform/{clusterName} [get]
+func (c *ClusterController) GetTerraformBy(clusterName string) (*dto.ClusterTerraformState, error) {
+	return c.ClusterDriftService.GetTerraformState(clusterName)
+}
+
+// Get Cluster Drift
+// @Tags clusters
+// @Summary Show the drift between the provider vms and the cluster hosts
+// @Description 获取集群虚拟机漂移
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Success 200 {object} dto.ClusterDrift
+// @Security ApiKeyAuth
+// @Router /clusters/drift/{clusterName} [get]
+func (c *ClusterController) GetDriftBy(clusterName string) (*dto.ClusterDrift, error) {
+	return c.ClusterDriftService.Get(clusterName)
+}
+
+// Check Cluster Drift
+// @Tags clusters
+// @Summary Compare the provider vms with the cluster hosts now
+// @Description 检查集群虚拟机漂移
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Success 200 {object} dto.ClusterDrift
+// @Security ApiKeyAuth
+// @Router /clusters/drift/{clusterName} [post]
+func (c *ClusterController) PostDriftBy(clusterName string) (*dto.ClusterDrift, error) {
+	return c.ClusterDriftService.Check(clusterName)
+}
+
+// Reconcile Cluster Drift
+// @Tags clusters
+// @Summary Apply the cluster hosts to the provider again
+// @Description 修复集群虚拟机漂移
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Security ApiKeyAuth
+// @Router /clusters/drift/reconcile/{clusterName} [post]
+func (c *ClusterController) PostDriftReconcileBy(clusterName string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.RECONCILE_CLUSTER_DRIFT, clusterName)
+
+	return c.ClusterDriftService.Reconcile(clusterName)
+}
+
+// Adopt Cluster Drift
+// @Tags clusters
+// @Summary Accept the provider side of the drift of hosts
+// @Description 接受集群虚拟机漂移
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Param request body dto.ClusterDriftAdopt true "request"
+// @Security ApiKeyAuth
+// @Router /clusters/drift/adopt/{clusterName} [post]
+func (c *ClusterController) PostDriftAdoptBy(clusterName string) error {
+	var req dto.ClusterDriftAdopt
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ADOPT_CLUSTER_DRIFT, clusterName)
+
+	return c.ClusterDriftService.Adopt(clusterName, req)
+}
+
+// Get Cluster Load Balancer
+// @Tags clusters
+// @Summary Show the load balancer of the cluster apiserver
+// @Description 获取集群负载均衡
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Success 200 {object} dto.ClusterLoadBalancer
+// @Security ApiKeyAuth
+// @Router /clusters/lb/{clusterName} [get]
+func (c *ClusterController) GetLbBy(clusterName string) (*dto.ClusterLoadBalancer, error) {
+	return c.ClusterLoadBalancerService.Get(clusterName)
+}
+
+// Configure Cluster Load Balancer
+// @Tags clusters
+// @Summary Put a f5, haproxy or octavia load balancer in front of the cluster apiserver
+// @Description 配置集群负载均衡
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Param request body dto.ClusterLoadBalancerCreate true "request"
+// @Success 200 {object} dto.ClusterLoadBalancer
+// @Security ApiKeyAuth
+// @Router /clusters/lb/{clusterName} [post]
+func (c *ClusterController) PostLbBy(clusterName string) (*dto.ClusterLoadBalancer, error) {
+	var req dto.ClusterLoadBalancerCreate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_CLUSTER_LOAD_BALANCER, clusterName+"-"+req.Provider)
+
+	return c.ClusterLoadBalancerService.Create(clusterName, req)
+}
+
+// Delete Cluster Load Balancer
+// @Tags clusters
+// @Summary Delete the load balancer of the cluster apiserver
+// @Description 删除集群负载均衡
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Security ApiKeyAuth
+// @Router /clusters/lb/{clusterName} [delete]
+func (c *ClusterController) DeleteLbBy(clusterName string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_CLUSTER_LOAD_BALANCER, clusterName)
+
+	return c.ClusterLoadBalancerService.Delete(clusterName)
+}
+
+// Sync Cluster Load Balancer
+// @Tags clusters
+// @Summary Point the load balancer at the current masters of the cluster
+// @Description 同步集群负载均衡
+// @Accept  json
+// @Produce  json
+// @Param clusterName path string true "集群名称"
+// @Security ApiKeyAuth
+// @Router /clusters/lb/sync/{clusterName} [post]
+func (c *ClusterController) PostLbSyncBy(clusterName string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.SYNC_CLUSTER_LOAD_BALANCER, clusterName)
+
+	return c.ClusterLoadBalancerService.Sync(clusterName)
+}
+
 func (c *ClusterController) GetBackupaccountsBy(name string) ([]dto.BackupAccount, error) {
 	return c.BackupAccountService.ListByClusterName(name)
 }
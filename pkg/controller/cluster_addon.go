@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterAddonController struct {
+	Ctx                 context.Context
+	ClusterAddonService service.ClusterAddonService
+}
+
+func NewClusterAddonController() *ClusterAddonController {
+	return &ClusterAddonController{
+		ClusterAddonService: service.NewClusterAddonService(),
+	}
+}
+
+// List ClusterAddon
+// @Tags addons
+// @Summary Show all cluster add-ons
+// @Description 获取集群插件目录
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []dto.ClusterAddon
+// @Security ApiKeyAuth
+// @Router /addons [get]
+func (c ClusterAddonController) Get() ([]dto.ClusterAddon, error) {
+	return c.ClusterAddonService.List()
+}
+
+// Get ClusterAddon
+// @Tags addons
+// @Summary Show a cluster add-on
+// @Description 获取集群插件详情
+// @Accept  json
+// @Produce  json
+// @Param name path string true "插件名称"
+// @Success 200 {object} dto.ClusterAddon
+// @Security ApiKeyAuth
+// @Router /addons/{name} [get]
+func (c ClusterAddonController) GetBy(name string) (*dto.ClusterAddon, error) {
+	return c.ClusterAddonService.Get(name)
+}
+
+// Create ClusterAddon
+// @Tags addons
+// @Summary Register a cluster add-on or a new version of it
+// @Description 注册集群插件或插件新版本
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ClusterAddonCreate true "request"
+// @Success 200 {object} dto.ClusterAddon
+// @Security ApiKeyAuth
+// @Router /addons [post]
+func (c ClusterAddonController) Post() (*dto.ClusterAddon, error) {
+	var req dto.ClusterAddonCreate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_CLUSTER_ADDON, req.Name+"-"+req.Version)
+
+	return c.ClusterAddonService.Create(req)
+}
+
+// Delete ClusterAddon
+// @Tags addons
+// @Summary Delete a cluster add-on
+// @Description 删除未被启用的集群插件
+// @Accept  json
+// @Produce  json
+// @Param name path string true "插件名称"
+// @Security ApiKeyAuth
+// @Router /addons/{name} [delete]
+func (c ClusterAddonController) DeleteBy(name string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_CLUSTER_ADDON, name)
+
+	return c.ClusterAddonService.Delete(name)
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterAlertController struct {
+	Ctx                 context.Context
+	ClusterAlertService service.ClusterAlertService
+}
+
+func NewClusterAlertController() *ClusterAlertController {
+	return &ClusterAlertController{
+		ClusterAlertService: service.NewClusterAlertService(),
+	}
+}
+
+// Receive Alerts
+// @Tags alerts
+// @Summary Receive the alerts of the alertmanager of a cluster
+// @Description 接收集群 Alertmanager 告警，使用集群告警令牌认证
+// @Accept  json
+// @Produce  json
+// @Param request body dto.AlertWebhook true "request"
+// @Param cluster path string true "集群名称"
+// @Router /alerts/webhook/{cluster} [post]
+func (c ClusterAlertController) PostWebhookBy(clusterName string) error {
+	var req dto.AlertWebhook
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return err
+	}
+	token := strings.TrimPrefix(c.Ctx.GetHeader("Authorization"), "Bearer ")
+	return c.ClusterAlertService.Receive(clusterName, token, req)
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
 	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/KubeOperator/KubeOperator/pkg/util/validator_error"
+	"github.com/go-playground/validator/v10"
 	"github.com/kataras/iris/v12/context"
 )
 
@@ -58,3 +60,72 @@ func (c ClusterIstioController) PostDisableBy(clusterName string) (*[]dto.Cluste
 
 	return &cts, nil
 }
+
+func (c ClusterIstioController) GetStatusBy(clusterName string) (*dto.ClusterIstioStatus, error) {
+	return c.ClusterIstioService.Status(clusterName)
+}
+
+func (c ClusterIstioController) PostUpgradeBy(clusterName string) ([]dto.ClusterIstio, error) {
+	var req dto.ClusterIstioUpgrade
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	validator_error.RegisterTagNameFunc(c.Ctx, validate)
+	if err := validate.Struct(req); err != nil {
+		return nil, validator_error.Tr(c.Ctx, validate, err)
+	}
+	cts, err := c.ClusterIstioService.Upgrade(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPGRADE_CLUSTER_ISTIO, clusterName+"-"+req.Version)
+
+	return cts, nil
+}
+
+func (c ClusterIstioController) PostPromoteBy(clusterName string) ([]dto.ClusterIstio, error) {
+	var req dto.ClusterIstioPromote
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	cts, err := c.ClusterIstioService.Promote(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.PROMOTE_CLUSTER_ISTIO, clusterName)
+
+	return cts, nil
+}
+
+func (c ClusterIstioController) PostAbortBy(clusterName string) ([]dto.ClusterIstio, error) {
+	cts, err := c.ClusterIstioService.Abort(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ABORT_CLUSTER_ISTIO, clusterName)
+
+	return cts, nil
+}
+
+func (c ClusterIstioController) PostMeshBy(clusterName string) ([]dto.ClusterIstio, error) {
+	var req map[string]interface{}
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	cts, err := c.ClusterIstioService.UpdateMeshConfig(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_CLUSTER_ISTIO_MESH, clusterName)
+
+	return cts, nil
+}
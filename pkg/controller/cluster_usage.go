@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterUsageController struct {
+	Ctx                 context.Context
+	ClusterUsageService service.ClusterUsageService
+}
+
+func NewClusterUsageController() *ClusterUsageController {
+	return &ClusterUsageController{
+		ClusterUsageService: service.NewClusterUsageService(),
+	}
+}
+
+// Get ClusterUsage
+// @Tags clusters
+// @Summary Show the resource usage of a cluster
+// @Description 获取集群节点及命名空间的资源申请、可分配与使用量
+// @Accept  json
+// @Produce  json
+// @Param name path string true "集群名称"
+// @Success 200 {object} dto.ClusterUsage
+// @Security ApiKeyAuth
+// @Router /clusters/usage/{name} [get]
+func (c ClusterUsageController) GetBy(name string) (*dto.ClusterUsage, error) {
+	return c.ClusterUsageService.Get(name)
+}
+
+// Collect ClusterUsage
+// @Tags clusters
+// @Summary Add the current usage of a cluster to the daily report
+// @Description 采集集群资源使用量
+// @Accept  json
+// @Produce  json
+// @Param name path string true "集群名称"
+// @Success 200 {object} dto.ClusterUsage
+// @Security ApiKeyAuth
+// @Router /clusters/usage/collect/{name} [post]
+func (c ClusterUsageController) PostCollectBy(name string) (*dto.ClusterUsage, error) {
+	return c.ClusterUsageService.Collect(name)
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/KubeOperator/KubeOperator/pkg/util/validator_error"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type CostRateController struct {
+	Ctx         context.Context
+	CostService service.CostService
+}
+
+func NewCostRateController() *CostRateController {
+	return &CostRateController{
+		CostService: service.NewCostService(),
+	}
+}
+
+// List Cost Rates
+// @Tags costs
+// @Summary Show all cost rates
+// @Description 获取成本费率列表
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []dto.CostRate
+// @Security ApiKeyAuth
+// @Router /costs/rates [get]
+func (c CostRateController) Get() ([]dto.CostRate, error) {
+	return c.CostService.ListRates()
+}
+
+// Set Cost Rate
+// @Tags costs
+// @Summary Set the monthly rate of a host, a vm config or a storage class
+// @Description 设置成本费率，name 为空时为该类型的默认费率
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CostRateCreate true "request"
+// @Success 200 {object} dto.CostRate
+// @Security ApiKeyAuth
+// @Router /costs/rates [post]
+func (c CostRateController) Post() (*dto.CostRate, error) {
+	var req dto.CostRateCreate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	validator_error.RegisterTagNameFunc(c.Ctx, validate)
+	if err := validate.Struct(req); err != nil {
+		return nil, validator_error.Tr(c.Ctx, validate, err)
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_COST_RATE, req.Type+"/"+req.Name)
+
+	return c.CostService.SaveRate(req)
+}
+
+// Delete Cost Rate
+// @Tags costs
+// @Summary Delete a cost rate
+// @Description 删除成本费率
+// @Accept  json
+// @Produce  json
+// @Param id path string true "费率 ID"
+// @Security ApiKeyAuth
+// @Router /costs/rates/{id} [delete]
+func (c CostRateController) DeleteBy(id string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_COST_RATE, id)
+	return c.CostService.DeleteRate(id)
+}
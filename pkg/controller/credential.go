@@ -158,6 +158,21 @@ func (c CredentialController) PatchBy(name string) (*dto.Credential, error) {
 	return c.CredentialService.Update(name, req)
 }
 
+// Rotate Credential
+// @Tags credentials
+// @Summary Rotate the key or password of a credential
+// @Description 为凭据生成新密钥或密码并推送到所有使用它的主机
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.CredentialRotate
+// @Security ApiKeyAuth
+// @Router /credentials/rotate/{name}/ [post]
+func (c CredentialController) PostRotateBy(name string) (*dto.CredentialRotate, error) {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ROTATE_CREDENTIALS, name)
+	return c.CredentialService.Rotate(name)
+}
+
 func (c CredentialController) PostBatch() error {
 	var req dto.CredentialBatchOp
 	err := c.Ctx.ReadJSON(&req)
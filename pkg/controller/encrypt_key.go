@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type EncryptKeyController struct {
+	Ctx               context.Context
+	EncryptKeyService service.EncryptKeyService
+}
+
+func NewEncryptKeyController() *EncryptKeyController {
+	return &EncryptKeyController{
+		EncryptKeyService: service.NewEncryptKeyService(),
+	}
+}
+
+// Rotate Encrypt Key
+// @Tags encrypt
+// @Summary Rotate the master encryption key
+// @Description 生成新的主密钥并重新加密所有加密字段
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.EncryptKeyRotate
+// @Security ApiKeyAuth
+// @Router /encrypt/keys/rotate [post]
+func (e EncryptKeyController) PostKeysRotate() (*dto.EncryptKeyRotate, error) {
+	operator := e.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ROTATE_ENCRYPT_KEY, "-")
+	return e.EncryptKeyService.Rotate()
+}
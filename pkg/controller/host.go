@@ -16,15 +16,19 @@ import (
 )
 
 type HostController struct {
-	Ctx                  context.Context
-	HostService          service.HostService
-	SystemSettingService service.SystemSettingService
+	Ctx                    context.Context
+	HostService            service.HostService
+	HostKeyService         service.HostKeyService
+	HostMaintenanceService service.HostMaintenanceService
+	SystemSettingService   service.SystemSettingService
 }
 
 func NewHostController() *HostController {
 	return &HostController{
-		HostService:          service.NewHostService(),
-		SystemSettingService: service.NewSystemSettingService(),
+		HostService:            service.NewHostService(),
+		HostKeyService:         service.NewHostKeyService(),
+		HostMaintenanceService: service.NewHostMaintenanceService(),
+		SystemSettingService:   service.NewSystemSettingService(),
 	}
 }
 
@@ -216,6 +220,81 @@ func (h *HostController) PostSync() error {
 	return h.HostService.SyncList(req)
 }
 
+// Accept Host Key
+// @Tags hosts
+// @Summary Accept host key
+// @Description 信任主机待确认的主机密钥
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /hosts/hostkey/accept/{name} [post]
+func (h *HostController) PostHostkeyAcceptBy(name string) error {
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ACCEPT_HOST_KEY, name)
+	return h.HostKeyService.Accept(name)
+}
+
+// Reset Host Key
+// @Tags hosts
+// @Summary Reset host key
+// @Description 清除主机密钥，下次同步时重新记录
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /hosts/hostkey/reset/{name} [post]
+func (h *HostController) PostHostkeyResetBy(name string) error {
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.RESET_HOST_KEY, name)
+	return h.HostKeyService.Reset(name)
+}
+
+// Start Host Maintenance
+// @Tags hosts
+// @Summary Start host maintenance
+// @Description 主机进入维护，驱逐节点后可选执行系统补丁或重启，节点就绪后恢复调度
+// @Accept  json
+// @Produce  json
+// @Param request body dto.HostMaintenanceRequest true "request"
+// @Success 200 {object} dto.HostMaintenance
+// @Security ApiKeyAuth
+// @Router /hosts/maintenance/{name} [post]
+func (h *HostController) PostMaintenanceBy(name string) (*dto.HostMaintenance, error) {
+	var req dto.HostMaintenanceRequest
+	if err := h.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.MAINTAIN_HOST, name)
+	return h.HostMaintenanceService.Start(name, req)
+}
+
+// Exit Host Maintenance
+// @Tags hosts
+// @Summary Exit host maintenance
+// @Description 维护失败后恢复节点调度并退出维护
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /hosts/maintenance/exit/{name} [post]
+func (h *HostController) PostMaintenanceExitBy(name string) error {
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.EXIT_MAINTAIN, name)
+	return h.HostMaintenanceService.Exit(name)
+}
+
+// List Host Maintenance
+// @Tags hosts
+// @Summary Show maintenance records of a host
+// @Description 获取主机维护记录及步骤
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} dto.HostMaintenance
+// @Security ApiKeyAuth
+// @Router /hosts/maintenance/{name} [get]
+func (h *HostController) GetMaintenanceBy(name string) ([]dto.HostMaintenance, error) {
+	return h.HostMaintenanceService.List(name)
+}
+
 func (h *HostController) PostBatch() error {
 	var req dto.HostOp
 	err := h.Ctx.ReadJSON(&req)
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/KubeOperator/KubeOperator/pkg/util/validator_error"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ImageController struct {
+	Ctx          context.Context
+	ImageService service.ImageService
+}
+
+func NewImageController() *ImageController {
+	return &ImageController{
+		ImageService: service.NewImageService(),
+	}
+}
+
+// List Images
+// @Tags images
+// @Summary Show all images
+// @Description 获取镜像列表
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /images [get]
+func (i ImageController) Get() (*page.Page, error) {
+	p, _ := i.Ctx.Values().GetBool("page")
+	if p {
+		num, _ := i.Ctx.Values().GetInt(constant.PageNumQueryKey)
+		size, _ := i.Ctx.Values().GetInt(constant.PageSizeQueryKey)
+		return i.ImageService.Page(num, size, condition.TODO())
+	} else {
+		var p page.Page
+		items, err := i.ImageService.List(condition.TODO())
+		if err != nil {
+			return nil, err
+		}
+		p.Items = items
+		p.Total = len(items)
+		return &p, nil
+	}
+}
+
+// Search Images
+// @Tags images
+// @Summary Search images
+// @Description 过滤镜像
+// @Accept  json
+// @Produce  json
+// @Param conditions body condition.Conditions true "conditions"
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /images/search [post]
+func (i ImageController) PostSearch() (*page.Page, error) {
+	p, _ := i.Ctx.Values().GetBool("page")
+	var conditions condition.Conditions
+	if i.Ctx.GetContentLength() > 0 {
+		if err := i.Ctx.ReadJSON(&conditions); err != nil {
+			return nil, err
+		}
+	}
+	if p {
+		num, _ := i.Ctx.Values().GetInt(constant.PageNumQueryKey)
+		size, _ := i.Ctx.Values().GetInt(constant.PageSizeQueryKey)
+		return i.ImageService.Page(num, size, conditions)
+	} else {
+		var p page.Page
+		items, err := i.ImageService.List(conditions)
+		if err != nil {
+			return nil, err
+		}
+		p.Items = items
+		p.Total = len(items)
+		return &p, nil
+	}
+}
+
+// Get Image
+// @Tags images
+// @Summary Get a image
+// @Description 获取单个镜像
+// @Accept  json
+// @Produce  json
+// @Param name path string true "镜像名称"
+// @Success 200 {object} dto.Image
+// @Security ApiKeyAuth
+// @Router /images/{name} [get]
+func (i ImageController) GetBy(name string) (*dto.Image, error) {
+	return i.ImageService.Get(name)
+}
+
+// Create Image
+// @Tags images
+// @Summary Create a image
+// @Description 创建镜像
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ImageCreate true "request"
+// @Success 200 {object} dto.Image
+// @Security ApiKeyAuth
+// @Router /images [post]
+func (i ImageController) Post() (*dto.Image, error) {
+	var req dto.ImageCreate
+	err := i.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	validator_error.RegisterTagNameFunc(i.Ctx, validate)
+	err = validate.Struct(req)
+	if err != nil {
+		return nil, validator_error.Tr(i.Ctx, validate, err)
+	}
+
+	operator := i.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_IMAGE, req.Name)
+
+	return i.ImageService.Create(req)
+}
+
+// Update Image
+// @Tags images
+// @Summary Update a image
+// @Description 更新镜像
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ImageUpdate true "request"
+// @Param name path string true "镜像名称"
+// @Success 200 {object} dto.Image
+// @Security ApiKeyAuth
+// @Router /images/{name} [patch]
+func (i ImageController) PatchBy(name string) (*dto.Image, error) {
+	var req dto.ImageUpdate
+	err := i.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	validator_error.RegisterTagNameFunc(i.Ctx, validate)
+	err = validate.Struct(req)
+	if err != nil {
+		return nil, validator_error.Tr(i.Ctx, validate, err)
+	}
+	result, err := i.ImageService.Update(name, req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := i.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_IMAGE, name)
+
+	return result, nil
+}
+
+// Delete Image
+// @Tags images
+// @Summary Delete a image
+// @Description 删除镜像
+// @Accept  json
+// @Produce  json
+// @Param name path string true "镜像名称"
+// @Security ApiKeyAuth
+// @Router /images/{name} [delete]
+func (i ImageController) DeleteBy(name string) error {
+	operator := i.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_IMAGE, name)
+	return i.ImageService.Delete(name)
+}
+
+func (i ImageController) PostBatch() error {
+	var req dto.ImageOp
+	err := i.Ctx.ReadJSON(&req)
+	if err != nil {
+		return err
+	}
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		return err
+	}
+	err = i.ImageService.Batch(req)
+	if err != nil {
+		return err
+	}
+
+	operator := i.Ctx.Values().GetString("operator")
+	delImages := ""
+	for _, item := range req.Items {
+		delImages += item.Name + ","
+	}
+	go kolog.Save(operator, constant.DELETE_IMAGE, delImages)
+
+	return err
+}
@@ -167,6 +167,21 @@ func (i IpController) PatchSync() error {
 	return i.IpService.Sync(ipPoolName)
 }
 
+// Ip History
+// @Tags ips
+// @Summary Show the history of an ip
+// @Description 获取IP的分配历史
+// @Accept  json
+// @Produce  json
+// @Param name path string true "IP池名称"
+// @Param address path string true "IP地址"
+// @Success 200 {Array} dto.IpHistory
+// @Security ApiKeyAuth
+// @Router /ippools/{name}/ips/history/{address} [get]
+func (i IpController) GetHistoryBy(address string) ([]dto.IpHistory, error) {
+	return i.IpService.History(address)
+}
+
 // Delete Ip
 // @Tags ips
 // @Summary Delete a Ip
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/KubeOperator/KubeOperator/pkg/util/validator_error"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type LogSearchController struct {
+	Ctx              context.Context
+	LogSearchService service.LogSearchService
+}
+
+func NewLogSearchController() *LogSearchController {
+	return &LogSearchController{
+		LogSearchService: service.NewLogSearchService(),
+	}
+}
+
+// Search Logs
+// @Tags clusters
+// @Summary Search the logs of clusters
+// @Description 跨集群日志检索，按集群的日志工具转换为 Elasticsearch 或 LogQL 查询，默认检索用户可访问的全部集群最近 1 小时日志
+// @Accept  json
+// @Produce  json
+// @Param request body dto.LogSearch true "request"
+// @Success 200 {object} dto.LogSearchResult
+// @Security ApiKeyAuth
+// @Router /logsearch/search [post]
+func (l LogSearchController) PostSearch() (*dto.LogSearchResult, error) {
+	var req dto.LogSearch
+	if err := l.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	validator_error.RegisterTagNameFunc(l.Ctx, validate)
+	if err := validate.Struct(req); err != nil {
+		return nil, validator_error.Tr(l.Ctx, validate, err)
+	}
+	sessionUser := l.Ctx.Values().Get("user")
+	user, _ := sessionUser.(dto.SessionUser)
+	return l.LogSearchService.Search(user, req)
+}
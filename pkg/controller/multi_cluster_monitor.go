@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type MultiClusterMonitorController struct {
+	Ctx                        context.Context
+	MultiClusterMonitorService service.MultiClusterMonitorService
+}
+
+func NewMultiClusterMonitorController() *MultiClusterMonitorController {
+	return &MultiClusterMonitorController{
+		MultiClusterMonitorService: service.NewMultiClusterMonitorService(),
+	}
+}
+
+// Get MultiClusterMonitor
+// @Tags monitor
+// @Summary Show the central metrics store
+// @Description 获取多集群监控状态
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.MultiClusterMonitor
+// @Security ApiKeyAuth
+// @Router /monitor [get]
+func (m MultiClusterMonitorController) Get() (*dto.MultiClusterMonitor, error) {
+	return m.MultiClusterMonitorService.Get()
+}
+
+// Config MultiClusterMonitor
+// @Tags monitor
+// @Summary Set the central metrics store
+// @Description 设置中心监控存储地址及认证
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MultiClusterMonitorConfig true "request"
+// @Success 200 {object} dto.MultiClusterMonitor
+// @Security ApiKeyAuth
+// @Router /monitor/config [post]
+func (m MultiClusterMonitorController) PostConfig() (*dto.MultiClusterMonitor, error) {
+	var req dto.MultiClusterMonitorConfig
+	if err := m.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	result, err := m.MultiClusterMonitorService.Config(req)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := m.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.SYNC_CLUSTER_MONITOR, "-")
+
+	return result, nil
+}
+
+// Sync MultiClusterMonitor
+// @Tags monitor
+// @Summary Configure the central metrics store
+// @Description 配置多集群监控数据源、看板及集群 remote-write
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.MultiClusterMonitor
+// @Security ApiKeyAuth
+// @Router /monitor/sync [post]
+func (m MultiClusterMonitorController) PostSync() (*dto.MultiClusterMonitor, error) {
+	result, err := m.MultiClusterMonitorService.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := m.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.SYNC_CLUSTER_MONITOR, "-")
+
+	return result, nil
+}
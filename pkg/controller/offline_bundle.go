@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/kolog"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+	uuid "github.com/satori/go.uuid"
+)
+
+type OfflineBundleController struct {
+	Ctx                  context.Context
+	OfflineBundleService service.OfflineBundleService
+}
+
+func NewOfflineBundleController() *OfflineBundleController {
+	return &OfflineBundleController{
+		OfflineBundleService: service.NewOfflineBundleService(),
+	}
+}
+
+// List OfflineBundle
+// @Tags bundles
+// @Summary Show all offline bundles
+// @Description 获取离线包导入记录
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []dto.OfflineBundle
+// @Security ApiKeyAuth
+// @Router /bundles [get]
+func (o OfflineBundleController) Get() ([]dto.OfflineBundle, error) {
+	return o.OfflineBundleService.List()
+}
+
+// Get OfflineBundle
+// @Tags bundles
+// @Summary Show an offline bundle
+// @Description 获取离线包导入详情
+// @Accept  json
+// @Produce  json
+// @Param id path string true "导入记录 id"
+// @Success 200 {object} dto.OfflineBundle
+// @Security ApiKeyAuth
+// @Router /bundles/{id} [get]
+func (o OfflineBundleController) GetBy(id string) (*dto.OfflineBundle, error) {
+	return o.OfflineBundleService.Get(id)
+}
+
+// Upload OfflineBundle
+// @Tags bundles
+// @Summary Upload and import an offline bundle
+// @Description 上传并导入签名离线包
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "离线包"
+// @Success 200 {object} dto.OfflineBundle
+// @Security ApiKeyAuth
+// @Router /bundles [post]
+func (o OfflineBundleController) Post() (*dto.OfflineBundle, error) {
+	f, _, err := o.Ctx.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := os.MkdirAll(constant.DefaultBundleDir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(constant.DefaultBundleDir, uuid.NewV4().String()+".tar.gz")
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(out, f)
+	out.Close()
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	operator := o.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.IMPORT_OFFLINE_BUNDLE, "-")
+
+	return o.OfflineBundleService.Import(path, true)
+}
+
+// Import OfflineBundle
+// @Tags bundles
+// @Summary Import an offline bundle copied to the server
+// @Description 导入已拷贝至服务器的签名离线包
+// @Accept  json
+// @Produce  json
+// @Param request body dto.OfflineBundleImport true "request"
+// @Success 200 {object} dto.OfflineBundle
+// @Security ApiKeyAuth
+// @Router /bundles/import [post]
+func (o OfflineBundleController) PostImport() (*dto.OfflineBundle, error) {
+	var req dto.OfflineBundleImport
+	if err := o.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := o.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.IMPORT_OFFLINE_BUNDLE, req.Path)
+
+	return o.OfflineBundleService.Import(req.Path, false)
+}
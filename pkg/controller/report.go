@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ReportController struct {
+	Ctx                 context.Context
+	ClusterUsageService service.ClusterUsageService
+	CostService         service.CostService
+}
+
+func NewReportController() *ReportController {
+	return &ReportController{
+		ClusterUsageService: service.NewClusterUsageService(),
+		CostService:         service.NewCostService(),
+	}
+}
+
+func (r ReportController) usageQuery() dto.UsageReportQuery {
+	return dto.UsageReportQuery{
+		Start:       r.Ctx.URLParam("start"),
+		End:         r.Ctx.URLParam("end"),
+		Scope:       r.Ctx.URLParam("scope"),
+		ClusterName: r.Ctx.URLParam("clusterName"),
+		ProjectName: r.Ctx.URLParam("projectName"),
+	}
+}
+
+// Usage Report
+// @Tags reports
+// @Summary Show the capacity and usage report
+// @Description 资源容量与使用报表，scope 为 cluster, node, namespace 或 project，默认最近 30 天
+// @Accept  json
+// @Produce  json
+// @Param start query string false "开始日期 (2006-01-02)"
+// @Param end query string false "结束日期 (2006-01-02)"
+// @Param scope query string false "统计范围"
+// @Param clusterName query string false "集群名称"
+// @Param projectName query string false "项目名称"
+// @Success 200 {object} []dto.UsageReportItem
+// @Security ApiKeyAuth
+// @Router /reports/usage [get]
+func (r ReportController) GetUsage() ([]dto.UsageReportItem, error) {
+	return r.ClusterUsageService.Report(r.usageQuery())
+}
+
+// Export Usage Report
+// @Tags reports
+// @Summary Download the capacity and usage report as csv
+// @Description 导出资源容量与使用报表 (CSV)
+// @Produce  text/csv
+// @Security ApiKeyAuth
+// @Router /reports/usage/export [get]
+func (r ReportController) GetUsageExport() error {
+	data, err := r.ClusterUsageService.ExportCSV(r.usageQuery())
+	if err != nil {
+		return err
+	}
+	r.Ctx.ContentType("text/csv")
+	r.Ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=usage-%s.csv", time.Now().Format("20060102")))
+	_, err = r.Ctx.Write(data)
+	return err
+}
+
+func (r ReportController) costQuery() dto.CostReportQuery {
+	return dto.CostReportQuery{
+		Month:       r.Ctx.URLParam("month"),
+		Scope:       r.Ctx.URLParam("scope"),
+		ClusterName: r.Ctx.URLParam("clusterName"),
+		ProjectName: r.Ctx.URLParam("projectName"),
+	}
+}
+
+// Cost Report
+// @Tags reports
+// @Summary Show the monthly cost report
+// @Description 月度成本报表，scope 为 namespace, cluster 或 project，默认当月
+// @Accept  json
+// @Produce  json
+// @Param month query string false "月份 (2006-01)"
+// @Param scope query string false "统计范围"
+// @Param clusterName query string false "集群名称"
+// @Param projectName query string false "项目名称"
+// @Success 200 {object} []dto.CostReportItem
+// @Security ApiKeyAuth
+// @Router /reports/cost [get]
+func (r ReportController) GetCost() ([]dto.CostReportItem, error) {
+	return r.CostService.Report(r.costQuery())
+}
+
+// Export Cost Report
+// @Tags reports
+// @Summary Download the monthly cost report as csv
+// @Description 导出月度成本报表 (CSV)
+// @Produce  text/csv
+// @Security ApiKeyAuth
+// @Router /reports/cost/export [get]
+func (r ReportController) GetCostExport() error {
+	query := r.costQuery()
+	data, err := r.CostService.ExportCSV(query)
+	if err != nil {
+		return err
+	}
+	month := query.Month
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	r.Ctx.ContentType("text/csv")
+	r.Ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=cost-%s.csv", month))
+	_, err = r.Ctx.Write(data)
+	return err
+}
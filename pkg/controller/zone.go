@@ -113,6 +113,20 @@ func (z ZoneController) GetListBy(regionName string) ([]dto.Zone, error) {
 	return z.ZoneService.ListByRegionName(regionName)
 }
 
+// Get Zone Capacity
+// @Tags zones
+// @Summary Get the capacity of a zone
+// @Description 获取可用区剩余的 IP、存储和计算资源
+// @Accept  json
+// @Produce  json
+// @Param name path string true "可用区名称"
+// @Success 200 {object} dto.ZoneCapacity
+// @Security ApiKeyAuth
+// @Router /zones/capacity/{name} [get]
+func (z ZoneController) GetCapacityBy(name string) (*dto.ZoneCapacity, error) {
+	return z.ZoneService.Capacity(name)
+}
+
 // Create Zone
 // @Tags zones
 // @Summary Create a zone
@@ -32,6 +32,18 @@ func (c *InitCronPhase) Init() error {
 		if err != nil {
 			return fmt.Errorf("can not add cluster event corn job: %s", err.Error())
 		}
+		_, err = Cron.AddJob("@every 30m", job.NewIpConflictCheck())
+		if err != nil {
+			return fmt.Errorf("can not add ip conflict check corn job: %s", err.Error())
+		}
+		_, err = Cron.AddJob("@hourly", job.NewClusterDriftCheck())
+		if err != nil {
+			return fmt.Errorf("can not add cluster drift check corn job: %s", err.Error())
+		}
+		_, err = Cron.AddJob("@hourly", job.NewClusterUsageCollect())
+		if err != nil {
+			return fmt.Errorf("can not add cluster usage collect corn job: %s", err.Error())
+		}
 		//_, err = Cron.AddJob("@every 1m", job.NewClusterHealthCheck())
 		//if err != nil {
 		//	return fmt.Errorf("can not add cluster health check corn job: %s", err.Error())
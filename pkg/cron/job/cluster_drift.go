@@ -0,0 +1,35 @@
+package job
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+)
+
+type ClusterDriftCheck struct {
+	clusterService      service.ClusterService
+	clusterDriftService service.ClusterDriftService
+}
+
+func NewClusterDriftCheck() *ClusterDriftCheck {
+	return &ClusterDriftCheck{
+		clusterService:      service.NewClusterService(),
+		clusterDriftService: service.NewClusterDriftService(),
+	}
+}
+
+func (c *ClusterDriftCheck) Run() {
+	clusters, err := c.clusterService.List()
+	if err != nil {
+		logger.Log.Errorf("list clusters error: %s", err.Error())
+		return
+	}
+	for _, cluster := range clusters {
+		if cluster.Provider != constant.ClusterProviderPlan || cluster.Status != constant.ClusterRunning {
+			continue
+		}
+		if _, err := c.clusterDriftService.Check(cluster.Name); err != nil {
+			logger.Log.Errorf("check drift of cluster %s error: %s", cluster.Name, err.Error())
+		}
+	}
+}
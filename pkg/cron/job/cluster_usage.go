@@ -0,0 +1,35 @@
+package job
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+)
+
+type ClusterUsageCollect struct {
+	clusterService      service.ClusterService
+	clusterUsageService service.ClusterUsageService
+}
+
+func NewClusterUsageCollect() *ClusterUsageCollect {
+	return &ClusterUsageCollect{
+		clusterService:      service.NewClusterService(),
+		clusterUsageService: service.NewClusterUsageService(),
+	}
+}
+
+func (c *ClusterUsageCollect) Run() {
+	clusters, err := c.clusterService.List()
+	if err != nil {
+		logger.Log.Errorf("list clusters error: %s", err.Error())
+		return
+	}
+	for _, cluster := range clusters {
+		if cluster.Status != constant.ClusterRunning {
+			continue
+		}
+		if _, err := c.clusterUsageService.Collect(cluster.Name); err != nil {
+			logger.Log.Errorf("collect usage of cluster %s error: %s", cluster.Name, err.Error())
+		}
+	}
+}
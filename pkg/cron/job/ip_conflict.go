@@ -0,0 +1,22 @@
+package job
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+)
+
+type IpConflictCheck struct {
+	ipService service.IpService
+}
+
+func NewIpConflictCheck() *IpConflictCheck {
+	return &IpConflictCheck{
+		ipService: service.NewIpService(),
+	}
+}
+
+func (i *IpConflictCheck) Run() {
+	if err := i.ipService.DetectConflicts(); err != nil {
+		logger.Log.Errorf("detect ip conflicts error: %s", err.Error())
+	}
+}
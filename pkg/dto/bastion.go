@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type Bastion struct {
+	model.Bastion
+	CredentialName string `json:"credentialName"`
+	Fingerprint    string `json:"fingerprint"`
+}
+
+type BastionCreate struct {
+	Name           string `json:"name" validate:"required"`
+	Ip             string `json:"ip" validate:"required"`
+	Port           int    `json:"port" validate:"required"`
+	CredentialName string `json:"credentialName" validate:"required"`
+}
+
+type BastionUpdate struct {
+	Ip             string `json:"ip" validate:"required"`
+	Port           int    `json:"port" validate:"required"`
+	CredentialName string `json:"credentialName" validate:"required"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type ClusterAddon struct {
+	model.ClusterAddon
+	Values map[string]interface{} `json:"values"`
+	Charts []ClusterAddonChart    `json:"charts"`
+}
+
+type ClusterAddonChart struct {
+	Version      string `json:"version"`
+	Architecture string `json:"architecture" validate:"required,oneof=all amd64 arm64"`
+	ChartName    string `json:"chartName" validate:"required"`
+	ChartVersion string `json:"chartVersion" validate:"required"`
+}
+
+// ClusterAddonCreate registers an add-on, or a new version of it when the add-on exists. String
+// values may use the {registry}, {registry_host}, {registry_port}, {namespace} and {cluster_name}
+// placeholders.
+type ClusterAddonCreate struct {
+	Name        string                 `json:"name" validate:"required,max=64"`
+	Version     string                 `json:"version" validate:"required"`
+	Describe    string                 `json:"describe"`
+	Logo        string                 `json:"logo"`
+	Frame       bool                   `json:"frame"`
+	Url         string                 `json:"url"`
+	ProxyType   string                 `json:"proxyType" validate:"omitempty,oneof=nodeport ingress"`
+	Values      map[string]interface{} `json:"values"`
+	ReadyKind   string                 `json:"readyKind" validate:"omitempty,oneof=deployment statefulset"`
+	ReadyName   string                 `json:"readyName" validate:"required_with=ReadyKind"`
+	ServiceName string                 `json:"serviceName"`
+	ServicePort int                    `json:"servicePort" validate:"required_with=ServiceName"`
+	Charts      []ClusterAddonChart    `json:"charts" validate:"required,min=1,dive"`
+}
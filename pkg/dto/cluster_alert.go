@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// AlertWebhook is the payload alertmanager posts to its webhook receivers.
+type AlertWebhook struct {
+	Version  string  `json:"version"`
+	Status   string  `json:"status"`
+	Receiver string  `json:"receiver"`
+	Alerts   []Alert `json:"alerts"`
+}
+
+type Alert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+type ClusterTerraformState struct {
+	model.ClusterTerraformState
+	Hosts []string `json:"hosts"`
+}
+
+type ClusterDrift struct {
+	CheckedAt time.Time            `json:"checkedAt"`
+	Items     []model.ClusterDrift `json:"items"`
+}
+
+type ClusterDriftAdopt struct {
+	Hosts []string `json:"hosts" validate:"required"`
+}
@@ -7,4 +7,46 @@ type ClusterIstio struct {
 	Operation    string                 `json:"operation"`
 	Enable       bool                   `json:"enable"`
 	Vars         map[string]interface{} `json:"vars"`
+	// Versions are the versions the component can be upgraded to.
+	Versions []string `json:"versions"`
+}
+
+// ClusterIstioUpgrade upgrades the enabled components to Version, Canary installs the pilot of it as
+// a revision next to the active one instead of upgrading it in place.
+type ClusterIstioUpgrade struct {
+	Version string `json:"version" validate:"required"`
+	Canary  bool   `json:"canary"`
+}
+
+// ClusterIstioPromote promotes the canary revision, Force removes the old revision while sidecars
+// are still on it.
+type ClusterIstioPromote struct {
+	Force bool `json:"force"`
+}
+
+type ClusterIstioStatus struct {
+	Version        string                     `json:"version"`
+	Revision       string                     `json:"revision"`
+	CanaryVersion  string                     `json:"canary_version"`
+	CanaryRevision string                     `json:"canary_revision"`
+	ControlPlanes  []ClusterIstioControlPlane `json:"control_planes"`
+	Proxies        []ClusterIstioProxy        `json:"proxies"`
+	// Skewed is the number of sidecars whose version differs from the istiod of their revision.
+	Skewed int `json:"skewed"`
+}
+
+type ClusterIstioControlPlane struct {
+	Revision      string `json:"revision"`
+	Version       string `json:"version"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"ready_replicas"`
+}
+
+// ClusterIstioProxy is the sidecars of a namespace on a version of a revision.
+type ClusterIstioProxy struct {
+	Namespace string `json:"namespace"`
+	Revision  string `json:"revision"`
+	Version   string `json:"version"`
+	Pods      int    `json:"pods"`
+	Skew      bool   `json:"skew"`
 }
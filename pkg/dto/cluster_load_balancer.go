@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type ClusterLoadBalancer struct {
+	model.ClusterLoadBalancer
+	Hosts   []string          `json:"hosts"`
+	Members []string          `json:"members"`
+	Vars    map[string]string `json:"vars"`
+	F5      *model.F5Setting  `json:"f5,omitempty"`
+}
+
+// ClusterLoadBalancerCreate configures the apiserver load balancer, Hosts are the dedicated hosts of
+// haproxy, Url, User and Partition address the f5 and Vars hold the octavia settings: identity,
+// username, domainName, projectId, region and subnetId. Password is the one of f5 or octavia.
+// InsecureSkipVerify accepts a self-signed certificate on the f5 management api.
+type ClusterLoadBalancerCreate struct {
+	Provider           string            `json:"provider" validate:"required,oneof=f5 haproxy octavia"`
+	Vip                string            `json:"vip" validate:"required,ip"`
+	Port               int               `json:"port" validate:"omitempty,min=1,max=65535"`
+	Hosts              []string          `json:"hosts"`
+	Url                string            `json:"url"`
+	User               string            `json:"user"`
+	Partition          string            `json:"partition"`
+	InsecureSkipVerify bool              `json:"insecureSkipVerify"`
+	Password           string            `json:"password"`
+	Vars               map[string]string `json:"vars"`
+}
@@ -1,9 +1,78 @@
 package dto
 
-import "github.com/KubeOperator/KubeOperator/pkg/model"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
 
 type ClusterTool struct {
 	model.ClusterTool
 	NodePort string                 `json:"nodePort"`
 	Vars     map[string]interface{} `json:"vars"`
+	Release  *ClusterToolRelease    `json:"release,omitempty"`
+}
+
+type ClusterToolValues struct {
+	Vars map[string]interface{} `json:"vars"`
+}
+
+type ClusterToolValuesPlan struct {
+	Name         string                   `json:"name"`
+	Version      string                   `json:"version"`
+	ChartName    string                   `json:"chartName"`
+	ChartVersion string                   `json:"chartVersion"`
+	Schema       json.RawMessage          `json:"schema"`
+	Vars         map[string]interface{}   `json:"vars"`
+	Current      map[string]interface{}   `json:"current"`
+	Proposed     map[string]interface{}   `json:"proposed"`
+	Changes      []ClusterToolValueChange `json:"changes"`
+	Errors       []string                 `json:"errors"`
+}
+
+type ClusterToolValueChange struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+type ClusterToolRelease struct {
+	Name         string                `json:"name"`
+	Namespace    string                `json:"namespace"`
+	Status       string                `json:"status"`
+	Revision     int                   `json:"revision"`
+	Chart        string                `json:"chart"`
+	ChartVersion string                `json:"chartVersion"`
+	AppVersion   string                `json:"appVersion"`
+	Updated      time.Time             `json:"updated"`
+	Description  string                `json:"description"`
+	Drifted      bool                  `json:"drifted"`
+	DriftMessage string                `json:"driftMessage"`
+	Workloads    []ClusterToolWorkload `json:"workloads"`
+}
+
+type ClusterToolWorkload struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+	Missing   bool   `json:"missing"`
+}
+
+type ClusterToolRevision struct {
+	Revision     int       `json:"revision"`
+	Status       string    `json:"status"`
+	Chart        string    `json:"chart"`
+	ChartVersion string    `json:"chartVersion"`
+	AppVersion   string    `json:"appVersion"`
+	Updated      time.Time `json:"updated"`
+	Description  string    `json:"description"`
+	Current      bool      `json:"current"`
+}
+
+type ClusterToolRollback struct {
+	Revision int `json:"revision" validate:"required"`
 }
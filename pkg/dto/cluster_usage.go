@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
+
+type ClusterUsage struct {
+	ClusterName      string                     `json:"clusterName"`
+	MetricsAvailable bool                       `json:"metricsAvailable"`
+	StorageAvailable bool                       `json:"storageAvailable"`
+	Message          string                     `json:"message"`
+	Total            kubernetes.ResourceUsage   `json:"total"`
+	Nodes            []kubernetes.ResourceUsage `json:"nodes"`
+	Namespaces       []kubernetes.ResourceUsage `json:"namespaces"`
+	Storage          []kubernetes.StorageUsage  `json:"storage"`
+}
+
+type UsageReportQuery struct {
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	Scope       string `json:"scope"`
+	ClusterName string `json:"clusterName"`
+	ProjectName string `json:"projectName"`
+}
+
+// UsageReportItem averages the daily usage over the days of the report, the peaks are the highest
+// of the days.
+type UsageReportItem struct {
+	ProjectName       string `json:"projectName"`
+	ClusterName       string `json:"clusterName"`
+	Scope             string `json:"scope"`
+	Name              string `json:"name"`
+	Days              int    `json:"days"`
+	CpuRequested      int64  `json:"cpuRequested"`
+	CpuAllocatable    int64  `json:"cpuAllocatable"`
+	CpuUsed           int64  `json:"cpuUsed"`
+	CpuUsedPeak       int64  `json:"cpuUsedPeak"`
+	MemoryRequested   int64  `json:"memoryRequested"`
+	MemoryAllocatable int64  `json:"memoryAllocatable"`
+	MemoryUsed        int64  `json:"memoryUsed"`
+	MemoryUsedPeak    int64  `json:"memoryUsedPeak"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type CostRate struct {
+	model.CostRate
+}
+
+// CostRateCreate sets the rate of Type and Name, an empty Name sets the default rate of the type.
+type CostRateCreate struct {
+	Type          string  `json:"type" validate:"required,oneof=host vm_config storage_class"`
+	Name          string  `json:"name"`
+	CpuMonth      float64 `json:"cpuMonth" validate:"gte=0"`
+	MemoryMonth   float64 `json:"memoryMonth" validate:"gte=0"`
+	GpuMonth      float64 `json:"gpuMonth" validate:"gte=0"`
+	StorageMonth  float64 `json:"storageMonth" validate:"gte=0"`
+	InstanceMonth float64 `json:"instanceMonth" validate:"gte=0"`
+}
+
+type CostReportQuery struct {
+	Month       string `json:"month"`
+	Scope       string `json:"scope"`
+	ClusterName string `json:"clusterName"`
+	ProjectName string `json:"projectName"`
+}
+
+// CostReportItem is the cost of a project, a cluster or a namespace over the days of Month with
+// usage data. Compute is the cost of the hosts, shared by the namespaces on their requests or usage.
+type CostReportItem struct {
+	Month       string  `json:"month"`
+	ProjectName string  `json:"projectName"`
+	ClusterName string  `json:"clusterName"`
+	Scope       string  `json:"scope"`
+	Name        string  `json:"name"`
+	Days        int     `json:"days"`
+	Currency    string  `json:"currency"`
+	Compute     float64 `json:"compute"`
+	Storage     float64 `json:"storage"`
+	Total       float64 `json:"total"`
+}
@@ -30,3 +30,16 @@ type CredentialBatchOp struct {
 	Operation string       `json:"operation" validate:"required"`
 	Items     []Credential `json:"items" validate:"required"`
 }
+
+type CredentialRotate struct {
+	Name   string                 `json:"name"`
+	Status string                 `json:"status"`
+	Hosts  []CredentialRotateHost `json:"hosts"`
+}
+
+type CredentialRotateHost struct {
+	Name    string `json:"name"`
+	Ip      string `json:"ip"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
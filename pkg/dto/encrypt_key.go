@@ -0,0 +1,12 @@
+package dto
+
+type EncryptKeyRotate struct {
+	Version int                    `json:"version"`
+	Columns []EncryptColumnRotated `json:"columns"`
+}
+
+type EncryptColumnRotated struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Count  int    `json:"count"`
+}
@@ -21,6 +21,7 @@ type HostCreate struct {
 	Cluster      string                 `json:"cluster"`
 	CredentialID string                 `json:"credentialId"`
 	Credential   CredentialOfHostCreate `json:"credential"`
+	BastionID    string                 `json:"bastionId"`
 }
 
 type HostUptate struct {
@@ -29,6 +30,7 @@ type HostUptate struct {
 	Port         int                    `json:"port" validate:"required"`
 	CredentialID string                 `json:"credentialId"`
 	Credential   CredentialOfHostCreate `json:"credential"`
+	BastionID    string                 `json:"bastionId"`
 }
 
 type CredentialOfHostCreate struct {
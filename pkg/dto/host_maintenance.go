@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type HostMaintenance struct {
+	model.HostMaintenance
+	HostName string `json:"hostName"`
+}
+
+type HostMaintenanceRequest struct {
+	Patch  bool `json:"patch"`
+	Reboot bool `json:"reboot"`
+	// DrainTimeout in minutes
+	DrainTimeout int `json:"drainTimeout"`
+}
+
+type ClusterMaintenanceRequest struct {
+	HostMaintenanceRequest
+	// MinAvailable is the number of ready and schedulable nodes kept during the rolling maintenance
+	MinAvailable int      `json:"minAvailable"`
+	Nodes        []string `json:"nodes"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type Image struct {
+	model.Image
+	ImageArtifacts map[string]map[string]string `json:"artifacts"`
+}
+
+type ImageCreate struct {
+	Name           string                       `json:"name" validate:"required"`
+	OsFamily       string                       `json:"osFamily" validate:"required"`
+	OsVersion      string                       `json:"osVersion" validate:"required"`
+	Architecture   string                       `json:"architecture" validate:"required,oneof=x86_64 aarch64"`
+	Checksum       string                       `json:"checksum"`
+	ImageArtifacts map[string]map[string]string `json:"artifacts" validate:"required"`
+}
+
+type ImageUpdate struct {
+	OsFamily       string                       `json:"osFamily"`
+	OsVersion      string                       `json:"osVersion"`
+	Architecture   string                       `json:"architecture" validate:"omitempty,oneof=x86_64 aarch64"`
+	Checksum       string                       `json:"checksum"`
+	ImageArtifacts map[string]map[string]string `json:"artifacts"`
+}
+
+type ImageOp struct {
+	Operation string  `json:"operation" validate:"required"`
+	Items     []Image `json:"items" validate:"required"`
+}
@@ -14,6 +14,9 @@ type IpCreate struct {
 	DNS1       string `json:"dns1"`
 	DNS2       string `json:"dns2"`
 	IpPoolName string `json:"ipPoolName"`
+	// Cidr and Exclusions create the addresses of a cidr inside the pool subnet when no start and end is given
+	Cidr       string   `json:"cidr"`
+	Exclusions []string `json:"exclusions"`
 }
 
 type IpOp struct {
@@ -22,6 +25,11 @@ type IpOp struct {
 }
 
 type IpUpdate struct {
-	Address   string `json:"address"`
-	Operation string `json:"operation"`
+	Address     string `json:"address"`
+	Operation   string `json:"operation"`
+	ReservedFor string `json:"reservedFor"`
+}
+
+type IpHistory struct {
+	model.IpHistory
 }
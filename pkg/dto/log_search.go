@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/util/logsearch"
+)
+
+// LogSearch searches the logs of the clusters, all clusters of the user without clusters. The
+// last hour is searched without time range.
+type LogSearch struct {
+	Clusters  []string  `json:"clusters"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Query     string    `json:"query"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Limit     int       `json:"limit" validate:"gte=0,lte=1000"`
+}
+
+type LogSearchResult struct {
+	Items  []LogEntry       `json:"items"`
+	Errors []LogSearchError `json:"errors"`
+}
+
+type LogEntry struct {
+	ClusterName string `json:"clusterName"`
+	Source      string `json:"source"`
+	logsearch.Entry
+}
+
+// LogSearchError is a cluster which could not be searched, the results of the others are returned.
+type LogSearchError struct {
+	ClusterName string `json:"clusterName"`
+	Message     string `json:"message"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+type MultiClusterMonitor struct {
+	RemoteWriteUrl string                       `json:"remoteWriteUrl"`
+	QueryUrl       string                       `json:"queryUrl"`
+	Auth           string                       `json:"auth"`
+	User           string                       `json:"user"`
+	DataSource     string                       `json:"dataSource"`
+	DashboardUrl   string                       `json:"dashboardUrl"`
+	Clusters       []MultiClusterMonitorCluster `json:"clusters"`
+}
+
+type MultiClusterMonitorCluster struct {
+	Name             string `json:"name"`
+	PrometheusStatus string `json:"prometheusStatus"`
+	Reporting        bool   `json:"reporting"`
+	Message          string `json:"message"`
+}
+
+type MultiClusterMonitorConfig struct {
+	RemoteWriteUrl string `json:"remoteWriteUrl" validate:"required,url"`
+	QueryUrl       string `json:"queryUrl" validate:"omitempty,url"`
+	Auth           string `json:"auth" validate:"required,oneof=none basic bearer"`
+	User           string `json:"user"`
+	Password       string `json:"password"`
+}
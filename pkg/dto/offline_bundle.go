@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/KubeOperator/KubeOperator/pkg/model"
+
+type OfflineBundle struct {
+	model.OfflineBundle
+	Manifests []string `json:"manifests"`
+}
+
+// OfflineBundleImport imports a bundle already copied to the KubeOperator server.
+type OfflineBundleImport struct {
+	Path string `json:"path" validate:"required"`
+}
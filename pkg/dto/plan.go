@@ -12,6 +12,7 @@ type Plan struct {
 	Zones    []string    `json:"zones"`
 	Projects []string    `json:"projects"`
 	Provider string      `json:"provider"`
+	Image    string      `json:"image"`
 }
 
 type PlanCreate struct {
@@ -21,6 +22,7 @@ type PlanCreate struct {
 	DeployTemplate string      `json:"deployTemplate" validate:"required"`
 	Projects       []string    `json:"projects" validate:"required"`
 	Region         string      `json:"region" validate:"required"`
+	Image          string      `json:"image"`
 }
 
 type PlanOp struct {
@@ -36,4 +38,5 @@ type PlanVmConfig struct {
 type PlanUpdate struct {
 	PlanVars interface{} `json:"planVars" validate:"required"`
 	Projects []string    `json:"projects" validate:"required"`
+	Image    string      `json:"image"`
 }
@@ -4,15 +4,38 @@ import "github.com/KubeOperator/KubeOperator/pkg/model"
 
 type Project struct {
 	model.Project
+	Quota *model.ProjectQuota `json:"quota,omitempty"`
+	Usage *ProjectUsage       `json:"usage,omitempty"`
 }
 
 type ProjectCreate struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description"`
+	Name        string        `json:"name" validate:"required"`
+	Description string        `json:"description"`
+	BastionName string        `json:"bastionName"`
+	Quota       *ProjectQuota `json:"quota"`
 }
 
 type ProjectUpdate struct {
-	Description string `json:"description"`
+	Description string        `json:"description"`
+	BastionName string        `json:"bastionName"`
+	Quota       *ProjectQuota `json:"quota"`
+}
+
+type ProjectQuota struct {
+	MaxClusters int `json:"maxClusters" validate:"min=0"`
+	MaxNodes    int `json:"maxNodes" validate:"min=0"`
+	MaxCpu      int `json:"maxCpu" validate:"min=0"`
+	MaxMemory   int `json:"maxMemory" validate:"min=0"`
+	MaxIps      int `json:"maxIps" validate:"min=0"`
+}
+
+// ProjectUsage is what the clusters of a project take, memory in GB.
+type ProjectUsage struct {
+	Clusters int `json:"clusters"`
+	Nodes    int `json:"nodes"`
+	Cpu      int `json:"cpu"`
+	Memory   int `json:"memory"`
+	Ips      int `json:"ips"`
 }
 type ProjectPage struct {
 	Items []Project `json:"items"`
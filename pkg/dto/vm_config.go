@@ -4,6 +4,7 @@ import "github.com/KubeOperator/KubeOperator/pkg/model"
 
 type VmConfig struct {
 	model.VmConfig
+	Image string `json:"image"`
 }
 
 type VmConfigOp struct {
@@ -16,6 +17,7 @@ type VmConfigCreate struct {
 	Provider string `json:"provider"`
 	Cpu      int    `json:"cpu" validate:"min=1,max=1000,required" en:"CPU" zh:"CPU"`
 	Memory   int    `json:"memory"  validate:"min=1,max=1000,required" en:"Memory" zh:"内存"`
+	Image    string `json:"image"`
 }
 
 type VmConfigUpdate struct {
@@ -23,4 +25,5 @@ type VmConfigUpdate struct {
 	Provider string `json:"provider"`
 	Cpu      int    `json:"cpu" validate:"min=1,max=1000,required" en:"CPU" zh:"CPU"`
 	Memory   int    `json:"memory" validate:"min=1,max=1000,required" en:"Memory" zh:"内存"`
+	Image    string `json:"image"`
 }
@@ -9,6 +9,7 @@ type Zone struct {
 	Provider       string      `json:"provider"`
 	IpPoolName     string      `json:"ipPoolName"`
 	CredentialName string      `json:"credentialName"`
+	BastionName    string      `json:"bastionName"`
 	IpPool         IpPool      `json:"ipPool"`
 }
 
@@ -18,6 +19,7 @@ type ZoneCreate struct {
 	RegionName     string      `json:"regionName" validate:"required"`
 	IpPoolName     string      `json:"ipPoolName"`
 	CredentialName string      `json:"credentialName"`
+	BastionName    string      `json:"bastionName"`
 }
 
 type ZoneOp struct {
@@ -40,8 +42,9 @@ type ZoneUpdate struct {
 	Name           string      `json:"name" validate:"required"`
 	CloudVars      interface{} `json:"cloudVars" validate:"required"`
 	RegionID       string      `json:"regionID" validate:"required"`
-	IpPoolName     string      `json:"ipPoolName" validate:"required"`
+	IpPoolName     string      `json:"ipPoolName"`
 	CredentialName string      `json:"credentialName"`
+	BastionName    string      `json:"bastionName"`
 }
 
 type CloudDatastore struct {
@@ -49,3 +52,18 @@ type CloudDatastore struct {
 	Capacity  int    `json:"capacity"`
 	FreeSpace int    `json:"freeSpace"`
 }
+
+type CloudCompute struct {
+	CpuTotal    int `json:"cpuTotal"`
+	CpuFree     int `json:"cpuFree"`
+	MemoryTotal int `json:"memoryTotal"`
+	MemoryFree  int `json:"memoryFree"`
+}
+
+type ZoneCapacity struct {
+	Name       string           `json:"name"`
+	IpTotal    int              `json:"ipTotal"`
+	IpFree     int              `json:"ipFree"`
+	Datastores []CloudDatastore `json:"datastores"`
+	Compute    *CloudCompute    `json:"compute"`
+}
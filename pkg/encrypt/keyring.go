@@ -0,0 +1,18 @@
+package encrypt
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/service"
+)
+
+const keyringPhaseName = "encrypt keyring"
+
+// InitKeyringPhase loads the master keys added by rotation, it runs after the migrations.
+type InitKeyringPhase struct{}
+
+func (c *InitKeyringPhase) Init() error {
+	return service.NewEncryptKeyService().Load()
+}
+
+func (c *InitKeyringPhase) PhaseName() string {
+	return keyringPhaseName
+}
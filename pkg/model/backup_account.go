@@ -5,6 +5,7 @@ import (
 
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -38,3 +39,25 @@ func (b *BackupAccount) BeforeDelete() (err error) {
 	}
 	return err
 }
+
+// BeforeSave encrypts the credential vars, they are decrypted again once saved or loaded.
+func (b *BackupAccount) BeforeSave() (err error) {
+	if b.Credential == "" || encrypt.IsEnvelope(b.Credential) {
+		return nil
+	}
+	b.Credential, err = encrypt.StringEncrypt(b.Credential)
+	return err
+}
+
+func (b *BackupAccount) AfterSave() (err error) {
+	return b.AfterFind()
+}
+
+// AfterFind decrypts the credential vars, the ones saved before they were encrypted are kept as is.
+func (b *BackupAccount) AfterFind() (err error) {
+	if !encrypt.IsEnvelope(b.Credential) {
+		return nil
+	}
+	b.Credential, err = encrypt.StringDecrypt(b.Credential)
+	return err
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	DeleteBastionFailed       = "DELETE_BASTION_FAILED"
+	BastionHostKeyNotVerified = "BASTION_HOST_KEY_NOT_VERIFIED"
+)
+
+type Bastion struct {
+	common.BaseModel
+	ID           string     `json:"id" gorm:"type:varchar(64)"`
+	Name         string     `json:"name" gorm:"type:varchar(256);not null;unique"`
+	Ip           string     `json:"ip" gorm:"type:varchar(128);not null"`
+	Port         int        `json:"port" gorm:"type:int(64)"`
+	CredentialID string     `json:"credentialId" gorm:"type:varchar(64)"`
+	Credential   Credential `json:"-" gorm:"save_associations:false"`
+	HostKey      string     `json:"hostKey" gorm:"type:text(65535)"`
+}
+
+func (b *Bastion) BeforeCreate() (err error) {
+	b.ID = uuid.NewV4().String()
+	return err
+}
+
+func (b *Bastion) BeforeDelete(tx *gorm.DB) (err error) {
+	var num int
+	for _, m := range []interface{}{&Host{}, &Zone{}, &Project{}} {
+		if err := tx.Model(m).Where("bastion_id = ?", b.ID).Count(&num).Error; err != nil {
+			return err
+		}
+		if num > 0 {
+			return errors.New(DeleteBastionFailed)
+		}
+	}
+	return nil
+}
+
+func (b Bastion) ToSSHConfig() (*ssh.Config, error) {
+	config := &ssh.Config{
+		User:        b.Credential.Username,
+		Host:        b.Ip,
+		Port:        b.Port,
+		DialTimeOut: 5 * time.Second,
+		HostKey:     b.HostKey,
+	}
+	password, privateKey, err := b.Credential.GetSecret()
+	if err != nil {
+		return nil, err
+	}
+	switch b.Credential.Type {
+	case constant.Password:
+		config.Password = password
+	case constant.PrivateKey:
+		config.PrivateKey = []byte(privateKey)
+	}
+	return config, nil
+}
+
+// ProxyCommandArg renders the ssh option ansible uses to jump through the bastion, the files it refers
+// to are written by WriteConnectionFiles. The password of the bastion is read by sshpass from a file
+// so it shows up neither in the inventory nor in the process list.
+func (b Bastion) ProxyCommandArg() string {
+	args := []string{"ssh", "-W", "%h:%p", "-q", "-p", fmt.Sprint(b.Port),
+		"-o", "UserKnownHostsFile=" + ansible.KnownHostsPath(constant.BastionFilePrefix+b.Name), "-o", "StrictHostKeyChecking=yes"}
+	switch b.Credential.Type {
+	case constant.Password:
+		args = append([]string{"sshpass", "-f", ansible.BastionKeyPath(b.Name + constant.BastionPasswordFileSuffix)}, args...)
+	case constant.PrivateKey:
+		args = append(args, "-i", ansible.BastionKeyPath(b.Name))
+	}
+	args = append(args, fmt.Sprintf("%s@%s", b.Credential.Username, b.Ip))
+	return fmt.Sprintf("-o ProxyCommand=\"%s\"", strings.Join(args, " "))
+}
+
+// WriteConnectionFiles writes the known_hosts and the secret the proxy command of the bastion uses,
+// a bastion whose key is not verified is refused.
+func (b Bastion) WriteConnectionFiles() error {
+	if b.HostKey == "" {
+		return errors.New(BastionHostKeyNotVerified)
+	}
+	line, err := ssh.KnownHostsLine(b.Ip, b.Port, b.HostKey)
+	if err != nil {
+		return err
+	}
+	if _, err := ansible.WriteKnownHosts(constant.BastionFilePrefix+b.Name, line); err != nil {
+		return err
+	}
+	password, privateKey, err := b.Credential.GetSecret()
+	if err != nil {
+		return err
+	}
+	switch b.Credential.Type {
+	case constant.Password:
+		_, err = ansible.WriteBastionKey(b.Name+constant.BastionPasswordFileSuffix, password)
+	case constant.PrivateKey:
+		_, err = ansible.WriteBastionKey(b.Name, privateKey)
+	}
+	return err
+}
+
+// GetBastion returns the bastion of the host, falling back to the one of its zone and then of its project.
+func (h Host) GetBastion() (*Bastion, error) {
+	bastionID := h.BastionID
+	if bastionID == "" && h.ZoneID != "" {
+		var zone Zone
+		if err := db.DB.Select("bastion_id").Where("id = ?", h.ZoneID).First(&zone).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+			return nil, err
+		}
+		bastionID = zone.BastionID
+	}
+	if bastionID == "" && h.ID != "" {
+		var resource ProjectResource
+		if err := db.DB.Where("resource_id = ? AND resource_type = ?", h.ID, constant.ResourceHost).First(&resource).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+			return nil, err
+		}
+		if resource.ProjectID != "" {
+			var project Project
+			if err := db.DB.Select("bastion_id").Where("id = ?", resource.ProjectID).First(&project).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+				return nil, err
+			}
+			bastionID = project.BastionID
+		}
+	}
+	if bastionID == "" {
+		return nil, nil
+	}
+	var bastion Bastion
+	if err := db.DB.Where("id = ?", bastionID).Preload("Credential").First(&bastion).Error; err != nil {
+		return nil, err
+	}
+	return &bastion, nil
+}
+
+// BastionSSHConfig is the ssh config of the bastion of the host, nil when it is reached directly.
+func (h Host) BastionSSHConfig() (*ssh.Config, error) {
+	bastion, err := h.GetBastion()
+	if err != nil || bastion == nil {
+		return nil, err
+	}
+	if bastion.HostKey == "" {
+		return nil, errors.New(BastionHostKeyNotVerified)
+	}
+	return bastion.ToSSHConfig()
+}
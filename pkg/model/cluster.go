@@ -93,11 +93,9 @@ func (c Cluster) BeforeDelete() error {
 			tx.Rollback()
 			return err
 		}
-		if len(hostIPList) > 0 {
-			if err := tx.Model(&Ip{}).Where("address in (?)", hostIPList).Update("status", constant.IpAvailable).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
+		if err := ReleaseIps(tx, hostIPList); err != nil {
+			tx.Rollback()
+			return err
 		}
 	} else {
 		if err := tx.Model(&Host{}).Where("cluster_id = ?", c.ID).Updates(map[string]interface{}{"ClusterID": ""}).Error; err != nil {
@@ -158,6 +156,23 @@ func (c Cluster) BeforeDelete() error {
 		return err
 	}
 
+	if err := tx.Where("cluster_id = ?", c.ID).Delete(&ClusterTerraformState{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", c.ID).Delete(&ClusterDrift{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", c.ID).Delete(&ClusterLoadBalancer{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", c.ID).Delete(&F5Setting{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	var (
 		messages   []Message
 		messageIDs []string
@@ -229,25 +244,25 @@ func (c Cluster) PrepareIstios() []ClusterIstio {
 	return []ClusterIstio{
 		{
 			Name:     "base",
-			Version:  "v1.8.0",
+			Version:  constant.DefaultIstioVersion,
 			Describe: "",
 			Status:   constant.ClusterWaiting,
 		},
 		{
 			Name:     "pilot",
-			Version:  "v1.8.0",
+			Version:  constant.DefaultIstioVersion,
 			Describe: "",
 			Status:   constant.ClusterWaiting,
 		},
 		{
 			Name:     "ingress",
-			Version:  "v1.8.0",
+			Version:  constant.DefaultIstioVersion,
 			Describe: "",
 			Status:   constant.ClusterWaiting,
 		},
 		{
 			Name:     "egress",
-			Version:  "v1.8.0",
+			Version:  constant.DefaultIstioVersion,
 			Describe: "",
 			Status:   constant.ClusterWaiting,
 		},
@@ -255,7 +270,7 @@ func (c Cluster) PrepareIstios() []ClusterIstio {
 }
 
 func (c Cluster) PrepareTools() []ClusterTool {
-	return []ClusterTool{
+	tools := []ClusterTool{
 		{
 			Name:         "kubepi",
 			Version:      "v1.1.0",
@@ -339,6 +354,12 @@ func (c Cluster) PrepareTools() []ClusterTool {
 			Architecture: supportedArchitectureAll,
 		},
 	}
+	var addons []ClusterAddon
+	db.DB.Order("name").Find(&addons)
+	for _, addon := range addons {
+		tools = append(tools, addon.Tool())
+	}
+	return tools
 }
 
 func (c Cluster) GetKobeVars() map[string]string {
@@ -441,12 +462,66 @@ func (c Cluster) GetKobeVars() map[string]string {
 	return result
 }
 
-func (c Cluster) ParseInventory() *api.Inventory {
+// NodeArchitectures returns the distinct architectures of the cluster nodes,
+// falling back to the spec when the node hosts carry no architecture.
+func (c Cluster) NodeArchitectures() []string {
+	var archs []string
+	for _, node := range c.Nodes {
+		arch := node.Host.Arch()
+		if arch == "" {
+			continue
+		}
+		found := false
+		for _, a := range archs {
+			if a == arch {
+				found = true
+				break
+			}
+		}
+		if !found {
+			archs = append(archs, arch)
+		}
+	}
+	if len(archs) == 0 {
+		switch c.Spec.Architectures {
+		case constant.ArchAll:
+			archs = []string{constant.ArchAMD64, constant.ArchARM64}
+		case "":
+		default:
+			archs = []string{c.Spec.Architectures}
+		}
+	}
+	return archs
+}
+
+// WriteConnectionFiles writes the known_hosts and bastion files the inventory of the cluster refers
+// to, for the nodes and the hosts of a haproxy load balancer. lb is the managed load balancer, nil for none.
+func (c Cluster) WriteConnectionFiles(lb *ClusterLoadBalancer) error {
+	hosts := make([]Host, 0, len(c.Nodes))
+	for _, node := range c.Nodes {
+		hosts = append(hosts, node.Host)
+	}
+	if lb != nil && lb.Provider == constant.LbProviderHaproxy {
+		hosts = append(hosts, lb.HaproxyHosts...)
+	}
+	for _, h := range hosts {
+		if err := h.WriteConnectionFiles(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseInventory lists the nodes of the cluster for ansible, lb is the managed load balancer, nil for none.
+func (c Cluster) ParseInventory(lb *ClusterLoadBalancer) *api.Inventory {
 	var masters []string
 	var workers []string
 	var chrony []string
 	var hosts []*api.Host
 	var lbhosts []string
+	archHosts := map[string][]string{}
+	// a managed load balancer takes over keepalived from the masters
+	managedLb := lb != nil
 
 	i := 0
 	for _, node := range c.Nodes {
@@ -460,7 +535,7 @@ func (c Cluster) ParseInventory() *api.Inventory {
 				workers = append(workers, node.Name)
 			}
 		}
-		if c.Spec.LbMode == "external" {
+		if c.Spec.LbMode == "external" && !managedLb {
 			if node.Role == constant.NodeRoleNameMaster {
 				lbhosts = append(lbhosts, node.Name)
 			}
@@ -473,53 +548,81 @@ func (c Cluster) ParseInventory() *api.Inventory {
 		} else {
 			hosts = append(hosts, node.ToKobeHost("internal"))
 		}
+		arch := hosts[len(hosts)-1].Vars["architectures"]
+		if arch == "" {
+			arch = node.Host.Arch()
+		}
+		if arch != "" {
+			archHosts[arch] = append(archHosts[arch], node.Name)
+		}
+	}
+	if managedLb && lb.Provider == constant.LbProviderHaproxy {
+		for j, h := range lb.HaproxyHosts {
+			host := ClusterNode{Name: h.Name, Host: h}.ToKobeHost(constant.LbModeInternal)
+			host.Vars["lb_role"] = "backup"
+			if j == 0 {
+				host.Vars["lb_role"] = "master"
+			}
+			hosts = append(hosts, host)
+			lbhosts = append(lbhosts, h.Name)
+		}
 	}
 	if len(masters) > 0 {
 		chrony = append(chrony, masters[0])
 	}
-	return &api.Inventory{
-		Hosts: hosts,
-		Groups: []*api.Group{
-			{
-				Name:     "kube-master",
-				Hosts:    masters,
-				Children: []string{},
-				Vars:     map[string]string{},
-			},
-			{
-				Name:  "kube-worker",
-				Hosts: workers,
-				Children: []string{
-					"kube-master",
-				},
-				Vars: map[string]string{},
-			},
-			{
-				Name:  "new-worker",
-				Hosts: []string{},
-				Vars:  map[string]string{},
-			}, {
-				Name:  "ex_lb",
-				Hosts: lbhosts,
-				Vars:  map[string]string{},
-			},
-			{
-				Name:     "etcd",
-				Hosts:    masters,
-				Children: []string{"kube-master"},
-				Vars:     map[string]string{},
-			}, {
-				Name:     "chrony",
-				Hosts:    chrony,
-				Children: []string{},
-				Vars:     map[string]string{},
-			},
-			{
-				Name:     "del-worker",
-				Hosts:    []string{},
-				Children: []string{},
-				Vars:     map[string]string{},
+	groups := []*api.Group{
+		{
+			Name:     "kube-master",
+			Hosts:    masters,
+			Children: []string{},
+			Vars:     map[string]string{},
+		},
+		{
+			Name:  "kube-worker",
+			Hosts: workers,
+			Children: []string{
+				"kube-master",
 			},
+			Vars: map[string]string{},
+		},
+		{
+			Name:  "new-worker",
+			Hosts: []string{},
+			Vars:  map[string]string{},
+		}, {
+			Name:  "ex_lb",
+			Hosts: lbhosts,
+			Vars:  map[string]string{},
 		},
+		{
+			Name:     "etcd",
+			Hosts:    masters,
+			Children: []string{"kube-master"},
+			Vars:     map[string]string{},
+		}, {
+			Name:     "chrony",
+			Hosts:    chrony,
+			Children: []string{},
+			Vars:     map[string]string{},
+		},
+		{
+			Name:     "del-worker",
+			Hosts:    []string{},
+			Children: []string{},
+			Vars:     map[string]string{},
+		},
+	}
+	// per architecture groups let playbooks pull the binaries of each node's arch
+	for _, arch := range []string{constant.ArchAMD64, constant.ArchARM64} {
+		groups = append(groups, &api.Group{
+			Name:     fmt.Sprintf("arch_%s", arch),
+			Hosts:    archHosts[arch],
+			Children: []string{},
+			Vars:     map[string]string{"architectures": arch},
+		})
+	}
+	return &api.Inventory{
+		Hosts:  hosts,
+		Groups: groups,
 	}
 }
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterAddon is a helm chart registered by admins as a cluster tool. Values holds the json helm
+// values template, ReadyKind and ReadyName the workload telling the add-on is running. The charts
+// of every version and architecture are kept as ClusterToolDetail rows whose vars carry chart_name.
+type ClusterAddon struct {
+	common.BaseModel
+	ID           string `json:"-" gorm:"type:varchar(64)"`
+	Name         string `json:"name" gorm:"type:varchar(64)"`
+	Version      string `json:"version" gorm:"type:varchar(64)"`
+	Describe     string `json:"describe" gorm:"type:varchar(255)"`
+	Logo         string `json:"logo" gorm:"type:varchar(255)"`
+	Frame        bool   `json:"frame"`
+	Url          string `json:"url" gorm:"type:varchar(255)"`
+	ProxyType    string `json:"proxyType" gorm:"type:varchar(64)"`
+	Architecture string `json:"architecture" gorm:"type:varchar(64)"`
+	Values       string `json:"-" gorm:"type:text(65535)"`
+	ReadyKind    string `json:"readyKind" gorm:"type:varchar(64)"`
+	ReadyName    string `json:"readyName" gorm:"type:varchar(255)"`
+	ServiceName  string `json:"serviceName" gorm:"type:varchar(255)"`
+	ServicePort  int    `json:"servicePort"`
+}
+
+func (c *ClusterAddon) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
+
+// Tool returns the cluster tool of the add-on, waiting to be enabled.
+func (c ClusterAddon) Tool() ClusterTool {
+	return ClusterTool{
+		Name:         c.Name,
+		Version:      c.Version,
+		Describe:     c.Describe,
+		Status:       constant.ClusterWaiting,
+		Logo:         c.Logo,
+		Frame:        c.Frame,
+		Url:          c.Url,
+		ProxyType:    c.ProxyType,
+		Architecture: c.Architecture,
+	}
+}
+
+// IngressHost is the host the route of the add-on serves on the ingress of the cluster.
+func (c ClusterAddon) IngressHost() string {
+	return c.Name + "." + constant.DefaultIngress
+}
@@ -11,10 +11,15 @@ type ClusterIstio struct {
 	Name      string `json:"name"`
 	ClusterID string `json:"cluster_id"`
 	Version   string `json:"version"`
-	Describe  string `json:"describe"`
-	Status    string `json:"status"`
-	Message   string `json:"message" gorm:"type:text(65535)"`
-	Vars      string `json:"vars" gorm:"type:text(65535)"`
+	// Revision is the istiod revision of the component, empty for the default one. The pilot
+	// holds the canary revision installed next to it until it is promoted.
+	Revision       string `json:"revision"`
+	CanaryVersion  string `json:"canary_version"`
+	CanaryRevision string `json:"canary_revision"`
+	Describe       string `json:"describe"`
+	Status         string `json:"status"`
+	Message        string `json:"message" gorm:"type:text(65535)"`
+	Vars           string `json:"vars" gorm:"type:text(65535)"`
 }
 
 func (c *ClusterIstio) BeforeCreate() (err error) {
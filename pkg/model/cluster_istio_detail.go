@@ -0,0 +1,21 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterIstioDetail is a version of an istio component, Vars holds the images of the version.
+type ClusterIstioDetail struct {
+	common.BaseModel
+	ID           string `json:"-" gorm:"type:varchar(64)"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	ChartVersion string `json:"chart_version"`
+	Vars         string `json:"-" gorm:"type:text(65535)"`
+}
+
+func (c *ClusterIstioDetail) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterLoadBalancer is the load balancer in front of the apiserver of a cluster. Hosts holds the comma
+// joined dedicated hosts of haproxy, Vars the json settings of octavia and Members the masters last synced.
+// InsecureSkipVerify lets the f5 management api go unverified, HaproxyHosts are the dedicated hosts
+// loaded for the inventory.
+type ClusterLoadBalancer struct {
+	common.BaseModel
+	ID                 string `json:"-" gorm:"type:varchar(64)"`
+	ClusterID          string `json:"-" gorm:"type:varchar(64)"`
+	Provider           string `json:"provider" gorm:"type:varchar(64)"`
+	Vip                string `json:"vip" gorm:"type:varchar(64)"`
+	Port               int    `json:"port"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	Hosts              string `json:"-" gorm:"type:varchar(1024)"`
+	Vars               string `json:"-" gorm:"type:text(65535)"`
+	Password           string `json:"-" gorm:"type:varchar(256)"`
+	Members            string `json:"-" gorm:"type:varchar(1024)"`
+	Status             string `json:"status" gorm:"type:varchar(64)"`
+	Message            string `json:"message" gorm:"type:text(65535)"`
+	HaproxyHosts       []Host `json:"-" gorm:"-"`
+}
+
+func (c *ClusterLoadBalancer) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return err
+}
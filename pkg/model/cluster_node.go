@@ -54,12 +54,7 @@ func (n ClusterNode) GetRegistry(arch string) (*Registry, error) {
 	registry.RepoPort = systemRegistry.RepoPort
 	registry.RegistryPort = systemRegistry.RegistryPort
 	registry.RegistryHostedPort = systemRegistry.RegistryHostedPort
-	switch n.Host.Architecture {
-	case "x86_64":
-		registry.Architecture = "amd64"
-	case "aarch64":
-		registry.Architecture = "arm64"
-	}
+	registry.Architecture = n.Host.Arch()
 	return &registry, nil
 }
 
@@ -92,6 +87,9 @@ func (n ClusterNode) ToKobeHost(role string) *api.Host {
 			"registry_hosted_port": fmt.Sprintf("%v", r.RegistryHostedPort),
 		},
 	}
+	for k, v := range n.Host.ConnectionVars() {
+		apiHost.Vars[k] = v
+	}
 	if role == constant.LbModeInternal {
 		return &apiHost
 	}
@@ -105,6 +103,10 @@ func (n ClusterNode) ToKobeHost(role string) *api.Host {
 
 func (n ClusterNode) ToSSHConfig() ssh.Config {
 	password, privateKey, _ := n.Host.GetHostPasswordAndPrivateKey()
+	bastion, err := n.Host.BastionSSHConfig()
+	if err != nil {
+		logger.Log.Errorf("get bastion of %s err, err: %s", n.Host.Name, err.Error())
+	}
 	return ssh.Config{
 		User:        n.Host.Credential.Username,
 		Host:        n.Host.Ip,
@@ -113,5 +115,7 @@ func (n ClusterNode) ToSSHConfig() ssh.Config {
 		Password:    password,
 		DialTimeOut: 5 * time.Second,
 		Retry:       3,
+		HostKey:     n.Host.HostKey,
+		Bastion:     bastion,
 	}
 }
@@ -1,14 +1,49 @@
 package model
 
-import uuid "github.com/satori/go.uuid"
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	uuid "github.com/satori/go.uuid"
+)
 
 type ClusterSecret struct {
 	ID              string
 	KubeadmToken    string `gorm:"type:text(65535)" json:"kubeadmToken"`
 	KubernetesToken string `gorm:"type:text(65535)" json:"kubernetesToken"`
+	// AlertToken authenticates the alertmanager of the cluster posting to the alert webhook.
+	AlertToken string `gorm:"type:text(65535)" json:"-"`
 }
 
 func (n *ClusterSecret) BeforeCreate() (err error) {
 	n.ID = uuid.NewV4().String()
 	return nil
 }
+
+// BeforeSave encrypts the tokens, they are decrypted again once saved or loaded.
+func (n *ClusterSecret) BeforeSave() (err error) {
+	for _, token := range []*string{&n.KubeadmToken, &n.KubernetesToken, &n.AlertToken} {
+		if *token == "" || encrypt.IsEnvelope(*token) {
+			continue
+		}
+		if *token, err = encrypt.StringEncrypt(*token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ClusterSecret) AfterSave() (err error) {
+	return n.AfterFind()
+}
+
+// AfterFind decrypts the tokens, the ones saved before they were encrypted are kept as is.
+func (n *ClusterSecret) AfterFind() (err error) {
+	for _, token := range []*string{&n.KubeadmToken, &n.KubernetesToken, &n.AlertToken} {
+		if !encrypt.IsEnvelope(*token) {
+			continue
+		}
+		if *token, err = encrypt.StringDecrypt(*token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterTerraformState is the summary of the last terraform run of a cluster, Hosts holds the json list of
+// the host names terraform was handed.
+type ClusterTerraformState struct {
+	common.BaseModel
+	ID        string `json:"-" gorm:"type:varchar(64)"`
+	ClusterID string `json:"-" gorm:"type:varchar(64)"`
+	Action    string `json:"action" gorm:"type:varchar(64)"`
+	Success   bool   `json:"success"`
+	Added     int    `json:"added"`
+	Changed   int    `json:"changed"`
+	Destroyed int    `json:"destroyed"`
+	Hosts     string `json:"-" gorm:"type:text(65535)"`
+	Message   string `json:"message" gorm:"type:text(65535)"`
+}
+
+func (c *ClusterTerraformState) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return err
+}
+
+// ClusterDrift is a difference found between the vms of the provider and the hosts of a cluster.
+type ClusterDrift struct {
+	common.BaseModel
+	ID        string `json:"-" gorm:"type:varchar(64)"`
+	ClusterID string `json:"-" gorm:"type:varchar(64)"`
+	HostName  string `json:"hostName" gorm:"type:varchar(255)"`
+	Type      string `json:"type" gorm:"type:varchar(64)"`
+	Expected  string `json:"expected" gorm:"type:varchar(255)"`
+	Actual    string `json:"actual" gorm:"type:varchar(255)"`
+}
+
+func (c *ClusterDrift) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return err
+}
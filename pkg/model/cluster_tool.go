@@ -27,6 +27,9 @@ type ClusterTool struct {
 	Architecture  string `json:"architecture"`
 	HigherVersion string `json:"higher_version"`
 	ProxyType     string `json:"proxyType"`
+	// ReleaseRevision is the revision of the helm release last installed, upgraded or rolled back by
+	// KubeOperator, a release of another revision was changed outside of KubeOperator.
+	ReleaseRevision int `json:"releaseRevision"`
 }
 
 func (c *ClusterTool) BeforeCreate() (err error) {
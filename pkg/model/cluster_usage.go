@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterUsage is the daily aggregate of the resources of a node, a namespace or the whole cluster,
+// kept with the names of the cluster and of its project on the day. Requested, allocatable and used
+// are averaged over the samples of the day, cpu in millicores and memory in bytes. Storage rows hold
+// the bytes claimed by a namespace on StorageClass. Node rows also hold what the node is priced by
+// on the day: its host, the vm config it was created with and its cores, memory in MiB and gpus.
+type ClusterUsage struct {
+	common.BaseModel
+	ID                string `json:"-" gorm:"type:varchar(64)"`
+	Date              string `json:"date" gorm:"type:varchar(10)"`
+	ClusterID         string `json:"-" gorm:"type:varchar(64)"`
+	ClusterName       string `json:"clusterName" gorm:"type:varchar(255)"`
+	ProjectName       string `json:"projectName" gorm:"type:varchar(255)"`
+	Scope             string `json:"scope" gorm:"type:varchar(64)"`
+	Name              string `json:"name" gorm:"type:varchar(255)"`
+	StorageClass      string `json:"storageClass" gorm:"type:varchar(255)"`
+	HostName          string `json:"hostName" gorm:"type:varchar(255)"`
+	VmConfig          string `json:"vmConfig" gorm:"type:varchar(255)"`
+	CpuCore           int    `json:"cpuCore"`
+	Memory            int    `json:"memory"`
+	GpuNum            int    `json:"gpuNum"`
+	CpuRequested      int64  `json:"cpuRequested"`
+	CpuAllocatable    int64  `json:"cpuAllocatable"`
+	CpuUsed           int64  `json:"cpuUsed"`
+	CpuUsedPeak       int64  `json:"cpuUsedPeak"`
+	MemoryRequested   int64  `json:"memoryRequested"`
+	MemoryAllocatable int64  `json:"memoryAllocatable"`
+	MemoryUsed        int64  `json:"memoryUsed"`
+	MemoryUsedPeak    int64  `json:"memoryUsedPeak"`
+	StorageRequested  int64  `json:"storageRequested"`
+	Samples           int    `json:"samples"`
+}
+
+func (c *ClusterUsage) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return err
+}
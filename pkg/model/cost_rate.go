@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CostRate is the price per month of a host, a vm config or a storage class. Host rates are per
+// cpu core, GiB of memory and gpu, vm config rates per instance and storage class rates per GiB.
+// The cpu and memory prices of a vm config rate weigh how its instance price is split between cpu
+// and memory. The rate without name is the default of its type.
+type CostRate struct {
+	common.BaseModel
+	ID            string  `json:"id" gorm:"type:varchar(64)"`
+	Type          string  `json:"type" gorm:"type:varchar(64)"`
+	Name          string  `json:"name" gorm:"type:varchar(255)"`
+	CpuMonth      float64 `json:"cpuMonth"`
+	MemoryMonth   float64 `json:"memoryMonth"`
+	GpuMonth      float64 `json:"gpuMonth"`
+	StorageMonth  float64 `json:"storageMonth"`
+	InstanceMonth float64 `json:"instanceMonth"`
+}
+
+func (c *CostRate) BeforeCreate() (err error) {
+	c.ID = uuid.NewV4().String()
+	return err
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	"github.com/KubeOperator/KubeOperator/pkg/util/secret"
 	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -14,16 +16,20 @@ var (
 
 type Credential struct {
 	common.BaseModel
-	ID         string `json:"id" gorm:"type:varchar(64)"`
-	Name       string `json:"name" gorm:"type:varchar(256);not null;unique"`
-	Username   string `json:"username" gorm:"type:varchar(64)"`
-	Password   string `json:"password" gorm:"type:varchar(256)"`
-	PrivateKey string `json:"privateKey" gorm:"type: text(0)"`
-	Type       string `json:"type" gorm:"type:varchar(64)"`
+	ID            string `json:"id" gorm:"type:varchar(64)"`
+	Name          string `json:"name" gorm:"type:varchar(256);not null;unique"`
+	Username      string `json:"username" gorm:"type:varchar(64)"`
+	Password      string `json:"password" gorm:"type:varchar(256)"`
+	PrivateKey    string `json:"privateKey" gorm:"type: text(0)"`
+	Type          string `json:"type" gorm:"type:varchar(64)"`
+	SecretBackend string `json:"secretBackend" gorm:"type:varchar(64)"`
 }
 
 func (c *Credential) BeforeCreate() (err error) {
-	c.ID = uuid.NewV4().String()
+	// the id is already set when the secret was stored before the row
+	if c.ID == "" {
+		c.ID = uuid.NewV4().String()
+	}
 	return err
 }
 
@@ -33,3 +39,75 @@ func (c *Credential) BeforeDelete() (err error) {
 	}
 	return err
 }
+
+// SetSecret stores the password or private key in the configured secret backend,
+// the one previously holding it is cleaned when the backend changed.
+func (c *Credential) SetSecret(password, privateKey string) error {
+	if c.ID == "" {
+		c.ID = uuid.NewV4().String()
+	}
+	backendName := viper.GetString("secret.type")
+	if backendName == "" {
+		backendName = constant.SecretBackendDB
+	}
+	backend, err := newSecretBackend(backendName)
+	if err != nil {
+		return err
+	}
+	stored, err := backend.Put(c.secretPath(), map[string]string{
+		constant.Password:   password,
+		constant.PrivateKey: privateKey,
+	})
+	if err != nil {
+		return err
+	}
+	if c.SecretBackend != "" && c.SecretBackend != backendName {
+		if err := c.DeleteSecret(); err != nil {
+			return err
+		}
+	}
+	c.SecretBackend = backendName
+	c.Password = stored[constant.Password]
+	c.PrivateKey = stored[constant.PrivateKey]
+	return nil
+}
+
+// GetSecret returns the plain password and private key of the credential.
+func (c Credential) GetSecret() (string, string, error) {
+	backend, err := newSecretBackend(c.SecretBackend)
+	if err != nil {
+		return "", "", err
+	}
+	s, err := backend.Get(c.secretPath(), map[string]string{
+		constant.Password:   c.Password,
+		constant.PrivateKey: c.PrivateKey,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return s[constant.Password], s[constant.PrivateKey], nil
+}
+
+func (c Credential) DeleteSecret() error {
+	backend, err := newSecretBackend(c.SecretBackend)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(c.secretPath())
+}
+
+func (c Credential) secretPath() string {
+	return "credentials/" + c.ID
+}
+
+func newSecretBackend(name string) (secret.Backend, error) {
+	vars := map[string]interface{}{}
+	for k, v := range viper.GetStringMap("secret") {
+		vars[k] = v
+	}
+	if name == "" {
+		name = constant.SecretBackendDB
+	}
+	vars["type"] = name
+	return secret.NewSecretBackend(vars)
+}
@@ -0,0 +1,12 @@
+package model
+
+import "github.com/KubeOperator/KubeOperator/pkg/model/common"
+
+// EncryptKey is a master key added by rotation, Key is wrapped with the encrypt.key of the config
+// and Active marks the one new values are encrypted with.
+type EncryptKey struct {
+	common.BaseModel
+	Version int    `json:"version" gorm:"primary_key;auto_increment:false"`
+	Key     string `json:"-" gorm:"type:text"`
+	Active  bool   `json:"active"`
+}
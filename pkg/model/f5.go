@@ -11,9 +11,11 @@ type F5Setting struct {
 	ClusterID string `json:"clusterID" gorm:"type:varchar(64)"`
 	URL       string `json:"url" gorm:"type:varchar(64)"`
 	User      string `json:"user" gorm:"type:varchar(64)"`
+	Password  string `json:"-" gorm:"type:varchar(256)"`
 	Partition string `json:"partition" gorm:"type:varchar(64)" `
 	PublicIP  string `json:"publicIP" gorm:"type:varchar(64)"`
 	Status    string `json:"status" gorm:"type:varchar(64)"`
+	Message   string `json:"message" gorm:"type:text(65535)"`
 }
 
 func (s *F5Setting) BeforeCreate() (err error) {
@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
-	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
 	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
 	"github.com/KubeOperator/kobe/api"
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
@@ -45,22 +48,69 @@ type Host struct {
 	Message      string     `json:"message" gorm:"type:text(65535)"`
 	Datastore    string     `json:"datastore" gorm:"type:varchar(64)"`
 	Architecture string     `json:"architecture" gorm:"type:varchar(64)"`
+	Image        string     `json:"image" gorm:"type:varchar(256)"`
+	BastionID    string     `json:"bastionId" gorm:"type:varchar(64)"`
+
+	HostKey        string `json:"hostKey" gorm:"type:text(65535)"`
+	HostKeyStatus  string `json:"hostKeyStatus" gorm:"type:varchar(64)"`
+	PendingHostKey string `json:"pendingHostKey" gorm:"type:text(65535)"`
+
+	Maintenance bool `json:"maintenance"`
 }
 
 func (h Host) GetHostPasswordAndPrivateKey() (string, []byte, error) {
-	password := ""
-	privateKey := []byte("")
+	password, privateKey, err := h.Credential.GetSecret()
+	if err != nil {
+		return "", nil, err
+	}
 	switch h.Credential.Type {
-	case "password":
-		p, err := encrypt.StringDecrypt(h.Credential.Password)
-		if err != nil {
-			return "", nil, err
-		}
-		password = p
-	case "privateKey":
-		privateKey = []byte(h.Credential.PrivateKey)
+	case constant.Password:
+		return password, []byte(""), nil
+	case constant.PrivateKey:
+		return "", []byte(privateKey), nil
+	}
+	return "", []byte(""), nil
+}
+
+// ConnectionVars pins ansible to the recorded host key and routes it through the bastion of the host.
+// The files the vars refer to are written by WriteConnectionFiles, which refuses a host without a key.
+func (h Host) ConnectionVars() map[string]string {
+	vars := map[string]string{}
+	var args []string
+	vars["ansible_host_key_checking"] = "true"
+	args = append(args, fmt.Sprintf("-o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", ansible.KnownHostsPath(h.Name)))
+	bastion, err := h.GetBastion()
+	if err != nil {
+		logger.Log.Errorf("get bastion of %s err, err: %s", h.Name, err.Error())
+	}
+	if bastion != nil {
+		args = append(args, bastion.ProxyCommandArg())
+	}
+	if len(args) > 0 {
+		vars["ansible_ssh_common_args"] = strings.Join(args, " ")
+	}
+	return vars
+}
+
+// WriteConnectionFiles writes the known_hosts of the host and the files of its bastion, they have to be
+// in place before ansible connects to it. A host whose key is not recorded yet is refused like ssh.New
+// does, ansible would connect to it unverified.
+func (h Host) WriteConnectionFiles() error {
+	if h.HostKey == "" {
+		return &ssh.HostKeyNotPinnedError{Host: fmt.Sprintf("%s:%d", h.Ip, h.Port)}
+	}
+	line, err := ssh.KnownHostsLine(h.Ip, h.Port, h.HostKey)
+	if err != nil {
+		return err
+	}
+	if _, err := ansible.WriteKnownHosts(h.Name, line); err != nil {
+		return err
+	}
+	bastion, err := h.GetBastion()
+	if err != nil || bastion == nil {
+		return err
 	}
-	return password, privateKey, nil
+	return bastion.WriteConnectionFiles()
 }
 
 func (h *Host) BeforeCreate() error {
@@ -86,14 +136,9 @@ func (h *Host) BeforeDelete(tx *gorm.DB) error {
 		if len(projectResources) > 0 {
 			return errors.New("DELETE_HOST_FAILED_BY_PROJECT")
 		}
-		var ip Ip
-		tx.Where(Ip{Address: h.Ip}).First(&ip)
-		if ip.ID != "" {
-			ip.Status = constant.IpAvailable
-			if err := tx.Save(&ip).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
+		if err := ReleaseIps(tx, []string{h.Ip}); err != nil {
+			tx.Rollback()
+			return err
 		}
 	}
 	return nil
@@ -112,6 +157,9 @@ func (h *Host) GetHostConfig() error {
 	if err != nil {
 		return err
 	}
+	if err := h.WriteConnectionFiles(); err != nil {
+		return err
+	}
 	ansible := kobe.NewAnsible(&kobe.Config{
 		Inventory: &api.Inventory{
 			Hosts: []*api.Host{
@@ -122,7 +170,7 @@ func (h *Host) GetHostConfig() error {
 					User:       h.Credential.Username,
 					Password:   password,
 					PrivateKey: string(privateKey),
-					Vars:       map[string]string{},
+					Vars:       h.ConnectionVars(),
 				},
 			},
 			Groups: []*api.Group{
@@ -194,3 +242,31 @@ func (h *Host) GetHostConfig() error {
 	}
 	return nil
 }
+
+// Arch returns the kubernetes style architecture of the host, e.g. amd64.
+func (h Host) Arch() string {
+	switch h.Architecture {
+	case constant.ArchitectureOfAMD64:
+		return constant.ArchAMD64
+	case constant.ArchitectureOfARM64:
+		return constant.ArchARM64
+	}
+	return h.Architecture
+}
+
+// ArchitecturesOf returns the cluster architectures for hosts, all when
+// the hosts span more than one architecture.
+func ArchitecturesOf(hosts []Host) string {
+	arch := ""
+	for _, h := range hosts {
+		a := h.Arch()
+		if a == "" {
+			continue
+		}
+		if arch != "" && arch != a {
+			return constant.ArchAll
+		}
+		arch = a
+	}
+	return arch
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+type HostMaintenance struct {
+	common.BaseModel
+	ID        string                `json:"id"`
+	HostID    string                `json:"hostId"`
+	ClusterID string                `json:"clusterId"`
+	NodeName  string                `json:"nodeName"`
+	Patch     bool                  `json:"patch"`
+	Reboot    bool                  `json:"reboot"`
+	Status    string                `json:"status"`
+	Message   string                `json:"message" gorm:"type:text(65535)"`
+	StartTime time.Time             `json:"startTime"`
+	EndTime   time.Time             `json:"endTime"`
+	Steps     []HostMaintenanceStep `json:"steps" gorm:"foreignkey:MaintenanceID;save_associations:false"`
+}
+
+func (m *HostMaintenance) BeforeCreate() (err error) {
+	m.ID = uuid.NewV4().String()
+	return nil
+}
+
+type HostMaintenanceStep struct {
+	common.BaseModel
+	ID            string    `json:"-"`
+	MaintenanceID string    `json:"-"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Message       string    `json:"message" gorm:"type:text(65535)"`
+	OrderNum      int       `json:"-"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+}
+
+func (s *HostMaintenanceStep) BeforeCreate() (err error) {
+	s.ID = uuid.NewV4().String()
+	return nil
+}
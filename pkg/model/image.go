@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	DeleteImageBuiltin = "IMAGE_BUILTIN_DELETE"
+	DeleteImageInUse   = "IMAGE_IN_USE"
+)
+
+type Image struct {
+	common.BaseModel
+	ID           string `json:"id" gorm:"type:varchar(64)"`
+	Name         string `json:"name" gorm:"type:varchar(256);not null;unique"`
+	OsFamily     string `json:"osFamily" gorm:"type:varchar(64)"`
+	OsVersion    string `json:"osVersion" gorm:"type:varchar(64)"`
+	Architecture string `json:"architecture" gorm:"type:varchar(64)"`
+	Checksum     string `json:"checksum" gorm:"type:varchar(256)"`
+	Artifacts    string `json:"-" gorm:"type:text(65535)"`
+	Builtin      bool   `json:"builtin"`
+}
+
+func (i *Image) BeforeCreate() error {
+	i.ID = uuid.NewV4().String()
+	return nil
+}
+
+func (i *Image) BeforeDelete(tx *gorm.DB) error {
+	if i.Builtin {
+		return errors.New(DeleteImageBuiltin)
+	}
+	var plans []Plan
+	if err := tx.Where("image_id = ?", i.ID).Find(&plans).Error; err != nil {
+		return err
+	}
+	var vmConfigs []VmConfig
+	if err := tx.Where("image_id = ?", i.ID).Find(&vmConfigs).Error; err != nil {
+		return err
+	}
+	if len(plans) > 0 || len(vmConfigs) > 0 {
+		return errors.New(DeleteImageInUse)
+	}
+	return nil
+}
+
+// ArtifactsOf returns the artifact urls of the provider keyed by kind, e.g. ovf, vmdk, vhd or qcow2.
+func (i Image) ArtifactsOf(provider string) map[string]string {
+	artifacts := map[string]map[string]string{}
+	_ = json.Unmarshal([]byte(i.Artifacts), &artifacts)
+	return artifacts[provider]
+}
+
+// TemplateName is the name of the image once imported into the provider.
+func (i Image) TemplateName(provider string) string {
+	switch provider {
+	case constant.Proxmox:
+		return strings.Replace(i.Name, "_", "-", -1)
+	case constant.Libvirt:
+		return i.Name + ".qcow2"
+	}
+	return i.Name
+}
@@ -7,14 +7,18 @@ import (
 
 type Ip struct {
 	common.BaseModel
-	ID        string `json:"id" gorm:"type:varchar(64)"`
-	Address   string `json:"address" gorm:"type:varchar(255)"`
-	Gateway   string `json:"gateway" gorm:"type:varchar(255)"`
-	DNS1      string `json:"dns1" gorm:"type:varchar(255)"`
-	DNS2      string `json:"dns2" gorm:"type:varchar(255)"`
-	Status    string `json:"status" gorm:"type:varchar(255)"`
-	IpPoolID  string `json:"ipPoolId" gorm:"type:varchar(64)"`
-	ClusterID string `json:"clusterId" gorm:"type:varchar(64)"`
+	ID          string `json:"id" gorm:"type:varchar(64)"`
+	Address     string `json:"address" gorm:"type:varchar(255)"`
+	Gateway     string `json:"gateway" gorm:"type:varchar(255)"`
+	DNS1        string `json:"dns1" gorm:"type:varchar(255)"`
+	DNS2        string `json:"dns2" gorm:"type:varchar(255)"`
+	Status      string `json:"status" gorm:"type:varchar(255)"`
+	IpPoolID    string `json:"ipPoolId" gorm:"type:varchar(64)"`
+	ClusterID   string `json:"clusterId" gorm:"type:varchar(64)"`
+	ReservedFor string `json:"reservedFor" gorm:"type:varchar(255)"`
+	HostName    string `json:"hostName" gorm:"type:varchar(255)"`
+	Mac         string `json:"mac" gorm:"type:varchar(64)"`
+	Conflict    bool   `json:"conflict"`
 }
 
 func (i *Ip) BeforeCreate() (err error) {
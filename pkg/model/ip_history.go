@@ -0,0 +1,89 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IpHistory records which cluster and host held a pool ip and when it changed hands.
+type IpHistory struct {
+	common.BaseModel
+	ID          string `json:"id" gorm:"type:varchar(64)"`
+	IpID        string `json:"ipId" gorm:"type:varchar(64)"`
+	Address     string `json:"address" gorm:"type:varchar(255)"`
+	IpPoolID    string `json:"ipPoolId" gorm:"type:varchar(64)"`
+	ClusterID   string `json:"clusterId" gorm:"type:varchar(64)"`
+	ClusterName string `json:"clusterName" gorm:"type:varchar(255)"`
+	HostName    string `json:"hostName" gorm:"type:varchar(255)"`
+	Action      string `json:"action" gorm:"type:varchar(64)"`
+	Message     string `json:"message" gorm:"type:varchar(255)"`
+}
+
+func (i *IpHistory) BeforeCreate() (err error) {
+	i.ID = uuid.NewV4().String()
+	return err
+}
+
+// RecordIpHistory appends an entry for the ip with the cluster and host holding it.
+func RecordIpHistory(tx *gorm.DB, ip Ip, action, message string) error {
+	history := IpHistory{
+		IpID:      ip.ID,
+		Address:   ip.Address,
+		IpPoolID:  ip.IpPoolID,
+		ClusterID: ip.ClusterID,
+		HostName:  ip.HostName,
+		Action:    action,
+		Message:   message,
+	}
+	if ip.ClusterID != "" {
+		var cluster Cluster
+		tx.Select("name").Where("id = ?", ip.ClusterID).First(&cluster)
+		history.ClusterName = cluster.Name
+	}
+	return tx.Create(&history).Error
+}
+
+// AllocateIp marks the pool ip as held by the host of the cluster, nothing is recorded when it already is.
+func AllocateIp(tx *gorm.DB, ip *Ip, clusterID, hostName string) error {
+	if ip.Status == constant.IpUsed && ip.HostName == hostName && (clusterID == "" || ip.ClusterID == clusterID) {
+		return nil
+	}
+	ip.Status = constant.IpUsed
+	if clusterID != "" {
+		ip.ClusterID = clusterID
+	}
+	ip.HostName = hostName
+	if err := tx.Save(ip).Error; err != nil {
+		return err
+	}
+	return RecordIpHistory(tx, *ip, constant.IpActionAllocate, "")
+}
+
+// ReleaseIps hands the pool ips of removed hosts back to their pool.
+func ReleaseIps(tx *gorm.DB, addresses []string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	var ips []Ip
+	if err := tx.Where("address in (?)", addresses).Find(&ips).Error; err != nil {
+		return err
+	}
+	for i := range ips {
+		if ips[i].Status == constant.IpUsed {
+			if err := RecordIpHistory(tx, ips[i], constant.IpActionRelease, ""); err != nil {
+				return err
+			}
+		}
+		ips[i].Status = constant.IpAvailable
+		ips[i].ClusterID = ""
+		ips[i].HostName = ""
+		ips[i].Mac = ""
+		ips[i].Conflict = false
+		if err := tx.Save(&ips[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ type IpPool struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Subnet      string `json:"subnet"`
+	Exclusions  string `json:"exclusions"`
 	Ips         []Ip   `json:"ips"`
 }
 
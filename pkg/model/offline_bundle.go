@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// OfflineBundle records the import of a signed offline bundle, Manifests holds the comma joined
+// names of the cluster manifests it activated.
+type OfflineBundle struct {
+	common.BaseModel
+	ID           string `json:"id" gorm:"type:varchar(64)"`
+	Name         string `json:"name" gorm:"type:varchar(255)"`
+	Architecture string `json:"architecture" gorm:"type:varchar(64)"`
+	Images       int    `json:"images"`
+	Charts       int    `json:"charts"`
+	Binaries     int    `json:"binaries"`
+	Manifests    string `json:"-" gorm:"type:varchar(1024)"`
+	Status       string `json:"status" gorm:"type:varchar(64)"`
+	Message      string `json:"message" gorm:"type:text(65535)"`
+}
+
+func (o *OfflineBundle) BeforeCreate() (err error) {
+	o.ID = uuid.NewV4().String()
+	return err
+}
@@ -19,8 +19,10 @@ type Plan struct {
 	RegionID       string `json:"regionId" grom:"type:varchar(64)"`
 	DeployTemplate string `json:"deployTemplate" grom:"type:varchar(64)"`
 	Vars           string `json:"vars" gorm:"type text(65535)"`
+	ImageID        string `json:"imageId" gorm:"type:varchar(64)"`
 	Zones          []Zone `json:"-" gorm:"many2many:plan_zones"`
 	Region         Region `json:"-"`
+	Image          Image  `json:"-" gorm:"save_associations:false"`
 }
 
 func (p *Plan) BeforeCreate() (err error) {
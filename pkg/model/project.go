@@ -22,6 +22,7 @@ type Project struct {
 	ID          string    `json:"id" gorm:"type:varchar(64)"`
 	Name        string    `json:"name" gorm:"type:varchar(64);not null;unique"`
 	Description string    `json:"description" gorm:"type:varchar(128)"`
+	BastionID   string    `json:"bastionId" gorm:"type:varchar(64)"`
 	Clusters    []Cluster `json:"-"`
 }
 
@@ -53,6 +54,10 @@ func (p *Project) BeforeDelete() (err error) {
 			return err
 		}
 	}
+	err = db.DB.Where(ProjectQuota{ProjectID: p.ID}).Delete(&ProjectQuota{}).Error
+	if err != nil {
+		return err
+	}
 	err = db.DB.Model(model.User{}).Where("current_project_id = ?", p.ID).Updates(&User{CurrentProjectID: ""}).Error
 	if err != nil {
 		return err
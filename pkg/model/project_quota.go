@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ProjectQuota limits what the clusters of a project may take, 0 leaves a limit unset.
+// Memory is counted in GB.
+type ProjectQuota struct {
+	common.BaseModel
+	ID          string `json:"-" gorm:"type:varchar(64)"`
+	ProjectID   string `json:"-" gorm:"type:varchar(64)"`
+	MaxClusters int    `json:"maxClusters"`
+	MaxNodes    int    `json:"maxNodes"`
+	MaxCpu      int    `json:"maxCpu"`
+	MaxMemory   int    `json:"maxMemory"`
+	MaxIps      int    `json:"maxIps"`
+}
+
+func (p *ProjectQuota) BeforeCreate() (err error) {
+	p.ID = uuid.NewV4().String()
+	return err
+}
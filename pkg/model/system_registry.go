@@ -1,6 +1,8 @@
 package model
 
 import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
 	uuid "github.com/satori/go.uuid"
 )
@@ -21,3 +23,15 @@ func (s *SystemRegistry) BeforeCreate() (err error) {
 	s.ID = uuid.NewV4().String()
 	return err
 }
+
+// GetSystemRegistry loads the registry serving images of arch. Mixed clusters
+// pull from the arm64 registry which also carries the multi-arch manifests.
+func GetSystemRegistry(arch string) (SystemRegistry, error) {
+	var registry SystemRegistry
+	architecture := constant.ArchitectureOfARM64
+	if arch == constant.ArchAMD64 {
+		architecture = constant.ArchitectureOfAMD64
+	}
+	err := db.DB.Where("architecture = ?", architecture).First(&registry).Error
+	return registry, err
+}
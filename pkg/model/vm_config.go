@@ -17,6 +17,8 @@ type VmConfig struct {
 	Memory   int    `json:"memory"`
 	Disk     int    `json:"disk"`
 	Provider string `json:"provider"`
+	ImageID  string `json:"imageId"`
+	Image    Image  `json:"-" gorm:"save_associations:false"`
 }
 
 func (v *VmConfig) BeforeCreate() error {
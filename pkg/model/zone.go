@@ -21,9 +21,11 @@ type Zone struct {
 	RegionID     string     `json:"regionID" gorm:"type:varchar(64)"`
 	CredentialID string     `json:"credentialId" gorm:"type:varchar(64)"`
 	IpPoolID     string     `json:"ipPoolId"`
+	BastionID    string     `json:"bastionId" gorm:"type:varchar(64)"`
 	Region       Region     `json:"-"`
 	IpPool       IpPool     `json:"_"`
 	Credential   Credential `json:"_"`
+	Bastion      Bastion    `json:"-" gorm:"save_associations:false"`
 }
 
 func (z *Zone) BeforeCreate() (err error) {
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+type BastionRepository interface {
+	Get(name string) (model.Bastion, error)
+	List() ([]model.Bastion, error)
+	Save(bastion *model.Bastion) error
+	Delete(name string) error
+}
+
+func NewBastionRepository() BastionRepository {
+	return &bastionRepository{}
+}
+
+type bastionRepository struct {
+}
+
+func (b bastionRepository) Get(name string) (model.Bastion, error) {
+	var bastion model.Bastion
+	if err := db.DB.Where("name = ?", name).Preload("Credential").First(&bastion).Error; err != nil {
+		return bastion, err
+	}
+	return bastion, nil
+}
+
+func (b bastionRepository) List() ([]model.Bastion, error) {
+	var bastions []model.Bastion
+	err := db.DB.Preload("Credential").Order("name").Find(&bastions).Error
+	return bastions, err
+}
+
+func (b bastionRepository) Save(bastion *model.Bastion) error {
+	if db.DB.NewRecord(bastion) {
+		return db.DB.Create(&bastion).Error
+	} else {
+		return db.DB.Save(&bastion).Error
+	}
+}
+
+func (b bastionRepository) Delete(name string) error {
+	bastion, err := b.Get(name)
+	if err != nil {
+		return err
+	}
+	return db.DB.Delete(&bastion).Error
+}
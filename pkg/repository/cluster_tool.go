@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 )
@@ -23,7 +24,12 @@ func (c clusterToolRepository) List(clusterName string) ([]model.ClusterTool, er
 	if err := db.DB.Where("name = ?", clusterName).Preload("Spec").First(&cluster).Error; err != nil {
 		return tools, err
 	}
-	if err := db.DB.Where("cluster_id = ? AND architecture in (?)", cluster.ID, []string{cluster.Spec.Architectures, "all"}).Find(&tools).Error; err != nil {
+	archs := []string{cluster.Spec.Architectures, constant.ArchAll}
+	if cluster.Spec.Architectures == constant.ArchAll {
+		// amd64 only tools are pinned to the amd64 nodes of mixed clusters
+		archs = append(archs, constant.ArchAMD64)
+	}
+	if err := db.DB.Where("cluster_id = ? AND architecture in (?)", cluster.ID, archs).Find(&tools).Error; err != nil {
 		return tools, err
 	}
 	return tools, nil
@@ -80,7 +80,10 @@ func (c credentialRepository) Delete(name string) error {
 	if len(zones) > 0 {
 		return errors.New(DeleteFailedErrorZone)
 	}
-	return db.DB.Delete(&credential).Error
+	if err := db.DB.Delete(&credential).Error; err != nil {
+		return err
+	}
+	return credential.DeleteSecret()
 }
 
 func (c credentialRepository) GetById(id string) (model.Credential, error) {
@@ -115,10 +118,20 @@ func (c credentialRepository) Batch(operation string, items []model.Credential)
 			return errors.New(DeleteFailedErrorZone)
 		}
 
+		var credentials []model.Credential
+		err = db.DB.Where("id in (?)", ids).Find(&credentials).Error
+		if err != nil {
+			return err
+		}
 		err = db.DB.Where("id in (?)", ids).Delete(&items).Error
 		if err != nil {
 			return err
 		}
+		for _, credential := range credentials {
+			if err := credential.DeleteSecret(); err != nil {
+				return err
+			}
+		}
 	default:
 		return constant.NotSupportedBatchOperation
 	}
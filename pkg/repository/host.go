@@ -118,9 +118,8 @@ func (h hostRepository) Save(host *model.Host) error {
 	}
 	var ip model.Ip
 	tx.Where("address = ?", host.Ip).First(&ip)
-	if ip.ID != "" && ip.Status != constant.IpUsed {
-		ip.Status = constant.IpUsed
-		if err := tx.Save(&ip).Error; err != nil {
+	if ip.ID != "" {
+		if err := model.AllocateIp(tx, &ip, host.ClusterID, host.Name); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -160,9 +159,8 @@ func (h hostRepository) BatchSave(hosts []*model.Host) error {
 		}
 		var ip model.Ip
 		tx.Where("address = ?", hosts[i].Ip).First(&ip)
-		if ip.ID != "" && ip.Status != constant.IpUsed {
-			ip.Status = constant.IpUsed
-			if err := tx.Save(&ip).Error; err != nil {
+		if ip.ID != "" {
+			if err := model.AllocateIp(tx, &ip, hosts[i].ClusterID, hosts[i].Name); err != nil {
 				tx.Rollback()
 				return err
 			}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+type ImageRepository interface {
+	Get(name string) (model.Image, error)
+	GetById(id string) (model.Image, error)
+	List() ([]model.Image, error)
+	Save(item *model.Image) error
+	Delete(name string) error
+	Batch(operation string, items []model.Image) error
+}
+
+func NewImageRepository() ImageRepository {
+	return &imageRepository{}
+}
+
+type imageRepository struct {
+}
+
+func (i imageRepository) Get(name string) (model.Image, error) {
+	var image model.Image
+	if err := db.DB.Where("name = ?", name).First(&image).Error; err != nil {
+		return image, err
+	}
+	return image, nil
+}
+
+func (i imageRepository) GetById(id string) (model.Image, error) {
+	var image model.Image
+	if err := db.DB.Where("id = ?", id).First(&image).Error; err != nil {
+		return image, err
+	}
+	return image, nil
+}
+
+func (i imageRepository) List() ([]model.Image, error) {
+	var images []model.Image
+	err := db.DB.Order("name").Find(&images).Error
+	return images, err
+}
+
+func (i imageRepository) Save(item *model.Image) error {
+	if db.DB.NewRecord(item) {
+		return db.DB.Create(&item).Error
+	} else {
+		return db.DB.Save(&item).Error
+	}
+}
+
+func (i imageRepository) Delete(name string) error {
+	image, err := i.Get(name)
+	if err != nil {
+		return err
+	}
+	return db.DB.Delete(&image).Error
+}
+
+func (i imageRepository) Batch(operation string, items []model.Image) error {
+	switch operation {
+	case constant.BatchOperationDelete:
+		tx := db.DB.Begin()
+		for _, item := range items {
+			var image model.Image
+			if err := tx.Where("name = ?", item.Name).First(&image).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Delete(&image).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		tx.Commit()
+	default:
+		return constant.NotSupportedBatchOperation
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// AddonProxy serves the add-ons of the catalog through the route their install creates for
+// ServiceName and ServicePort, add-ons without a service or exposed by nodeport have none.
+func AddonProxy(ctx context.Context) {
+	toolName := ctx.Params().Get("tool")
+	clusterName := ctx.Params().Get("cluster_name")
+	proxyPath := ctx.Params().Get("p")
+	if toolName == "" || clusterName == "" {
+		_, _ = ctx.JSON(http.StatusBadRequest)
+		return
+	}
+	addon, err := clusterAddonService.Get(toolName)
+	if err != nil || addon.ServiceName == "" || addon.ProxyType == "nodeport" {
+		_, _ = ctx.JSON(http.StatusNotFound)
+		return
+	}
+	endpoint, err := clusterService.GetRouterEndpoint(clusterName)
+	if err != nil {
+		_, _ = ctx.JSON(http.StatusInternalServerError)
+		return
+	}
+	u, err := url.Parse(fmt.Sprintf("http://%s", endpoint.Address))
+	if err != nil {
+		_, _ = ctx.JSON(http.StatusInternalServerError)
+		return
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	req := ctx.Request()
+	req.Host = addon.IngressHost()
+	if proxyPath == "root" {
+		proxyPath = "/"
+	}
+	req.URL.Path = proxyPath
+	proxy.ServeHTTP(ctx.ResponseWriter(), req)
+}
@@ -9,6 +9,7 @@ var (
 	keyPrefix           = "Bearer"
 	AuthorizationHeader = "Authorization"
 	clusterService      = service.NewClusterService()
+	clusterAddonService = service.NewClusterAddonService()
 )
 
 func RegisterProxy(parent iris.Party) {
@@ -23,4 +24,5 @@ func RegisterProxy(parent iris.Party) {
 	proxy.Any("/dashboard/{cluster_name}/{p:path}", DashboardProxy)
 	proxy.Any("/registry/{cluster_name}/{p:path}", RegistryProxy)
 	proxy.Any("/kubeapps/{cluster_name}/{p:path}", KubeappsProxy)
+	proxy.Any("/{tool}/{cluster_name}/{p:path}", AddonProxy)
 }
@@ -30,12 +30,14 @@ func V1(parent iris.Party) {
 	AuthScope.Use(middleware.ForceMiddleware)
 	mvc.New(AuthScope.Party("/clusters")).HandleError(ErrorHandler).Handle(controller.NewClusterController())
 	mvc.New(AuthScope.Party("/credentials")).HandleError(ErrorHandler).Handle(controller.NewCredentialController())
+	mvc.New(AuthScope.Party("/bastions")).HandleError(ErrorHandler).Handle(controller.NewBastionController())
 	mvc.New(AuthScope.Party("/hosts")).HandleError(ErrorHandler).Handle(controller.NewHostController())
 	mvc.New(AuthScope.Party("/users")).HandleError(ErrorHandler).Handle(controller.NewUserController())
 	mvc.New(AuthScope.Party("/regions")).HandleError(ErrorHandler).Handle(controller.NewRegionController())
 	mvc.New(AuthScope.Party("/zones")).HandleError(ErrorHandler).Handle(controller.NewZoneController())
 	mvc.New(AuthScope.Party("/plans")).HandleError(ErrorHandler).Handle(controller.NewPlanController())
 	mvc.New(AuthScope.Party("/settings")).HandleError(ErrorHandler).Handle(controller.NewSystemSettingController())
+	mvc.New(AuthScope.Party("/encrypt")).HandleError(ErrorHandler).Handle(controller.NewEncryptKeyController())
 	mvc.New(AuthScope.Party("/logs")).HandleError(ErrorHandler).Handle(controller.NewSystemLogController())
 	mvc.New(AuthScope.Party("/projects")).HandleError(ErrorHandler).Handle(controller.NewProjectController())
 	mvc.New(AuthScope.Party("/clusters/istio")).HandleError(ErrorHandler).Handle(controller.NewClusterIstioController())
@@ -44,7 +46,15 @@ func V1(parent iris.Party) {
 	mvc.New(AuthScope.Party("/license")).Handle(ErrorHandler).Handle(controller.NewLicenseController())
 	mvc.New(AuthScope.Party("/clusters/backup/files")).HandleError(ErrorHandler).Handle(controller.NewClusterBackupFileController())
 	mvc.New(AuthScope.Party("/manifests")).HandleError(ErrorHandler).Handle(controller.NewManifestController())
+	mvc.New(AuthScope.Party("/bundles")).HandleError(ErrorHandler).Handle(controller.NewOfflineBundleController())
+	mvc.New(AuthScope.Party("/addons")).HandleError(ErrorHandler).Handle(controller.NewClusterAddonController())
+	mvc.New(AuthScope.Party("/monitor")).HandleError(ErrorHandler).Handle(controller.NewMultiClusterMonitorController())
+	mvc.New(AuthScope.Party("/clusters/usage")).HandleError(ErrorHandler).Handle(controller.NewClusterUsageController())
+	mvc.New(AuthScope.Party("/logsearch")).HandleError(ErrorHandler).Handle(controller.NewLogSearchController())
+	mvc.New(AuthScope.Party("/costs/rates")).HandleError(ErrorHandler).Handle(controller.NewCostRateController())
+	mvc.New(AuthScope.Party("/reports")).HandleError(ErrorHandler).Handle(controller.NewReportController())
 	mvc.New(AuthScope.Party("/vmconfigs")).HandleError(ErrorHandler).Handle(controller.NewVmConfigController())
+	mvc.New(AuthScope.Party("/images")).HandleError(ErrorHandler).Handle(controller.NewImageController())
 	mvc.New(AuthScope.Party("/clusters/events")).HandleError(ErrorHandler).Handle(controller.NewClusterEventController())
 	mvc.New(AuthScope.Party("/ippools")).HandleError(ErrorHandler).Handle(controller.NewIpPoolController())
 	mvc.New(AuthScope.Party("/ippools/{name}/ips")).HandleError(ErrorHandler).Handle(controller.NewIpController())
@@ -56,6 +66,7 @@ func V1(parent iris.Party) {
 	WhiteScope.Get("/clusters/kubeconfig/{name}", downloadKubeconfig)
 	WhiteScope.Get("/captcha", generateCaptcha)
 	mvc.New(WhiteScope.Party("/theme")).HandleError(ErrorHandler).Handle(controller.NewThemeController())
+	mvc.New(WhiteScope.Party("/alerts")).HandleError(ErrorHandler).Handle(controller.NewClusterAlertController())
 
 }
 
@@ -44,6 +44,7 @@ func Phases() []Phase {
 			User:     viper.GetString("db.user"),
 			Password: viper.GetString("db.password"),
 		},
+		&encrypt.InitKeyringPhase{},
 		&data.InitDataPhase{},
 		&plugin.InitPluginDBPhase{},
 		&cron.InitCronPhase{
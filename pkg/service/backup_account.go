@@ -125,7 +125,7 @@ func (b backupAccountService) ListByClusterName(clusterName string) ([]dto.Backu
 		backupAccountDTOs []dto.BackupAccount
 		backupAccounts    []model.BackupAccount
 	)
-	err := db.DB.Raw("SELECT * FROM ko_backup_account WHERE id IN (SELECT resource_id FROM ko_cluster_resource WHERE  resource_type = 'BACKUP_ACCOUNT' AND cluster_id = (SELECT DISTINCT id FROM ko_cluster WHERE `name` = ?) )", clusterName).Scan(&backupAccounts).Error
+	err := db.DB.Raw("SELECT * FROM ko_backup_account WHERE id IN (SELECT resource_id FROM ko_cluster_resource WHERE  resource_type = 'BACKUP_ACCOUNT' AND cluster_id = (SELECT DISTINCT id FROM ko_cluster WHERE `name` = ?) )", clusterName).Find(&backupAccounts).Error
 	if err != nil {
 		return nil, err
 	}
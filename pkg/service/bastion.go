@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
+)
+
+var BastionNameExist = "NAME_EXISTS"
+
+type BastionService interface {
+	Get(name string) (*dto.Bastion, error)
+	List() ([]dto.Bastion, error)
+	Create(creation dto.BastionCreate) (*dto.Bastion, error)
+	Update(name string, update dto.BastionUpdate) (*dto.Bastion, error)
+	Delete(name string) error
+}
+
+type bastionService struct {
+	bastionRepo    repository.BastionRepository
+	credentialRepo repository.CredentialRepository
+}
+
+func NewBastionService() BastionService {
+	return &bastionService{
+		bastionRepo:    repository.NewBastionRepository(),
+		credentialRepo: repository.NewCredentialRepository(),
+	}
+}
+
+func (b bastionService) Get(name string) (*dto.Bastion, error) {
+	mo, err := b.bastionRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	item := toBastionDTO(mo)
+	return &item, nil
+}
+
+func (b bastionService) List() ([]dto.Bastion, error) {
+	var bastionDTOs []dto.Bastion
+	mos, err := b.bastionRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, mo := range mos {
+		bastionDTOs = append(bastionDTOs, toBastionDTO(mo))
+	}
+	return bastionDTOs, nil
+}
+
+func (b bastionService) Create(creation dto.BastionCreate) (*dto.Bastion, error) {
+	old, _ := b.bastionRepo.Get(creation.Name)
+	if old.ID != "" {
+		return nil, errors.New(BastionNameExist)
+	}
+	credential, err := b.credentialRepo.Get(creation.CredentialName)
+	if err != nil {
+		return nil, err
+	}
+	bastion := model.Bastion{
+		Name:         creation.Name,
+		Ip:           creation.Ip,
+		Port:         creation.Port,
+		CredentialID: credential.ID,
+		Credential:   credential,
+	}
+	if err := b.trust(&bastion); err != nil {
+		return nil, err
+	}
+	if err := b.bastionRepo.Save(&bastion); err != nil {
+		return nil, err
+	}
+	item := toBastionDTO(bastion)
+	return &item, nil
+}
+
+func (b bastionService) Update(name string, update dto.BastionUpdate) (*dto.Bastion, error) {
+	bastion, err := b.bastionRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := b.credentialRepo.Get(update.CredentialName)
+	if err != nil {
+		return nil, err
+	}
+	bastion.Ip = update.Ip
+	bastion.Port = update.Port
+	bastion.CredentialID = credential.ID
+	bastion.Credential = credential
+	if err := b.trust(&bastion); err != nil {
+		return nil, err
+	}
+	if err := b.bastionRepo.Save(&bastion); err != nil {
+		return nil, err
+	}
+	item := toBastionDTO(bastion)
+	return &item, nil
+}
+
+func (b bastionService) Delete(name string) error {
+	return b.bastionRepo.Delete(name)
+}
+
+// trust records the key of the bastion, the admin adding it is the one approving it.
+func (b bastionService) trust(bastion *model.Bastion) error {
+	config, err := bastion.ToSSHConfig()
+	if err != nil {
+		return err
+	}
+	key, err := ssh.ScanHostKey(config)
+	if err != nil {
+		return err
+	}
+	bastion.HostKey = key
+	return nil
+}
+
+func toBastionDTO(mo model.Bastion) dto.Bastion {
+	item := dto.Bastion{Bastion: mo, CredentialName: mo.Credential.Name}
+	if mo.HostKey != "" {
+		item.Fingerprint, _ = ssh.HostKeyFingerprint(mo.HostKey)
+	}
+	return item
+}
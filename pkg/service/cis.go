@@ -114,15 +114,9 @@ func (c *cisService) Create(clusterName string) (*dto.CisTask, error) {
 	if err != nil {
 		return nil, err
 	}
-	var registery model.SystemRegistry
-	if cluster.Spec.Architectures == constant.ArchAMD64 {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&registery).Error; err != nil {
-			return nil, errors.New("load image pull port of arm failed")
-		}
-	} else {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfARM64).First(&registery).Error; err != nil {
-			return nil, errors.New("load image pull port of arm failed")
-		}
+	registery, err := model.GetSystemRegistry(cisArch(&cluster))
+	if err != nil {
+		return nil, errors.New("load image pull port of arm failed")
 	}
 	localRepoPort := registery.RegistryPort
 
@@ -192,6 +186,7 @@ func Do(cluster *model.Cluster, client *kubernetes.Clientset, task *model.CisTas
 				Spec: corev1.PodSpec{
 					HostPID:       true,
 					RestartPolicy: "Never",
+					NodeSelector:  cisNodeSelector(cluster),
 					Containers: []corev1.Container{
 						{
 							Name:    "kube-bench",
@@ -346,3 +341,24 @@ func Do(cluster *model.Cluster, client *kubernetes.Clientset, task *model.CisTas
 		return
 	}
 }
+
+// cisArch returns the architecture kube-bench runs on, mixed clusters use the
+// architecture of their first master.
+func cisArch(cluster *model.Cluster) string {
+	if cluster.Spec.Architectures != constant.ArchAll {
+		return cluster.Spec.Architectures
+	}
+	for _, node := range cluster.Nodes {
+		if node.Role == constant.NodeRoleNameMaster && node.Host.Arch() != "" {
+			return node.Host.Arch()
+		}
+	}
+	return constant.ArchAMD64
+}
+
+func cisNodeSelector(cluster *model.Cluster) map[string]string {
+	if cluster.Spec.Architectures != constant.ArchAll {
+		return nil
+	}
+	return map[string]string{constant.NodeArchLabel: cisArch(cluster)}
+}
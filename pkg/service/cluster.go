@@ -19,6 +19,7 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/facts"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/loadbalancer"
 	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
 	clusterUtil "github.com/KubeOperator/KubeOperator/pkg/util/cluster"
 	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
@@ -383,6 +384,16 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 	secret := model.ClusterSecret{
 		KubeadmToken: clusterUtil.GenerateKubeadmToken(),
 	}
+	if spec.Provider == constant.ClusterProviderPlan {
+		// the capacity check asks the provider apis, keep it out of the create transaction
+		var plan model.Plan
+		if err := db.DB.Where("name = ?", creation.Plan).First(&plan).Error; err != nil {
+			return nil, fmt.Errorf("can not query plan %s reason %s", creation.Plan, err.Error())
+		}
+		if err := checkPlanCapacity(plan, planMasterAmount(plan), creation.WorkerAmount); err != nil {
+			return nil, err
+		}
+	}
 	tx := db.DB.Begin()
 	if err := tx.Create(&spec).Error; err != nil {
 		tx.Rollback()
@@ -418,6 +429,16 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 			tx.Rollback()
 			return nil, fmt.Errorf("can not query plan %s reason %s", creation.Plan, err.Error())
 		}
+		request, err := planQuotaRequest(plan, planMasterAmount(plan), creation.WorkerAmount)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		request.Clusters = 1
+		if err := checkProjectQuota(tx, project.ID, request); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 		cluster.PlanID = plan.ID
 		if err := tx.Save(&cluster).Error; err != nil {
 			tx.Rollback()
@@ -433,6 +454,20 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 			return nil, fmt.Errorf("can not create cluster  %s resource reason %s", cluster.Name, err.Error())
 		}
 	case constant.ClusterProviderBareMetal:
+		var hostNames []string
+		for _, nc := range creation.Nodes {
+			hostNames = append(hostNames, nc.HostName)
+		}
+		request, err := hostQuotaRequest(hostNames)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		request.Clusters = 1
+		if err := checkProjectQuota(tx, project.ID, request); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 		workerNo := 1
 		masterNo := 1
 		firstMasterIP := ""
@@ -485,6 +520,13 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 			cluster.Spec.LbKubeApiserverIp = firstMasterIP
 		}
 		spec.KubeRouter = firstMasterIP
+		var hosts []model.Host
+		for _, n := range cluster.Nodes {
+			hosts = append(hosts, n.Host)
+		}
+		if model.ArchitecturesOf(hosts) == constant.ArchAll {
+			spec.Architectures = constant.ArchAll
+		}
 	}
 	if err := tx.Save(&spec).Error; err != nil {
 		tx.Rollback()
@@ -631,7 +673,14 @@ func (c *clusterService) uninstallCluster(cluster *model.Cluster, force bool) {
 	cluster.LogId = logId
 	_ = db.DB.Save(cluster)
 
-	inventory := cluster.ParseInventory()
+	lb, err := loadbalancer.LoadForInventory(cluster.ID)
+	if err != nil {
+		logger.Log.Errorf("load load balancer of cluster %s error: %s", cluster.Name, err.Error())
+	}
+	if err := cluster.WriteConnectionFiles(lb); err != nil {
+		logger.Log.Errorf("write connection files of cluster %s error: %s", cluster.Name, err.Error())
+	}
+	inventory := cluster.ParseInventory(lb)
 	k := kobe.NewAnsible(&kobe.Config{
 		Inventory: inventory,
 	})
@@ -14,6 +14,7 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/facts"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/loadbalancer"
 	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
 )
 
@@ -73,8 +74,15 @@ func NewCluster(cluster model.Cluster, writer ...io.Writer) *Cluster {
 	if writer != nil {
 		c.writer = writer[0]
 	}
+	lb, err := loadbalancer.LoadForInventory(c.ID)
+	if err != nil {
+		logger.Log.Errorf("load load balancer of cluster %s error: %s", c.Name, err.Error())
+	}
+	if err := c.WriteConnectionFiles(lb); err != nil {
+		logger.Log.Errorf("write connection files of cluster %s error: %s", c.Name, err.Error())
+	}
 	c.Kobe = kobe.NewAnsible(&kobe.Config{
-		Inventory: c.ParseInventory(),
+		Inventory: c.ParseInventory(lb),
 	})
 	for i := range facts.DefaultFacts {
 		c.Kobe.SetVar(i, facts.DefaultFacts[i])
@@ -11,6 +11,7 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases/initial"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases/plugin/ingress"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases/prepare"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/loadbalancer"
 )
 
 func (ca *ClusterAdm) Create(c *Cluster) error {
@@ -117,6 +118,9 @@ func (ca *ClusterAdm) EnsurePrepareKubernetesComponent(c *Cluster) error {
 }
 
 func (ca *ClusterAdm) EnsurePrepareLoadBalancer(c *Cluster) error {
+	if _, ok := loadbalancer.Load(c.ID); ok {
+		return loadbalancer.Sync(c.Cluster, c.Kobe, c.writer)
+	}
 	phase := prepare.LoadBalancerPhase{}
 	return phase.Run(c.Kobe, c.writer)
 }
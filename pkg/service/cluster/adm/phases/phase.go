@@ -27,10 +27,26 @@ type Interface interface {
 
 func RunPlaybookAndGetResult(b kobe.Interface, playbookName, tag string, writer io.Writer) error {
 	taskId, err := b.RunPlaybook(playbookName, tag)
-	var result kobe.Result
 	if err != nil {
 		return err
 	}
+	return waitForResult(b, taskId, writer)
+}
+
+// RunAdhocAndGetResult runs a single module on the hosts of the pattern, for tasks no playbook ships.
+func RunAdhocAndGetResult(b kobe.Interface, pattern, module, param string, writer io.Writer) error {
+	taskId, err := b.RunAdhoc(pattern, module, param)
+	if err != nil {
+		return err
+	}
+	return waitForResult(b, taskId, writer)
+}
+
+func waitForResult(b kobe.Interface, taskId string, writer io.Writer) error {
+	var (
+		result kobe.Result
+		err    error
+	)
 	// 读取 ansible 执行日志
 	if writer != nil {
 		go func() {
@@ -1,7 +1,7 @@
 package istios
 
 import (
-	"encoding/json"
+	"fmt"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
@@ -20,20 +20,27 @@ func NewBaseInterface(component *model.ClusterIstio, helmInfo IstioHelmInfo) *Ba
 }
 
 func (b *BaseInterface) setDefaultValue() map[string]interface{} {
-	values := map[string]interface{}{}
-	_ = json.Unmarshal([]byte(b.Component.Vars), &values)
-
+	values := componentValues(b.Component)
+	if b.Component.Revision != "" {
+		// the crds are validated by the istiod of the revision once it is promoted
+		values["base.validationURL"] = fmt.Sprintf("https://istiod-%s.%s.svc:443/validate", b.Component.Revision, constant.IstioNamespace)
+	}
 	return values
 }
 
 func (b *BaseInterface) Install() error {
 	valueMaps := b.setDefaultValue()
-	if err := installChart(b.HelmInfo.HelmClient, b.Component, valueMaps, constant.BaseChartName); err != nil {
+	if err := installChart(b.HelmInfo.HelmClient, b.Component, valueMaps, constant.BaseChartName, b.HelmInfo.Detail.ChartVersion); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (b *BaseInterface) Upgrade() error {
+	valueMaps := b.setDefaultValue()
+	return upgradeChart(b.HelmInfo.HelmClient, b.Component, valueMaps, constant.BaseChartName, b.HelmInfo.Detail.ChartVersion)
+}
+
 func (b *BaseInterface) Uninstall() error {
 	return uninstall(b.Component, b.HelmInfo.HelmClient)
 }
@@ -1,8 +1,11 @@
 package istios
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/util/helm"
 	"helm.sh/helm/v3/pkg/strvals"
@@ -11,26 +14,61 @@ import (
 
 type IstioInterface interface {
 	Install() error
+	Upgrade() error
 	Uninstall() error
 }
 
+// IstioHelmInfo is where the component is deployed, Detail is the version installed or upgraded to.
 type IstioHelmInfo struct {
 	Namespace     string
 	Cluster       model.Cluster
 	LocalhostName string
 	LocalhostPort int
+	Detail        model.ClusterIstioDetail
 	HelmClient    helm.Interface
 	KubeClient    *kubernetes.Clientset
 }
 
-func preInstallChart(h helm.Interface, istio *model.ClusterIstio) error {
+// image returns the image of the detail in the local repository, fallback without detail.
+func (h IstioHelmInfo) image(name, fallback string) string {
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(h.Detail.Vars), &vars)
+	image := fallback
+	if n, ok := vars[name+"_image_name"]; ok {
+		image = fmt.Sprintf("%v:%v", n, vars[name+"_image_tag"])
+	}
+	return fmt.Sprintf("%s:%d/%s", h.LocalhostName, h.LocalhostPort, image)
+}
+
+// Revision returns the istiod revision of version, v1.9.9 is revision 1-9-9.
+func Revision(version string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(version, "v"), ".", "-")
+}
+
+// releaseName is the helm release of the component, a pilot of a revision has a release of its own.
+func releaseName(istio *model.ClusterIstio) string {
+	if istio.Name == "pilot" && istio.Revision != "" {
+		return istio.Name + "-" + istio.Revision
+	}
+	return istio.Name
+}
+
+// componentValues reads the vars of the component, the settings of KubeOperator are left out.
+func componentValues(istio *model.ClusterIstio) map[string]interface{} {
+	values := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(istio.Vars), &values)
+	delete(values, constant.IstioMeshConfigVar)
+	return values
+}
+
+func preInstallChart(h helm.Interface, name string) error {
 	rs, err := h.List()
 	if err != nil {
 		return err
 	}
 	for _, r := range rs {
-		if r.Name == istio.Name {
-			_, err := h.Uninstall(istio.Name)
+		if r.Name == name {
+			_, err := h.Uninstall(name)
 			if err != nil {
 				return err
 			}
@@ -39,8 +77,14 @@ func preInstallChart(h helm.Interface, istio *model.ClusterIstio) error {
 	return nil
 }
 
-func installChart(h helm.Interface, istio *model.ClusterIstio, valueMap map[string]interface{}, chartName string) error {
-	err := preInstallChart(h, istio)
+func installChart(h helm.Interface, istio *model.ClusterIstio, valueMap map[string]interface{}, chartName, chartVersion string) error {
+	return installRelease(h, releaseName(istio), valueMap, nil, chartName, chartVersion)
+}
+
+// installRelease installs the chart with the flat vars and the structured values, the ones which
+// can not be written as flat vars.
+func installRelease(h helm.Interface, name string, valueMap, values map[string]interface{}, chartName, chartVersion string) error {
+	err := preInstallChart(h, name)
 	if err != nil {
 		return err
 	}
@@ -48,13 +92,42 @@ func installChart(h helm.Interface, istio *model.ClusterIstio, valueMap map[stri
 	if err != nil {
 		return err
 	}
-	_, err = h.Install(istio.Name, chartName, "", m)
+	mergeValues(m, values)
+	_, err = h.Install(name, chartName, chartVersion, m)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func upgradeChart(h helm.Interface, istio *model.ClusterIstio, valueMap map[string]interface{}, chartName, chartVersion string) error {
+	return upgradeRelease(h, releaseName(istio), valueMap, nil, chartName, chartVersion)
+}
+
+// upgradeRelease upgrades the release in place, the values of the chart are replaced by the vars.
+func upgradeRelease(h helm.Interface, name string, valueMap, values map[string]interface{}, chartName, chartVersion string) error {
+	m, err := MergeValueMap(valueMap)
+	if err != nil {
+		return err
+	}
+	mergeValues(m, values)
+	_, err = h.Upgrade(name, chartName, chartVersion, m)
+	return err
+}
+
+// mergeValues merges src into dst, nested maps are merged key by key.
+func mergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				mergeValues(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
 func MergeValueMap(source map[string]interface{}) (map[string]interface{}, error) {
 	result := map[string]interface{}{}
 
@@ -73,13 +146,17 @@ func MergeValueMap(source map[string]interface{}) (map[string]interface{}, error
 }
 
 func uninstall(istio *model.ClusterIstio, h helm.Interface) error {
+	return uninstallRelease(releaseName(istio), h)
+}
+
+func uninstallRelease(name string, h helm.Interface) error {
 	rs, err := h.List()
 	if err != nil {
 		return err
 	}
 	for _, r := range rs {
-		if r.Name == istio.Name {
-			_, _ = h.Uninstall(istio.Name)
+		if r.Name == name {
+			_, _ = h.Uninstall(name)
 		}
 	}
 	return nil
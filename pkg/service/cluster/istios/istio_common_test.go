@@ -0,0 +1,43 @@
+package istios
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRevision(t *testing.T) {
+	cases := map[string]string{
+		"v1.8.0": "1-8-0",
+		"1.9.5":  "1-9-5",
+		"v1.10":  "1-10",
+		"":       "",
+	}
+	for version, want := range cases {
+		if got := Revision(version); got != want {
+			t.Errorf("Revision(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]interface{}{
+		"meshConfig": map[string]interface{}{"enableTracing": false, "accessLogFile": "/dev/stdout"},
+		"global":     map[string]interface{}{"hub": "registry.local"},
+		"revision":   "1-8-0",
+	}
+	src := map[string]interface{}{
+		"meshConfig": map[string]interface{}{"enableTracing": true},
+		"global":     "replaced",
+		"pilot":      map[string]interface{}{"traceSampling": 1.0},
+	}
+	mergeValues(dst, src)
+	want := map[string]interface{}{
+		"meshConfig": map[string]interface{}{"enableTracing": true, "accessLogFile": "/dev/stdout"},
+		"global":     "replaced",
+		"revision":   "1-8-0",
+		"pilot":      map[string]interface{}{"traceSampling": 1.0},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("merged values = %v, want %v", dst, want)
+	}
+}
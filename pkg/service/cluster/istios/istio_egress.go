@@ -1,7 +1,6 @@
 package istios
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
@@ -25,9 +24,11 @@ func NewEgressInterface(component *model.ClusterIstio, helmInfo IstioHelmInfo) *
 }
 
 func (e *EgressInterface) setDefaultValue() map[string]interface{} {
-	values := map[string]interface{}{}
-	_ = json.Unmarshal([]byte(e.Component.Vars), &values)
-	values["global.proxy.image"] = fmt.Sprintf("%s:%d/%s", e.HelmInfo.LocalhostName, e.HelmInfo.LocalhostPort, EgressImage)
+	values := componentValues(e.Component)
+	values["global.proxy.image"] = e.HelmInfo.image("proxy", EgressImage)
+	if e.Component.Revision != "" {
+		values["revision"] = e.Component.Revision
+	}
 	values["global.jwtPolicy"] = "first-party-jwt"
 	values["gateways.istio-egressgateway.resources.requests.cpu"] = fmt.Sprintf("%vm", values["gateways.istio-egressgateway.resources.requests.cpu"])
 	values["gateways.istio-egressgateway.resources.requests.memory"] = fmt.Sprintf("%vMi", values["gateways.istio-egressgateway.resources.requests.memory"])
@@ -39,12 +40,17 @@ func (e *EgressInterface) setDefaultValue() map[string]interface{} {
 
 func (e *EgressInterface) Install() error {
 	valueMaps := e.setDefaultValue()
-	if err := installChart(e.HelmInfo.HelmClient, e.Component, valueMaps, constant.EgressChartName); err != nil {
+	if err := installChart(e.HelmInfo.HelmClient, e.Component, valueMaps, constant.EgressChartName, e.HelmInfo.Detail.ChartVersion); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (e *EgressInterface) Upgrade() error {
+	valueMaps := e.setDefaultValue()
+	return upgradeChart(e.HelmInfo.HelmClient, e.Component, valueMaps, constant.EgressChartName, e.HelmInfo.Detail.ChartVersion)
+}
+
 func (e *EgressInterface) Uninstall() error {
 	return uninstall(e.Component, e.HelmInfo.HelmClient)
 }
@@ -1,7 +1,6 @@
 package istios
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
@@ -25,9 +24,11 @@ func NewIngressInterface(component *model.ClusterIstio, helmInfo IstioHelmInfo)
 }
 
 func (i *IngressInterface) setDefaultValue() map[string]interface{} {
-	values := map[string]interface{}{}
-	_ = json.Unmarshal([]byte(i.Component.Vars), &values)
-	values["global.proxy.image"] = fmt.Sprintf("%s:%d/%s", i.HelmInfo.LocalhostName, i.HelmInfo.LocalhostPort, IngressImage)
+	values := componentValues(i.Component)
+	values["global.proxy.image"] = i.HelmInfo.image("proxy", IngressImage)
+	if i.Component.Revision != "" {
+		values["revision"] = i.Component.Revision
+	}
 	values["global.jwtPolicy"] = "first-party-jwt"
 	values["gateways.istio-ingressgateway.resources.requests.cpu"] = fmt.Sprintf("%vm", values["gateways.istio-ingressgateway.resources.requests.cpu"])
 	values["gateways.istio-ingressgateway.resources.requests.memory"] = fmt.Sprintf("%vMi", values["gateways.istio-ingressgateway.resources.requests.memory"])
@@ -38,12 +39,17 @@ func (i *IngressInterface) setDefaultValue() map[string]interface{} {
 
 func (i *IngressInterface) Install() error {
 	valueMaps := i.setDefaultValue()
-	if err := installChart(i.HelmInfo.HelmClient, i.Component, valueMaps, constant.IngressChartName); err != nil {
+	if err := installChart(i.HelmInfo.HelmClient, i.Component, valueMaps, constant.IngressChartName, i.HelmInfo.Detail.ChartVersion); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (i *IngressInterface) Upgrade() error {
+	valueMaps := i.setDefaultValue()
+	return upgradeChart(i.HelmInfo.HelmClient, i.Component, valueMaps, constant.IngressChartName, i.HelmInfo.Detail.ChartVersion)
+}
+
 func (i *IngressInterface) Uninstall() error {
 	return uninstall(i.Component, i.HelmInfo.HelmClient)
 }
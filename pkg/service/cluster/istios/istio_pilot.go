@@ -1,7 +1,6 @@
 package istios
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
@@ -25,9 +24,13 @@ func NewPilotInterface(component *model.ClusterIstio, helmInfo IstioHelmInfo) *P
 }
 
 func (d *PilotInterface) setDefaultValue() map[string]interface{} {
-	values := map[string]interface{}{}
-	_ = json.Unmarshal([]byte(d.Component.Vars), &values)
-	values["pilot.image"] = fmt.Sprintf("%s:%d/%s", d.HelmInfo.LocalhostName, d.HelmInfo.LocalhostPort, PilotImageName)
+	values := componentValues(d.Component)
+	values["pilot.image"] = d.HelmInfo.image("pilot", PilotImageName)
+	// the sidecars injected by the pilot follow its version
+	values["global.proxy.image"] = d.HelmInfo.image("proxy", IngressImage)
+	if d.Component.Revision != "" {
+		values["revision"] = d.Component.Revision
+	}
 	values["global.jwtPolicy"] = "first-party-jwt"
 	values["pilot.resources.requests.cpu"] = fmt.Sprintf("%vm", values["pilot.resources.requests.cpu"])
 	values["pilot.resources.requests.memory"] = fmt.Sprintf("%vMi", values["pilot.resources.requests.memory"])
@@ -38,13 +41,59 @@ func (d *PilotInterface) setDefaultValue() map[string]interface{} {
 }
 
 func (d *PilotInterface) Install() error {
+	config, err := componentMeshConfig(d.Component)
+	if err != nil {
+		return err
+	}
+	valueMaps := d.setDefaultValue()
+	if err := installRelease(d.HelmInfo.HelmClient, releaseName(d.Component), valueMaps, config.values(), constant.PilotChartName, d.HelmInfo.Detail.ChartVersion); err != nil {
+		return err
+	}
+	return d.applyMtls(config)
+}
+
+func (d *PilotInterface) Upgrade() error {
+	config, err := componentMeshConfig(d.Component)
+	if err != nil {
+		return err
+	}
 	valueMaps := d.setDefaultValue()
-	if err := installChart(d.HelmInfo.HelmClient, d.Component, valueMaps, constant.PilotChartName); err != nil {
+	if err := upgradeRelease(d.HelmInfo.HelmClient, releaseName(d.Component), valueMaps, config.values(), constant.PilotChartName, d.HelmInfo.Detail.ChartVersion); err != nil {
 		return err
 	}
-	return nil
+	return d.applyMtls(config)
 }
 
 func (d *PilotInterface) Uninstall() error {
+	if d.Component.CanaryRevision != "" {
+		if err := d.UninstallCanary(); err != nil {
+			return err
+		}
+	}
 	return uninstall(d.Component, d.HelmInfo.HelmClient)
 }
+
+// canary is the pilot of the canary revision, HelmInfo holds the detail of the canary version.
+func (d *PilotInterface) canary() *PilotInterface {
+	component := *d.Component
+	component.Version = d.Component.CanaryVersion
+	component.Revision = d.Component.CanaryRevision
+	return NewPilotInterface(&component, d.HelmInfo)
+}
+
+// InstallCanary installs the istiod of the canary revision next to the active one, the sidecars
+// move to it as the namespaces are labeled with the revision and their workloads restarted.
+func (d *PilotInterface) InstallCanary() error {
+	return d.canary().Install()
+}
+
+func (d *PilotInterface) UninstallCanary() error {
+	return uninstall(d.canary().Component, d.HelmInfo.HelmClient)
+}
+
+func (d *PilotInterface) applyMtls(config *MeshConfig) error {
+	if config == nil || config.MtlsMode == "" {
+		return nil
+	}
+	return applyPeerAuthentication(d.HelmInfo.KubeClient, config.MtlsMode)
+}
@@ -0,0 +1,166 @@
+package istios
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MeshConfig is the mesh settings of the pilot, stored in the var constant.IstioMeshConfigVar of it.
+type MeshConfig struct {
+	// MtlsMode is the mesh wide mode of the default PeerAuthentication, it is left alone when empty.
+	MtlsMode          string `json:"mtlsMode"`
+	AccessLog         bool   `json:"accessLog"`
+	AccessLogEncoding string `json:"accessLogEncoding"`
+	Tracing           bool   `json:"tracing"`
+	// TracingSampling is the percentage of the requests traced.
+	TracingSampling float64        `json:"tracingSampling"`
+	ZipkinAddress   string         `json:"zipkinAddress"`
+	ProxyResources  ProxyResources `json:"proxyResources"`
+}
+
+// ProxyResources are the quantities of the resources of the sidecars.
+type ProxyResources struct {
+	RequestsCpu    string `json:"requestsCpu"`
+	RequestsMemory string `json:"requestsMemory"`
+	LimitsCpu      string `json:"limitsCpu"`
+	LimitsMemory   string `json:"limitsMemory"`
+}
+
+// ParseMeshConfig reads and validates the mesh settings of the vars of the pilot, nil is returned
+// when they are not set.
+func ParseMeshConfig(vars map[string]interface{}) (*MeshConfig, error) {
+	v, ok := vars[constant.IstioMeshConfigVar]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var config MeshConfig
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("%s is invalid: %s", constant.IstioMeshConfigVar, err.Error())
+	}
+	switch config.MtlsMode {
+	case "", "PERMISSIVE", "STRICT", "DISABLE":
+	default:
+		return nil, fmt.Errorf("mtls mode %q must be PERMISSIVE, STRICT or DISABLE", config.MtlsMode)
+	}
+	switch config.AccessLogEncoding {
+	case "", "TEXT", "JSON":
+	default:
+		return nil, fmt.Errorf("access log encoding %q must be TEXT or JSON", config.AccessLogEncoding)
+	}
+	if config.TracingSampling < 0 || config.TracingSampling > 100 {
+		return nil, fmt.Errorf("tracing sampling %v must be between 0 and 100", config.TracingSampling)
+	}
+	if config.Tracing && config.ZipkinAddress != "" {
+		if _, _, err := net.SplitHostPort(config.ZipkinAddress); err != nil {
+			return nil, fmt.Errorf("zipkin address %q must be host:port", config.ZipkinAddress)
+		}
+	}
+	r := config.ProxyResources
+	for _, pair := range [][2]string{{r.RequestsCpu, r.LimitsCpu}, {r.RequestsMemory, r.LimitsMemory}} {
+		var quantities []resource.Quantity
+		for _, s := range pair {
+			if s == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(s)
+			if err != nil {
+				return nil, fmt.Errorf("proxy resource %q is not a quantity", s)
+			}
+			quantities = append(quantities, q)
+		}
+		if len(quantities) == 2 && quantities[0].Cmp(quantities[1]) > 0 {
+			return nil, fmt.Errorf("proxy request %s is more than the limit %s", pair[0], pair[1])
+		}
+	}
+	return &config, nil
+}
+
+func componentMeshConfig(istio *model.ClusterIstio) (*MeshConfig, error) {
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(istio.Vars), &vars)
+	return ParseMeshConfig(vars)
+}
+
+// values returns the structured values of the istio-discovery chart, nil keeps the defaults of it.
+func (m *MeshConfig) values() map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	meshConfig := map[string]interface{}{"enableTracing": m.Tracing, "accessLogFile": ""}
+	if m.AccessLog {
+		meshConfig["accessLogFile"] = "/dev/stdout"
+		if m.AccessLogEncoding != "" {
+			meshConfig["accessLogEncoding"] = m.AccessLogEncoding
+		}
+	}
+	values := map[string]interface{}{"meshConfig": meshConfig}
+	if m.Tracing {
+		tracing := map[string]interface{}{"sampling": m.TracingSampling}
+		if m.ZipkinAddress != "" {
+			tracing["zipkin"] = map[string]interface{}{"address": m.ZipkinAddress}
+		}
+		meshConfig["defaultConfig"] = map[string]interface{}{"tracing": tracing}
+		values["pilot"] = map[string]interface{}{"traceSampling": m.TracingSampling}
+	}
+	resources := map[string]interface{}{}
+	if requests := quantities(m.ProxyResources.RequestsCpu, m.ProxyResources.RequestsMemory); len(requests) > 0 {
+		resources["requests"] = requests
+	}
+	if limits := quantities(m.ProxyResources.LimitsCpu, m.ProxyResources.LimitsMemory); len(limits) > 0 {
+		resources["limits"] = limits
+	}
+	if len(resources) > 0 {
+		values["global"] = map[string]interface{}{"proxy": map[string]interface{}{"resources": resources}}
+	}
+	return values
+}
+
+func quantities(cpu, memory string) map[string]interface{} {
+	q := map[string]interface{}{}
+	if cpu != "" {
+		q["cpu"] = cpu
+	}
+	if memory != "" {
+		q["memory"] = memory
+	}
+	return q
+}
+
+// applyPeerAuthentication creates or replaces the mesh wide PeerAuthentication of the mode.
+func applyPeerAuthentication(kubeClient *kubernetes.Clientset, mode string) error {
+	path := fmt.Sprintf("/apis/security.istio.io/v1beta1/namespaces/%s/peerauthentications", constant.IstioNamespace)
+	policy := map[string]interface{}{
+		"apiVersion": "security.istio.io/v1beta1",
+		"kind":       "PeerAuthentication",
+		"metadata":   map[string]interface{}{"name": "default", "namespace": constant.IstioNamespace},
+		"spec":       map[string]interface{}{"mtls": map[string]interface{}{"mode": mode}},
+	}
+	rest := kubeClient.CoreV1().RESTClient()
+	existing, err := rest.Get().AbsPath(path, "default").DoRaw(context.TODO())
+	if err != nil {
+		data, _ := json.Marshal(policy)
+		_, err = rest.Post().AbsPath(path).SetHeader("Content-Type", "application/json").Body(data).DoRaw(context.TODO())
+		return err
+	}
+	var current struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(existing, &current)
+	policy["metadata"].(map[string]interface{})["resourceVersion"] = current.Metadata.ResourceVersion
+	data, _ := json.Marshal(policy)
+	_, err = rest.Put().AbsPath(path, "default").SetHeader("Content-Type", "application/json").Body(data).DoRaw(context.TODO())
+	return err
+}
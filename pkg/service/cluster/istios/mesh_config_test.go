@@ -0,0 +1,45 @@
+package istios
+
+import (
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+)
+
+func TestParseMeshConfig(t *testing.T) {
+	config, err := ParseMeshConfig(map[string]interface{}{})
+	if err != nil || config != nil {
+		t.Fatalf("want no settings without the var, got %v, %v", config, err)
+	}
+	valid := map[string]interface{}{
+		"mtlsMode":          "STRICT",
+		"accessLog":         true,
+		"accessLogEncoding": "JSON",
+		"tracing":           true,
+		"tracingSampling":   10,
+		"zipkinAddress":     "zipkin.istio-system:9411",
+		"proxyResources":    map[string]interface{}{"requestsCpu": "100m", "limitsCpu": "1", "requestsMemory": "128Mi", "limitsMemory": "1Gi"},
+	}
+	config, err = ParseMeshConfig(map[string]interface{}{constant.IstioMeshConfigVar: valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MtlsMode != "STRICT" || config.TracingSampling != 10 || config.ProxyResources.LimitsMemory != "1Gi" {
+		t.Errorf("parsed %+v", config)
+	}
+
+	invalid := map[string]map[string]interface{}{
+		"mtls mode":          {"mtlsMode": "OPTIONAL"},
+		"encoding":           {"accessLogEncoding": "XML"},
+		"sampling":           {"tracingSampling": 101},
+		"zipkin address":     {"tracing": true, "zipkinAddress": "zipkin"},
+		"quantity":           {"proxyResources": map[string]interface{}{"requestsCpu": "a lot"}},
+		"request over limit": {"proxyResources": map[string]interface{}{"requestsMemory": "2Gi", "limitsMemory": "1Gi"}},
+		"type":               {"tracing": "yes"},
+	}
+	for name, v := range invalid {
+		if _, err := ParseMeshConfig(map[string]interface{}{constant.IstioMeshConfigVar: v}); err == nil {
+			t.Errorf("%s: want %v rejected", name, v)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package istios
+
+import (
+	"context"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RevisionLabel is the value of the revision label of the pods injected by the istiod of revision.
+func RevisionLabel(revision string) string {
+	if revision == "" {
+		return "default"
+	}
+	return revision
+}
+
+// RelabelNamespaces moves the sidecar injection of the namespaces from a revision to another, the
+// empty revision is the default istiod injecting the namespaces labeled istio-injection=enabled.
+// The running pods keep their sidecars until their workloads are restarted.
+func RelabelNamespaces(kubeClient *kubernetes.Clientset, from, to string) error {
+	selector := constant.IstioRevisionLabel + "=" + from
+	if from == "" {
+		selector = constant.IstioInjectionLabel + "=enabled"
+	}
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		delete(ns.Labels, constant.IstioInjectionLabel)
+		delete(ns.Labels, constant.IstioRevisionLabel)
+		if to == "" {
+			ns.Labels[constant.IstioInjectionLabel] = "enabled"
+		} else {
+			ns.Labels[constant.IstioRevisionLabel] = to
+		}
+		if _, err := kubeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package loadbalancer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+// F5 drives a BIG-IP through its iControl REST api, the virtual server listens on the public ip
+// of the setting and forwards to a pool holding the masters. The certificate of the api is verified
+// unless insecure is set for a self-signed one.
+type F5 struct {
+	setting  model.F5Setting
+	password string
+	name     string
+	port     int
+	client   *http.Client
+}
+
+func NewF5(setting model.F5Setting, password, name string, port int, insecure bool) *F5 {
+	if setting.Partition == "" {
+		setting.Partition = "Common"
+	}
+	return &F5{
+		setting:  setting,
+		password: password,
+		name:     name,
+		port:     port,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure}},
+		},
+	}
+}
+
+type f5PoolMember struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func (f *F5) poolName() string {
+	return f.name + "_pool"
+}
+
+func (f *F5) path(kind, name string) string {
+	return fmt.Sprintf("/mgmt/tm/ltm/%s/~%s~%s", kind, f.setting.Partition, name)
+}
+
+func (f *F5) SyncMembers(members []Member) error {
+	var poolMembers []f5PoolMember
+	for _, m := range members {
+		poolMembers = append(poolMembers, f5PoolMember{Name: fmt.Sprintf("%s:%d", m.Address, m.Port), Address: m.Address})
+	}
+	if poolMembers == nil {
+		poolMembers = []f5PoolMember{}
+	}
+	exist, err := f.exist(f.path("pool", f.poolName()))
+	if err != nil {
+		return err
+	}
+	if exist {
+		// patching the members replaces the whole member collection of the pool
+		if err := f.do(http.MethodPatch, f.path("pool", f.poolName()), map[string]interface{}{"members": poolMembers}); err != nil {
+			return err
+		}
+	} else {
+		if err := f.do(http.MethodPost, "/mgmt/tm/ltm/pool", map[string]interface{}{
+			"name":      f.poolName(),
+			"partition": f.setting.Partition,
+			"monitor":   "tcp",
+			"members":   poolMembers,
+		}); err != nil {
+			return err
+		}
+	}
+	exist, err = f.exist(f.path("virtual", f.name))
+	if err != nil || exist {
+		return err
+	}
+	return f.do(http.MethodPost, "/mgmt/tm/ltm/virtual", map[string]interface{}{
+		"name":                     f.name,
+		"partition":                f.setting.Partition,
+		"destination":              fmt.Sprintf("/%s/%s:%d", f.setting.Partition, f.setting.PublicIP, f.port),
+		"ipProtocol":               "tcp",
+		"pool":                     fmt.Sprintf("/%s/%s", f.setting.Partition, f.poolName()),
+		"sourceAddressTranslation": map[string]string{"type": "automap"},
+	})
+}
+
+func (f *F5) Delete() error {
+	for _, p := range []string{f.path("virtual", f.name), f.path("pool", f.poolName())} {
+		exist, err := f.exist(p)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+		if err := f.do(http.MethodDelete, p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *F5) exist(path string) (bool, error) {
+	resp, err := f.request(http.MethodGet, path, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	return false, fmt.Errorf("f5 get %s failed: %s %s", path, resp.Status, string(body))
+}
+
+func (f *F5) do(method, path string, payload interface{}) error {
+	resp, err := f.request(method, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("f5 %s %s failed: %s %s", strings.ToLower(method), path, resp.Status, string(body))
+	}
+	return nil
+}
+
+func (f *F5) request(method, path string, payload interface{}) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(f.setting.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(f.setting.User, f.password)
+	req.Header.Set("Content-Type", "application/json")
+	return f.client.Do(req)
+}
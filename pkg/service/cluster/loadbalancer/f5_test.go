@@ -0,0 +1,150 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+// f5StandIn keeps the pools and virtual servers of a BIG-IP by their ~partition~name path and the
+// payload of every write.
+type f5StandIn struct {
+	mu       sync.Mutex
+	objects  map[string]map[string]interface{}
+	requests []string
+}
+
+func newF5StandIn(t *testing.T) (*f5StandIn, *httptest.Server) {
+	f := &f5StandIn{objects: map[string]map[string]interface{}{}}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		var payload map[string]interface{}
+		if body, _ := ioutil.ReadAll(r.Body); len(body) > 0 {
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Errorf("invalid payload of %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodDelete, http.MethodPatch:
+			obj, ok := f.objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			switch r.Method {
+			case http.MethodDelete:
+				delete(f.objects, r.URL.Path)
+			case http.MethodPatch:
+				for k, v := range payload {
+					obj[k] = v
+				}
+			}
+			_ = json.NewEncoder(w).Encode(obj)
+		case http.MethodPost:
+			key := r.URL.Path + "/~" + payload["partition"].(string) + "~" + payload["name"].(string)
+			if _, ok := f.objects[key]; ok {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.objects[key] = payload
+			_ = json.NewEncoder(w).Encode(payload)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return f, server
+}
+
+func (f *f5StandIn) memberNames(t *testing.T, path string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pool, ok := f.objects[path]
+	if !ok {
+		t.Fatalf("pool %s does not exist", path)
+	}
+	var names []string
+	for _, m := range pool["members"].([]interface{}) {
+		names = append(names, m.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func newTestF5(server *httptest.Server, insecure bool) *F5 {
+	return NewF5(model.F5Setting{URL: server.URL + "/", User: "admin", PublicIP: "10.1.0.100"}, "secret", "ko_demo_apiserver", 6443, insecure)
+}
+
+func TestF5SyncMembers(t *testing.T) {
+	standIn, server := newF5StandIn(t)
+	defer server.Close()
+	f := newTestF5(server, true)
+
+	if err := f.SyncMembers([]Member{{Name: "demo-master-1", Address: "10.1.0.11", Port: 6443}, {Name: "demo-master-2", Address: "10.1.0.12", Port: 6443}}); err != nil {
+		t.Fatal(err)
+	}
+	pool := "/mgmt/tm/ltm/pool/~Common~ko_demo_apiserver_pool"
+	if got := strings.Join(standIn.memberNames(t, pool), ","); got != "10.1.0.11:6443,10.1.0.12:6443" {
+		t.Errorf("unexpected members %s", got)
+	}
+	virtual, ok := standIn.objects["/mgmt/tm/ltm/virtual/~Common~ko_demo_apiserver"]
+	if !ok {
+		t.Fatal("virtual server not created")
+	}
+	if virtual["destination"] != "/Common/10.1.0.100:6443" || virtual["pool"] != "/Common/ko_demo_apiserver_pool" {
+		t.Errorf("unexpected virtual server %v", virtual)
+	}
+
+	standIn.requests = nil
+	if err := f.SyncMembers([]Member{{Name: "demo-master-2", Address: "10.1.0.12", Port: 6443}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(standIn.memberNames(t, pool), ","); got != "10.1.0.12:6443" {
+		t.Errorf("members not replaced, got %s", got)
+	}
+	for _, r := range standIn.requests {
+		if strings.HasPrefix(r, http.MethodPost) {
+			t.Errorf("sync of an existing load balancer created %s", r)
+		}
+	}
+}
+
+func TestF5Delete(t *testing.T) {
+	standIn, server := newF5StandIn(t)
+	defer server.Close()
+	f := newTestF5(server, true)
+	if err := f.Delete(); err != nil {
+		t.Fatalf("delete of a missing load balancer failed: %v", err)
+	}
+	if err := f.SyncMembers(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if len(standIn.objects) != 0 {
+		t.Errorf("objects left after delete: %v", standIn.objects)
+	}
+}
+
+func TestF5VerifiesCertificate(t *testing.T) {
+	standIn, server := newF5StandIn(t)
+	defer server.Close()
+	err := newTestF5(server, false).SyncMembers(nil)
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("expect the self-signed certificate to be rejected, got %v", err)
+	}
+	if len(standIn.requests) != 0 {
+		t.Errorf("requests reached an unverified f5: %v", standIn.requests)
+	}
+}
@@ -0,0 +1,32 @@
+package loadbalancer
+
+import (
+	"io"
+
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases"
+	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
+)
+
+const (
+	haproxyPlaybook = "04-load-balancer.yml"
+)
+
+// Haproxy runs keepalived and haproxy on the dedicated hosts of the ex_lb group, the members come
+// from the kube-master group of the inventory so syncing only renders the playbook again.
+type Haproxy struct {
+	kobe   kobe.Interface
+	writer io.Writer
+}
+
+func NewHaproxy(b kobe.Interface, writer io.Writer) *Haproxy {
+	return &Haproxy{kobe: b, writer: writer}
+}
+
+func (h *Haproxy) SyncMembers(members []Member) error {
+	return phases.RunPlaybookAndGetResult(h.kobe, haproxyPlaybook, "", h.writer)
+}
+
+// Delete leaves keepalived and haproxy on the dedicated hosts, they are released back as plain hosts.
+func (h *Haproxy) Delete() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package loadbalancer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/KubeOperator/kobe/api"
+)
+
+// kobeStandIn plays the playbooks it is asked to and reports the canned result of the task.
+type kobeStandIn struct {
+	playbooks []string
+	runErr    error
+	result    *api.Result
+}
+
+func (k *kobeStandIn) RunPlaybook(name, tag string) (string, error) {
+	k.playbooks = append(k.playbooks, name)
+	return "task-1", k.runErr
+}
+
+func (k *kobeStandIn) RunAdhoc(pattern, module, param string) (string, error) {
+	return "", errors.New("adhoc not expected")
+}
+
+func (k *kobeStandIn) Watch(writer io.Writer, taskId string) error {
+	return nil
+}
+
+func (k *kobeStandIn) GetResult(taskId string) (*api.Result, error) {
+	return k.result, nil
+}
+
+func (k *kobeStandIn) SetVar(key string, value string) {}
+
+func TestHaproxySyncMembers(t *testing.T) {
+	t.Parallel()
+	b := &kobeStandIn{result: &api.Result{Finished: true, Success: true, Content: `{"stats":{},"plays":[]}`}}
+	if err := NewHaproxy(b, nil).SyncMembers([]Member{{Name: "demo-master-1", Address: "10.1.0.11", Port: 6443}}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(b.playbooks, ",") != haproxyPlaybook {
+		t.Errorf("expect %s to run once, got %v", haproxyPlaybook, b.playbooks)
+	}
+}
+
+func TestHaproxySyncMembersFailed(t *testing.T) {
+	t.Parallel()
+	b := &kobeStandIn{result: &api.Result{Finished: true, Content: `{"plays":[{"tasks":[{"name":"start haproxy",` +
+		`"hosts":{"lb-1":{"failed":true,"msg":"haproxy.service failed"}}}]}]}`}}
+	err := NewHaproxy(b, nil).SyncMembers(nil)
+	if err == nil || !strings.Contains(err.Error(), "haproxy.service failed") {
+		t.Fatalf("expect the failed host to be reported, got %v", err)
+	}
+
+	b = &kobeStandIn{runErr: errors.New("kobe unavailable")}
+	if err := NewHaproxy(b, nil).SyncMembers(nil); err == nil || err.Error() != "kobe unavailable" {
+		t.Fatalf("expect the run error, got %v", err)
+	}
+}
+
+func TestHaproxyDelete(t *testing.T) {
+	b := &kobeStandIn{}
+	if err := NewHaproxy(b, nil).Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.playbooks) != 0 {
+		t.Errorf("delete ran %v", b.playbooks)
+	}
+}
@@ -0,0 +1,139 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
+)
+
+// Interface is a load balancer in front of the apiserver of a cluster.
+type Interface interface {
+	// SyncMembers makes the masters the only members of the apiserver pool, creating the
+	// load balancer when it does not exist yet.
+	SyncMembers(members []Member) error
+	Delete() error
+}
+
+type Member struct {
+	Name    string
+	Address string
+	Port    int
+}
+
+// NewProvider returns the provider of lb, b runs the playbooks of the haproxy provider.
+func NewProvider(cluster model.Cluster, lb model.ClusterLoadBalancer, b kobe.Interface, writer io.Writer) (Interface, error) {
+	name := fmt.Sprintf("ko-%s-apiserver", cluster.Name)
+	port := lb.Port
+	if port == 0 {
+		port = apiServerPort(cluster)
+	}
+	switch lb.Provider {
+	case constant.LbProviderF5:
+		var setting model.F5Setting
+		if err := db.DB.Where("cluster_id = ?", cluster.ID).First(&setting).Error; err != nil {
+			return nil, fmt.Errorf("load f5 setting failed: %v", err)
+		}
+		password, err := encrypt.StringDecrypt(setting.Password)
+		if err != nil {
+			return nil, err
+		}
+		return NewF5(setting, password, strings.ReplaceAll(name, "-", "_"), port, lb.InsecureSkipVerify), nil
+	case constant.LbProviderHaproxy:
+		return NewHaproxy(b, writer), nil
+	case constant.LbProviderOctavia:
+		vars := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(lb.Vars), &vars); err != nil {
+			return nil, fmt.Errorf("load octavia vars failed: %v", err)
+		}
+		password, err := encrypt.StringDecrypt(lb.Password)
+		if err != nil {
+			return nil, err
+		}
+		vars["password"] = password
+		return NewOctavia(vars, name, lb.Vip, port), nil
+	}
+	return nil, errors.New("LB_PROVIDER_NOT_SUPPORT")
+}
+
+func apiServerPort(cluster model.Cluster) int {
+	if cluster.Spec.KubeApiServerPort != 0 {
+		return cluster.Spec.KubeApiServerPort
+	}
+	return constant.DefaultApiServerPort
+}
+
+// Masters returns the apiserver members of cluster.
+func Masters(cluster model.Cluster) []Member {
+	var members []Member
+	for _, n := range cluster.Nodes {
+		if n.Role != constant.NodeRoleNameMaster || n.Host.Ip == "" {
+			continue
+		}
+		members = append(members, Member{Name: n.Name, Address: n.Host.Ip, Port: apiServerPort(cluster)})
+	}
+	return members
+}
+
+// Load returns the managed load balancer of the cluster.
+func Load(clusterID string) (model.ClusterLoadBalancer, bool) {
+	var lb model.ClusterLoadBalancer
+	notFound := db.DB.Where("cluster_id = ?", clusterID).First(&lb).RecordNotFound()
+	return lb, !notFound
+}
+
+// LoadForInventory returns the managed load balancer of the cluster with the dedicated haproxy hosts
+// the inventory lists, nil when the cluster has none.
+func LoadForInventory(clusterID string) (*model.ClusterLoadBalancer, error) {
+	if clusterID == "" {
+		return nil, nil
+	}
+	lb, ok := Load(clusterID)
+	if !ok {
+		return nil, nil
+	}
+	if lb.Provider == constant.LbProviderHaproxy && lb.Hosts != "" {
+		if err := db.DB.Where("name in (?)", strings.Split(lb.Hosts, ",")).Preload("Credential").Find(&lb.HaproxyHosts).Error; err != nil {
+			return nil, fmt.Errorf("load load balancer hosts failed: %v", err)
+		}
+	}
+	return &lb, nil
+}
+
+// Sync points the load balancer of cluster at its current masters and records the result, it does
+// nothing for clusters without a managed load balancer.
+func Sync(cluster model.Cluster, b kobe.Interface, writer io.Writer) error {
+	lb, ok := Load(cluster.ID)
+	if !ok {
+		return nil
+	}
+	members := Masters(cluster)
+	p, err := NewProvider(cluster, lb, b, writer)
+	if err == nil {
+		logger.Log.Infof("sync %s load balancer of cluster %s with %d members", lb.Provider, cluster.Name, len(members))
+		err = p.SyncMembers(members)
+	}
+	var addresses []string
+	for _, m := range members {
+		addresses = append(addresses, m.Address)
+	}
+	lb.Members = strings.Join(addresses, ",")
+	lb.Status = constant.StatusRunning
+	lb.Message = ""
+	if err != nil {
+		lb.Status = constant.StatusFailed
+		lb.Message = err.Error()
+	}
+	if e := db.DB.Save(&lb).Error; e != nil {
+		logger.Log.Errorf("save load balancer of cluster %s failed: %s", cluster.Name, e.Error())
+	}
+	return err
+}
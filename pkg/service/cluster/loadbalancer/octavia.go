@@ -0,0 +1,162 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+)
+
+// Octavia manages an OpenStack Octavia load balancer named after the cluster, with a tcp listener
+// and a pool whose members are replaced in one batch on every sync.
+type Octavia struct {
+	vars map[string]interface{}
+	name string
+	vip  string
+	port int
+}
+
+func NewOctavia(vars map[string]interface{}, name, vip string, port int) *Octavia {
+	return &Octavia{vars: vars, name: name, vip: vip, port: port}
+}
+
+func (o *Octavia) getVar(key string) string {
+	if v, ok := o.vars[key]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func (o *Octavia) client() (*gophercloud.ServiceClient, error) {
+	provider, err := openstack.AuthenticatedClient(gophercloud.AuthOptions{
+		IdentityEndpoint: o.getVar("identity"),
+		Username:         o.getVar("username"),
+		Password:         o.getVar("password"),
+		DomainName:       o.getVar("domainName"),
+		Scope:            &gophercloud.AuthScope{ProjectID: o.getVar("projectId")},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return openstack.NewLoadBalancerV2(provider, gophercloud.EndpointOpts{Region: o.getVar("region")})
+}
+
+func (o *Octavia) find(c *gophercloud.ServiceClient) (*loadbalancers.LoadBalancer, error) {
+	page, err := loadbalancers.List(c, loadbalancers.ListOpts{Name: o.name}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	lbs, err := loadbalancers.ExtractLoadBalancers(page)
+	if err != nil || len(lbs) == 0 {
+		return nil, err
+	}
+	return &lbs[0], nil
+}
+
+// waitActive waits for the load balancer to leave its pending state, octavia rejects changes meanwhile.
+func (o *Octavia) waitActive(c *gophercloud.ServiceClient, id string) error {
+	for i := 0; i < 60; i++ {
+		lb, err := loadbalancers.Get(c, id).Extract()
+		if err != nil {
+			return err
+		}
+		switch lb.ProvisioningStatus {
+		case "ACTIVE":
+			return nil
+		case "ERROR":
+			return fmt.Errorf("octavia load balancer %s is in error", o.name)
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return fmt.Errorf("wait octavia load balancer %s active timeout", o.name)
+}
+
+func (o *Octavia) SyncMembers(members []Member) error {
+	c, err := o.client()
+	if err != nil {
+		return err
+	}
+	lb, err := o.find(c)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		lb, err = loadbalancers.Create(c, loadbalancers.CreateOpts{
+			Name:        o.name,
+			VipSubnetID: o.getVar("subnetId"),
+			VipAddress:  o.vip,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+	}
+	if err := o.waitActive(c, lb.ID); err != nil {
+		return err
+	}
+	page, err := pools.List(c, pools.ListOpts{Name: o.name, LoadbalancerID: lb.ID}).AllPages()
+	if err != nil {
+		return err
+	}
+	ps, err := pools.ExtractPools(page)
+	if err != nil {
+		return err
+	}
+	var poolID string
+	if len(ps) > 0 {
+		poolID = ps[0].ID
+	} else {
+		listener, err := listeners.Create(c, listeners.CreateOpts{
+			Name:           o.name,
+			LoadbalancerID: lb.ID,
+			Protocol:       listeners.ProtocolTCP,
+			ProtocolPort:   o.port,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+		if err := o.waitActive(c, lb.ID); err != nil {
+			return err
+		}
+		pool, err := pools.Create(c, pools.CreateOpts{
+			Name:       o.name,
+			ListenerID: listener.ID,
+			Protocol:   pools.ProtocolTCP,
+			LBMethod:   pools.LBMethodRoundRobin,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+		if err := o.waitActive(c, lb.ID); err != nil {
+			return err
+		}
+		poolID = pool.ID
+	}
+	opts := []pools.BatchUpdateMemberOpts{}
+	for i := range members {
+		opts = append(opts, pools.BatchUpdateMemberOpts{
+			Name:         &members[i].Name,
+			Address:      members[i].Address,
+			ProtocolPort: members[i].Port,
+		})
+	}
+	if err := pools.BatchUpdateMembers(c, poolID, opts).ExtractErr(); err != nil {
+		return err
+	}
+	return o.waitActive(c, lb.ID)
+}
+
+func (o *Octavia) Delete() error {
+	c, err := o.client()
+	if err != nil {
+		return err
+	}
+	lb, err := o.find(c)
+	if err != nil || lb == nil {
+		return err
+	}
+	return loadbalancers.Delete(c, lb.ID, loadbalancers.DeleteOpts{Cascade: true}).ExtractErr()
+}
@@ -0,0 +1,188 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// octaviaStandIn answers keystone v3 and the octavia v2 api, keeping one load balancer, its listener,
+// its pool and the members last put.
+type octaviaStandIn struct {
+	mu       sync.Mutex
+	lb       map[string]interface{}
+	listener map[string]interface{}
+	pool     map[string]interface{}
+	members  []map[string]interface{}
+	requests []string
+}
+
+func newOctaviaStandIn(t *testing.T) (*octaviaStandIn, *httptest.Server) {
+	o := &octaviaStandIn{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		var payload map[string]interface{}
+		if body, _ := ioutil.ReadAll(r.Body); len(body) > 0 {
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Errorf("invalid payload of %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v3/auth/tokens" {
+			password := payload["auth"].(map[string]interface{})["identity"].(map[string]interface{})["password"].(map[string]interface{})["user"].(map[string]interface{})["password"]
+			if password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Subject-Token", "token-1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"token":{"expires_at":"2099-01-01T00:00:00.000000Z","catalog":[{"type":"load-balancer","name":"octavia",`+
+				`"endpoints":[{"interface":"public","region":"RegionOne","region_id":"RegionOne","url":"%s/load-balancer"}]}]}}`, server.URL)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") != "token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		o.requests = append(o.requests, r.Method+" "+r.URL.Path)
+		path := strings.TrimPrefix(r.URL.Path, "/load-balancer/v2.0/lbaas/")
+		switch {
+		case r.Method == http.MethodGet && path == "loadbalancers":
+			items := []interface{}{}
+			if o.lb != nil && r.URL.Query().Get("name") == o.lb["name"] {
+				items = append(items, o.lb)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"loadbalancers": items})
+		case r.Method == http.MethodPost && path == "loadbalancers":
+			o.lb = payload["loadbalancer"].(map[string]interface{})
+			o.lb["id"] = "lb-1"
+			o.lb["provisioning_status"] = "ACTIVE"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"loadbalancer": o.lb})
+		case r.Method == http.MethodGet && path == "loadbalancers/lb-1" && o.lb != nil:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"loadbalancer": o.lb})
+		case r.Method == http.MethodDelete && path == "loadbalancers/lb-1" && o.lb != nil:
+			if r.URL.Query().Get("cascade") != "true" {
+				t.Errorf("load balancer deleted without cascade")
+			}
+			o.lb, o.listener, o.pool, o.members = nil, nil, nil, nil
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && path == "listeners":
+			o.listener = payload["listener"].(map[string]interface{})
+			o.listener["id"] = "listener-1"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"listener": o.listener})
+		case r.Method == http.MethodGet && path == "pools":
+			items := []interface{}{}
+			if o.pool != nil {
+				items = append(items, o.pool)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"pools": items})
+		case r.Method == http.MethodPost && path == "pools":
+			o.pool = payload["pool"].(map[string]interface{})
+			o.pool["id"] = "pool-1"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"pool": o.pool})
+		case r.Method == http.MethodPut && path == "pools/pool-1/members" && o.pool != nil:
+			o.members = nil
+			for _, m := range payload["members"].([]interface{}) {
+				o.members = append(o.members, m.(map[string]interface{}))
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, `{"faultstring":"%s %s"}`, r.Method, r.URL.Path)
+		}
+	}))
+	return o, server
+}
+
+func newTestOctavia(server *httptest.Server, password string) *Octavia {
+	return NewOctavia(map[string]interface{}{
+		"identity":   server.URL + "/v3",
+		"username":   "admin",
+		"password":   password,
+		"domainName": "Default",
+		"projectId":  "project-1",
+		"region":     "RegionOne",
+		"subnetId":   "subnet-1",
+	}, "ko-demo-apiserver", "10.1.0.100", 6443)
+}
+
+func (o *octaviaStandIn) memberAddresses() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var addresses []string
+	for _, m := range o.members {
+		addresses = append(addresses, fmt.Sprintf("%s:%v", m["address"], m["protocol_port"]))
+	}
+	return strings.Join(addresses, ",")
+}
+
+func TestOctaviaSyncMembers(t *testing.T) {
+	standIn, server := newOctaviaStandIn(t)
+	defer server.Close()
+	o := newTestOctavia(server, "secret")
+
+	if err := o.SyncMembers([]Member{{Name: "demo-master-1", Address: "10.1.0.11", Port: 6443}, {Name: "demo-master-2", Address: "10.1.0.12", Port: 6443}}); err != nil {
+		t.Fatal(err)
+	}
+	if standIn.lb["vip_address"] != "10.1.0.100" || standIn.lb["vip_subnet_id"] != "subnet-1" {
+		t.Errorf("unexpected load balancer %v", standIn.lb)
+	}
+	if standIn.listener["protocol"] != "TCP" || standIn.listener["protocol_port"] != float64(6443) {
+		t.Errorf("unexpected listener %v", standIn.listener)
+	}
+	if got := standIn.memberAddresses(); got != "10.1.0.11:6443,10.1.0.12:6443" {
+		t.Errorf("unexpected members %s", got)
+	}
+
+	standIn.requests = nil
+	if err := o.SyncMembers([]Member{{Name: "demo-master-2", Address: "10.1.0.12", Port: 6443}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := standIn.memberAddresses(); got != "10.1.0.12:6443" {
+		t.Errorf("members not replaced, got %s", got)
+	}
+	for _, r := range standIn.requests {
+		if strings.HasPrefix(r, http.MethodPost) {
+			t.Errorf("sync of an existing load balancer created %s", r)
+		}
+	}
+}
+
+func TestOctaviaDelete(t *testing.T) {
+	standIn, server := newOctaviaStandIn(t)
+	defer server.Close()
+	o := newTestOctavia(server, "secret")
+	if err := o.Delete(); err != nil {
+		t.Fatalf("delete of a missing load balancer failed: %v", err)
+	}
+	if err := o.SyncMembers(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if standIn.lb != nil {
+		t.Errorf("load balancer left after delete: %v", standIn.lb)
+	}
+}
+
+func TestOctaviaAuthFailure(t *testing.T) {
+	standIn, server := newOctaviaStandIn(t)
+	defer server.Close()
+	if err := newTestOctavia(server, "wrong").SyncMembers(nil); err == nil {
+		t.Fatal("expect the wrong password to fail")
+	}
+	if len(standIn.requests) != 0 {
+		t.Errorf("octavia called without a token: %v", standIn.requests)
+	}
+}
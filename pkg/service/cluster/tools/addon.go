@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+const (
+	AddonReadyDeployment  = "deployment"
+	AddonReadyStatefulSet = "statefulset"
+)
+
+// Addon installs an add-on of the catalog, it needs no code of its own: the chart comes from the
+// tool detail and the values from the template of the add-on.
+type Addon struct {
+	Cluster             *Cluster
+	Tool                *model.ClusterTool
+	Addon               model.ClusterAddon
+	LocalHostName       string
+	LocalRepositoryPort int
+}
+
+func NewAddon(cluster *Cluster, tool *model.ClusterTool, addon model.ClusterAddon) (*Addon, error) {
+	return &Addon{
+		Tool:                tool,
+		Cluster:             cluster,
+		Addon:               addon,
+		LocalHostName:       constant.LocalRepositoryDomainName,
+		LocalRepositoryPort: cluster.helmRepoPort,
+	}, nil
+}
+
+// AddonChartName returns the chart of the tool detail of an add-on, charts without a repository
+// are looked up in the nexus repository.
+func AddonChartName(toolDetail model.ClusterToolDetail) string {
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(toolDetail.Vars), &vars)
+	name, _ := vars["chart_name"].(string)
+	if name != "" && !strings.Contains(name, "/") {
+		name = "nexus/" + name
+	}
+	return name
+}
+
+// setDefaultValue renders the values template into the vars of the tool. Values bound to the
+// registry or the cluster are rendered on every install and upgrade, the others are defaults only
+// and never override what the user has set.
+func (a Addon) setDefaultValue() {
+	template := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(a.Addon.Values), &template)
+
+	replacer := strings.NewReplacer(
+		"{registry}", fmt.Sprintf("%s:%d", a.LocalHostName, a.LocalRepositoryPort),
+		"{registry_host}", a.LocalHostName,
+		"{registry_port}", fmt.Sprint(a.LocalRepositoryPort),
+		"{namespace}", a.Cluster.Namespace,
+		"{cluster_name}", a.Cluster.Name,
+	)
+	values := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(a.Tool.Vars), &values)
+	for k, v := range template {
+		s, ok := v.(string)
+		if ok && replacer.Replace(s) != s {
+			values[k] = replacer.Replace(s)
+			continue
+		}
+		if _, set := values[k]; !set {
+			values[k] = v
+		}
+	}
+	str, _ := json.Marshal(&values)
+	a.Tool.Vars = string(str)
+}
+
+func (a Addon) ingressName() string {
+	return a.Addon.Name + "-ingress"
+}
+
+func (a Addon) Install(toolDetail model.ClusterToolDetail) error {
+	a.setDefaultValue()
+	if err := installChart(a.Cluster, a.Tool, AddonChartName(toolDetail), toolDetail.ChartVersion); err != nil {
+		return err
+	}
+	if a.Addon.ServiceName != "" && a.Addon.ProxyType != "nodeport" {
+		if err := createRoute(a.Cluster.Namespace, a.ingressName(), a.Addon.IngressHost(), a.Addon.ServiceName, a.Addon.ServicePort, a.Cluster.KubeClient); err != nil {
+			return err
+		}
+	}
+	return a.waitForReady()
+}
+
+func (a Addon) waitForReady() error {
+	switch a.Addon.ReadyKind {
+	case AddonReadyDeployment:
+		return waitForRunning(a.Cluster.Namespace, a.Addon.ReadyName, 1, a.Cluster.KubeClient)
+	case AddonReadyStatefulSet:
+		return waitForStatefulSetsRunning(a.Cluster.Namespace, a.Addon.ReadyName, 1, a.Cluster.KubeClient)
+	}
+	return nil
+}
+
+func (a Addon) Upgrade(toolDetail model.ClusterToolDetail) error {
+	a.setDefaultValue()
+	return upgradeChart(a.Cluster, a.Tool, AddonChartName(toolDetail), toolDetail.ChartVersion)
+}
+
+func (a Addon) Uninstall() error {
+	return uninstall(a.Cluster.Namespace, a.Tool, a.ingressName(), a.Cluster.HelmClient, a.Cluster.KubeClient)
+}
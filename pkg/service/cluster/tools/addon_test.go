@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+func TestAddonSetDefaultValue(t *testing.T) {
+	cluster := &Cluster{Namespace: "kube-operator", Cluster: model.Cluster{Name: "demo"}}
+	addon := model.ClusterAddon{Values: `{
+		"image.repository": "{registry}/minio/minio",
+		"registry.host": "{registry_host}",
+		"registry.port": "{registry_port}",
+		"namespace": "{namespace}",
+		"cluster": "{cluster_name}",
+		"replicas": 1,
+		"mode": "standalone"
+	}`}
+	tests := []struct {
+		name string
+		vars string
+		want map[string]interface{}
+	}{
+		{
+			name: "defaults",
+			want: map[string]interface{}{
+				"image.repository": "registry.kubeoperator.io:8082/minio/minio",
+				"registry.host":    "registry.kubeoperator.io",
+				"registry.port":    "8082",
+				"namespace":        "kube-operator",
+				"cluster":          "demo",
+				"replicas":         float64(1),
+				"mode":             "standalone",
+			},
+		},
+		{
+			name: "user values kept, bound values rendered again",
+			vars: `{"replicas": 3, "mode": "distributed", "namespace": "other", "image.repository": "docker.io/minio/minio", "extra": true}`,
+			want: map[string]interface{}{
+				"image.repository": "registry.kubeoperator.io:8082/minio/minio",
+				"registry.host":    "registry.kubeoperator.io",
+				"registry.port":    "8082",
+				"namespace":        "kube-operator",
+				"cluster":          "demo",
+				"replicas":         float64(3),
+				"mode":             "distributed",
+				"extra":            true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tool := &model.ClusterTool{Vars: tt.vars}
+		a := Addon{Cluster: cluster, Tool: tool, Addon: addon, LocalHostName: "registry.kubeoperator.io", LocalRepositoryPort: 8082}
+		a.setDefaultValue()
+		got := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(tool.Vars), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("%s: %s want %v, got %v", tt.name, k, v, got[k])
+			}
+		}
+	}
+}
@@ -53,7 +53,7 @@ func (c Chartmuseum) setDefaultValue(toolDetail model.ClusterToolDetail, isInsta
 
 func (c Chartmuseum) Install(toolDetail model.ClusterToolDetail) error {
 	c.setDefaultValue(toolDetail, true)
-	if err := installChart(c.Cluster.HelmClient, c.Tool, constant.ChartmuseumChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(c.Cluster, c.Tool, constant.ChartmuseumChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(c.Cluster.Namespace, constant.DefaultChartmuseumIngressName, constant.DefaultChartmuseumIngress, constant.DefaultChartmuseumServiceName, 8080, c.Cluster.KubeClient); err != nil {
@@ -67,7 +67,7 @@ func (c Chartmuseum) Install(toolDetail model.ClusterToolDetail) error {
 
 func (c Chartmuseum) Upgrade(toolDetail model.ClusterToolDetail) error {
 	c.setDefaultValue(toolDetail, false)
-	return upgradeChart(c.Cluster.HelmClient, c.Tool, constant.ChartmuseumChartName, toolDetail.ChartVersion)
+	return upgradeChart(c.Cluster, c.Tool, constant.ChartmuseumChartName, toolDetail.ChartVersion)
 }
 
 func (c Chartmuseum) Uninstall() error {
@@ -46,7 +46,7 @@ func (d Dashboard) setDefaultValue(toolDetail model.ClusterToolDetail) {
 
 func (d Dashboard) Install(toolDetail model.ClusterToolDetail) error {
 	d.setDefaultValue(toolDetail)
-	if err := installChart(d.Cluster.HelmClient, d.Tool, constant.DashboardChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(d.Cluster, d.Tool, constant.DashboardChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(d.Cluster.Namespace, constant.DefaultDashboardIngressName, constant.DefaultDashboardIngress, constant.DefaultDashboardServiceName, 9090, d.Cluster.KubeClient); err != nil {
@@ -60,7 +60,7 @@ func (d Dashboard) Install(toolDetail model.ClusterToolDetail) error {
 
 func (d Dashboard) Upgrade(toolDetail model.ClusterToolDetail) error {
 	d.setDefaultValue(toolDetail)
-	return upgradeChart(d.Cluster.HelmClient, d.Tool, constant.DashboardChartName, toolDetail.ChartVersion)
+	return upgradeChart(d.Cluster, d.Tool, constant.DashboardChartName, toolDetail.ChartVersion)
 }
 
 func (d Dashboard) Uninstall() error {
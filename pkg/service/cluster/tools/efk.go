@@ -51,11 +51,32 @@ func (e EFK) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall bool)
 	e.Tool.Vars = string(str)
 }
 
+// values returns the structured values of the log settings of the tool, the credentials of the
+// external target are synced to their secret first.
+func (e EFK) values() (*LogConfig, map[string]interface{}, error) {
+	config, err := toolLogConfig(e.Tool)
+	if err != nil || config == nil {
+		return nil, nil, err
+	}
+	if err := config.syncTargetSecret(e.Cluster); err != nil {
+		return nil, nil, err
+	}
+	return config, config.efkValues(e.Cluster.Namespace), nil
+}
+
 func (e EFK) Install(toolDetail model.ClusterToolDetail) error {
 	e.setDefaultValue(toolDetail, true)
-	if err := installChart(e.Cluster.HelmClient, e.Tool, constant.LoggingChartName, toolDetail.ChartVersion); err != nil {
+	config, values, err := e.values()
+	if err != nil {
 		return err
 	}
+	if err := installChartWithValues(e.Cluster, e.Tool, constant.LoggingChartName, toolDetail.ChartVersion, values); err != nil {
+		return err
+	}
+	// logs forwarded to an external store leave no elasticsearch in the cluster
+	if config != nil && config.external() {
+		return nil
+	}
 	if err := createRoute(e.Cluster.Namespace, constant.DefaultLoggingIngressName, constant.DefaultLoggingIngress, constant.DefaultLoggingServiceName, 9200, e.Cluster.KubeClient); err != nil {
 		return err
 	}
@@ -67,7 +88,17 @@ func (e EFK) Install(toolDetail model.ClusterToolDetail) error {
 
 func (e EFK) Upgrade(toolDetail model.ClusterToolDetail) error {
 	e.setDefaultValue(toolDetail, false)
-	return upgradeChart(e.Cluster.HelmClient, e.Tool, constant.LoggingChartName, toolDetail.ChartVersion)
+	config, values, err := e.values()
+	if err != nil {
+		return err
+	}
+	if err := upgradeChartWithValues(e.Cluster, e.Tool, constant.LoggingChartName, toolDetail.ChartVersion, values); err != nil {
+		return err
+	}
+	if e.Cluster.plan == nil && config != nil && config.external() {
+		return preCreateRoute(e.Cluster.Namespace, constant.DefaultLoggingIngressName, e.Cluster.KubeClient)
+	}
+	return nil
 }
 
 func (e EFK) Uninstall() error {
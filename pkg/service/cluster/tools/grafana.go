@@ -61,6 +61,9 @@ func (g Grafana) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall b
 			values["datasources.'datasources\\.yaml'.datasources[1].type"] = "loki"
 			values["datasources.'datasources\\.yaml'.datasources[1].url"] = "http://loki." + g.lokiNs + ":3100"
 			values["datasources.'datasources\\.yaml'.datasources[1].access"] = "proxy"
+			// loki with log routes has auth on, grafana reads the logs which are not routed
+			values["datasources.'datasources\\.yaml'.datasources[1].jsonData.httpHeaderName1"] = "X-Scope-OrgID"
+			values["datasources.'datasources\\.yaml'.datasources[1].secureJsonData.httpHeaderValue1"] = constant.DefaultLokiTenant
 		}
 
 		values["dashboardProviders.'dashboardproviders\\.yaml'.apiVersion"] = 1
@@ -89,7 +92,7 @@ func (g Grafana) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall b
 
 func (g Grafana) Install(toolDetail model.ClusterToolDetail) error {
 	g.setDefaultValue(toolDetail, true)
-	if err := installChart(g.Cluster.HelmClient, g.Tool, constant.GrafanaChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(g.Cluster, g.Tool, constant.GrafanaChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(g.Cluster.Namespace, constant.DefaultGrafanaIngressName, constant.DefaultGrafanaIngress, constant.DefaultGrafanaServiceName, 80, g.Cluster.KubeClient); err != nil {
@@ -103,7 +106,7 @@ func (g Grafana) Install(toolDetail model.ClusterToolDetail) error {
 
 func (g Grafana) Upgrade(toolDetail model.ClusterToolDetail) error {
 	g.setDefaultValue(toolDetail, false)
-	return upgradeChart(g.Cluster.HelmClient, g.Tool, constant.GrafanaChartName, toolDetail.ChartVersion)
+	return upgradeChart(g.Cluster, g.Tool, constant.GrafanaChartName, toolDetail.ChartVersion)
 }
 
 func (g Grafana) Uninstall() error {
@@ -56,7 +56,7 @@ func (k Kubeapps) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall
 
 func (k Kubeapps) Install(toolDetail model.ClusterToolDetail) error {
 	k.setDefaultValue(toolDetail, true)
-	if err := installChart(k.Cluster.HelmClient, k.Tool, constant.KubeappsChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(k.Cluster, k.Tool, constant.KubeappsChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(k.Cluster.Namespace, constant.DefaultKubeappsIngressName, constant.DefaultKubeappsIngress, constant.DefaultKubeappsServiceName, 80, k.Cluster.KubeClient); err != nil {
@@ -70,7 +70,7 @@ func (k Kubeapps) Install(toolDetail model.ClusterToolDetail) error {
 
 func (k Kubeapps) Upgrade(toolDetail model.ClusterToolDetail) error {
 	k.setDefaultValue(toolDetail, false)
-	return upgradeChart(k.Cluster.HelmClient, k.Tool, constant.KubeappsChartName, toolDetail.ChartVersion)
+	return upgradeChart(k.Cluster, k.Tool, constant.KubeappsChartName, toolDetail.ChartVersion)
 }
 
 func (k Kubeapps) Uninstall() error {
@@ -40,7 +40,7 @@ func (k KubePi) setDefaultValue(toolDetail model.ClusterToolDetail) {
 
 func (k KubePi) Install(toolDetail model.ClusterToolDetail) error {
 	k.setDefaultValue(toolDetail)
-	if err := installChart(k.Cluster.HelmClient, k.Tool, constant.KubePiChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(k.Cluster, k.Tool, constant.KubePiChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(k.Cluster.Namespace, constant.DefaultKubePiIngressName, constant.DefaultKubePiIngress, constant.DefaultKubePiServiceName, 80, k.Cluster.KubeClient); err != nil {
@@ -54,7 +54,7 @@ func (k KubePi) Install(toolDetail model.ClusterToolDetail) error {
 
 func (k KubePi) Upgrade(toolDetail model.ClusterToolDetail) error {
 	k.setDefaultValue(toolDetail)
-	return upgradeChart(k.Cluster.HelmClient, k.Tool, constant.KubePiChartName, toolDetail.ChartVersion)
+	return upgradeChart(k.Cluster, k.Tool, constant.KubePiChartName, toolDetail.ChartVersion)
 }
 
 func (k KubePi) Uninstall() error {
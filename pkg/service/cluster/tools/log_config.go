@@ -0,0 +1,385 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+)
+
+const logTargetSecretPath = "/etc/secrets/log-target"
+
+// LogConfig is the log settings of the logging and loki tools, stored in the var
+// constant.LogConfigVar of the tool and applied on every install and upgrade.
+type LogConfig struct {
+	// RetentionDays deletes the logs older than the days, 0 keeps them.
+	RetentionDays int `json:"retentionDays"`
+	// HotDays and HotMaxSize roll the elasticsearch indices over, WarmDays moves them to the warm
+	// phase where they are merged and read only.
+	HotDays    int    `json:"hotDays"`
+	HotMaxSize string `json:"hotMaxSize"`
+	WarmDays   int    `json:"warmDays"`
+	// Routes send the logs of a namespace to an index of its own, ExcludeNamespaces drops them.
+	Routes            []LogRoute `json:"routes"`
+	ExcludeNamespaces []string   `json:"excludeNamespaces"`
+	Target            LogTarget  `json:"target"`
+}
+
+// LogRoute is the elasticsearch index prefix of the logs of a namespace, with loki the tenant the
+// logs are pushed to. Routes turn the auth of the loki of the tool on, the logs which are not
+// routed go to constant.DefaultLokiTenant.
+type LogRoute struct {
+	Namespace string `json:"namespace"`
+	Index     string `json:"index"`
+}
+
+// LogTarget forwards the logs to an external store instead of the one installed by the tool. Url is
+// the push url of loki, Protocol the tcp or udp of syslog. Password is only set by requests, it is
+// stored as EncryptedPassword.
+type LogTarget struct {
+	Type              string `json:"type"`
+	Host              string `json:"host"`
+	Port              int    `json:"port"`
+	Scheme            string `json:"scheme"`
+	Protocol          string `json:"protocol"`
+	User              string `json:"user"`
+	Password          string `json:"password,omitempty"`
+	EncryptedPassword string `json:"encryptedPassword,omitempty"`
+	Url               string `json:"url"`
+}
+
+// Credentials returns the user and the decrypted password of the target.
+func (t LogTarget) Credentials() (string, string, error) {
+	if t.EncryptedPassword == "" {
+		return t.User, t.Password, nil
+	}
+	password, err := encrypt.StringDecrypt(t.EncryptedPassword)
+	if err != nil {
+		return "", "", err
+	}
+	return t.User, password, nil
+}
+
+var logNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9_.]*[a-z0-9])?$`)
+
+// ParseLogConfig reads and validates the log settings of the vars of the tool, nil is returned
+// when they are not set.
+func ParseLogConfig(toolName string, vars map[string]interface{}) (*LogConfig, error) {
+	v, ok := vars[constant.LogConfigVar]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var config LogConfig
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("%s is invalid: %s", constant.LogConfigVar, err.Error())
+	}
+	if config.RetentionDays < 0 || config.HotDays < 0 || config.WarmDays < 0 {
+		return nil, fmt.Errorf("days of the log retention can not be negative")
+	}
+	if config.RetentionDays > 0 && config.WarmDays >= config.RetentionDays {
+		return nil, fmt.Errorf("warm days %d must be less than the retention days %d", config.WarmDays, config.RetentionDays)
+	}
+	if config.WarmDays > 0 && config.HotDays > config.WarmDays {
+		return nil, fmt.Errorf("hot days %d can not be more than the warm days %d", config.HotDays, config.WarmDays)
+	}
+	for _, r := range config.Routes {
+		if !logNamePattern.MatchString(r.Namespace) {
+			return nil, fmt.Errorf("namespace %q of the log route is invalid", r.Namespace)
+		}
+		if r.Index != "" && !logNamePattern.MatchString(r.Index) {
+			return nil, fmt.Errorf("index %q of the log route is invalid", r.Index)
+		}
+	}
+	for _, ns := range config.ExcludeNamespaces {
+		if !logNamePattern.MatchString(ns) {
+			return nil, fmt.Errorf("excluded namespace %q is invalid", ns)
+		}
+	}
+	t := config.Target
+	switch {
+	case t.Type == "":
+	case toolName == "logging" && (t.Type == constant.LogTargetElasticsearch || t.Type == constant.LogTargetSyslog):
+		if t.Host == "" || t.Port <= 0 {
+			return nil, fmt.Errorf("host and port of the %s target are required", t.Type)
+		}
+		if t.Type == constant.LogTargetSyslog && t.Protocol != "" && t.Protocol != "tcp" && t.Protocol != "udp" {
+			return nil, fmt.Errorf("protocol %q of the syslog target must be tcp or udp", t.Protocol)
+		}
+	case toolName == "loki" && t.Type == constant.LogTargetLoki:
+		if !strings.HasPrefix(t.Url, "http://") && !strings.HasPrefix(t.Url, "https://") {
+			return nil, fmt.Errorf("url of the loki target must be a http or https url")
+		}
+	default:
+		return nil, fmt.Errorf("log target %q is not supported by tool %s", t.Type, toolName)
+	}
+	return &config, nil
+}
+
+// SealLogConfig moves the credentials of the target out of its url and stores the password
+// encrypted. A target sent back without credentials, as they are never shown, keeps the stored ones.
+func SealLogConfig(toolName string, stored, vars map[string]interface{}) error {
+	config, err := ParseLogConfig(toolName, vars)
+	if err != nil || config == nil {
+		return err
+	}
+	t := &config.Target
+	if u, err := url.Parse(t.Url); err == nil && u.User != nil {
+		t.User = u.User.Username()
+		t.Password, _ = u.User.Password()
+		u.User = nil
+		t.Url = u.String()
+	}
+	if t.Password != "" {
+		password, err := encrypt.StringEncrypt(t.Password)
+		if err != nil {
+			return err
+		}
+		t.Password, t.EncryptedPassword = "", password
+	} else if t.EncryptedPassword == "" {
+		if old, _ := ParseLogConfig(toolName, stored); old != nil && old.Target.Type == t.Type {
+			if t.User == "" {
+				t.User = old.Target.User
+			}
+			if t.User == old.Target.User {
+				t.EncryptedPassword = old.Target.EncryptedPassword
+			}
+		}
+	}
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	sealed := map[string]interface{}{}
+	if err := json.Unmarshal(buf, &sealed); err != nil {
+		return err
+	}
+	vars[constant.LogConfigVar] = sealed
+	return nil
+}
+
+// toolLogConfig returns the log settings of the tool, nil keeps the defaults of the chart.
+func toolLogConfig(tool *model.ClusterTool) (*LogConfig, error) {
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(tool.Vars), &vars)
+	config, err := ParseLogConfig(tool.Name, vars)
+	if err != nil {
+		return nil, fmt.Errorf("log settings of tool %s are invalid: %s", tool.Name, err.Error())
+	}
+	return config, nil
+}
+
+// syncTargetSecret keeps the credentials of the external target in a secret next to the tool.
+func (l LogConfig) syncTargetSecret(c *Cluster) error {
+	if !l.external() || l.Target.User == "" || c.plan != nil {
+		return nil
+	}
+	user, password, err := l.Target.Credentials()
+	if err != nil {
+		return err
+	}
+	return applySecret(c.Namespace, constant.DefaultLogTargetSecretName, map[string][]byte{"user": []byte(user), "password": []byte(password)}, c.KubeClient)
+}
+
+// LokiTenants returns the tenants the logs are pushed to, none when loki runs without routes.
+func (l LogConfig) LokiTenants() []string {
+	if len(l.Routes) == 0 {
+		return nil
+	}
+	tenants := []string{constant.DefaultLokiTenant}
+	seen := map[string]bool{constant.DefaultLokiTenant: true}
+	for _, r := range l.Routes {
+		if tenant := l.index(r.Namespace); !seen[tenant] {
+			seen[tenant] = true
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
+}
+
+func (l LogConfig) external() bool {
+	return l.Target.Type != ""
+}
+
+func (l LogConfig) index(namespace string) string {
+	for _, r := range l.Routes {
+		if r.Namespace == namespace && r.Index != "" {
+			return r.Index
+		}
+	}
+	return namespace
+}
+
+// ilmPolicy is the elasticsearch index lifecycle policy of the settings.
+func (l LogConfig) ilmPolicy() map[string]interface{} {
+	maxAge := "1d"
+	if l.HotDays > 0 {
+		maxAge = fmt.Sprintf("%dd", l.HotDays)
+	}
+	maxSize := l.HotMaxSize
+	if maxSize == "" {
+		maxSize = constant.DefaultLogHotMaxSize
+	}
+	phases := map[string]interface{}{
+		"hot": map[string]interface{}{
+			"min_age": "0ms",
+			"actions": map[string]interface{}{"rollover": map[string]interface{}{"max_age": maxAge, "max_size": maxSize}},
+		},
+	}
+	if l.WarmDays > 0 {
+		phases["warm"] = map[string]interface{}{
+			"min_age": fmt.Sprintf("%dd", l.WarmDays),
+			"actions": map[string]interface{}{
+				"forcemerge": map[string]interface{}{"max_num_segments": 1},
+				"readonly":   map[string]interface{}{},
+			},
+		}
+	}
+	if l.RetentionDays > 0 {
+		phases["delete"] = map[string]interface{}{
+			"min_age": fmt.Sprintf("%dd", l.RetentionDays),
+			"actions": map[string]interface{}{"delete": map[string]interface{}{}},
+		}
+	}
+	return map[string]interface{}{"policy": map[string]interface{}{"phases": phases}}
+}
+
+// fluentdOutput renders the output of fluentd: the excluded namespaces are dropped, the routed
+// ones written to indices of their own and the rest to the default index. With a syslog target the
+// index is the program of the messages.
+func (l LogConfig) fluentdOutput(namespace string) string {
+	var b strings.Builder
+	for _, ns := range l.ExcludeNamespaces {
+		fmt.Fprintf(&b, "<match kubernetes.var.log.containers.**_%s_**>\n  @type null\n</match>\n", ns)
+	}
+	for _, r := range l.Routes {
+		fmt.Fprintf(&b, "<match kubernetes.var.log.containers.**_%s_**>\n%s</match>\n", r.Namespace, l.fluentdStore(namespace, "route-"+r.Namespace, l.index(r.Namespace)))
+	}
+	fmt.Fprintf(&b, "<match **>\n%s</match>\n", l.fluentdStore(namespace, "default", constant.DefaultLogIndexPrefix))
+	return b.String()
+}
+
+func (l LogConfig) fluentdStore(namespace, id, prefix string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  @id %s\n", id)
+	if l.Target.Type == constant.LogTargetSyslog {
+		protocol := l.Target.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		// needs a fluentd image with fluent-plugin-remote_syslog
+		fmt.Fprintf(&b, "  @type remote_syslog\n  host %s\n  port %d\n  protocol %s\n  program %s\n", l.Target.Host, l.Target.Port, protocol, prefix)
+		b.WriteString("  <format>\n    @type json\n  </format>\n")
+		b.WriteString("  <buffer>\n    @type file\n    path /var/log/fluentd-buffers/" + id + ".buffer\n    flush_mode interval\n    flush_interval 5s\n    retry_forever\n    overflow_action block\n  </buffer>\n")
+		return b.String()
+	}
+	host, port, scheme := fmt.Sprintf("%s.%s.svc", constant.DefaultLoggingServiceName, namespace), 9200, "http"
+	if l.Target.Type == constant.LogTargetElasticsearch {
+		host, port = l.Target.Host, l.Target.Port
+		if l.Target.Scheme != "" {
+			scheme = l.Target.Scheme
+		}
+	}
+	fmt.Fprintf(&b, "  @type elasticsearch\n  @log_level info\n  include_tag_key true\n  host %s\n  port %d\n  scheme %s\n", host, port, scheme)
+	if l.Target.User != "" {
+		b.WriteString("  user \"#{ENV['OUTPUT_USER']}\"\n  password \"#{ENV['OUTPUT_PASSWORD']}\"\n")
+	}
+	fmt.Fprintf(&b, "  logstash_format true\n  logstash_prefix %s\n  reconnect_on_error true\n  reload_on_failure true\n  reload_connections false\n", prefix)
+	policy, _ := json.Marshal(l.ilmPolicy())
+	fmt.Fprintf(&b, "  enable_ilm true\n  ilm_policy_id %s\n  ilm_policy %s\n  ilm_policy_overwrite true\n", constant.DefaultLogIlmPolicy, policy)
+	b.WriteString("  <buffer>\n    @type file\n    path /var/log/fluentd-buffers/" + id + ".buffer\n    flush_mode interval\n    flush_interval 5s\n    retry_forever\n    chunk_limit_size 2M\n    overflow_action block\n  </buffer>\n")
+	return b.String()
+}
+
+// efkValues returns the structured values of the logging chart, its default output is replaced by
+// the one of the settings. The elasticsearch of the chart is not installed with an external target.
+func (l LogConfig) efkValues(namespace string) map[string]interface{} {
+	fluentd := map[string]interface{}{
+		"configMaps":      map[string]interface{}{"useDefaults": map[string]interface{}{"outputConf": false}},
+		"extraConfigMaps": map[string]interface{}{"output.conf": l.fluentdOutput(namespace)},
+	}
+	if l.external() && l.Target.User != "" {
+		fluentd["secret"] = []interface{}{
+			map[string]interface{}{"name": "OUTPUT_USER", "secret_name": constant.DefaultLogTargetSecretName, "secret_key": "user"},
+			map[string]interface{}{"name": "OUTPUT_PASSWORD", "secret_name": constant.DefaultLogTargetSecretName, "secret_key": "password"},
+		}
+	}
+	values := map[string]interface{}{"fluentd-elasticsearch": fluentd}
+	if l.external() {
+		values["elasticsearch"] = map[string]interface{}{"enabled": false}
+	}
+	return values
+}
+
+// lokiValues returns the structured values of the loki-stack chart. Loki deletes whole index
+// tables, the retention is rounded up to the index period of a week. The tenants of the routes
+// need the auth of loki. The retention and the auth of an external loki are left to it.
+func (l LogConfig) lokiValues(runtimeType string) map[string]interface{} {
+	values := map[string]interface{}{}
+	lokiConfig := map[string]interface{}{}
+	if l.RetentionDays > 0 {
+		weeks := (l.RetentionDays + constant.DefaultLokiIndexPeriod - 1) / constant.DefaultLokiIndexPeriod
+		lokiConfig["table_manager"] = map[string]interface{}{
+			"retention_deletes_enabled": true,
+			"retention_period":          fmt.Sprintf("%dh", weeks*constant.DefaultLokiIndexPeriod*24),
+		}
+	}
+	client := map[string]interface{}{}
+	if len(l.Routes) > 0 {
+		lokiConfig["auth_enabled"] = true
+		client["tenant_id"] = constant.DefaultLokiTenant
+	}
+	if len(lokiConfig) > 0 {
+		values["loki"] = map[string]interface{}{"config": lokiConfig}
+	}
+	parser := "docker"
+	if runtimeType == "containerd" {
+		parser = "cri"
+	}
+	stages := []interface{}{map[string]interface{}{parser: map[string]interface{}{}}}
+	for _, ns := range l.ExcludeNamespaces {
+		stages = append(stages, map[string]interface{}{
+			"match": map[string]interface{}{"selector": fmt.Sprintf(`{namespace="%s"}`, ns), "action": "drop"},
+		})
+	}
+	for _, r := range l.Routes {
+		stages = append(stages, map[string]interface{}{
+			"match": map[string]interface{}{
+				"selector": fmt.Sprintf(`{namespace="%s"}`, r.Namespace),
+				"stages":   []interface{}{map[string]interface{}{"tenant": map[string]interface{}{"value": l.index(r.Namespace)}}},
+			},
+		})
+	}
+	promtail := map[string]interface{}{"pipelineStages": stages}
+	promtailConfig := map[string]interface{}{}
+	if l.external() {
+		values["loki"] = map[string]interface{}{"enabled": false}
+		promtailConfig["lokiAddress"] = l.Target.Url
+		client = map[string]interface{}{}
+		if l.Target.User != "" {
+			client["basic_auth"] = map[string]interface{}{"username": l.Target.User, "password_file": logTargetSecretPath + "/password"}
+			promtail["extraVolumes"] = []interface{}{
+				map[string]interface{}{"name": "log-target", "secret": map[string]interface{}{"secretName": constant.DefaultLogTargetSecretName}},
+			}
+			promtail["extraVolumeMounts"] = []interface{}{
+				map[string]interface{}{"name": "log-target", "mountPath": logTargetSecretPath, "readOnly": true},
+			}
+		}
+	}
+	if len(client) > 0 {
+		promtailConfig["client"] = client
+	}
+	if len(promtailConfig) > 0 {
+		promtail["config"] = promtailConfig
+	}
+	values["promtail"] = promtail
+	return values
+}
@@ -51,11 +51,32 @@ func (l Loki) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall bool
 	l.Tool.Vars = string(str)
 }
 
+// values returns the structured values of the log settings of the tool, the credentials of the
+// external target are synced to their secret first.
+func (l Loki) values() (*LogConfig, map[string]interface{}, error) {
+	config, err := toolLogConfig(l.Tool)
+	if err != nil || config == nil {
+		return nil, nil, err
+	}
+	if err := config.syncTargetSecret(l.Cluster); err != nil {
+		return nil, nil, err
+	}
+	return config, config.lokiValues(l.Cluster.Spec.RuntimeType), nil
+}
+
 func (l Loki) Install(toolDetail model.ClusterToolDetail) error {
 	l.setDefaultValue(toolDetail, true)
-	if err := installChart(l.Cluster.HelmClient, l.Tool, constant.LokiChartName, toolDetail.ChartVersion); err != nil {
+	config, values, err := l.values()
+	if err != nil {
 		return err
 	}
+	if err := installChartWithValues(l.Cluster, l.Tool, constant.LokiChartName, toolDetail.ChartVersion, values); err != nil {
+		return err
+	}
+	// logs pushed to an external loki leave no loki in the cluster
+	if config != nil && config.external() {
+		return nil
+	}
 	if err := createRoute(l.Cluster.Namespace, constant.DefaultLokiIngressName, constant.DefaultLokiIngress, constant.DefaultLokiServiceName, 3100, l.Cluster.KubeClient); err != nil {
 		return err
 	}
@@ -67,7 +88,17 @@ func (l Loki) Install(toolDetail model.ClusterToolDetail) error {
 
 func (l Loki) Upgrade(toolDetail model.ClusterToolDetail) error {
 	l.setDefaultValue(toolDetail, false)
-	return upgradeChart(l.Cluster.HelmClient, l.Tool, constant.LokiChartName, toolDetail.ChartVersion)
+	config, values, err := l.values()
+	if err != nil {
+		return err
+	}
+	if err := upgradeChartWithValues(l.Cluster, l.Tool, constant.LokiChartName, toolDetail.ChartVersion, values); err != nil {
+		return err
+	}
+	if l.Cluster.plan == nil && config != nil && config.external() {
+		return preCreateRoute(l.Cluster.Namespace, constant.DefaultLokiIngressName, l.Cluster.KubeClient)
+	}
+	return nil
 }
 
 func (l Loki) Uninstall() error {
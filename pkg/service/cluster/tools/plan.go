@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/helm"
+)
+
+// ValuesPlan is an upgrade of a tool not applied yet: the values of the deployed release, the
+// ones of the upgrade and the violations of the values.schema.json of the chart.
+type ValuesPlan struct {
+	ChartName    string
+	ChartVersion string
+	Schema       json.RawMessage
+	Vars         map[string]interface{}
+	Current      map[string]interface{}
+	Proposed     map[string]interface{}
+	Changes      []helm.ValueChange
+	Errors       []string
+}
+
+func (p *ValuesPlan) fill(h helm.Interface, tool *model.ClusterTool, chartName, chartVersion string, values map[string]interface{}) error {
+	p.ChartName = chartName
+	p.ChartVersion = chartVersion
+	p.Proposed = values
+	p.Vars = map[string]interface{}{}
+	_ = json.Unmarshal([]byte(tool.Vars), &p.Vars)
+	p.Current = map[string]interface{}{}
+	rs, err := h.List()
+	if err != nil {
+		return err
+	}
+	for _, r := range rs {
+		if r.Name == tool.Name && r.Config != nil {
+			p.Current = r.Config
+		}
+	}
+	ct, err := h.LoadChart(chartName, chartVersion)
+	if err != nil {
+		return err
+	}
+	if ct.Schema != nil {
+		p.Schema = ct.Schema
+	}
+	p.Errors = helm.ValidateValues(ct, values)
+	p.Changes = helm.DiffValues(p.Current, p.Proposed)
+	return nil
+}
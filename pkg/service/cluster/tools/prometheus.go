@@ -31,8 +31,19 @@ func (p Prometheus) setDefaultValue(toolDetail model.ClusterToolDetail, isInstal
 
 	values := map[string]interface{}{}
 	_ = json.Unmarshal([]byte(p.Tool.Vars), &values)
-	values["alertmanager.enabled"] = false
+	values["alertmanager.enabled"] = true
 	values["pushgateway.enabled"] = false
+	alertmanagerImage, alertmanagerTag := imageMap["alertmanager_image_name"], imageMap["alertmanager_image_tag"]
+	if alertmanagerImage == nil {
+		alertmanagerImage, alertmanagerTag = "prom/alertmanager", "v0.20.0"
+	}
+	values["alertmanager.image.repository"] = fmt.Sprintf("%s:%d/%s", p.LocalHostName, p.LocalRepositoryPort, alertmanagerImage)
+	values["alertmanager.image.tag"] = alertmanagerTag
+	values["configmapReload.alertmanager.image.repository"] = fmt.Sprintf("%s:%d/%s", p.LocalHostName, p.LocalRepositoryPort, imageMap["configmap_image_name"])
+	values["configmapReload.alertmanager.image.tag"] = imageMap["configmap_image_tag"]
+	if _, ok := values["alertmanager.persistentVolume.enabled"]; !ok {
+		values["alertmanager.persistentVolume.enabled"] = false
+	}
 	values["configmapReload.prometheus.image.repository"] = fmt.Sprintf("%s:%d/%s", p.LocalHostName, p.LocalRepositoryPort, imageMap["configmap_image_name"])
 	values["configmapReload.prometheus.image.tag"] = imageMap["configmap_image_tag"]
 	values["kube-state-metrics.image.repository"] = fmt.Sprintf("%s:%d/%s", p.LocalHostName, p.LocalRepositoryPort, imageMap["metrics_image_name"])
@@ -61,7 +72,11 @@ func (p Prometheus) setDefaultValue(toolDetail model.ClusterToolDetail, isInstal
 
 func (p Prometheus) Install(toolDetail model.ClusterToolDetail) error {
 	p.setDefaultValue(toolDetail, true)
-	if err := installChart(p.Cluster.HelmClient, p.Tool, constant.PrometheusChartName, toolDetail.ChartVersion); err != nil {
+	values, err := p.values()
+	if err != nil {
+		return err
+	}
+	if err := installChartWithValues(p.Cluster, p.Tool, constant.PrometheusChartName, toolDetail.ChartVersion, values); err != nil {
 		return err
 	}
 	if err := createRoute(p.Cluster.Namespace, constant.DefaultPrometheusIngressName, constant.DefaultPrometheusIngress, constant.DefaultPrometheusServiceName, 80, p.Cluster.KubeClient); err != nil {
@@ -75,7 +90,11 @@ func (p Prometheus) Install(toolDetail model.ClusterToolDetail) error {
 
 func (p Prometheus) Upgrade(toolDetail model.ClusterToolDetail) error {
 	p.setDefaultValue(toolDetail, false)
-	return upgradeChart(p.Cluster.HelmClient, p.Tool, constant.PrometheusChartName, toolDetail.ChartVersion)
+	values, err := p.values()
+	if err != nil {
+		return err
+	}
+	return upgradeChartWithValues(p.Cluster, p.Tool, constant.PrometheusChartName, toolDetail.ChartVersion, values)
 }
 
 func (p Prometheus) Uninstall() error {
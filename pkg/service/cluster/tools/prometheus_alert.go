@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+)
+
+type alertRule struct {
+	alert       string
+	expr        string
+	duration    string
+	severity    string
+	summary     string
+	description string
+}
+
+// defaultAlertRules are shipped with the prometheus tool, the etcd rule uses the metrics of the
+// etcd client of the apiserver as etcd itself is not scraped.
+var defaultAlertRules = []alertRule{
+	{
+		alert:       "NodeDown",
+		expr:        `up{job="kubernetes-nodes"} == 0`,
+		duration:    "5m",
+		severity:    "critical",
+		summary:     "Node {{ $labels.instance }} is down",
+		description: "The kubelet of node {{ $labels.instance }} can not be scraped for 5 minutes.",
+	},
+	{
+		alert:       "NodeDiskPressure",
+		expr:        `kube_node_status_condition{condition="DiskPressure",status="true"} == 1`,
+		duration:    "5m",
+		severity:    "warning",
+		summary:     "Node {{ $labels.node }} is under disk pressure",
+		description: "Node {{ $labels.node }} reports DiskPressure, pods may be evicted.",
+	},
+	{
+		alert:       "NodeFilesystemAlmostFull",
+		expr:        `node_filesystem_avail_bytes{fstype!~"tmpfs|overlay|squashfs"} / node_filesystem_size_bytes{fstype!~"tmpfs|overlay|squashfs"} < 0.1`,
+		duration:    "10m",
+		severity:    "warning",
+		summary:     "Filesystem {{ $labels.mountpoint }} of {{ $labels.instance }} is almost full",
+		description: "Less than 10% of filesystem {{ $labels.mountpoint }} of {{ $labels.instance }} is available.",
+	},
+	{
+		alert:       "ApiServerErrors",
+		expr:        `sum(rate(apiserver_request_total{code=~"5.."}[5m])) / sum(rate(apiserver_request_total[5m])) > 0.05`,
+		duration:    "10m",
+		severity:    "critical",
+		summary:     "Apiserver is returning errors",
+		description: "More than 5% of the apiserver requests fail with 5xx: {{ $value | humanizePercentage }}.",
+	},
+	{
+		alert:       "ApiServerCertificateExpiration",
+		expr:        `apiserver_client_certificate_expiration_seconds_count{job="kubernetes-apiservers"} > 0 and on(job) histogram_quantile(0.01, sum by (job, le) (rate(apiserver_client_certificate_expiration_seconds_bucket{job="kubernetes-apiservers"}[5m]))) < 604800`,
+		severity:    "warning",
+		summary:     "Client certificate expires in less than 7 days",
+		description: "A client certificate used against the apiserver expires in less than 7 days.",
+	},
+	{
+		alert:       "EtcdHighRequestLatency",
+		expr:        `histogram_quantile(0.99, sum by (le, operation) (rate(etcd_request_duration_seconds_bucket[5m]))) > 1`,
+		duration:    "10m",
+		severity:    "warning",
+		summary:     "Etcd requests of the apiserver are slow",
+		description: "The 99th percentile of etcd {{ $labels.operation }} requests is {{ $value }}s.",
+	},
+}
+
+// alertValues returns the structured values enabling alertmanager with the default rules and the
+// webhook of KubeOperator as receiver. Without endpoint setting alerts are only shown in alertmanager.
+func (p Prometheus) alertValues() map[string]interface{} {
+	var groups []map[string]interface{}
+	var rules []map[string]interface{}
+	for _, r := range defaultAlertRules {
+		rule := map[string]interface{}{
+			"alert":       r.alert,
+			"expr":        r.expr,
+			"labels":      map[string]interface{}{"severity": r.severity},
+			"annotations": map[string]interface{}{"summary": r.summary, "description": r.description},
+		}
+		if r.duration != "" {
+			rule["for"] = r.duration
+		}
+		rules = append(rules, rule)
+	}
+	groups = append(groups, map[string]interface{}{"name": "kubeoperator", "rules": rules})
+
+	receiver := map[string]interface{}{"name": constant.AlertReceiverName}
+	if endpoint, token := p.alertWebhook(); endpoint != "" {
+		receiver["webhook_configs"] = []interface{}{
+			map[string]interface{}{
+				"url":           strings.TrimSuffix(endpoint, "/") + constant.AlertWebhookPath + p.Cluster.Name,
+				"send_resolved": true,
+				"http_config":   map[string]interface{}{"bearer_token": token},
+			},
+		}
+	} else {
+		logger.Log.Warnf("system setting %s is not set, alerts of cluster %s are not sent to KubeOperator", constant.AlertWebhookEndpointKey, p.Cluster.Name)
+	}
+	return map[string]interface{}{
+		"serverFiles": map[string]interface{}{
+			"alerting_rules.yml": map[string]interface{}{"groups": groups},
+		},
+		"alertmanagerFiles": map[string]interface{}{
+			"alertmanager.yml": map[string]interface{}{
+				"global": map[string]interface{}{},
+				"route": map[string]interface{}{
+					"receiver":        constant.AlertReceiverName,
+					"group_by":        []interface{}{"alertname", "instance"},
+					"group_wait":      "30s",
+					"group_interval":  "5m",
+					"repeat_interval": "4h",
+				},
+				"receivers": []interface{}{receiver},
+			},
+		},
+	}
+}
+
+// alertWebhook returns the endpoint of KubeOperator and the token of the cluster, the token is
+// generated on first use.
+func (p Prometheus) alertWebhook() (string, string) {
+	var setting model.SystemSetting
+	if err := db.DB.Where(map[string]interface{}{"key": constant.AlertWebhookEndpointKey}).First(&setting).Error; err != nil || setting.Value == "" {
+		return "", ""
+	}
+	var secret model.ClusterSecret
+	if err := db.DB.Where("id = ?", p.Cluster.SecretID).First(&secret).Error; err != nil {
+		logger.Log.Errorf("load secret of cluster %s failed: %s", p.Cluster.Name, err.Error())
+		return "", ""
+	}
+	if secret.AlertToken != "" {
+		return setting.Value, secret.AlertToken
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		logger.Log.Errorf("generate alert token of cluster %s failed: %s", p.Cluster.Name, err.Error())
+		return "", ""
+	}
+	token := hex.EncodeToString(b)
+	encrypted, err := encrypt.StringEncrypt(token)
+	if err != nil {
+		logger.Log.Errorf("encrypt alert token of cluster %s failed: %s", p.Cluster.Name, err.Error())
+		return "", ""
+	}
+	if err := db.DB.Model(&model.ClusterSecret{}).Where("id = ?", secret.ID).Update("alert_token", encrypted).Error; err != nil {
+		logger.Log.Errorf("save alert token of cluster %s failed: %s", p.Cluster.Name, err.Error())
+		return "", ""
+	}
+	return setting.Value, token
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+)
+
+const remoteWriteSecretPath = "/etc/secrets/remote-write"
+
+// MetricsStore is the central metrics store, the clusters write to WriteUrl and grafana queries
+// QueryUrl. Password is the password of basic auth or the token of bearer auth.
+type MetricsStore struct {
+	WriteUrl string
+	QueryUrl string
+	Auth     string
+	User     string
+	Password string
+}
+
+// GetMetricsStore reads the store from the system settings, it is nil without a remote write url. The
+// query url is derived from the remote write url only while it is not set.
+func GetMetricsStore() (*MetricsStore, error) {
+	settings := map[string]string{}
+	for _, key := range []string{constant.MetricsRemoteWriteUrlKey, constant.MetricsQueryUrlKey, constant.MetricsRemoteWriteAuthKey, constant.MetricsRemoteWriteUserKey, constant.MetricsRemoteWritePasswordKey} {
+		var setting model.SystemSetting
+		if err := db.DB.Where(map[string]interface{}{"key": key}).First(&setting).Error; err == nil {
+			settings[key] = setting.Value
+		}
+	}
+	writeUrl := settings[constant.MetricsRemoteWriteUrlKey]
+	if writeUrl == "" {
+		return nil, nil
+	}
+	store := &MetricsStore{
+		WriteUrl: writeUrl,
+		QueryUrl: strings.TrimSuffix(settings[constant.MetricsQueryUrlKey], "/"),
+		Auth:     settings[constant.MetricsRemoteWriteAuthKey],
+		User:     settings[constant.MetricsRemoteWriteUserKey],
+	}
+	if store.QueryUrl == "" {
+		store.QueryUrl = strings.TrimSuffix(strings.TrimSuffix(writeUrl, "/"), "/api/v1/write")
+	}
+	if store.Auth == "" {
+		store.Auth = constant.MetricsAuthNone
+	}
+	if settings[constant.MetricsRemoteWritePasswordKey] != "" {
+		password, err := encrypt.StringDecrypt(settings[constant.MetricsRemoteWritePasswordKey])
+		if err != nil {
+			return nil, err
+		}
+		store.Password = password
+	}
+	return store, nil
+}
+
+// Authorize sets the credentials of the store on a request to it.
+func (m MetricsStore) Authorize(req *http.Request) {
+	switch m.Auth {
+	case constant.MetricsAuthBasic:
+		req.SetBasicAuth(m.User, m.Password)
+	case constant.MetricsAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+m.Password)
+	}
+}
+
+// syncRemoteWriteSecret keeps the credentials of the store in a secret next to prometheus, the
+// values only refer to the mounted file so the password never lands in the release.
+func (p Prometheus) syncRemoteWriteSecret(store *MetricsStore) error {
+	if store == nil || store.Auth == constant.MetricsAuthNone || p.Cluster.plan != nil {
+		return nil
+	}
+	return applySecret(p.Cluster.Namespace, constant.MetricsRemoteWriteSecretName, map[string][]byte{"password": []byte(store.Password)}, p.Cluster.KubeClient)
+}
+
+// remoteWriteValues returns the structured values writing the metrics to the central metrics store,
+// every series is labeled with the cluster. Nothing is written without the setting.
+func (p Prometheus) remoteWriteValues(store *MetricsStore) map[string]interface{} {
+	if store == nil {
+		return nil
+	}
+	remoteWrite := map[string]interface{}{
+		"url":          store.WriteUrl,
+		"queue_config": map[string]interface{}{"max_samples_per_send": 1000, "capacity": 5000},
+	}
+	server := map[string]interface{}{
+		"global": map[string]interface{}{
+			"external_labels": map[string]interface{}{constant.MetricsClusterLabel: p.Cluster.Name},
+		},
+		"remoteWrite": []interface{}{remoteWrite},
+	}
+	switch store.Auth {
+	case constant.MetricsAuthBasic:
+		remoteWrite["basic_auth"] = map[string]interface{}{"username": store.User, "password_file": remoteWriteSecretPath + "/password"}
+	case constant.MetricsAuthBearer:
+		remoteWrite["bearer_token_file"] = remoteWriteSecretPath + "/password"
+	}
+	if store.Auth != constant.MetricsAuthNone {
+		server["extraSecretMounts"] = []interface{}{
+			map[string]interface{}{
+				"name":       "remote-write",
+				"mountPath":  remoteWriteSecretPath,
+				"secretName": constant.MetricsRemoteWriteSecretName,
+				"readOnly":   true,
+			},
+		}
+	}
+	return map[string]interface{}{"server": server}
+}
+
+// values returns the structured values of the prometheus chart.
+func (p Prometheus) values() (map[string]interface{}, error) {
+	store, err := GetMetricsStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.syncRemoteWriteSecret(store); err != nil {
+		return nil, err
+	}
+	values := p.alertValues()
+	mergeValues(values, p.remoteWriteValues(store))
+	return values, nil
+}
@@ -51,7 +51,7 @@ func (r Registry) setDefaultValue(toolDetail model.ClusterToolDetail, isInstall
 
 func (r Registry) Install(toolDetail model.ClusterToolDetail) error {
 	r.setDefaultValue(toolDetail, true)
-	if err := installChart(r.Cluster.HelmClient, r.Tool, constant.DockerRegistryChartName, toolDetail.ChartVersion); err != nil {
+	if err := installChart(r.Cluster, r.Tool, constant.DockerRegistryChartName, toolDetail.ChartVersion); err != nil {
 		return err
 	}
 	if err := createRoute(r.Cluster.Namespace, constant.DefaultRegistryIngressName, constant.DefaultRegistryIngress, constant.DefaultRegistryServiceName, 5000, r.Cluster.KubeClient); err != nil {
@@ -65,7 +65,7 @@ func (r Registry) Install(toolDetail model.ClusterToolDetail) error {
 
 func (r Registry) Upgrade(toolDetail model.ClusterToolDetail) error {
 	r.setDefaultValue(toolDetail, false)
-	return upgradeChart(r.Cluster.HelmClient, r.Tool, constant.DockerRegistryChartName, toolDetail.ChartVersion)
+	return upgradeChart(r.Cluster, r.Tool, constant.DockerRegistryChartName, toolDetail.ChartVersion)
 }
 
 func (r Registry) Uninstall() error {
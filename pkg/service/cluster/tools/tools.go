@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
@@ -15,7 +16,9 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/util/helm"
 	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
 	"helm.sh/helm/v3/pkg/strvals"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -33,23 +36,20 @@ type Cluster struct {
 	Namespace    string
 	model.Cluster
 	helmRepoPort int
-	HelmClient   helm.Interface
-	KubeClient   *kubernetes.Clientset
+	nodeArch     string
+	// plan is set when the upgrade is only planned, the chart is then left untouched.
+	plan       *ValuesPlan
+	HelmClient helm.Interface
+	KubeClient *kubernetes.Clientset
 }
 
 func NewCluster(cluster model.Cluster, hosts []kubernetesUtil.Host, oldNamespace, namespace string) (*Cluster, error) {
 	c := Cluster{
 		Cluster: cluster,
 	}
-	var registery model.SystemRegistry
-	if cluster.Spec.Architectures == constant.ArchAMD64 {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&registery).Error; err != nil {
-			return nil, errors.New("load image pull port failed")
-		}
-	} else {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfARM64).First(&registery).Error; err != nil {
-			return nil, errors.New("load image pull port failed")
-		}
+	registery, err := model.GetSystemRegistry(cluster.Spec.Architectures)
+	if err != nil {
+		return nil, errors.New("load image pull port failed")
 	}
 	c.helmRepoPort = registery.RegistryPort
 	c.Namespace = namespace
@@ -80,6 +80,40 @@ func NewClusterTool(tool *model.ClusterTool, cluster model.Cluster, hosts []kube
 	if err != nil {
 		return nil, err
 	}
+	return newClusterTool(c, tool, cluster, enable)
+}
+
+// PlanUpgrade runs the upgrade of tool to toolDetail without touching the release, the plan
+// holds the values the chart would be upgraded with, checked against the chart schema.
+func PlanUpgrade(tool *model.ClusterTool, cluster model.Cluster, hosts []kubernetesUtil.Host, namespace string, toolDetail model.ClusterToolDetail) (*ValuesPlan, error) {
+	c, err := NewCluster(cluster, hosts, namespace, namespace)
+	if err != nil {
+		return nil, err
+	}
+	c.plan = &ValuesPlan{}
+	ct, err := newClusterTool(c, tool, cluster, true)
+	if err != nil {
+		return nil, err
+	}
+	if ct == nil {
+		return nil, fmt.Errorf("tool %s not supported", tool.Name)
+	}
+	if err := ct.Upgrade(toolDetail); err != nil {
+		return nil, err
+	}
+	return c.plan, nil
+}
+
+func newClusterTool(c *Cluster, tool *model.ClusterTool, cluster model.Cluster, enable bool) (Interface, error) {
+	// amd64 only tools of a mixed cluster pull from the amd64 registry and stay on amd64 nodes
+	if cluster.Spec.Architectures == constant.ArchAll && tool.Architecture == constant.ArchAMD64 {
+		registery, err := model.GetSystemRegistry(constant.ArchAMD64)
+		if err != nil {
+			return nil, errors.New("load image pull port failed")
+		}
+		c.helmRepoPort = registery.RegistryPort
+		c.nodeArch = constant.ArchAMD64
+	}
 	switch tool.Name {
 	case "prometheus":
 		return NewPrometheus(c, tool)
@@ -109,6 +143,10 @@ func NewClusterTool(tool *model.ClusterTool, cluster model.Cluster, hosts []kube
 	case "kubeapps":
 		return NewKubeapps(c, tool)
 	}
+	var addon model.ClusterAddon
+	if err := db.DB.Where("name = ?", tool.Name).First(&addon).Error; err == nil {
+		return NewAddon(c, tool, addon)
+	}
 	return nil, nil
 }
 
@@ -129,6 +167,22 @@ func MergeValueMap(source map[string]interface{}) (map[string]interface{}, error
 	return result, nil
 }
 
+// archNodeSelectors holds the node selector value paths of the charts whose
+// workloads must be pinned to one architecture in mixed clusters.
+var archNodeSelectors = map[string][]string{
+	"kubeapps": {"frontend.nodeSelector", "dashboard.nodeSelector", "apprepository.nodeSelector", "kubeops.nodeSelector", "assetsvc.nodeSelector", "postgresql.primary.nodeSelector"},
+	"logging":  {"elasticsearch.nodeSelector", "fluentd-elasticsearch.nodeSelector"},
+}
+
+func setArchNodeSelector(c *Cluster, tool *model.ClusterTool, valueMap map[string]interface{}) {
+	if c.nodeArch == "" {
+		return
+	}
+	for _, key := range archNodeSelectors[tool.Name] {
+		valueMap[fmt.Sprintf("%s.%s", key, strings.ReplaceAll(constant.NodeArchLabel, ".", "\\."))] = c.nodeArch
+	}
+}
+
 func preInstallChart(h helm.Interface, tool *model.ClusterTool) error {
 	rs, err := h.List()
 	if err != nil {
@@ -147,44 +201,80 @@ func preInstallChart(h helm.Interface, tool *model.ClusterTool) error {
 	return nil
 }
 
-func installChart(h helm.Interface, tool *model.ClusterTool, chartName, chartVersion string) error {
+func installChart(c *Cluster, tool *model.ClusterTool, chartName, chartVersion string) error {
+	return installChartWithValues(c, tool, chartName, chartVersion, nil)
+}
+
+// installChartWithValues installs the chart with the vars of tool and the structured values, the
+// ones which can not be written as flat vars like lists of rules.
+func installChartWithValues(c *Cluster, tool *model.ClusterTool, chartName, chartVersion string, values map[string]interface{}) error {
+	h := c.HelmClient
 	err := preInstallChart(h, tool)
 	if err != nil {
 		return err
 	}
 	valueMap := map[string]interface{}{}
 	_ = json.Unmarshal([]byte(tool.Vars), &valueMap)
+	delete(valueMap, constant.LogConfigVar)
+	setArchNodeSelector(c, tool, valueMap)
 	m, err := MergeValueMap(valueMap)
 	if err != nil {
 		return err
 	}
+	mergeValues(m, values)
 	logger.Log.Infof("start install tool %s with chartName: %s, chartVersion: %s", tool.Name, chartName, chartVersion)
-	_, err = h.Install(tool.Name, chartName, chartVersion, m)
+	rel, err := h.Install(tool.Name, chartName, chartVersion, m)
 	if err != nil {
 		return err
 	}
+	tool.ReleaseRevision = rel.Version
 	logger.Log.Infof("install tool %s successful", tool.Name)
 	return nil
 }
 
-func upgradeChart(h helm.Interface, tool *model.ClusterTool, chartName, chartVersion string) error {
+func upgradeChart(c *Cluster, tool *model.ClusterTool, chartName, chartVersion string) error {
+	return upgradeChartWithValues(c, tool, chartName, chartVersion, nil)
+}
+
+func upgradeChartWithValues(c *Cluster, tool *model.ClusterTool, chartName, chartVersion string, values map[string]interface{}) error {
+	h := c.HelmClient
 	valueMap := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(tool.Vars), &valueMap); err != nil {
 		return err
 	}
+	delete(valueMap, constant.LogConfigVar)
+	setArchNodeSelector(c, tool, valueMap)
 	m, err := MergeValueMap(valueMap)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("merge value map failed: %v", err))
 	}
+	mergeValues(m, values)
+	if c.plan != nil {
+		return c.plan.fill(h, tool, chartName, chartVersion, m)
+	}
 	logger.Log.Infof("start upgrade tool %s with chartName: %s, chartVersion: %s", tool.Name, chartName, chartVersion)
-	_, err = h.Upgrade(tool.Name, chartName, chartVersion, m)
+	rel, err := h.Upgrade(tool.Name, chartName, chartVersion, m)
 	if err != nil {
 		return err
 	}
+	tool.ReleaseRevision = rel.Version
 	logger.Log.Infof("upgrade tool %s successful", tool.Name)
 	return nil
 }
 
+// mergeValues merges src into dst, nested maps are merged key by key.
+func mergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				mergeValues(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
 func preCreateRoute(namespace string, ingressName string, kubeClient *kubernetes.Clientset) error {
 	ingress, _ := kubeClient.NetworkingV1beta1().Ingresses(namespace).Get(context.TODO(), ingressName, metav1.GetOptions{})
 	if ingress.Name != "" {
@@ -308,3 +398,23 @@ func getGrafanaSourceNs(cluster model.Cluster, sourceFrom string) (string, error
 	}
 	return sp.(string), nil
 }
+
+// applySecret creates the secret in the namespace of the tool or replaces the data of the existing one.
+func applySecret(namespace, name string, data map[string][]byte, kubeClient *kubernetes.Clientset) error {
+	secrets := kubeClient.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = secrets.Create(context.TODO(), &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       v1.SecretTypeOpaque,
+			Data:       data,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	secret.Data = data
+	_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	AddonNameInvalid  = "ADDON_NAME_INVALID"
+	AddonNameReserved = "ADDON_NAME_RESERVED"
+	AddonVersionExist = "ADDON_VERSION_EXIST"
+	AddonInUse        = "ADDON_IN_USE"
+)
+
+// addonNamePattern keeps add-on names usable as helm release and ingress names.
+var addonNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+type ClusterAddonService interface {
+	List() ([]dto.ClusterAddon, error)
+	Get(name string) (*dto.ClusterAddon, error)
+	Create(creation dto.ClusterAddonCreate) (*dto.ClusterAddon, error)
+	Delete(name string) error
+}
+
+type clusterAddonService struct{}
+
+func NewClusterAddonService() ClusterAddonService {
+	return &clusterAddonService{}
+}
+
+func (c clusterAddonService) List() ([]dto.ClusterAddon, error) {
+	var addons []model.ClusterAddon
+	if err := db.DB.Order("name").Find(&addons).Error; err != nil {
+		return nil, err
+	}
+	var result []dto.ClusterAddon
+	for _, addon := range addons {
+		item, err := toClusterAddonDTO(addon)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *item)
+	}
+	return result, nil
+}
+
+func (c clusterAddonService) Get(name string) (*dto.ClusterAddon, error) {
+	var addon model.ClusterAddon
+	if err := db.DB.Where("name = ?", name).First(&addon).Error; err != nil {
+		return nil, err
+	}
+	return toClusterAddonDTO(addon)
+}
+
+func toClusterAddonDTO(addon model.ClusterAddon) (*dto.ClusterAddon, error) {
+	result := dto.ClusterAddon{ClusterAddon: addon, Values: map[string]interface{}{}, Charts: []dto.ClusterAddonChart{}}
+	_ = json.Unmarshal([]byte(addon.Values), &result.Values)
+	var details []model.ClusterToolDetail
+	if err := db.DB.Where("name = ?", addon.Name).Order("created_at").Find(&details).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range details {
+		vars := map[string]string{}
+		_ = json.Unmarshal([]byte(d.Vars), &vars)
+		result.Charts = append(result.Charts, dto.ClusterAddonChart{
+			Version:      d.Version,
+			Architecture: d.Architecture,
+			ChartName:    vars["chart_name"],
+			ChartVersion: d.ChartVersion,
+		})
+	}
+	return &result, nil
+}
+
+// Create registers the add-on on every cluster. Registering a new version of an existing add-on
+// offers it as upgrade to the clusters which enabled the add-on.
+func (c clusterAddonService) Create(creation dto.ClusterAddonCreate) (*dto.ClusterAddon, error) {
+	if !addonNamePattern.MatchString(creation.Name) {
+		return nil, errors.New(AddonNameInvalid)
+	}
+	var addon model.ClusterAddon
+	exist := !db.DB.Where("name = ?", creation.Name).First(&addon).RecordNotFound()
+	var details int
+	db.DB.Model(&model.ClusterToolDetail{}).Where("name = ?", creation.Name).Count(&details)
+	if !exist && details > 0 {
+		return nil, errors.New(AddonNameReserved)
+	}
+	db.DB.Model(&model.ClusterToolDetail{}).Where("name = ? AND version = ?", creation.Name, creation.Version).Count(&details)
+	if details > 0 {
+		return nil, errors.New(AddonVersionExist)
+	}
+
+	values, _ := json.Marshal(creation.Values)
+	addon.Name = creation.Name
+	addon.Version = creation.Version
+	addon.Describe = creation.Describe
+	addon.Logo = creation.Logo
+	addon.Frame = creation.Frame
+	addon.Url = creation.Url
+	addon.ProxyType = creation.ProxyType
+	addon.Architecture = addonArchitecture(creation.Charts)
+	addon.Values = string(values)
+	addon.ReadyKind = creation.ReadyKind
+	addon.ReadyName = creation.ReadyName
+	addon.ServiceName = creation.ServiceName
+	addon.ServicePort = creation.ServicePort
+
+	tx := db.DB.Begin()
+	if err := tx.Save(&addon).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for _, chart := range creation.Charts {
+		vars, _ := json.Marshal(map[string]string{"chart_name": chart.ChartName})
+		detail := model.ClusterToolDetail{
+			Name:         addon.Name,
+			Version:      addon.Version,
+			ChartVersion: chart.ChartVersion,
+			Architecture: chart.Architecture,
+			Vars:         string(vars),
+		}
+		if err := tx.Create(&detail).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := addClusterAddonTools(tx, addon); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+	return toClusterAddonDTO(addon)
+}
+
+func addonArchitecture(charts []dto.ClusterAddonChart) string {
+	archs := map[string]bool{}
+	for _, chart := range charts {
+		archs[chart.Architecture] = true
+	}
+	if archs[constant.ArchAll] || (archs[constant.ArchAMD64] && archs[constant.ArchARM64]) {
+		return constant.ArchAll
+	}
+	if archs[constant.ArchARM64] {
+		return constant.ArchARM64
+	}
+	return constant.ArchAMD64
+}
+
+// addClusterAddonTools adds the add-on to the tools of the clusters, like updateToolVersion the
+// new version is only offered as upgrade where the add-on is enabled.
+func addClusterAddonTools(tx *gorm.DB, addon model.ClusterAddon) error {
+	var clusters []model.Cluster
+	if err := tx.Find(&clusters).Error; err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		var exist model.ClusterTool
+		if tx.Where("cluster_id = ? AND name = ?", cluster.ID, addon.Name).First(&exist).RecordNotFound() {
+			tool := addonTool(addon, cluster.ID, nil)
+			if err := tx.Create(&tool).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		tool := addonTool(addon, cluster.ID, &exist)
+		if err := tx.Save(&tool).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addonTool is the tool of the add-on in a cluster. A tool the cluster already has keeps its state,
+// one which is enabled keeps its version and is offered the version of the add-on as upgrade.
+func addonTool(addon model.ClusterAddon, clusterID string, exist *model.ClusterTool) model.ClusterTool {
+	tool := addon.Tool()
+	tool.ClusterID = clusterID
+	if exist == nil {
+		return tool
+	}
+	if exist.Status != constant.ClusterWaiting {
+		tool.Version = exist.Version
+		tool.HigherVersion = addon.Version
+	}
+	tool.ID = exist.ID
+	tool.Status = exist.Status
+	tool.Message = exist.Message
+	tool.Vars = exist.Vars
+	tool.CreatedAt = exist.CreatedAt
+	return tool
+}
+
+func (c clusterAddonService) Delete(name string) error {
+	var addon model.ClusterAddon
+	if err := db.DB.Where("name = ?", name).First(&addon).Error; err != nil {
+		return err
+	}
+	var enabled int
+	db.DB.Model(&model.ClusterTool{}).Where("name = ? AND status <> ?", name, constant.ClusterWaiting).Count(&enabled)
+	if enabled > 0 {
+		return errors.New(AddonInUse)
+	}
+	tx := db.DB.Begin()
+	for _, m := range []interface{}{&model.ClusterTool{}, &model.ClusterToolDetail{}, &model.ClusterAddon{}} {
+		if err := tx.Where("name = ?", name).Delete(m).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	tx.Commit()
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+func TestAddonTool(t *testing.T) {
+	addon := model.ClusterAddon{Name: "minio", Version: "v2.0.0", ProxyType: "ingress"}
+	tests := []struct {
+		name          string
+		exist         *model.ClusterTool
+		version       string
+		higherVersion string
+		status        string
+		vars          string
+	}{
+		{name: "new", version: "v2.0.0", status: constant.ClusterWaiting},
+		{
+			name:    "waiting",
+			exist:   &model.ClusterTool{ID: "t1", Version: "v1.0.0", Status: constant.ClusterWaiting, Vars: `{"a":"b"}`},
+			version: "v2.0.0", status: constant.ClusterWaiting, vars: `{"a":"b"}`,
+		},
+		{
+			name:    "enabled",
+			exist:   &model.ClusterTool{ID: "t1", Version: "v1.0.0", Status: constant.ClusterRunning, Vars: `{"a":"b"}`},
+			version: "v1.0.0", higherVersion: "v2.0.0", status: constant.ClusterRunning, vars: `{"a":"b"}`,
+		},
+		{
+			name:    "failed",
+			exist:   &model.ClusterTool{ID: "t1", Version: "v1.0.0", Status: constant.ClusterFailed, Message: "timeout"},
+			version: "v1.0.0", higherVersion: "v2.0.0", status: constant.ClusterFailed,
+		},
+	}
+	for _, tt := range tests {
+		tool := addonTool(addon, "c1", tt.exist)
+		if tool.ClusterID != "c1" || tool.ProxyType != "ingress" {
+			t.Errorf("%s: want the add-on in cluster c1, got %+v", tt.name, tool)
+		}
+		if tool.Version != tt.version || tool.HigherVersion != tt.higherVersion {
+			t.Errorf("%s: want version %q higher %q, got %q higher %q", tt.name, tt.version, tt.higherVersion, tool.Version, tool.HigherVersion)
+		}
+		if tool.Status != tt.status || tool.Vars != tt.vars {
+			t.Errorf("%s: want status %s vars %q, got %s %q", tt.name, tt.status, tt.vars, tool.Status, tool.Vars)
+		}
+		if tt.exist != nil && (tool.ID != tt.exist.ID || tool.Message != tt.exist.Message) {
+			t.Errorf("%s: want the existing tool kept, got %+v", tt.name, tool)
+		}
+	}
+}
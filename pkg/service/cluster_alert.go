@@ -0,0 +1,69 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+var AlertTokenInvalid = "ALERT_TOKEN_INVALID"
+
+type ClusterAlertService interface {
+	Receive(clusterName, token string, webhook dto.AlertWebhook) error
+}
+
+type clusterAlertService struct {
+	messageService MessageService
+}
+
+func NewClusterAlertService() ClusterAlertService {
+	return &clusterAlertService{
+		messageService: NewMessageService(),
+	}
+}
+
+// Receive turns the alerts posted by the alertmanager of the cluster into messages, they reach
+// the project members and admins through the channels they subscribed.
+func (c clusterAlertService) Receive(clusterName, token string, webhook dto.AlertWebhook) error {
+	var cluster model.Cluster
+	if err := db.DB.Where("name = ?", clusterName).Preload("Secret").First(&cluster).Error; err != nil {
+		return errors.New(AlertTokenInvalid)
+	}
+	if cluster.Secret.AlertToken == "" || subtle.ConstantTimeCompare([]byte(cluster.Secret.AlertToken), []byte(token)) != 1 {
+		return errors.New(AlertTokenInvalid)
+	}
+	for _, alert := range webhook.Alerts {
+		content, _ := json.Marshal(alertContent(alert))
+		resolved := alert.Status == constant.AlertStatusResolved
+		if err := c.messageService.SendMessage(constant.Cluster, resolved, string(content), clusterName, constant.ClusterAlert); err != nil {
+			logger.Log.Errorf("send alert %s of cluster %s failed: %s", alert.Labels["alertname"], clusterName, err.Error())
+		}
+	}
+	return nil
+}
+
+func alertContent(alert dto.Alert) map[string]string {
+	instance := alert.Labels["instance"]
+	if instance == "" {
+		instance = alert.Labels["node"]
+	}
+	message := alert.Annotations["summary"]
+	if message == "" {
+		message = alert.Labels["alertname"]
+	}
+	return map[string]string{
+		"message":     message,
+		"name":        alert.Labels["alertname"],
+		"severity":    alert.Labels["severity"],
+		"status":      alert.Status,
+		"instance":    instance,
+		"description": alert.Annotations["description"],
+		"startsAt":    alert.StartsAt.Local().Format("2006-01-02 15:04:05"),
+	}
+}
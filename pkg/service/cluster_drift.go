@@ -0,0 +1,415 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider/client"
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/util/kotf"
+	"github.com/KubeOperator/kotf/api"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	ClusterDriftNotPlan      = "CLUSTER_DRIFT_NOT_PLAN"
+	ClusterDriftBusy         = "CLUSTER_DRIFT_BUSY"
+	ClusterDriftNotAdoptable = "CLUSTER_DRIFT_NOT_ADOPTABLE"
+	ClusterDriftHostNotFound = "CLUSTER_DRIFT_HOST_NOT_FOUND"
+	ClusterDriftNoIp         = "CLUSTER_DRIFT_NO_IP"
+)
+
+// terraformStateMessageSize is how much of the end of the terraform output is kept.
+const terraformStateMessageSize = 4096
+
+type ClusterDriftService interface {
+	GetTerraformState(clusterName string) (*dto.ClusterTerraformState, error)
+	Get(clusterName string) (*dto.ClusterDrift, error)
+	Check(clusterName string) (*dto.ClusterDrift, error)
+	Reconcile(clusterName string) error
+	Adopt(clusterName string, adopt dto.ClusterDriftAdopt) error
+}
+
+type clusterDriftService struct {
+	clusterRepo         repository.ClusterRepository
+	planRepo            repository.PlanRepository
+	hostRepo            repository.HostRepository
+	projectResourceRepo repository.ProjectResourceRepository
+}
+
+func NewClusterDriftService() ClusterDriftService {
+	return &clusterDriftService{
+		clusterRepo:         repository.NewClusterRepository(),
+		planRepo:            repository.NewPlanRepository(),
+		hostRepo:            repository.NewHostRepository(),
+		projectResourceRepo: repository.NewProjectResourceRepository(),
+	}
+}
+
+func (c clusterDriftService) GetTerraformState(clusterName string) (*dto.ClusterTerraformState, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var state model.ClusterTerraformState
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).First(&state).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	result := dto.ClusterTerraformState{ClusterTerraformState: state}
+	_ = json.Unmarshal([]byte(state.Hosts), &result.Hosts)
+	return &result, nil
+}
+
+func (c clusterDriftService) Get(clusterName string) (*dto.ClusterDrift, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var items []model.ClusterDrift
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Order("host_name").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	drift := dto.ClusterDrift{Items: items}
+	for _, item := range items {
+		if item.CreatedAt.After(drift.CheckedAt) {
+			drift.CheckedAt = item.CreatedAt
+		}
+	}
+	return &drift, nil
+}
+
+// Check compares the vms of the provider with the hosts of the cluster and keeps what differs.
+func (c clusterDriftService) Check(clusterName string) (*dto.ClusterDrift, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	items, _, err := c.detect(cluster)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.DB.Begin()
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&model.ClusterDrift{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for i := range items {
+		if err := tx.Create(&items[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	tx.Commit()
+	return c.Get(clusterName)
+}
+
+// Reconcile hands the hosts of the cluster to terraform again, which recreates, resizes and powers on the vms.
+// Only a running cluster is reconciled, it stays Reconciling meanwhile and returns to Running whatever terraform did,
+// the outcome is kept in the status message and the terraform state.
+func (c clusterDriftService) Reconcile(clusterName string) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	if cluster.Spec.Provider != constant.ClusterProviderPlan {
+		return errors.New(ClusterDriftNotPlan)
+	}
+	plan, err := c.planRepo.GetById(cluster.PlanID)
+	if err != nil {
+		return err
+	}
+	var hosts []*model.Host
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Preload("Zone").Find(&hosts).Error; err != nil {
+		return err
+	}
+	// the phase moves only from Running, which keeps a second reconcile or any other operation out
+	result := db.DB.Model(&model.ClusterStatus{}).
+		Where("id = ? AND phase = ?", cluster.StatusID, constant.ClusterRunning).
+		Updates(map[string]interface{}{"phase": constant.ClusterReconciling, "pre_phase": constant.ClusterRunning, "message": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(ClusterDriftBusy)
+	}
+	go c.reconcile(cluster, plan, hosts)
+	return nil
+}
+
+func (c clusterDriftService) reconcile(cluster model.Cluster, plan model.Plan, hosts []*model.Host) {
+	message := ""
+	k := kotf.NewTerraform(&kotf.Config{Cluster: cluster.Name})
+	if err := doInit(k, plan, hosts); err != nil {
+		logger.Log.Errorf("reconcile cluster %s error: %s", cluster.Name, err.Error())
+		message = err.Error()
+	}
+	if err := db.DB.Model(&model.ClusterStatus{}).Where("id = ?", cluster.StatusID).
+		Updates(map[string]interface{}{"phase": constant.ClusterRunning, "pre_phase": constant.ClusterReconciling, "message": message}).Error; err != nil {
+		logger.Log.Errorf("save status of cluster %s error: %s", cluster.Name, err.Error())
+	}
+	if _, err := c.Check(cluster.Name); err != nil {
+		logger.Log.Errorf("check drift of cluster %s error: %s", cluster.Name, err.Error())
+	}
+}
+
+// Adopt takes the provider side of the drift of the hosts: resized vms keep their size and
+// vms terraform does not know are attached to the cluster as nodes.
+func (c clusterDriftService) Adopt(clusterName string, adopt dto.ClusterDriftAdopt) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	items, vms, err := c.detect(cluster)
+	if err != nil {
+		return err
+	}
+	var errs errorf.CErrFs
+	for _, name := range adopt.Hosts {
+		var item *model.ClusterDrift
+		for i := range items {
+			if items[i].HostName == name {
+				item = &items[i]
+			}
+		}
+		if item == nil {
+			errs = errs.Add(errorf.New(ClusterDriftHostNotFound, name))
+			continue
+		}
+		vm := vms[name]
+		switch item.Type {
+		case constant.DriftResized:
+			if err := db.DB.Model(&model.Host{}).Where("name = ?", name).
+				Updates(map[string]interface{}{"cpu_core": vm.Cpu, "memory": vm.Memory}).Error; err != nil {
+				return err
+			}
+		case constant.DriftExtra:
+			if err := c.attachHost(cluster, vm); err != nil {
+				cErr, ok := err.(errorf.CErrF)
+				if !ok {
+					return err
+				}
+				errs = errs.Add(cErr)
+			}
+		default:
+			errs = errs.Add(errorf.New(ClusterDriftNotAdoptable, name, item.Type))
+		}
+	}
+	if _, err := c.Check(clusterName); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// attachHost joins a vm of the cluster terraform did not create to the cluster: it is recorded as a
+// host of the cluster and the project, reusing the credential and zone of the cluster hosts, with a
+// node of the role its plan name carries and its pool ip held by the cluster.
+func (c clusterDriftService) attachHost(cluster model.Cluster, vm client.VmResult) error {
+	if len(vm.Ips) == 0 {
+		return errorf.New(ClusterDriftNoIp, vm.Name)
+	}
+	if len(cluster.Nodes) == 0 {
+		return errorf.New(ClusterDriftNotAdoptable, vm.Name, constant.DriftExtra)
+	}
+	prs, err := c.projectResourceRepo.ListByResourceIDAndType(cluster.ID, constant.ResourceCluster)
+	if err != nil {
+		return err
+	}
+	sibling := cluster.Nodes[0].Host
+	host := model.Host{
+		Name:         vm.Name,
+		Ip:           vm.Ips[0],
+		Port:         sibling.Port,
+		CredentialID: sibling.CredentialID,
+		ZoneID:       sibling.ZoneID,
+		ClusterID:    cluster.ID,
+		Architecture: sibling.Architecture,
+		CpuCore:      vm.Cpu,
+		Memory:       vm.Memory,
+		Status:       constant.ClusterRunning,
+	}
+	tx := db.DB.Begin()
+	if err := tx.Create(&host).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(prs) > 0 {
+		if err := tx.Create(&model.ProjectResource{
+			ProjectID:    prs[0].ProjectID,
+			ResourceID:   host.ID,
+			ResourceType: constant.ResourceHost,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Create(&model.ClusterResource{
+		ClusterID:    cluster.ID,
+		ResourceID:   host.ID,
+		ResourceType: constant.ResourceHost,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	role := getHostRole(vm.Name)
+	if err := tx.Create(&model.ClusterNode{
+		Name:      vm.Name,
+		ClusterID: cluster.ID,
+		HostID:    host.ID,
+		Role:      role,
+		Status:    constant.StatusRunning,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	var ip model.Ip
+	if err := tx.Where("address = ?", host.Ip).First(&ip).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return err
+	}
+	if ip.ID != "" {
+		if err := model.AllocateIp(tx, &ip, cluster.ID, host.Name); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	logger.Log.Infof("attached vm %s to cluster %s as %s", vm.Name, cluster.Name, role)
+	go NewHostService().RunGetHostConfig(&host)
+	return nil
+}
+
+// detect lists the vms of every zone of the plan and compares them with the hosts of the cluster,
+// the vms found are returned by name for the actions taken on the drift.
+func (c clusterDriftService) detect(cluster model.Cluster) ([]model.ClusterDrift, map[string]client.VmResult, error) {
+	if cluster.Spec.Provider != constant.ClusterProviderPlan {
+		return nil, nil, errors.New(ClusterDriftNotPlan)
+	}
+	plan, err := c.planRepo.GetById(cluster.PlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	vms := map[string]client.VmResult{}
+	for _, zone := range plan.Zones {
+		cloudClient, _, err := zoneCloudClient(plan.Region, zone)
+		if err != nil {
+			return nil, nil, err
+		}
+		zoneVms, err := cloudClient.ListVms()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, vm := range zoneVms {
+			vms[vm.Name] = vm
+		}
+	}
+	sizes, err := planHostSizes(plan)
+	if err != nil {
+		return nil, nil, err
+	}
+	var hosts []model.Host
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Find(&hosts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var items []model.ClusterDrift
+	known := map[string]bool{}
+	for _, h := range hosts {
+		known[h.Name] = true
+		drift := model.ClusterDrift{ClusterID: cluster.ID, HostName: h.Name}
+		vm, ok := vms[h.Name]
+		switch {
+		case !ok:
+			drift.Type = constant.DriftMissing
+			drift.Expected = h.Ip
+		case !vm.PowerOn:
+			drift.Type = constant.DriftPoweredOff
+			drift.Actual = strings.Join(vm.Ips, ",")
+		default:
+			// the host record follows the os facts once synced, the plan size is what it was created with
+			cpu, memory := h.CpuCore, roundGB(h.Memory)
+			if cpu == 0 || memory == 0 {
+				size := sizes[getHostRole(h.Name)]
+				cpu, memory = size.Cpu, size.Memory
+			}
+			if cpu == 0 || (vm.Cpu == cpu && roundGB(vm.Memory) == memory) {
+				continue
+			}
+			drift.Type = constant.DriftResized
+			drift.Expected = fmt.Sprintf("%dC/%dG", cpu, memory)
+			drift.Actual = fmt.Sprintf("%dC/%dG", vm.Cpu, roundGB(vm.Memory))
+		}
+		items = append(items, drift)
+	}
+	for name, vm := range vms {
+		if known[name] {
+			continue
+		}
+		if !strings.HasPrefix(name, cluster.Name+"-"+constant.NodeRoleNameMaster+"-") &&
+			!strings.HasPrefix(name, cluster.Name+"-"+constant.NodeRoleNameWorker+"-") {
+			continue
+		}
+		items = append(items, model.ClusterDrift{
+			ClusterID: cluster.ID,
+			HostName:  name,
+			Type:      constant.DriftExtra,
+			Actual:    strings.Join(vm.Ips, ","),
+		})
+	}
+	return items, vms, nil
+}
+
+func roundGB(mb int) int {
+	return (mb + 512) / 1024
+}
+
+// saveTerraformState keeps the summary of the last terraform run of the cluster.
+func saveTerraformState(clusterName, action string, hosts []*model.Host, result *api.KotfResult, runErr error) {
+	var cluster model.Cluster
+	if err := db.DB.Where("name = ?", clusterName).First(&cluster).Error; err != nil {
+		logger.Log.Errorf("save terraform state of cluster %s error: %s", clusterName, err.Error())
+		return
+	}
+	var state model.ClusterTerraformState
+	db.DB.Where("cluster_id = ?", cluster.ID).First(&state)
+	var names []string
+	for _, h := range hosts {
+		names = append(names, h.Name)
+	}
+	hostsStr, _ := json.Marshal(names)
+	state.ClusterID = cluster.ID
+	state.Action = action
+	state.Hosts = string(hostsStr)
+	state.Success = runErr == nil && result != nil && result.Success
+	output := ""
+	if result != nil {
+		output = result.Output
+	}
+	if runErr != nil {
+		output = runErr.Error()
+	}
+	summary := kotf.ParseSummary(output)
+	state.Added, state.Changed, state.Destroyed = summary.Added, summary.Changed, summary.Destroyed
+	if len(output) > terraformStateMessageSize {
+		output = output[len(output)-terraformStateMessageSize:]
+	}
+	state.Message = output
+	if err := db.DB.Save(&state).Error; err != nil {
+		logger.Log.Errorf("save terraform state of cluster %s error: %s", clusterName, err.Error())
+	}
+}
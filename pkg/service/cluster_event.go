@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
-	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
@@ -140,15 +139,9 @@ func (c clusterEventService) CreateNpd(clusterName string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	var registery model.SystemRegistry
-	if cluster.Spec.Architectures == constant.ArchAMD64 {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&registery).Error; err != nil {
-			return false, errors.New("load image pull port failed")
-		}
-	} else {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfARM64).First(&registery).Error; err != nil {
-			return false, errors.New("load image pull port failed")
-		}
+	registery, err := model.GetSystemRegistry(cluster.Spec.Architectures)
+	if err != nil {
+		return false, errors.New("load image pull port failed")
 	}
 	localRepoPort := registery.RegistryPort
 
@@ -180,151 +173,35 @@ func (c clusterEventService) CreateNpd(clusterName string) (bool, error) {
 		if err != nil {
 			return false, err
 		}
-		var test = true
-		_, err = client.AppsV1().DaemonSets("kube-system").Create(context.Background(), &v1.DaemonSet{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "node-problem-detector",
-				Namespace: "kube-system",
-				Labels:    map[string]string{"app": "node-problem-detector"},
-			},
-			Spec: v1.DaemonSetSpec{
-				Selector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{"app": "node-problem-detector"},
-				},
-				Template: corev1.PodTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Labels: map[string]string{"app": "node-problem-detector"},
-					},
-					Spec: corev1.PodSpec{
-						Containers: []corev1.Container{
-							{
-								Name: "node-problem-detector",
-								Command: []string{
-									"/node-problem-detector",
-									"--logtostderr",
-									"--config.system-log-monitor=/config/abrt-adaptor.json,/config/docker-monitor.json,/config/kernel-monitor.json,/config/systemd-monitor.json",
-								},
-								Image: fmt.Sprintf("%s:%d/kubeoperator/node-problem-detector:v0.8.1", constant.LocalRepositoryDomainName, localRepoPort),
-								Resources: corev1.ResourceRequirements{
-									Limits: corev1.ResourceList{
-										"cpu":    resource.MustParse("10m"),
-										"memory": resource.MustParse("80Mi"),
-									},
-									Requests: corev1.ResourceList{
-										"cpu":    resource.MustParse("10m"),
-										"memory": resource.MustParse("80Mi"),
-									},
-								},
-								ImagePullPolicy: corev1.PullPolicy(corev1.PullIfNotPresent),
-								SecurityContext: &corev1.SecurityContext{
-									Privileged: &test,
-								},
-								Env: []corev1.EnvVar{
-									{
-										Name: "NODE_NAME",
-										ValueFrom: &corev1.EnvVarSource{
-											FieldRef: &corev1.ObjectFieldSelector{
-												FieldPath: "spec.nodeName",
-											},
-										},
-									},
-								},
-								VolumeMounts: []corev1.VolumeMount{
-									{
-										Name:      "log",
-										MountPath: "/var/log",
-										ReadOnly:  true,
-									},
-									{
-										Name:      "kmsg",
-										MountPath: "/dev/kmsg",
-										ReadOnly:  true,
-									},
-									{
-										Name:      "localtime",
-										MountPath: "/etc/localtime",
-										ReadOnly:  true,
-									},
-									{
-										Name:      "config",
-										MountPath: "/config",
-										ReadOnly:  true,
-									},
-								},
-							},
-						},
-						Volumes: []corev1.Volume{
-							{
-								Name: "log",
-								VolumeSource: corev1.VolumeSource{
-									HostPath: &corev1.HostPathVolumeSource{
-										Path: "/var/log/",
-									},
-								},
-							},
-							{
-								Name: "kmsg",
-								VolumeSource: corev1.VolumeSource{
-									HostPath: &corev1.HostPathVolumeSource{
-										Path: "/dev/kmsg",
-									},
-								},
-							},
-							{
-								Name: "localtime",
-								VolumeSource: corev1.VolumeSource{
-									HostPath: &corev1.HostPathVolumeSource{
-										Path: "/etc/localtime",
-									},
-								},
-							},
-							{
-								Name: "config",
-								VolumeSource: corev1.VolumeSource{
-									ConfigMap: &corev1.ConfigMapVolumeSource{
-										LocalObjectReference: corev1.LocalObjectReference{
-											Name: "node-problem-detector-config",
-										},
-										Items: []corev1.KeyToPath{
-											{
-												Key:  "abrt-adaptor.json",
-												Path: "abrt-adaptor.json",
-											},
-											{
-												Key:  "docker-monitor.json",
-												Path: "docker-monitor.json",
-											},
-											{
-												Key:  "kernel-monitor.json",
-												Path: "kernel-monitor.json",
-											},
-											{
-												Key:  "systemd-monitor.json",
-												Path: "systemd-monitor.json",
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		}, metav1.CreateOptions{})
-		if err != nil {
-			return false, err
+		for _, arch := range npdArchs(cluster.Spec.Architectures) {
+			port := localRepoPort
+			var nodeSelector map[string]string
+			if arch != "" {
+				registery, err := model.GetSystemRegistry(arch)
+				if err != nil {
+					return false, errors.New("load image pull port failed")
+				}
+				port = registery.RegistryPort
+				nodeSelector = map[string]string{constant.NodeArchLabel: arch}
+			}
+			_, err = client.AppsV1().DaemonSets("kube-system").Create(context.Background(), npdDaemonSet(npdName(arch), port, nodeSelector), metav1.CreateOptions{})
+			if err != nil {
+				return false, err
+			}
 		}
 		err = wait.Poll(5*time.Second, 5*time.Minute, func() (done bool, err error) {
-			ds, err := client.AppsV1().DaemonSets("kube-system").Get(context.Background(), "node-problem-detector", metav1.GetOptions{})
-			if err != nil {
-				err = client.CoreV1().ConfigMaps("kube-system").Delete(context.Background(), "node-problem-detector-config", metav1.DeleteOptions{})
+			for _, arch := range npdArchs(cluster.Spec.Architectures) {
+				ds, err := client.AppsV1().DaemonSets("kube-system").Get(context.Background(), npdName(arch), metav1.GetOptions{})
 				if err != nil {
+					err = client.CoreV1().ConfigMaps("kube-system").Delete(context.Background(), "node-problem-detector-config", metav1.DeleteOptions{})
+					if err != nil {
+						return true, err
+					}
 					return true, err
 				}
-				return true, err
-			}
-			if ds.Status.DesiredNumberScheduled == ds.Status.NumberReady {
-				return true, nil
+				if ds.Status.DesiredNumberScheduled != ds.Status.NumberReady {
+					return true, nil
+				}
 			}
 			return true, nil
 		})
@@ -358,9 +235,11 @@ func (c clusterEventService) DeleteNpd(clusterName string) (bool, error) {
 		if err != nil {
 			return false, err
 		}
-		err = client.AppsV1().DaemonSets("kube-system").Delete(context.Background(), "node-problem-detector", metav1.DeleteOptions{})
-		if err != nil {
-			return false, err
+		for _, arch := range npdArchs(cluster.Spec.Architectures) {
+			err = client.AppsV1().DaemonSets("kube-system").Delete(context.Background(), npdName(arch), metav1.DeleteOptions{})
+			if err != nil {
+				return false, err
+			}
 		}
 		err = client.CoreV1().ConfigMaps("kube-system").Delete(context.Background(), "node-problem-detector-config", metav1.DeleteOptions{})
 		if err != nil {
@@ -370,3 +249,156 @@ func (c clusterEventService) DeleteNpd(clusterName string) (bool, error) {
 	}
 	return false, err
 }
+
+// npdDaemonSet builds the node problem detector daemonset, mixed clusters run
+// one per architecture restricted by node selector.
+func npdDaemonSet(name string, port int, nodeSelector map[string]string) *v1.DaemonSet {
+	privileged := true
+	return &v1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{"app": name},
+		},
+		Spec: v1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: nodeSelector,
+					Containers: []corev1.Container{
+						{
+							Name: "node-problem-detector",
+							Command: []string{
+								"/node-problem-detector",
+								"--logtostderr",
+								"--config.system-log-monitor=/config/abrt-adaptor.json,/config/docker-monitor.json,/config/kernel-monitor.json,/config/systemd-monitor.json",
+							},
+							Image: fmt.Sprintf("%s:%d/kubeoperator/node-problem-detector:v0.8.1", constant.LocalRepositoryDomainName, port),
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									"cpu":    resource.MustParse("10m"),
+									"memory": resource.MustParse("80Mi"),
+								},
+								Requests: corev1.ResourceList{
+									"cpu":    resource.MustParse("10m"),
+									"memory": resource.MustParse("80Mi"),
+								},
+							},
+							ImagePullPolicy: corev1.PullPolicy(corev1.PullIfNotPresent),
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: &privileged,
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "NODE_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "spec.nodeName",
+										},
+									},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "log",
+									MountPath: "/var/log",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "kmsg",
+									MountPath: "/dev/kmsg",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "localtime",
+									MountPath: "/etc/localtime",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "config",
+									MountPath: "/config",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "log",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: "/var/log/",
+								},
+							},
+						},
+						{
+							Name: "kmsg",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: "/dev/kmsg",
+								},
+							},
+						},
+						{
+							Name: "localtime",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: "/etc/localtime",
+								},
+							},
+						},
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: "node-problem-detector-config",
+									},
+									Items: []corev1.KeyToPath{
+										{
+											Key:  "abrt-adaptor.json",
+											Path: "abrt-adaptor.json",
+										},
+										{
+											Key:  "docker-monitor.json",
+											Path: "docker-monitor.json",
+										},
+										{
+											Key:  "kernel-monitor.json",
+											Path: "kernel-monitor.json",
+										},
+										{
+											Key:  "systemd-monitor.json",
+											Path: "systemd-monitor.json",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// npdArchs returns the architectures needing a dedicated daemonset, a single
+// empty entry for clusters of one architecture.
+func npdArchs(arch string) []string {
+	if arch == constant.ArchAll {
+		return []string{constant.ArchAMD64, constant.ArchARM64}
+	}
+	return []string{""}
+}
+
+func npdName(arch string) string {
+	if arch == "" {
+		return "node-problem-detector"
+	}
+	return fmt.Sprintf("node-problem-detector-%s", arch)
+}
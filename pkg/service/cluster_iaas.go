@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -78,6 +79,9 @@ func (c clusterIaasService) Init(name string) error {
 	if err := c.hostRepo.BatchSave(hosts); err != nil {
 		return err
 	}
+	if err := NewHostKeyService().VerifyCreated(hosts); err != nil {
+		return err
+	}
 
 	var projectResources []model.ProjectResource
 	prs, err := c.projectResourceRepo.ListByResourceIDAndType(cluster.ID, constant.ResourceCluster)
@@ -144,10 +148,7 @@ func (c clusterIaasService) createNodes(cluster model.Cluster, hosts []*model.Ho
 
 func (c clusterIaasService) createHosts(cluster model.Cluster, plan model.Plan) ([]*model.Host, error) {
 	var hosts []*model.Host
-	masterAmount := 1
-	if plan.DeployTemplate != constant.SINGLE {
-		masterAmount = 3
-	}
+	masterAmount := planMasterAmount(plan)
 	planVars := map[string]string{}
 	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
 
@@ -159,7 +160,7 @@ func (c clusterIaasService) createHosts(cluster model.Cluster, plan model.Plan)
 			Status:    constant.ClusterCreating,
 			ClusterID: cluster.ID,
 		}
-		if plan.Region.Provider != constant.OpenStack {
+		if !useFlavor(plan.Region.Provider) {
 			role := getHostRole(host.Name)
 			masterConfig, err := c.vmConfigRepo.Get(planVars[fmt.Sprintf("%sModel", role)])
 			if err != nil {
@@ -178,7 +179,7 @@ func (c clusterIaasService) createHosts(cluster model.Cluster, plan model.Plan)
 			Status:    constant.ClusterCreating,
 			ClusterID: cluster.ID,
 		}
-		if plan.Region.Provider != constant.OpenStack {
+		if !useFlavor(plan.Region.Provider) {
 			role := getHostRole(host.Name)
 			workerConfig, err := c.vmConfigRepo.Get(planVars[fmt.Sprintf("%sModel", role)])
 			if err != nil {
@@ -208,9 +209,25 @@ func (c clusterIaasService) createHosts(cluster model.Cluster, plan model.Plan)
 			return nil, err
 		}
 	}
+	if err := assignHostImages(plan, hosts); err != nil {
+		return nil, err
+	}
 	return hosts, nil
 }
 
+// planMasterAmount is the number of masters the deploy template of the plan provisions.
+func planMasterAmount(plan model.Plan) int {
+	if plan.DeployTemplate != constant.SINGLE {
+		return 3
+	}
+	return 1
+}
+
+// useFlavor reports whether the vm size of the provider comes from its flavors rather than from vm configs.
+func useFlavor(provider string) bool {
+	return provider == constant.OpenStack || provider == constant.AWS
+}
+
 func getHostRole(name string) string {
 	if strings.Contains(name, "-master-") {
 		return constant.NodeRoleNameMaster
@@ -219,6 +236,9 @@ func getHostRole(name string) string {
 }
 
 func doInit(k *kotf.Kotf, plan model.Plan, hosts []*model.Host) error {
+	if err := importHostImages(plan, hosts); err != nil {
+		return err
+	}
 	var zonesVars []map[string]interface{}
 	for _, zone := range plan.Zones {
 		zoneMap := map[string]interface{}{}
@@ -233,13 +253,17 @@ func doInit(k *kotf.Kotf, plan model.Plan, hosts []*model.Host) error {
 	}
 	cloudRegionStr, _ := json.Marshal(&cloudRegion)
 	res, err := k.Init(plan.Region.Provider, plan.Region.Vars, string(cloudRegionStr), string(hostsStr))
+	if err != nil || !res.Success {
+		saveTerraformState(k.Cluster, constant.TerraformActionInit, hosts, res, err)
+	}
 	if err != nil {
 		return err
 	}
 	if !res.Success {
 		return errors.New(res.GetOutput())
 	}
-	_, err = k.Apply(plan.Region.Vars)
+	res, err = k.Apply(plan.Region.Vars)
+	saveTerraformState(k.Cluster, constant.TerraformActionApply, hosts, res, err)
 	if err != nil {
 		return err
 	}
@@ -257,6 +281,12 @@ func parseHosts(hosts []*model.Host, plan model.Plan) []map[string]interface{} {
 		return parseOpenstackHosts(hosts, plan)
 	case constant.FusionCompute:
 		return parseFusionComputeHosts(hosts, plan)
+	case constant.Proxmox:
+		return parseProxmoxHosts(hosts, plan)
+	case constant.Libvirt:
+		return parseLibvirtHosts(hosts, plan)
+	case constant.AWS:
+		return parseAwsHosts(hosts, plan)
 	}
 
 	return []map[string]interface{}{}
@@ -276,6 +306,9 @@ func parseVsphereHosts(hosts []*model.Host, plan model.Plan) []map[string]interf
 		hMap["ip"] = h.Ip
 		hMap["zone"] = zoneVars
 		hMap["datastore"] = h.Datastore
+		if h.Image != "" {
+			hMap["imageName"] = h.Image
+		}
 		results = append(results, hMap)
 	}
 	return results
@@ -295,6 +328,56 @@ func parseFusionComputeHosts(hosts []*model.Host, plan model.Plan) []map[string]
 		hMap["ip"] = h.Ip
 		hMap["zone"] = zoneVars
 		hMap["datastore"] = h.Datastore
+		if h.Image != "" {
+			hMap["template"] = h.Image
+		}
+		results = append(results, hMap)
+	}
+	return results
+}
+
+func parseProxmoxHosts(hosts []*model.Host, plan model.Plan) []map[string]interface{} {
+	var results []map[string]interface{}
+	for _, h := range hosts {
+		var zoneVars map[string]interface{}
+		_ = json.Unmarshal([]byte(h.Zone.Vars), &zoneVars)
+		zoneVars["key"] = formatZoneName(h.Zone.Name)
+		hMap := map[string]interface{}{}
+		hMap["name"] = h.Name
+		hMap["shortName"] = h.Name
+		hMap["cpu"] = h.CpuCore
+		hMap["memory"] = h.Memory
+		hMap["ip"] = h.Ip
+		hMap["zone"] = zoneVars
+		hMap["node"] = zoneVars["cluster"]
+		hMap["datastore"] = h.Datastore
+		hMap["template"] = constant.ProxmoxImageName
+		if h.Image != "" {
+			hMap["template"] = h.Image
+		}
+		results = append(results, hMap)
+	}
+	return results
+}
+
+func parseLibvirtHosts(hosts []*model.Host, plan model.Plan) []map[string]interface{} {
+	var results []map[string]interface{}
+	for _, h := range hosts {
+		var zoneVars map[string]interface{}
+		_ = json.Unmarshal([]byte(h.Zone.Vars), &zoneVars)
+		zoneVars["key"] = formatZoneName(h.Zone.Name)
+		hMap := map[string]interface{}{}
+		hMap["name"] = h.Name
+		hMap["shortName"] = h.Name
+		hMap["cpu"] = h.CpuCore
+		hMap["memory"] = h.Memory
+		hMap["ip"] = h.Ip
+		hMap["zone"] = zoneVars
+		hMap["pool"] = h.Datastore
+		hMap["imageName"] = constant.LibvirtImageName
+		if h.Image != "" {
+			hMap["imageName"] = h.Image
+		}
 		results = append(results, hMap)
 	}
 	return results
@@ -315,6 +398,35 @@ func parseOpenstackHosts(hosts []*model.Host, plan model.Plan) []map[string]inte
 		hMap["ip"] = h.Ip
 		hMap["model"] = planVars[fmt.Sprintf("%sModel", role)]
 		hMap["zone"] = zoneVars
+		if h.Image != "" {
+			hMap["imageName"] = h.Image
+		}
+		results = append(results, hMap)
+	}
+	return results
+}
+
+func parseAwsHosts(hosts []*model.Host, plan model.Plan) []map[string]interface{} {
+	var results []map[string]interface{}
+	planVars := map[string]string{}
+	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
+	for _, h := range hosts {
+		var zoneVars map[string]interface{}
+		_ = json.Unmarshal([]byte(h.Zone.Vars), &zoneVars)
+		zoneVars["key"] = formatZoneName(h.Zone.Name)
+		role := getHostRole(h.Name)
+		hMap := map[string]interface{}{}
+		hMap["name"] = h.Name
+		hMap["shortName"] = h.Name
+		hMap["ip"] = h.Ip
+		hMap["model"] = planVars[fmt.Sprintf("%sModel", role)]
+		hMap["subnet"] = zoneVars["subnet"]
+		hMap["securityGroup"] = zoneVars["securityGroup"]
+		hMap["keyName"] = zoneVars["keyName"]
+		hMap["zone"] = zoneVars
+		if h.Image != "" {
+			hMap["imageName"] = h.Image
+		}
 		results = append(results, hMap)
 	}
 	return results
@@ -341,13 +453,31 @@ func allocateZone(zones []model.Zone, hosts []*model.Host) map[*model.Zone][]*mo
 func allocateIpAddr(p cloud_provider.CloudClient, zone model.Zone, hosts []*model.Host, clusterId string) error {
 	zoneVars := map[string]string{}
 	_ = json.Unmarshal([]byte(zone.Vars), &zoneVars)
-	pool, _ := p.GetIpInUsed(zoneVars["network"])
+	if zone.IpPoolID == "" && zoneVars["subnetCidr"] != "" {
+		return allocateSubnetAddr(p, zoneVars, hosts)
+	}
+	var ipPool model.IpPool
+	if err := db.DB.Where("id = ?", zone.IpPoolID).First(&ipPool).Error; err != nil {
+		return err
+	}
+	_, subnet, err := net.ParseCIDR(ipPool.Subnet)
+	if err != nil {
+		return err
+	}
+	// every address the provider or a host already holds is taken out of the pool range
+	poolRange := ipaddr.NewCIDRRange(subnet)
+	inUsed, _ := p.GetIpInUsed(zoneVars["network"])
 	var hs []model.Host
 	if err := db.DB.Find(&hs).Error; err != nil {
 		return err
 	}
 	for i := range hs {
-		pool = append(pool, hs[i].Ip)
+		inUsed = append(inUsed, hs[i].Ip)
+	}
+	for _, ip := range inUsed {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			_ = poolRange.Allocate(parsed)
+		}
 	}
 	var ips []model.Ip
 	if err := db.DB.Where("ip_pool_id = ? AND status = ?", zone.IpPoolID, constant.IpAvailable).Order("inet_aton(address)").Find(&ips).Error; err != nil {
@@ -367,22 +497,17 @@ func allocateIpAddr(p cloud_provider.CloudClient, zone model.Zone, hosts []*mode
 	}
 	wg.Wait()
 
-	var aIps []model.Ip
-	for i := range ips {
-		if ips[i].Status != constant.IpReachable {
-			aIps = append(aIps, ips[i])
-		}
-	}
-
 	var usedIps []model.Ip
-	var uIps []string
 end:
 	for i := range hosts {
-		for j := range aIps {
-			if !exists(aIps[j].Address, pool) && !exists(aIps[j].Address, uIps) {
-				hosts[i].Ip = aIps[j].Address
-				usedIps = append(usedIps, aIps[j])
-				uIps = append(uIps, aIps[j].Address)
+		for j := range ips {
+			if ips[j].Status == constant.IpReachable {
+				continue
+			}
+			if err := poolRange.Allocate(net.ParseIP(ips[j].Address)); err == nil {
+				hosts[i].Ip = ips[j].Address
+				ips[j].HostName = hosts[i].Name
+				usedIps = append(usedIps, ips[j])
 				continue end
 			}
 		}
@@ -393,9 +518,49 @@ end:
 		}
 	}
 	for i := range usedIps {
-		usedIps[i].ClusterID = clusterId
-		usedIps[i].Status = constant.IpUsed
-		db.DB.Save(&usedIps[i])
+		if err := model.AllocateIp(db.DB, &usedIps[i], clusterId, usedIps[i].HostName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocateSubnetAddr picks addresses of public cloud zones straight from the vpc subnet, skipping the ones the cloud reserves.
+func allocateSubnetAddr(p cloud_provider.CloudClient, zoneVars map[string]string, hosts []*model.Host) error {
+	_, cidr, err := net.ParseCIDR(zoneVars["subnetCidr"])
+	if err != nil {
+		return err
+	}
+	pool, err := p.GetIpInUsed(zoneVars["subnet"])
+	if err != nil {
+		return err
+	}
+	var hs []model.Host
+	if err := db.DB.Find(&hs).Error; err != nil {
+		return err
+	}
+	for i := range hs {
+		pool = append(pool, hs[i].Ip)
+	}
+	head, _ := strconv.Atoi(zoneVars["reservedHead"])
+	tail, _ := strconv.Atoi(zoneVars["reservedTail"])
+	size := int(ipaddr.RangeSize(cidr))
+	index := head
+	for i := range hosts {
+		for ; index < size-tail; index++ {
+			ip, err := ipaddr.GetIndexedIP(cidr, index)
+			if err != nil {
+				return err
+			}
+			if !exists(ip.String(), pool) {
+				hosts[i].Ip = ip.String()
+				index++
+				break
+			}
+		}
+		if hosts[i].Ip == "" {
+			return errors.New("NO_IP_AVAILABLE")
+		}
 	}
 	return nil
 }
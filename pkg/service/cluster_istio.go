@@ -4,24 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/istios"
 	"github.com/KubeOperator/KubeOperator/pkg/util/helm"
 	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
+	"github.com/jinzhu/gorm"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 type ClusterIstioService interface {
 	List(clusterName string) ([]dto.ClusterIstio, error)
 	Enable(clusterName string, istios []dto.ClusterIstio) ([]dto.ClusterIstio, error)
 	Disable(clusterName string, istio []dto.ClusterIstio) ([]dto.ClusterIstio, error)
+	Upgrade(clusterName string, upgrade dto.ClusterIstioUpgrade) ([]dto.ClusterIstio, error)
+	Promote(clusterName string, promote dto.ClusterIstioPromote) ([]dto.ClusterIstio, error)
+	Abort(clusterName string) ([]dto.ClusterIstio, error)
+	UpdateMeshConfig(clusterName string, config map[string]interface{}) ([]dto.ClusterIstio, error)
+	Status(clusterName string) (*dto.ClusterIstioStatus, error)
 }
 
+var (
+	IstioVersionNotFound      = "ISTIO_VERSION_NOT_FOUND"
+	IstioVersionInstalled     = "ISTIO_VERSION_INSTALLED"
+	IstioCanaryInProgress     = "ISTIO_CANARY_IN_PROGRESS"
+	IstioCanaryNotFound       = "ISTIO_CANARY_NOT_FOUND"
+	IstioCanaryNotReady       = "ISTIO_CANARY_NOT_READY"
+	IstioProxiesOnOldRevision = "ISTIO_PROXIES_ON_OLD_REVISION"
+	IstioMeshConfigInvalid    = "ISTIO_MESH_CONFIG_INVALID"
+	IstioUpgrading            = "ISTIO_UPGRADING"
+)
+
+// istioOrder is the order the components are installed and upgraded in, the crds of base first.
+var istioOrder = []string{"base", "pilot", "ingress", "egress"}
+
 func NewClusterIstioService() ClusterIstioService {
 	return &clusterIstioService{
 		clusterService: NewClusterService(),
@@ -48,12 +72,35 @@ func (c clusterIstioService) List(clusterName string) ([]dto.ClusterIstio, error
 		d := dto.ClusterIstio{ClusterIstio: m}
 		d.Vars = map[string]interface{}{}
 		_ = json.Unmarshal([]byte(m.Vars), &d.Vars)
+		var details []model.ClusterIstioDetail
+		if err := db.DB.Where("name = ?", m.Name).Order("version").Find(&details).Error; err != nil {
+			return istioDtos, err
+		}
+		for _, detail := range details {
+			d.Versions = append(d.Versions, detail.Version)
+		}
 		istioDtos = append(istioDtos, d)
 	}
 	return istioDtos, nil
 }
 
 func (c clusterIstioService) Enable(clusterName string, istioDtos []dto.ClusterIstio) ([]dto.ClusterIstio, error) {
+	details := map[string]model.ClusterIstioDetail{}
+	for i := range istioDtos {
+		if istioDtos[i].ClusterIstio.Name == "pilot" && istioDtos[i].Operation == "enable" {
+			if _, err := istios.ParseMeshConfig(istioDtos[i].Vars); err != nil {
+				return istioDtos, errorf.New(IstioMeshConfigInvalid, err.Error())
+			}
+		}
+		if istioDtos[i].ClusterIstio.Version == "" {
+			istioDtos[i].ClusterIstio.Version = constant.DefaultIstioVersion
+		}
+		detail, err := getIstioDetail(istioDtos[i].ClusterIstio.Name, istioDtos[i].ClusterIstio.Version)
+		if err != nil {
+			return istioDtos, err
+		}
+		details[istioDtos[i].ClusterIstio.Name] = detail
+	}
 	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
 	if err != nil {
 		return istioDtos, err
@@ -74,7 +121,7 @@ func (c clusterIstioService) Enable(clusterName string, istioDtos []dto.ClusterI
 			istioDtos[i].ClusterIstio.Vars = string(buf)
 			istioDtos[i].ClusterIstio.ClusterID = cluster.ID
 			istioDtos[i].ClusterIstio.Status = constant.ClusterInitializing
-			base := istios.NewBaseInterface(&istioDtos[i].ClusterIstio, helminfo)
+			base := istios.NewBaseInterface(&istioDtos[i].ClusterIstio, istioHelmInfo(helminfo, details["base"]))
 			if err = saveIstio(&istioDtos[i].ClusterIstio); err != nil {
 				return istioDtos, err
 			}
@@ -92,11 +139,11 @@ func (c clusterIstioService) Enable(clusterName string, istioDtos []dto.ClusterI
 		case "base":
 			continue
 		case "pilot":
-			ct = istios.NewPilotInterface(&istioDtos[i].ClusterIstio, helminfo)
+			ct = istios.NewPilotInterface(&istioDtos[i].ClusterIstio, istioHelmInfo(helminfo, details["pilot"]))
 		case "ingress":
-			ct = istios.NewIngressInterface(&istioDtos[i].ClusterIstio, helminfo)
+			ct = istios.NewIngressInterface(&istioDtos[i].ClusterIstio, istioHelmInfo(helminfo, details["ingress"]))
 		case "egress":
-			ct = istios.NewEgressInterface(&istioDtos[i].ClusterIstio, helminfo)
+			ct = istios.NewEgressInterface(&istioDtos[i].ClusterIstio, istioHelmInfo(helminfo, details["egress"]))
 		}
 		if err != nil {
 			return istioDtos, err
@@ -134,6 +181,12 @@ func (c clusterIstioService) Disable(clusterName string, istioDtos []dto.Cluster
 		buf, _ := json.Marshal(&istioDtos[i].Vars)
 		istioDtos[i].ClusterIstio.Vars = string(buf)
 		istioDtos[i].ClusterIstio.ClusterID = cluster.ID
+		// the releases to remove are the ones of the stored revisions
+		var stored model.ClusterIstio
+		if err := db.DB.Where("cluster_id = ? AND name = ?", cluster.ID, istioDtos[i].ClusterIstio.Name).First(&stored).Error; err == nil {
+			istioDtos[i].ClusterIstio.Revision = stored.Revision
+			istioDtos[i].ClusterIstio.CanaryVersion, istioDtos[i].ClusterIstio.CanaryRevision = stored.CanaryVersion, stored.CanaryRevision
+		}
 		switch istioDtos[i].ClusterIstio.Name {
 		case "base":
 			ct = istios.NewBaseInterface(&istioDtos[i].ClusterIstio, helminfo)
@@ -156,6 +209,419 @@ func (c clusterIstioService) Disable(clusterName string, istioDtos []dto.Cluster
 	return istioDtos, nil
 }
 
+// Upgrade upgrades the enabled components to the version in place, base first. With canary the
+// pilot of the version is installed as a revision next to the active one and the gateways stay
+// until it is promoted.
+func (c clusterIstioService) Upgrade(clusterName string, upgrade dto.ClusterIstioUpgrade) ([]dto.ClusterIstio, error) {
+	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	components, err := enabledIstios(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	if name := upgradingIstio(components); name != "" {
+		return nil, errorf.New(IstioUpgrading, name)
+	}
+	pilot, hasPilot := components["pilot"]
+	if hasPilot && pilot.CanaryRevision != "" {
+		return nil, errorf.New(IstioCanaryInProgress, pilot.CanaryRevision)
+	}
+	canary := upgrade.Canary && hasPilot
+	if canary && pilot.Version == upgrade.Version {
+		return nil, errorf.New(IstioVersionInstalled, upgrade.Version)
+	}
+	details := map[string]model.ClusterIstioDetail{}
+	for name := range components {
+		detail, err := getIstioDetail(name, upgrade.Version)
+		if err != nil {
+			return nil, err
+		}
+		details[name] = detail
+	}
+	helminfo, err := NewIstioHelmInfo(cluster.Cluster, endpoints, secret.ClusterSecret, constant.IstioNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	type step struct {
+		istio *model.ClusterIstio
+		run   func() bool
+	}
+	var steps []step
+	for _, name := range istioOrder {
+		istio, ok := components[name]
+		if !ok || (canary && (name == "ingress" || name == "egress")) {
+			continue
+		}
+		info := istioHelmInfo(helminfo, details[name])
+		if canary && name == "pilot" {
+			p := istios.NewPilotInterface(istio, info)
+			steps = append(steps, step{istio, func() bool {
+				istio.CanaryVersion = upgrade.Version
+				istio.CanaryRevision = istios.Revision(upgrade.Version)
+				return c.doUpgrade(p.InstallCanary, istio)
+			}})
+		} else {
+			ct := newIstioInterface(istio, info)
+			steps = append(steps, step{istio, func() bool {
+				istio.Version = upgrade.Version
+				return c.doUpgrade(ct.Upgrade, istio)
+			}})
+		}
+		istio.Status = constant.ClusterUpgrading
+		istio.Message = ""
+		if err := saveIstio(istio); err != nil {
+			return nil, err
+		}
+	}
+	// a component is only upgraded once the ones before it are, the rest stay on their version
+	go func() {
+		for i, st := range steps {
+			if st.run() {
+				continue
+			}
+			for _, skipped := range steps[i+1:] {
+				skipped.istio.Status = constant.ClusterRunning
+				skipped.istio.Message = "upgrade stopped: " + st.istio.Name + " failed"
+				_ = saveIstio(skipped.istio)
+			}
+			return
+		}
+	}()
+	return c.List(clusterName)
+}
+
+// Promote moves the namespaces and the gateways to the canary revision and removes the old one.
+// The sidecars move on the restart of their workloads, the old revision is kept while any is
+// still on it unless forced, promoting again after the restarts goes on.
+func (c clusterIstioService) Promote(clusterName string, promote dto.ClusterIstioPromote) ([]dto.ClusterIstio, error) {
+	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	components, err := enabledIstios(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	pilot, ok := components["pilot"]
+	if !ok || pilot.CanaryRevision == "" {
+		return nil, errors.New(IstioCanaryNotFound)
+	}
+	if name := upgradingIstio(components); name != "" {
+		return nil, errorf.New(IstioUpgrading, name)
+	}
+	if pilot.Status != constant.ClusterRunning {
+		return nil, errorf.New(IstioCanaryNotReady, pilot.CanaryRevision)
+	}
+	helminfo, err := NewIstioHelmInfo(cluster.Cluster, endpoints, secret.ClusterSecret, constant.IstioNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if !promote.Force {
+		proxies, err := istioProxies(helminfo.KubeClient)
+		if err != nil {
+			return nil, err
+		}
+		old, count := istios.RevisionLabel(pilot.Revision), 0
+		for _, p := range proxies {
+			if p.Revision == old && p.Namespace != constant.IstioNamespace {
+				count += p.Pods
+			}
+		}
+		if count > 0 {
+			return nil, errorf.New(IstioProxiesOnOldRevision, count, old)
+		}
+	}
+	if err := istios.RelabelNamespaces(helminfo.KubeClient, pilot.Revision, pilot.CanaryRevision); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"ingress", "egress"} {
+		if err := moveIstio(components[name], helminfo, pilot.CanaryVersion, pilot.CanaryRevision); err != nil {
+			return nil, err
+		}
+	}
+	if base, ok := components["base"]; ok {
+		if err := moveIstio(base, helminfo, base.Version, pilot.CanaryRevision); err != nil {
+			return nil, err
+		}
+	}
+
+	old := *pilot
+	old.CanaryRevision = ""
+	if err := istios.NewPilotInterface(&old, helminfo).Uninstall(); err != nil {
+		return nil, err
+	}
+	pilot.Version, pilot.Revision = pilot.CanaryVersion, pilot.CanaryRevision
+	pilot.CanaryVersion, pilot.CanaryRevision = "", ""
+	if err := saveIstio(pilot); err != nil {
+		return nil, err
+	}
+	return c.List(clusterName)
+}
+
+// Abort removes the canary revision and moves back what a promote has moved to it. Base keeps the
+// version it is upgraded to, its crds serve the old revision as well.
+func (c clusterIstioService) Abort(clusterName string) ([]dto.ClusterIstio, error) {
+	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	components, err := enabledIstios(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	pilot, ok := components["pilot"]
+	if !ok || pilot.CanaryRevision == "" {
+		return nil, errors.New(IstioCanaryNotFound)
+	}
+	helminfo, err := NewIstioHelmInfo(cluster.Cluster, endpoints, secret.ClusterSecret, constant.IstioNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := istios.RelabelNamespaces(helminfo.KubeClient, pilot.CanaryRevision, pilot.Revision); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"ingress", "egress"} {
+		if istio, ok := components[name]; ok && istio.Revision == pilot.CanaryRevision {
+			if err := moveIstio(istio, helminfo, pilot.Version, pilot.Revision); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := istios.NewPilotInterface(pilot, helminfo).UninstallCanary(); err != nil {
+		return nil, err
+	}
+	pilot.CanaryVersion, pilot.CanaryRevision = "", ""
+	pilot.Status = constant.ClusterRunning
+	pilot.Message = ""
+	if err := saveIstio(pilot); err != nil {
+		return nil, err
+	}
+	return c.List(clusterName)
+}
+
+// UpdateMeshConfig stores the mesh settings in the vars of the pilot, a running pilot is upgraded
+// with them.
+func (c clusterIstioService) UpdateMeshConfig(clusterName string, config map[string]interface{}) ([]dto.ClusterIstio, error) {
+	if _, err := istios.ParseMeshConfig(map[string]interface{}{constant.IstioMeshConfigVar: config}); err != nil {
+		return nil, errorf.New(IstioMeshConfigInvalid, err.Error())
+	}
+	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var pilot model.ClusterIstio
+	if err := db.DB.Where("cluster_id = ? AND name = ?", cluster.ID, "pilot").First(&pilot).Error; err != nil {
+		return nil, err
+	}
+	if pilot.CanaryRevision != "" {
+		return nil, errorf.New(IstioCanaryInProgress, pilot.CanaryRevision)
+	}
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(pilot.Vars), &vars)
+	vars[constant.IstioMeshConfigVar] = config
+	buf, _ := json.Marshal(vars)
+	pilot.Vars = string(buf)
+	if pilot.Status != constant.ClusterRunning {
+		if err := saveIstio(&pilot); err != nil {
+			return nil, err
+		}
+		return c.List(clusterName)
+	}
+
+	detail, err := getIstioDetail(pilot.Name, pilot.Version)
+	if err != nil {
+		return nil, err
+	}
+	helminfo, err := NewIstioHelmInfo(cluster.Cluster, endpoints, secret.ClusterSecret, constant.IstioNamespace)
+	if err != nil {
+		return nil, err
+	}
+	pilot.Status = constant.ClusterUpgrading
+	pilot.Message = ""
+	if err := saveIstio(&pilot); err != nil {
+		return nil, err
+	}
+	p := istios.NewPilotInterface(&pilot, istioHelmInfo(helminfo, detail))
+	go c.doUpgrade(p.Upgrade, &pilot)
+	return c.List(clusterName)
+}
+
+// Status shows the istiod of every revision and the sidecars on them, a sidecar whose version
+// differs from the istiod of its revision is skewed.
+func (c clusterIstioService) Status(clusterName string) (*dto.ClusterIstioStatus, error) {
+	cluster, endpoints, secret, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var status dto.ClusterIstioStatus
+	var pilot model.ClusterIstio
+	if err := db.DB.Where("cluster_id = ? AND name = ?", cluster.ID, "pilot").First(&pilot).Error; err == nil {
+		status.Version, status.Revision = pilot.Version, pilot.Revision
+		status.CanaryVersion, status.CanaryRevision = pilot.CanaryVersion, pilot.CanaryRevision
+	}
+	kubeClient, err := kubernetesUtil.NewKubernetesClient(&kubernetesUtil.Config{
+		Hosts: endpoints,
+		Token: secret.KubernetesToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := kubeClient.AppsV1().Deployments(constant.IstioNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "app=istiod"})
+	if err != nil {
+		return nil, err
+	}
+	versions := map[string]string{}
+	for _, d := range deployments.Items {
+		cp := dto.ClusterIstioControlPlane{
+			Revision:      istios.RevisionLabel(d.Labels[constant.IstioRevisionLabel]),
+			Replicas:      d.Status.Replicas,
+			ReadyReplicas: d.Status.ReadyReplicas,
+		}
+		if len(d.Spec.Template.Spec.Containers) > 0 {
+			cp.Version = imageTag(d.Spec.Template.Spec.Containers[0].Image)
+		}
+		versions[cp.Revision] = cp.Version
+		status.ControlPlanes = append(status.ControlPlanes, cp)
+	}
+	status.Proxies, err = istioProxies(kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	for i := range status.Proxies {
+		p := &status.Proxies[i]
+		if p.Version != versions[p.Revision] {
+			p.Skew = true
+			status.Skewed += p.Pods
+		}
+	}
+	return &status, nil
+}
+
+// istioProxies counts the sidecars and gateways by namespace, revision and version.
+func istioProxies(kubeClient *kubernetes.Clientset) ([]dto.ClusterIstioProxy, error) {
+	pods, err := kubeClient.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{LabelSelector: constant.IstioRevisionLabel})
+	if err != nil {
+		return nil, err
+	}
+	var proxies []dto.ClusterIstioProxy
+	index := map[string]int{}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if container.Name != "istio-proxy" {
+				continue
+			}
+			p := dto.ClusterIstioProxy{
+				Namespace: pod.Namespace,
+				Revision:  istios.RevisionLabel(pod.Labels[constant.IstioRevisionLabel]),
+				Version:   imageTag(container.Image),
+			}
+			key := p.Namespace + "/" + p.Revision + "/" + p.Version
+			if i, ok := index[key]; ok {
+				proxies[i].Pods++
+				continue
+			}
+			p.Pods = 1
+			index[key] = len(proxies)
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies, nil
+}
+
+func imageTag(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || i < strings.LastIndex(image, "/") {
+		return "latest"
+	}
+	return image[i+1:]
+}
+
+// upgradingIstio returns the name of the first component being upgraded, empty when none is.
+func upgradingIstio(components map[string]*model.ClusterIstio) string {
+	for _, name := range istioOrder {
+		if istio, ok := components[name]; ok && istio.Status == constant.ClusterUpgrading {
+			return name
+		}
+	}
+	return ""
+}
+
+// moveIstio upgrades the component to the version of the revision, nil is skipped.
+func moveIstio(istio *model.ClusterIstio, helminfo istios.IstioHelmInfo, version, revision string) error {
+	if istio == nil || (istio.Version == version && istio.Revision == revision) {
+		return nil
+	}
+	detail, err := getIstioDetail(istio.Name, version)
+	if err != nil {
+		return err
+	}
+	istio.Version, istio.Revision = version, revision
+	if err := newIstioInterface(istio, istioHelmInfo(helminfo, detail)).Upgrade(); err != nil {
+		return err
+	}
+	return saveIstio(istio)
+}
+
+// doUpgrade runs the upgrade of the component and stores its result, false when it failed.
+func (c clusterIstioService) doUpgrade(upgrade func() error, istio *model.ClusterIstio) bool {
+	err := upgrade()
+	if err != nil {
+		logger.Log.Errorf("upgrade istio %s failed: %+v", istio.Name, err)
+		istio.Status = constant.ClusterFailed
+		istio.Message = err.Error()
+	} else {
+		istio.Status = constant.ClusterRunning
+	}
+	_ = saveIstio(istio)
+	return err == nil
+}
+
+func newIstioInterface(istio *model.ClusterIstio, helminfo istios.IstioHelmInfo) istios.IstioInterface {
+	switch istio.Name {
+	case "base":
+		return istios.NewBaseInterface(istio, helminfo)
+	case "pilot":
+		return istios.NewPilotInterface(istio, helminfo)
+	case "ingress":
+		return istios.NewIngressInterface(istio, helminfo)
+	default:
+		return istios.NewEgressInterface(istio, helminfo)
+	}
+}
+
+// enabledIstios are the running, failed or upgrading components of the cluster by name.
+func enabledIstios(clusterID string) (map[string]*model.ClusterIstio, error) {
+	var items []model.ClusterIstio
+	if err := db.DB.Where("cluster_id = ? AND status IN (?)", clusterID, []string{constant.ClusterRunning, constant.ClusterFailed, constant.ClusterUpgrading}).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	components := map[string]*model.ClusterIstio{}
+	for i := range items {
+		components[items[i].Name] = &items[i]
+	}
+	return components, nil
+}
+
+func getIstioDetail(name, version string) (model.ClusterIstioDetail, error) {
+	var detail model.ClusterIstioDetail
+	if err := db.DB.Where("name = ? AND version = ?", name, version).First(&detail).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return detail, errorf.New(IstioVersionNotFound, name, version)
+		}
+		return detail, err
+	}
+	return detail, nil
+}
+
+// istioHelmInfo is the helm info of a component of the version of detail.
+func istioHelmInfo(helminfo istios.IstioHelmInfo, detail model.ClusterIstioDetail) istios.IstioHelmInfo {
+	helminfo.Detail = detail
+	return helminfo
+}
+
 func (c clusterIstioService) getBaseParams(clusterName string) (dto.Cluster, []kubernetesUtil.Host, dto.ClusterSecret, error) {
 	var (
 		cluster   dto.Cluster
@@ -190,9 +656,12 @@ func (c clusterIstioService) doInstall(p istios.IstioInterface, istio *model.Clu
 	_ = saveIstio(istio)
 }
 
+// doUninstall removes the releases of the component, it is enabled again without a revision.
 func (c clusterIstioService) doUninstall(p istios.IstioInterface, istio *model.ClusterIstio) {
 	_ = p.Uninstall()
 	istio.Status = constant.ClusterWaiting
+	istio.Revision = ""
+	istio.CanaryVersion, istio.CanaryRevision = "", ""
 	_ = saveIstio(istio)
 }
 
@@ -246,15 +715,9 @@ func NewIstioHelmInfo(cluster model.Cluster, endpoints []kubernetesUtil.Host, se
 		Namespace:     namespace,
 		Architectures: cluster.Spec.Architectures,
 	})
-	var registery model.SystemRegistry
-	if cluster.Spec.Architectures == constant.ArchAMD64 {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&registery).Error; err != nil {
-			return p, errors.New("load image pull port failed")
-		}
-	} else {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfARM64).First(&registery).Error; err != nil {
-			return p, errors.New("load image pull port failed")
-		}
+	registery, err := model.GetSystemRegistry(cluster.Spec.Architectures)
+	if err != nil {
+		return p, errors.New("load image pull port failed")
 	}
 	p.LocalhostPort = registery.RegistryPort
 	if err != nil {
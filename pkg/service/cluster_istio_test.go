@@ -0,0 +1,17 @@
+package service
+
+import "testing"
+
+func TestImageTag(t *testing.T) {
+	cases := map[string]string{
+		"docker.io/istio/proxyv2:1.8.0":                   "1.8.0",
+		"registry.kubeoperator.io:8082/istio/pilot":       "latest",
+		"registry.kubeoperator.io:8082/istio/pilot:1.9.5": "1.9.5",
+		"istio/proxyv2": "latest",
+	}
+	for image, want := range cases {
+		if got := imageTag(image); got != want {
+			t.Errorf("imageTag(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/loadbalancer"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	LbParamRequired = "LB_PARAM_REQUIRED"
+	LbHostNotFound  = "LB_HOST_NOT_FOUND"
+	LbHostUsed      = "LB_HOST_USED"
+	LbNotFound      = "LB_NOT_FOUND"
+	LbClusterBusy   = "LB_CLUSTER_BUSY"
+)
+
+// octaviaRequiredVars are the octavia settings a load balancer can not be created without.
+var octaviaRequiredVars = []string{"identity", "username", "projectId", "subnetId"}
+
+type ClusterLoadBalancerService interface {
+	Get(clusterName string) (*dto.ClusterLoadBalancer, error)
+	Create(clusterName string, creation dto.ClusterLoadBalancerCreate) (*dto.ClusterLoadBalancer, error)
+	Delete(clusterName string) error
+	Sync(clusterName string) error
+}
+
+type clusterLoadBalancerService struct {
+	clusterRepo repository.ClusterRepository
+}
+
+func NewClusterLoadBalancerService() ClusterLoadBalancerService {
+	return &clusterLoadBalancerService{
+		clusterRepo: repository.NewClusterRepository(),
+	}
+}
+
+func (c clusterLoadBalancerService) Get(clusterName string) (*dto.ClusterLoadBalancer, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	lb, ok := loadbalancer.Load(cluster.ID)
+	if !ok {
+		return nil, nil
+	}
+	result := dto.ClusterLoadBalancer{ClusterLoadBalancer: lb, Vars: map[string]string{}}
+	if lb.Hosts != "" {
+		result.Hosts = strings.Split(lb.Hosts, ",")
+	}
+	if lb.Members != "" {
+		result.Members = strings.Split(lb.Members, ",")
+	}
+	_ = json.Unmarshal([]byte(lb.Vars), &result.Vars)
+	if lb.Provider == constant.LbProviderF5 {
+		var setting model.F5Setting
+		if err := db.DB.Where("cluster_id = ?", cluster.ID).First(&setting).Error; err == nil {
+			result.F5 = &setting
+		}
+	}
+	return &result, nil
+}
+
+func (c clusterLoadBalancerService) Create(clusterName string, creation dto.ClusterLoadBalancerCreate) (*dto.ClusterLoadBalancer, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoadBalancerCreate(creation); err != nil {
+		return nil, err
+	}
+	lb, _ := loadbalancer.Load(cluster.ID)
+	lb.ClusterID = cluster.ID
+	lb.Provider = creation.Provider
+	lb.Vip = creation.Vip
+	lb.Port = creation.Port
+	lb.InsecureSkipVerify = creation.InsecureSkipVerify
+	lb.Hosts = ""
+	if creation.Provider == constant.LbProviderHaproxy {
+		lb.Hosts = strings.Join(creation.Hosts, ",")
+	}
+	lb.Vars = ""
+	lb.Password = ""
+	lb.Status = constant.StatusWaiting
+	lb.Message = ""
+	if creation.Provider == constant.LbProviderOctavia {
+		vars, _ := json.Marshal(creation.Vars)
+		lb.Vars = string(vars)
+		password, err := encrypt.StringEncrypt(creation.Password)
+		if err != nil {
+			return nil, err
+		}
+		lb.Password = password
+	}
+
+	tx := db.DB.Begin()
+	if creation.Provider == constant.LbProviderHaproxy {
+		if err := reserveLoadBalancerHosts(tx, cluster.ID, creation.Hosts); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Save(&lb).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if creation.Provider == constant.LbProviderF5 {
+		var setting model.F5Setting
+		tx.Where("cluster_id = ?", cluster.ID).First(&setting)
+		password, err := encrypt.StringEncrypt(creation.Password)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		setting.ClusterID = cluster.ID
+		setting.URL = creation.Url
+		setting.User = creation.User
+		setting.Password = password
+		setting.Partition = creation.Partition
+		setting.PublicIP = creation.Vip
+		if err := tx.Save(&setting).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	// the apiserver is reached through the vip of the load balancer from now on
+	if err := tx.Model(&model.ClusterSpec{}).Where("id = ?", cluster.SpecID).
+		Updates(map[string]interface{}{"lb_mode": constant.LbModeExternal, "lb_kube_apiserver_ip": creation.Vip}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+
+	if cluster.Status.Phase == constant.ClusterRunning {
+		cluster.Spec.LbMode = constant.LbModeExternal
+		cluster.Spec.LbKubeApiserverIp = creation.Vip
+		go syncClusterLoadBalancer(cluster)
+	}
+	return c.Get(clusterName)
+}
+
+func checkLoadBalancerCreate(creation dto.ClusterLoadBalancerCreate) error {
+	var errs errorf.CErrFs
+	switch creation.Provider {
+	case constant.LbProviderF5:
+		for k, v := range map[string]string{"url": creation.Url, "user": creation.User, "password": creation.Password} {
+			if v == "" {
+				errs = errs.Add(errorf.New(LbParamRequired, k))
+			}
+		}
+	case constant.LbProviderHaproxy:
+		if len(creation.Hosts) == 0 {
+			errs = errs.Add(errorf.New(LbParamRequired, "hosts"))
+		}
+		var hosts []model.Host
+		if err := db.DB.Where("name in (?)", creation.Hosts).Find(&hosts).Error; err != nil {
+			return err
+		}
+		for _, name := range creation.Hosts {
+			var host *model.Host
+			for i := range hosts {
+				if hosts[i].Name == name {
+					host = &hosts[i]
+				}
+			}
+			if host == nil {
+				errs = errs.Add(errorf.New(LbHostNotFound, name))
+			}
+		}
+	case constant.LbProviderOctavia:
+		for _, k := range octaviaRequiredVars {
+			if creation.Vars[k] == "" {
+				errs = errs.Add(errorf.New(LbParamRequired, k))
+			}
+		}
+		if creation.Password == "" {
+			errs = errs.Add(errorf.New(LbParamRequired, "password"))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// reserveLoadBalancerHosts holds the haproxy hosts for the load balancer of the cluster in the transaction
+// saving it. The hosts are locked, so a cluster or another load balancer taking them waits for it, and they
+// must be free of clusters and of the load balancers of other clusters. Deleting the load balancer releases them.
+func reserveLoadBalancerHosts(tx *gorm.DB, clusterID string, names []string) error {
+	var hosts []model.Host
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("name in (?)", names).Find(&hosts).Error; err != nil {
+		return err
+	}
+	var lbs []model.ClusterLoadBalancer
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("cluster_id <> ? AND provider = ?", clusterID, constant.LbProviderHaproxy).Find(&lbs).Error; err != nil {
+		return err
+	}
+	held := map[string]bool{}
+	for _, lb := range lbs {
+		for _, name := range strings.Split(lb.Hosts, ",") {
+			held[name] = true
+		}
+	}
+	var errs errorf.CErrFs
+	for _, h := range hosts {
+		if h.ClusterID != "" || held[h.Name] {
+			errs = errs.Add(errorf.New(LbHostUsed, h.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c clusterLoadBalancerService) Delete(clusterName string) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	lb, ok := loadbalancer.Load(cluster.ID)
+	if !ok {
+		return errors.New(LbNotFound)
+	}
+	admCluster := adm.NewCluster(cluster)
+	p, err := loadbalancer.NewProvider(cluster, lb, admCluster.Kobe, nil)
+	if err != nil {
+		return err
+	}
+	if err := p.Delete(); err != nil {
+		return err
+	}
+	firstMasterIP := ""
+	if members := loadbalancer.Masters(cluster); len(members) > 0 {
+		firstMasterIP = members[0].Address
+	}
+	tx := db.DB.Begin()
+	if err := tx.Delete(&lb).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&model.F5Setting{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&model.ClusterSpec{}).Where("id = ?", cluster.SpecID).
+		Updates(map[string]interface{}{"lb_mode": constant.LbModeInternal, "lb_kube_apiserver_ip": firstMasterIP}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+func (c clusterLoadBalancerService) Sync(clusterName string) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	lb, ok := loadbalancer.Load(cluster.ID)
+	if !ok {
+		return errors.New(LbNotFound)
+	}
+	if cluster.Status.Phase != constant.ClusterRunning {
+		return errors.New(LbClusterBusy)
+	}
+	if err := db.DB.Model(&lb).Update("status", constant.StatusWaiting).Error; err != nil {
+		return err
+	}
+	go syncClusterLoadBalancer(cluster)
+	return nil
+}
+
+func syncClusterLoadBalancer(cluster model.Cluster) {
+	admCluster := adm.NewCluster(cluster)
+	if err := loadbalancer.Sync(cluster, admCluster.Kobe, nil); err != nil {
+		logger.Log.Errorf("sync load balancer of cluster %s failed: %s", cluster.Name, err.Error())
+	}
+}
+
+// syncClusterLoadBalancerIfChanged syncs the load balancer of the cluster once its masters differ
+// from the members synced last.
+func syncClusterLoadBalancerIfChanged(clusterName string) {
+	cluster, err := repository.NewClusterRepository().Get(clusterName)
+	if err != nil {
+		logger.Log.Errorf("load cluster %s failed: %s", clusterName, err.Error())
+		return
+	}
+	lb, ok := loadbalancer.Load(cluster.ID)
+	if !ok {
+		return
+	}
+	var addresses []string
+	for _, m := range loadbalancer.Masters(cluster) {
+		addresses = append(addresses, m.Address)
+	}
+	if lb.Status == constant.StatusRunning && lb.Members == strings.Join(addresses, ",") {
+		return
+	}
+	syncClusterLoadBalancer(cluster)
+}
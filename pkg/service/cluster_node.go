@@ -16,10 +16,12 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/facts"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/loadbalancer"
 	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
 	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
 	"github.com/KubeOperator/KubeOperator/pkg/util/kotf"
 	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
+	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -190,6 +192,7 @@ func (c clusterNodeService) recreate(cluster *model.Cluster, node *model.Cluster
 	}
 	_ = c.messageService.SendMessage(constant.System, true, GetContent(constant.ClusterAddWorker, true, ""), cluster.Name, constant.ClusterAddWorker)
 	logger.Log.Info("create cluster nodes successful!")
+	syncClusterLoadBalancerIfChanged(cluster.Name)
 }
 
 func (c *clusterNodeService) Batch(clusterName string, item dto.NodeBatch) error {
@@ -308,8 +311,7 @@ func (c *clusterNodeService) removeNodes(cluster *model.Cluster, item dto.NodeBa
 			c.updateNodeStatus(constant.ClusterRemoveWorker, cluster.Name, constant.StatusFailed, constant.StatusTerminating, nodeIDs, err, false)
 			return
 		}
-		if err := tx.Model(&model.Ip{}).Where("address in (?)", hostIPs).
-			Update("status", constant.IpAvailable).Error; err != nil {
+		if err := model.ReleaseIps(tx, hostIPs); err != nil {
 			tx.Rollback()
 			c.updateNodeStatus(constant.ClusterRemoveWorker, cluster.Name, constant.StatusFailed, constant.StatusTerminating, nodeIDs, err, false)
 			return
@@ -347,6 +349,7 @@ func (c *clusterNodeService) removeNodes(cluster *model.Cluster, item dto.NodeBa
 	}
 	_ = c.messageService.SendMessage(constant.System, true, GetContent(constant.ClusterRemoveWorker, true, ""), cluster.Name, constant.ClusterRemoveWorker)
 	logger.Log.Info("delete node successful!")
+	syncClusterLoadBalancerIfChanged(cluster.Name)
 }
 
 func (c *clusterNodeService) destroyHosts(cluster *model.Cluster, currentNodes []model.ClusterNode, deleteNodeIDs []string) error {
@@ -376,6 +379,10 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 	logger.Log.Info("start create cluster nodes")
 	switch cluster.Spec.Provider {
 	case constant.ClusterProviderBareMetal:
+		request, err := hostQuotaRequest(hostNames)
+		if err != nil {
+			return err
+		}
 		var hosts []model.Host
 		if err := db.DB.Where("name in (?)", hostNames).
 			Preload("Volumes").
@@ -383,10 +390,23 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 			Find(&hosts).Error; err != nil {
 			return fmt.Errorf("get hosts failed: %v", err)
 		}
-		ns, err := c.createNodeModels(cluster, currentNodes, hosts)
+		tx := db.DB.Begin()
+		if err := checkProjectQuota(tx, cluster.ProjectID, request); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := updateClusterArchitectures(tx, cluster, currentNodes, hosts); err != nil {
+			tx.Rollback()
+			return err
+		}
+		ns, err := c.createNodeModels(tx, cluster, currentNodes, hosts)
 		if err != nil {
+			tx.Rollback()
 			return fmt.Errorf("create node model failed: %v", err)
 		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
 		newNodes = ns
 	case constant.ClusterProviderPlan:
 		var plan model.Plan
@@ -396,14 +416,31 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 			return fmt.Errorf("load plan failed: %v", err)
 		}
 		cluster.Plan = plan
-		hosts, err := c.createHostModels(cluster, item.Increase)
+		request, err := planQuotaRequest(plan, 0, item.Increase)
+		if err != nil {
+			return err
+		}
+		if err := checkPlanCapacity(plan, 0, item.Increase); err != nil {
+			return err
+		}
+		tx := db.DB.Begin()
+		if err := checkProjectQuota(tx, cluster.ProjectID, request); err != nil {
+			tx.Rollback()
+			return err
+		}
+		hosts, err := c.createHostModels(tx, cluster, item.Increase)
 		if err != nil {
+			tx.Rollback()
 			return fmt.Errorf("create host model failed: %v", err)
 		}
-		ns, err := c.createNodeModels(cluster, currentNodes, hosts)
+		ns, err := c.createNodeModels(tx, cluster, currentNodes, hosts)
 		if err != nil {
+			tx.Rollback()
 			return fmt.Errorf("create node model failed: %v", err)
 		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
 		newNodes = ns
 	}
 	go c.addNodes(cluster, newNodes, item.SupportGpu)
@@ -446,6 +483,7 @@ func (c clusterNodeService) addNodes(cluster *model.Cluster, newNodes []model.Cl
 	}
 	_ = c.messageService.SendMessage(constant.System, true, GetContent(constant.ClusterAddWorker, true, ""), cluster.Name, constant.ClusterAddWorker)
 	logger.Log.Info("create cluster nodes successful!")
+	syncClusterLoadBalancerIfChanged(cluster.Name)
 }
 
 func (c *clusterNodeService) updateNodeStatus(operation, clusterName, status, preStatus string, notDirtyNodeID []string, errMsg error, isDirty bool) {
@@ -512,7 +550,25 @@ func (c clusterNodeService) updataHostInfo(cluster *model.Cluster, newNodeIDs, n
 	return nil
 }
 
-func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNodes []model.ClusterNode, hosts []model.Host) ([]model.ClusterNode, error) {
+// updateClusterArchitectures marks the cluster as mixed once hosts of another
+// architecture join it.
+func updateClusterArchitectures(tx *gorm.DB, cluster *model.Cluster, currentNodes []model.ClusterNode, hosts []model.Host) error {
+	all := append([]model.Host{}, hosts...)
+	for _, n := range currentNodes {
+		all = append(all, n.Host)
+	}
+	arch := model.ArchitecturesOf(all)
+	if arch != constant.ArchAll || cluster.Spec.Architectures == arch {
+		return nil
+	}
+	if err := tx.Model(&model.ClusterSpec{}).Where("id = ?", cluster.SpecID).Update("architectures", arch).Error; err != nil {
+		return fmt.Errorf("update cluster architectures failed: %v", err)
+	}
+	cluster.Spec.Architectures = arch
+	return nil
+}
+
+func (c clusterNodeService) createNodeModels(tx *gorm.DB, cluster *model.Cluster, currentNodes []model.ClusterNode, hosts []model.Host) ([]model.ClusterNode, error) {
 	var newNodes []model.ClusterNode
 	hash := map[string]interface{}{}
 	for _, n := range currentNodes {
@@ -538,23 +594,19 @@ func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNode
 		}
 		newNodes = append(newNodes, n)
 	}
-	tx := db.DB.Begin()
 	for i := range newNodes {
 		newNodes[i].Host.ClusterID = cluster.ID
 		if err := tx.Save(&newNodes[i].Host).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not save host %s", newNodes[i].Host.Name)
 		}
 		if err := tx.Create(&newNodes[i]).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not save node %s", newNodes[i].Name)
 		}
 	}
-	tx.Commit()
 	return newNodes, nil
 }
 
-func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase int) ([]model.Host, error) {
+func (c clusterNodeService) createHostModels(tx *gorm.DB, cluster *model.Cluster, increase int) ([]model.Host, error) {
 	var hosts []*model.Host
 	hash := map[string]interface{}{}
 	for _, node := range cluster.Nodes {
@@ -577,7 +629,7 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 			Port:   22,
 			Status: constant.ClusterCreating,
 		}
-		if cluster.Plan.Region.Provider != constant.OpenStack {
+		if !useFlavor(cluster.Plan.Region.Provider) {
 			planVars := map[string]string{}
 			_ = json.Unmarshal([]byte(cluster.Plan.Vars), &planVars)
 			role := getHostRole(newHost.Name)
@@ -609,27 +661,27 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 			return nil, err
 		}
 	}
+	if err := assignHostImages(cluster.Plan, newHosts); err != nil {
+		return nil, err
+	}
 
 	var projectResource model.ProjectResource
-	if err := db.DB.Where("resource_id = ? AND resource_type = ?", cluster.ID, constant.ResourceCluster).First(&projectResource).Error; err != nil {
+	if err := tx.Where("resource_id = ? AND resource_type = ?", cluster.ID, constant.ResourceCluster).First(&projectResource).Error; err != nil {
 		return nil, fmt.Errorf("can not find project resource %s", err.Error())
 	}
 
-	tx := db.DB.Begin()
 	for i := range newHosts {
 		if err := tx.Create(newHosts[i]).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not save host %s reasone %s", newHosts[i].Name, err.Error())
 		}
 		var ip model.Ip
 		if err := tx.Where("address = ?", newHosts[i].Ip).First(&ip).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not save host %s reasone %s", newHosts[i].Name, err.Error())
 		}
 		if ip.ID != "" {
-			ip.Status = constant.IpUsed
-			ip.ClusterID = cluster.ID
-			tx.Save(&ip)
+			if err := model.AllocateIp(tx, &ip, cluster.ID, newHosts[i].Name); err != nil {
+				return nil, fmt.Errorf("can not save host %s reasone %s", newHosts[i].Name, err.Error())
+			}
 		}
 		hostProjectResource := model.ProjectResource{
 			ResourceType: constant.ResourceHost,
@@ -637,7 +689,6 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 			ProjectID:    projectResource.ProjectID,
 		}
 		if err := tx.Create(&hostProjectResource).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not create peroject resource host %s ", newHosts[i].Name)
 		}
 		clusterResource := model.ClusterResource{
@@ -646,11 +697,9 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 			ClusterID:    cluster.ID,
 		}
 		if err := tx.Create(&clusterResource).Error; err != nil {
-			tx.Rollback()
 			return nil, fmt.Errorf("can not create cluster resource host %s ", newHosts[i].Name)
 		}
 	}
-	tx.Commit()
 
 	res := func() []model.Host {
 		var hs []model.Host
@@ -664,7 +713,10 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 
 func (c clusterNodeService) doCreateHosts(cluster *model.Cluster, hosts []*model.Host) error {
 	k := kotf.NewTerraform(&kotf.Config{Cluster: cluster.Name})
-	return doInit(k, cluster.Plan, hosts)
+	if err := doInit(k, cluster.Plan, hosts); err != nil {
+		return err
+	}
+	return NewHostKeyService().VerifyCreated(hosts)
 }
 
 const removeWorkerPlaybook = "96-remove-worker.yml"
@@ -678,7 +730,14 @@ func (c *clusterNodeService) runDeleteWorkerPlaybook(cluster *model.Cluster, nod
 	cluster.LogId = logId
 	db.DB.Save(cluster)
 	cluster.Nodes, _ = c.NodeRepo.List(cluster.Name)
-	inventory := cluster.ParseInventory()
+	lb, err := loadbalancer.LoadForInventory(cluster.ID)
+	if err != nil {
+		return err
+	}
+	if err := cluster.WriteConnectionFiles(lb); err != nil {
+		return err
+	}
+	inventory := cluster.ParseInventory(lb)
 	for i := range inventory.Groups {
 		if inventory.Groups[i].Name == "del-worker" {
 			for _, n := range nodes {
@@ -719,7 +778,14 @@ func (c *clusterNodeService) runAddWorkerPlaybook(cluster *model.Cluster, nodes
 	cluster.LogId = logId
 	db.DB.Save(cluster)
 	cluster.Nodes, _ = c.NodeRepo.List(cluster.Name)
-	inventory := cluster.ParseInventory()
+	lb, err := loadbalancer.LoadForInventory(cluster.ID)
+	if err != nil {
+		return err
+	}
+	if err := cluster.WriteConnectionFiles(lb); err != nil {
+		return err
+	}
+	inventory := cluster.ParseInventory(lb)
 	for i := range inventory.Groups {
 		if inventory.Groups[i].Name == "new-worker" {
 			for _, n := range nodes {
@@ -279,7 +279,7 @@ func (c clusterResourceService) GetResources(resourceType, projectName, clusterN
 
 	if resourceType == constant.ResourceBackupAccount {
 		var backupAccounts []model.BackupAccount
-		if err := db.DB.Raw("SELECT * FROM ko_backup_account WHERE id in (SELECT resource_id FROM ko_project_resource WHERE resource_type='BACKUP_ACCOUNT' AND project_id= ? AND resource_id NOT IN (SELECT resource_id FROM ko_cluster_resource WHERE resource_type='BACKUP_ACCOUNT' AND cluster_id  =?) )", project.ID, cluster.ID).Scan(&backupAccounts).Error; err != nil {
+		if err := db.DB.Raw("SELECT * FROM ko_backup_account WHERE id in (SELECT resource_id FROM ko_project_resource WHERE resource_type='BACKUP_ACCOUNT' AND project_id= ? AND resource_id NOT IN (SELECT resource_id FROM ko_cluster_resource WHERE resource_type='BACKUP_ACCOUNT' AND cluster_id  =?) )", project.ID, cluster.ID).Find(&backupAccounts).Error; err != nil {
 			return nil, err
 		}
 		result = backupAccounts
@@ -126,15 +126,9 @@ func (c clusterStorageProvisionerService) CreateStorageProvisioner(clusterName s
 	if err != nil {
 		return dp, err
 	}
-	var registery model.SystemRegistry
-	if cluster.Spec.Architectures == constant.ArchAMD64 {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&registery).Error; err != nil {
-			return dp, errors.New("load image pull port failed")
-		}
-	} else {
-		if err := db.DB.Where("architecture = ?", constant.ArchitectureOfARM64).First(&registery).Error; err != nil {
-			return dp, errors.New("load image pull port failed")
-		}
+	registery, err := model.GetSystemRegistry(cluster.Spec.Architectures)
+	if err != nil {
+		return dp, errors.New("load image pull port failed")
 	}
 
 	//playbook
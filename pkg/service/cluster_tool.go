@@ -3,18 +3,23 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
 	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
 	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/tools"
 	helm2 "github.com/KubeOperator/KubeOperator/pkg/util/helm"
 	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
-	appv1 "k8s.io/api/apps/v1"
+	"helm.sh/helm/v3/pkg/release"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -26,6 +31,33 @@ type ClusterToolService interface {
 	Enable(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error)
 	Upgrade(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error)
 	Disable(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error)
+	GetValues(clusterName, toolName string) (*dto.ClusterToolValuesPlan, error)
+	PlanValues(clusterName, toolName string, vars map[string]interface{}) (*dto.ClusterToolValuesPlan, error)
+	UpdateValues(clusterName, toolName string, vars map[string]interface{}) (*dto.ClusterToolValuesPlan, error)
+	History(clusterName, toolName string) ([]dto.ClusterToolRevision, error)
+	Rollback(clusterName, toolName string, revision int) (dto.ClusterTool, error)
+}
+
+var (
+	ToolNotEnabled       = "TOOL_NOT_ENABLED"
+	ToolValuesInvalid    = "TOOL_VALUES_INVALID"
+	ToolLogConfigInvalid = "TOOL_LOG_CONFIG_INVALID"
+)
+
+// checkLogConfig validates the log settings of the logging and loki tools before they are stored.
+func checkLogConfig(toolName string, vars map[string]interface{}) error {
+	if _, err := tools.ParseLogConfig(toolName, vars); err != nil {
+		return errorf.New(ToolLogConfigInvalid, err.Error())
+	}
+	return nil
+}
+
+// sealLogConfig encrypts the credentials of the log target of vars, the ones of the stored tool
+// are kept when vars come back without them.
+func sealLogConfig(toolName string, stored model.ClusterTool, vars map[string]interface{}) error {
+	storedVars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(stored.Vars), &storedVars)
+	return tools.SealLogConfig(toolName, storedVars, vars)
 }
 
 func NewClusterToolService() ClusterToolService {
@@ -76,6 +108,12 @@ func (c clusterToolService) GetNodePort(clusterName, toolName, toolVersion, name
 		svcName = "kubepi"
 	case "prometheus":
 		svcName = "prometheus-server"
+	default:
+		var addon model.ClusterAddon
+		if err := db.DB.Where("name = ?", toolName).First(&addon).Error; err != nil {
+			return tool, err
+		}
+		svcName = addon.ServiceName
 	}
 	d, err := kubeClient.CoreV1().Services(namespace).Get(context.TODO(), svcName, metav1.GetOptions{})
 	if err != nil {
@@ -88,122 +126,119 @@ func (c clusterToolService) GetNodePort(clusterName, toolName, toolVersion, name
 	return tool, fmt.Errorf("can't get nodeport %s(%s) from cluster %s", svcName, namespace, clusterName)
 }
 
+// SyncStatus derives the status of the tools from their helm release and the workloads of the
+// release, releases changed outside of KubeOperator are reported as drifted.
 func (c clusterToolService) SyncStatus(clusterName string) ([]dto.ClusterTool, error) {
 	var (
-		cluster   model.Cluster
 		tools     []model.ClusterTool
 		backTools []dto.ClusterTool
 	)
-	if err := db.DB.Where("name = ?", clusterName).Preload("Spec").Preload("Secret").Find(&cluster).Error; err != nil {
+	cluster, hosts, err := c.getBaseParams(clusterName)
+	if err != nil {
 		return backTools, err
 	}
 	if err := db.DB.Where("cluster_id = ?", cluster.ID).Find(&tools).Error; err != nil {
 		return backTools, err
 	}
 	kubeClient, err := kubernetesUtil.NewKubernetesClient(&kubernetesUtil.Config{
-		Hosts: []kubernetesUtil.Host{kubernetesUtil.Host(fmt.Sprintf("%s:%d", cluster.Spec.KubeRouter, cluster.Spec.KubeApiServerPort))},
+		Hosts: hosts,
 		Token: cluster.Secret.KubernetesToken,
 	})
 	if err != nil {
 		return backTools, err
 	}
-	var (
-		allDeployments  []appv1.Deployment
-		allStatefulsets []appv1.StatefulSet
-	)
-	namespaceList, err := kubeClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	// an empty namespace lists the releases of all namespaces
+	h, err := helm2.NewClient(&helm2.Config{
+		Hosts:         hosts,
+		BearerToken:   cluster.Secret.KubernetesToken,
+		Architectures: cluster.Spec.Architectures,
+	})
 	if err != nil {
 		return backTools, err
 	}
-	for _, ns := range namespaceList.Items {
-		deployments, err := kubeClient.AppsV1().Deployments(ns.Name).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			return backTools, err
-		}
-		allDeployments = append(allDeployments, deployments.Items...)
-		statefulsets, err := kubeClient.AppsV1().StatefulSets(ns.Name).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			return backTools, err
-		}
-		allStatefulsets = append(allStatefulsets, statefulsets.Items...)
+	releases, err := h.List()
+	if err != nil {
+		return backTools, err
 	}
 	for _, tool := range tools {
 		dtoItem := dto.ClusterTool{
 			ClusterTool: tool,
 			Vars:        map[string]interface{}{},
 		}
-		isEnable := false
-		sourceName := ""
-		sourceType := "deployment"
-		switch tool.Name {
-		case "registry":
-			sourceName = constant.DefaultRegistryDeploymentName
-		case "chartmuseum":
-			sourceName = constant.DefaultChartmuseumDeploymentName
-		case "kubepi":
-			sourceName = constant.DefaultKubePiDeploymentName
-		case "kubeapps":
-			sourceName = constant.DefaultKubeappsDeploymentName
-		case "grafana":
-			sourceName = constant.DefaultGrafanaDeploymentName
-		case "prometheus":
-			sourceName = constant.DefaultPrometheusDeploymentName
-		case "logging":
-			sourceName = constant.DefaultLoggingStateSetsfulName
-			sourceType = "statefulset"
-		case "loki":
-			sourceName = constant.DefaultLokiStateSetsfulName
-			sourceType = "statefulset"
-		}
-		if sourceType == "deployment" {
-			for _, deploy := range allDeployments {
-				if deploy.ObjectMeta.Name == sourceName {
-					if deploy.Status.ReadyReplicas > 0 {
-						isEnable = true
-						tool.Status = constant.StatusRunning
-					} else {
-						tool.Status = constant.StatusWaiting
-					}
-					dtoItem.Vars["namespace"] = deploy.ObjectMeta.Namespace
-					buf, _ := json.Marshal(&dtoItem.Vars)
-					tool.Vars = string(buf)
-					_ = db.DB.Model(&model.ClusterTool{}).Updates(&tool)
-					break
-				}
+		_ = json.Unmarshal([]byte(tool.Vars), &dtoItem.Vars)
+		namespace, _ := dtoItem.Vars["namespace"].(string)
+		rel := findToolRelease(releases, tool.Name, namespace)
+		busy := isToolBusy(tool)
+		if rel == nil {
+			if !busy && tool.Status != constant.StatusWaiting {
+				tool.Status = constant.StatusWaiting
+				tool.Message = ""
+				_ = c.toolRepo.Save(&tool)
 			}
+			dtoItem.ClusterTool = tool
+			dtoItem.Vars = publicToolVars(dtoItem.Vars)
+			backTools = append(backTools, dtoItem)
+			continue
 		}
-		if sourceType == "statefulset" {
-			for _, statefulset := range allStatefulsets {
-				if statefulset.ObjectMeta.Name == sourceName {
-					if statefulset.Status.ReadyReplicas > 0 {
-						isEnable = true
-						tool.Status = constant.StatusRunning
-					} else {
-						tool.Status = constant.StatusWaiting
-					}
-					dtoItem.Vars["namespace"] = statefulset.ObjectMeta.Namespace
-					buf, _ := json.Marshal(&dtoItem.Vars)
-					tool.Vars = string(buf)
-					_ = db.DB.Model(&model.ClusterTool{}).Updates(&tool)
-					break
-				}
-			}
+
+		var chartVersion string
+		if toolDetail, err := getToolDetail(cluster, tool, tool.Version); err == nil {
+			chartVersion = toolDetail.ChartVersion
 		}
-		if !isEnable {
-			if tool.Status != constant.StatusWaiting {
-				tool.Status = constant.StatusWaiting
-				_ = db.DB.Model(&model.ClusterTool{}).Updates(&tool)
+		dtoItem.Release = toolRelease(kubeClient, rel, tool, chartVersion)
+		if !busy {
+			// releases deployed before their revision was tracked are adopted as they are
+			if tool.ReleaseRevision == 0 && rel.Info.Status == release.StatusDeployed {
+				tool.ReleaseRevision = rel.Version
+				dtoItem.Release.Drifted = false
+				dtoItem.Release.DriftMessage = ""
 			}
+			tool.Status, tool.Message = toolReleaseStatus(dtoItem.Release)
+			dtoItem.Vars["namespace"] = rel.Namespace
+			buf, _ := json.Marshal(&dtoItem.Vars)
+			tool.Vars = string(buf)
+			_ = c.toolRepo.Save(&tool)
 		}
 		dtoItem.ClusterTool = tool
+		dtoItem.Vars = publicToolVars(dtoItem.Vars)
 		backTools = append(backTools, dtoItem)
 	}
 
-	var h helm2.Client
-	err = h.SyncRepoCharts(cluster.Spec.Architectures)
+	var hc helm2.Client
+	err = hc.SyncRepoCharts(cluster.Spec.Architectures)
 	return backTools, err
 }
 
+// secretToolVar matches the keys of tool vars holding credentials, they are never sent back.
+var secretToolVar = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|access_?key)`)
+
+// publicToolVars copies vars without their credentials, including the user and password of the
+// external log target and the ones embedded in its url.
+func publicToolVars(vars map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		if secretToolVar.MatchString(k) {
+			continue
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			v = publicToolVars(m)
+		}
+		result[k] = v
+	}
+	if config, ok := result[constant.LogConfigVar].(map[string]interface{}); ok {
+		if target, ok := config["target"].(map[string]interface{}); ok {
+			delete(target, "user")
+			if raw, ok := target["url"].(string); ok {
+				if u, err := url.Parse(raw); err == nil && u.User != nil {
+					u.User = nil
+					target["url"] = u.String()
+				}
+			}
+		}
+	}
+	return result
+}
+
 func (c clusterToolService) Disable(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error) {
 	cluster, hosts, err := c.getBaseParams(clusterName)
 	if err != nil {
@@ -235,16 +270,23 @@ func (c clusterToolService) Disable(clusterName string, tool dto.ClusterTool) (d
 }
 
 func (c clusterToolService) Enable(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error) {
+	if err := checkLogConfig(tool.Name, tool.Vars); err != nil {
+		return tool, err
+	}
 	cluster, hosts, err := c.getBaseParams(clusterName)
 	if err != nil {
 		return tool, err
 	}
 
-	var toolDetail model.ClusterToolDetail
-	if err := db.DB.Where("name = ? AND version = ?", tool.Name, tool.Version).Find(&toolDetail).Error; err != nil {
+	toolDetail, err := getToolDetail(cluster, tool.ClusterTool, tool.Version)
+	if err != nil {
 		return tool, err
 	}
 
+	stored, _ := c.toolRepo.Get(clusterName, tool.Name)
+	if err := sealLogConfig(tool.Name, stored, tool.Vars); err != nil {
+		return tool, err
+	}
 	tool.ClusterID = cluster.ID
 	mo := tool.ClusterTool
 	buf, _ := json.Marshal(&tool.Vars)
@@ -278,20 +320,28 @@ func (c clusterToolService) Enable(clusterName string, tool dto.ClusterTool) (dt
 	mo.Status = constant.ClusterInitializing
 	_ = c.toolRepo.Save(&mo)
 	go c.doInstall(ct, &tool.ClusterTool, toolDetail)
+	tool.Vars = publicToolVars(tool.Vars)
 	return tool, nil
 }
 
 func (c clusterToolService) Upgrade(clusterName string, tool dto.ClusterTool) (dto.ClusterTool, error) {
+	if err := checkLogConfig(tool.Name, tool.Vars); err != nil {
+		return tool, err
+	}
 	cluster, hosts, err := c.getBaseParams(clusterName)
 	if err != nil {
 		return tool, err
 	}
 
-	var toolDetail model.ClusterToolDetail
-	if err := db.DB.Where("name = ? AND version = ?", tool.Name, tool.HigherVersion).Find(&toolDetail).Error; err != nil {
+	toolDetail, err := getToolDetail(cluster, tool.ClusterTool, tool.HigherVersion)
+	if err != nil {
 		return tool, err
 	}
 
+	stored, _ := c.toolRepo.Get(clusterName, tool.Name)
+	if err := sealLogConfig(tool.Name, stored, tool.Vars); err != nil {
+		return tool, err
+	}
 	tool.ClusterID = cluster.ID
 	mo := tool.ClusterTool
 	buf, _ := json.Marshal(&tool.Vars)
@@ -315,9 +365,147 @@ func (c clusterToolService) Upgrade(clusterName string, tool dto.ClusterTool) (d
 
 	_ = c.toolRepo.Save(&mo)
 	go c.doUpgrade(ct, &tool.ClusterTool, toolDetail)
+	tool.Vars = publicToolVars(tool.Vars)
 	return tool, nil
 }
 
+// GetValues plans the stored vars of the tool, the changes show what differs between the release
+// and the values the next upgrade would apply.
+func (c clusterToolService) GetValues(clusterName, toolName string) (*dto.ClusterToolValuesPlan, error) {
+	return c.PlanValues(clusterName, toolName, nil)
+}
+
+// PlanValues renders vars like an upgrade to the current version of the tool would, without
+// touching the release. Nil vars plans the stored vars.
+func (c clusterToolService) PlanValues(clusterName, toolName string, vars map[string]interface{}) (*dto.ClusterToolValuesPlan, error) {
+	_, plan, err := c.planValues(clusterName, toolName, vars)
+	if plan != nil {
+		plan.Vars = publicToolVars(plan.Vars)
+	}
+	return plan, err
+}
+
+// UpdateValues upgrades the tool to its current version with vars, vars the chart schema rejects
+// are never applied.
+func (c clusterToolService) UpdateValues(clusterName, toolName string, vars map[string]interface{}) (*dto.ClusterToolValuesPlan, error) {
+	tool, plan, err := c.planValues(clusterName, toolName, vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Errors) > 0 {
+		return plan, errorf.New(ToolValuesInvalid, strings.Join(plan.Errors, "; "))
+	}
+	cluster, hosts, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	toolDetail, err := getToolDetail(cluster, tool, tool.Version)
+	if err != nil {
+		return nil, err
+	}
+	namespace, _ := plan.Vars["namespace"].(string)
+	if namespace == "" {
+		return plan, errorf.New(ToolValuesInvalid, "namespace is required")
+	}
+	buf, _ := json.Marshal(&plan.Vars)
+	tool.Vars = string(buf)
+	tool.Status = constant.ClusterUpgrading
+	tool.Message = ""
+	ct, err := tools.NewClusterTool(&tool, cluster, hosts, namespace, namespace, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.toolRepo.Save(&tool); err != nil {
+		return nil, err
+	}
+	go c.doUpgrade(ct, &tool, toolDetail)
+	plan.Vars = publicToolVars(plan.Vars)
+	return plan, nil
+}
+
+func (c clusterToolService) planValues(clusterName, toolName string, vars map[string]interface{}) (model.ClusterTool, *dto.ClusterToolValuesPlan, error) {
+	tool, err := c.toolRepo.Get(clusterName, toolName)
+	if err != nil {
+		return tool, nil, err
+	}
+	if tool.Status == constant.ClusterWaiting {
+		return tool, nil, errors.New(ToolNotEnabled)
+	}
+	cluster, hosts, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return tool, nil, err
+	}
+	toolDetail, err := getToolDetail(cluster, tool, tool.Version)
+	if err != nil {
+		return tool, nil, err
+	}
+	stored := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(tool.Vars), &stored)
+	if vars == nil {
+		vars = stored
+	}
+	if err := checkLogConfig(toolName, vars); err != nil {
+		return tool, nil, err
+	}
+	if err := tools.SealLogConfig(toolName, stored, vars); err != nil {
+		return tool, nil, err
+	}
+	// the namespace of a release can not be changed by an upgrade
+	namespace, _ := stored["namespace"].(string)
+	if namespace == "" {
+		namespace = constant.DefaultNamespace
+	}
+	vars["namespace"] = namespace
+
+	planned := tool
+	buf, _ := json.Marshal(&vars)
+	planned.Vars = string(buf)
+	p, err := tools.PlanUpgrade(&planned, cluster, hosts, namespace, toolDetail)
+	if err != nil {
+		return tool, nil, err
+	}
+	plan := &dto.ClusterToolValuesPlan{
+		Name:         tool.Name,
+		Version:      tool.Version,
+		ChartName:    p.ChartName,
+		ChartVersion: p.ChartVersion,
+		Schema:       p.Schema,
+		Vars:         p.Vars,
+		Current:      p.Current,
+		Proposed:     p.Proposed,
+		Changes:      []dto.ClusterToolValueChange{},
+		Errors:       p.Errors,
+	}
+	for _, change := range p.Changes {
+		plan.Changes = append(plan.Changes, dto.ClusterToolValueChange(change))
+	}
+	return tool, plan, nil
+}
+
+// getToolDetail returns the detail of version of tool matching the architecture of the cluster,
+// add-ons of the catalog may register a chart per architecture.
+func getToolDetail(cluster model.Cluster, tool model.ClusterTool, version string) (model.ClusterToolDetail, error) {
+	var details []model.ClusterToolDetail
+	if err := db.DB.Where("name = ? AND version = ?", tool.Name, version).Find(&details).Error; err != nil {
+		return model.ClusterToolDetail{}, err
+	}
+	if len(details) == 0 {
+		return model.ClusterToolDetail{}, fmt.Errorf("tool %s of version %s not found", tool.Name, version)
+	}
+	arch := cluster.Spec.Architectures
+	if arch == constant.ArchAll && tool.Architecture == constant.ArchAMD64 {
+		arch = constant.ArchAMD64
+	}
+	for _, want := range []string{arch, constant.ArchAll} {
+		for _, d := range details {
+			if d.Architecture == want {
+				return d, nil
+			}
+		}
+	}
+	return model.ClusterToolDetail{}, fmt.Errorf("tool %s of version %s has no chart for architecture %s", tool.Name, version, arch)
+}
+
 func (c clusterToolService) doInstall(p tools.Interface, tool *model.ClusterTool, toolDetail model.ClusterToolDetail) {
 	err := p.Install(toolDetail)
 	if err != nil {
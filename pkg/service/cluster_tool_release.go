@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	helm2 "github.com/KubeOperator/KubeOperator/pkg/util/helm"
+	"github.com/ghodss/yaml"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ToolBusy             = "TOOL_BUSY"
+	ToolReleaseNotFound  = "TOOL_RELEASE_NOT_FOUND"
+	ToolRevisionNotFound = "TOOL_REVISION_NOT_FOUND"
+)
+
+func isToolBusy(tool model.ClusterTool) bool {
+	return tool.Status == constant.ClusterInitializing || tool.Status == constant.ClusterUpgrading || tool.Status == constant.ClusterTerminating
+}
+
+// findToolRelease returns the release of the tool, the one in the namespace of the tool wins when
+// a release of the same name exists in several namespaces.
+func findToolRelease(releases []*release.Release, name, namespace string) *release.Release {
+	var found *release.Release
+	for _, rel := range releases {
+		if rel.Name != name {
+			continue
+		}
+		if rel.Namespace == namespace {
+			return rel
+		}
+		if found == nil {
+			found = rel
+		}
+	}
+	return found
+}
+
+// toolRelease describes rel and the readiness of its workloads. The release drifted when its
+// revision is not the one KubeOperator deployed or its chart is not the one of the tool version.
+func toolRelease(kubeClient *kubernetes.Clientset, rel *release.Release, tool model.ClusterTool, chartVersion string) *dto.ClusterToolRelease {
+	result := &dto.ClusterToolRelease{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Workloads: releaseWorkloads(kubeClient, rel),
+	}
+	if rel.Info != nil {
+		result.Status = rel.Info.Status.String()
+		result.Updated = rel.Info.LastDeployed.Time
+		result.Description = rel.Info.Description
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		result.Chart = rel.Chart.Metadata.Name
+		result.ChartVersion = rel.Chart.Metadata.Version
+		result.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	var drifts []string
+	if tool.ReleaseRevision != 0 && tool.ReleaseRevision != rel.Version {
+		drifts = append(drifts, fmt.Sprintf("revision %d is not the revision %d deployed by KubeOperator", rel.Version, tool.ReleaseRevision))
+	}
+	if chartVersion != "" && result.ChartVersion != "" && chartVersion != result.ChartVersion {
+		drifts = append(drifts, fmt.Sprintf("chart version %s is not the version %s of tool %s", result.ChartVersion, chartVersion, tool.Version))
+	}
+	result.Drifted = len(drifts) > 0
+	result.DriftMessage = strings.Join(drifts, "; ")
+	return result
+}
+
+type manifestObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// releaseWorkloads returns the deployments, statefulsets and daemonsets rendered by the release.
+func releaseWorkloads(kubeClient *kubernetes.Clientset, rel *release.Release) []dto.ClusterToolWorkload {
+	workloads := []dto.ClusterToolWorkload{}
+	for _, manifest := range releaseutil.SplitManifests(rel.Manifest) {
+		var obj manifestObject
+		if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil || obj.Metadata.Name == "" {
+			continue
+		}
+		w := dto.ClusterToolWorkload{Kind: obj.Kind, Name: obj.Metadata.Name, Namespace: obj.Metadata.Namespace}
+		if w.Namespace == "" {
+			w.Namespace = rel.Namespace
+		}
+		switch obj.Kind {
+		case "Deployment":
+			d, err := kubeClient.AppsV1().Deployments(w.Namespace).Get(context.TODO(), w.Name, metav1.GetOptions{})
+			if err != nil {
+				w.Missing = true
+				break
+			}
+			w.Desired, w.Ready = 1, d.Status.ReadyReplicas
+			if d.Spec.Replicas != nil {
+				w.Desired = *d.Spec.Replicas
+			}
+		case "StatefulSet":
+			s, err := kubeClient.AppsV1().StatefulSets(w.Namespace).Get(context.TODO(), w.Name, metav1.GetOptions{})
+			if err != nil {
+				w.Missing = true
+				break
+			}
+			w.Desired, w.Ready = 1, s.Status.ReadyReplicas
+			if s.Spec.Replicas != nil {
+				w.Desired = *s.Spec.Replicas
+			}
+		case "DaemonSet":
+			ds, err := kubeClient.AppsV1().DaemonSets(w.Namespace).Get(context.TODO(), w.Name, metav1.GetOptions{})
+			if err != nil {
+				w.Missing = true
+				break
+			}
+			w.Desired, w.Ready = ds.Status.DesiredNumberScheduled, ds.Status.NumberReady
+		default:
+			continue
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads
+}
+
+// toolReleaseStatus maps the release to the status of the tool. A deployed release is running once
+// every workload of it is ready and progressing while some are still rolling out, it only fails when
+// a workload of it is missing.
+func toolReleaseStatus(rel *dto.ClusterToolRelease) (string, string) {
+	switch release.Status(rel.Status) {
+	case release.StatusDeployed:
+		var missing, notReady []string
+		for _, w := range rel.Workloads {
+			if w.Missing {
+				missing = append(missing, fmt.Sprintf("%s %s/%s is missing", w.Kind, w.Namespace, w.Name))
+			} else if w.Ready < w.Desired {
+				notReady = append(notReady, fmt.Sprintf("%s %s/%s is %d/%d ready", w.Kind, w.Namespace, w.Name, w.Ready, w.Desired))
+			}
+		}
+		if len(missing) > 0 {
+			return constant.ClusterFailed, strings.Join(append(missing, notReady...), "; ")
+		}
+		if len(notReady) > 0 {
+			return constant.ClusterProgressing, strings.Join(notReady, "; ")
+		}
+		return constant.ClusterRunning, rel.DriftMessage
+	case release.StatusPendingInstall:
+		return constant.ClusterInitializing, rel.Description
+	case release.StatusPendingUpgrade, release.StatusPendingRollback:
+		return constant.ClusterUpgrading, rel.Description
+	case release.StatusUninstalling:
+		return constant.ClusterTerminating, rel.Description
+	case release.StatusUninstalled:
+		return constant.ClusterWaiting, ""
+	default:
+		return constant.ClusterFailed, rel.Description
+	}
+}
+
+func (c clusterToolService) toolHelmClient(clusterName, toolName string) (model.Cluster, model.ClusterTool, *helm2.Client, error) {
+	tool, err := c.toolRepo.Get(clusterName, toolName)
+	if err != nil {
+		return model.Cluster{}, tool, nil, err
+	}
+	cluster, hosts, err := c.getBaseParams(clusterName)
+	if err != nil {
+		return cluster, tool, nil, err
+	}
+	namespace, _ := toolVars(tool)["namespace"].(string)
+	if namespace == "" {
+		namespace = constant.DefaultNamespace
+	}
+	h, err := helm2.NewClient(&helm2.Config{
+		Hosts:         hosts,
+		BearerToken:   cluster.Secret.KubernetesToken,
+		OldNamespace:  namespace,
+		Namespace:     namespace,
+		Architectures: cluster.Spec.Architectures,
+	})
+	return cluster, tool, h, err
+}
+
+// History returns the revisions of the release of the tool, the newest first.
+func (c clusterToolService) History(clusterName, toolName string) ([]dto.ClusterToolRevision, error) {
+	_, tool, h, err := c.toolHelmClient(clusterName, toolName)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := h.History(tool.Name)
+	if err != nil {
+		return nil, errors.New(ToolReleaseNotFound)
+	}
+	revisions := []dto.ClusterToolRevision{}
+	for i := len(rs) - 1; i >= 0; i-- {
+		r := rs[i]
+		item := dto.ClusterToolRevision{
+			Revision:    r.Version,
+			Status:      r.Info.Status.String(),
+			Updated:     r.Info.LastDeployed.Time,
+			Description: r.Info.Description,
+			Current:     r.Version == tool.ReleaseRevision,
+		}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			item.Chart = r.Chart.Metadata.Name
+			item.ChartVersion = r.Chart.Metadata.Version
+			item.AppVersion = r.Chart.Metadata.AppVersion
+		}
+		revisions = append(revisions, item)
+	}
+	return revisions, nil
+}
+
+// Rollback rolls the release of the tool back to revision in the background, the version of the
+// tool follows the chart of the revision.
+func (c clusterToolService) Rollback(clusterName, toolName string, revision int) (dto.ClusterTool, error) {
+	cluster, tool, h, err := c.toolHelmClient(clusterName, toolName)
+	if err != nil {
+		return dto.ClusterTool{}, err
+	}
+	if isToolBusy(tool) {
+		return dto.ClusterTool{}, errors.New(ToolBusy)
+	}
+	rs, err := h.History(tool.Name)
+	if err != nil {
+		return dto.ClusterTool{}, errors.New(ToolReleaseNotFound)
+	}
+	var target *release.Release
+	for _, r := range rs {
+		if r.Version == revision {
+			target = r
+		}
+	}
+	if target == nil {
+		return dto.ClusterTool{}, errorf.New(ToolRevisionNotFound, revision)
+	}
+	tool.Status = constant.ClusterUpgrading
+	tool.Message = ""
+	if err := c.toolRepo.Save(&tool); err != nil {
+		return dto.ClusterTool{}, err
+	}
+	go c.doRollback(h, cluster, &tool, target)
+	return dto.ClusterTool{ClusterTool: tool, Vars: toolVars(tool)}, nil
+}
+
+func (c clusterToolService) doRollback(h *helm2.Client, cluster model.Cluster, tool *model.ClusterTool, target *release.Release) {
+	rel, err := h.Rollback(tool.Name, target.Version)
+	if err != nil {
+		logger.Log.Errorf("rollback tool %s failed: %+v", tool.Name, err)
+		tool.Status = constant.ClusterFailed
+		tool.Message = err.Error()
+		_ = c.toolRepo.Save(tool)
+		return
+	}
+	logger.Log.Infof("rollback tool %s to revision %d successful", tool.Name, target.Version)
+	tool.Status = constant.ClusterRunning
+	tool.ReleaseRevision = rel.Version
+	tool.Vars = rollbackToolVars(*tool, target.Config)
+	if target.Chart != nil && target.Chart.Metadata != nil {
+		var details []model.ClusterToolDetail
+		db.DB.Where("name = ? AND chart_version = ?", tool.Name, target.Chart.Metadata.Version).Find(&details)
+		for _, d := range details {
+			if d.Architecture == cluster.Spec.Architectures || d.Architecture == constant.ArchAll || len(details) == 1 {
+				// the version rolled back from is offered as upgrade again
+				if d.Version != tool.Version {
+					tool.HigherVersion = tool.Version
+					tool.Version = d.Version
+				}
+				break
+			}
+		}
+	}
+	_ = c.toolRepo.Save(tool)
+}
+
+// rollbackToolVars returns the vars of the tool for the values of the revision rolled back to, so the
+// next upgrade renders them again. The namespace and the sealed log config are not values of the
+// release and stay as they are, lists only come from structured values and are rendered by the tool.
+func rollbackToolVars(tool model.ClusterTool, config map[string]interface{}) string {
+	current := toolVars(tool)
+	vars := map[string]interface{}{}
+	flattenToolValues("", config, vars)
+	for _, k := range []string{"namespace", constant.LogConfigVar} {
+		if v, ok := current[k]; ok {
+			vars[k] = v
+		}
+	}
+	buf, _ := json.Marshal(&vars)
+	return string(buf)
+}
+
+// flattenToolValues writes the scalar values as the dotted vars the values are parsed from, dots of
+// keys are escaped like the node selector labels of the vars.
+func flattenToolValues(prefix string, values map[string]interface{}, vars map[string]interface{}) {
+	for k, v := range values {
+		key := strings.ReplaceAll(k, ".", "\\.")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flattenToolValues(key, value, vars)
+		case []interface{}, nil:
+			// lists and unset values can not be written as vars
+		default:
+			vars[key] = value
+		}
+	}
+}
+
+func toolVars(tool model.ClusterTool) map[string]interface{} {
+	vars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(tool.Vars), &vars)
+	return vars
+}
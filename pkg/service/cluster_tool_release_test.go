@@ -0,0 +1,73 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestToolReleaseStatus(t *testing.T) {
+	ready := dto.ClusterToolWorkload{Kind: "Deployment", Name: "grafana", Desired: 1, Ready: 1}
+	rolling := dto.ClusterToolWorkload{Kind: "Deployment", Name: "grafana", Desired: 2, Ready: 1}
+	missing := dto.ClusterToolWorkload{Kind: "StatefulSet", Name: "loki", Missing: true}
+	tests := []struct {
+		name      string
+		status    release.Status
+		workloads []dto.ClusterToolWorkload
+		want      string
+	}{
+		{name: "ready", status: release.StatusDeployed, workloads: []dto.ClusterToolWorkload{ready}, want: constant.ClusterRunning},
+		{name: "rolling out", status: release.StatusDeployed, workloads: []dto.ClusterToolWorkload{ready, rolling}, want: constant.ClusterProgressing},
+		{name: "missing", status: release.StatusDeployed, workloads: []dto.ClusterToolWorkload{rolling, missing}, want: constant.ClusterFailed},
+		{name: "failed release", status: release.StatusFailed, workloads: []dto.ClusterToolWorkload{ready}, want: constant.ClusterFailed},
+		{name: "pending upgrade", status: release.StatusPendingUpgrade, want: constant.ClusterUpgrading},
+	}
+	for _, tt := range tests {
+		status, _ := toolReleaseStatus(&dto.ClusterToolRelease{Status: tt.status.String(), Workloads: tt.workloads})
+		if status != tt.want {
+			t.Errorf("%s: want %s, got %s", tt.name, tt.want, status)
+		}
+	}
+	if isToolBusy(model.ClusterTool{Status: constant.ClusterProgressing}) {
+		t.Error("a progressing tool must be synced again")
+	}
+}
+
+func TestRollbackToolVars(t *testing.T) {
+	tool := model.ClusterTool{Vars: `{"namespace":"monitor","replicas":3,"image.tag":"v2",` +
+		`"` + constant.LogConfigVar + `":{"target":{"type":"elasticsearch"}}}`}
+	config := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"tag": "v1"},
+		"nodeSelector": map[string]interface{}{
+			"kubernetes.io/arch": "amd64",
+		},
+		"extraArgs": []interface{}{"--a"},
+		"unset":     nil,
+	}
+	vars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(rollbackToolVars(tool, config)), &vars); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"namespace":                        "monitor",
+		"replicas":                         float64(1),
+		"image.tag":                        "v1",
+		`nodeSelector.kubernetes\.io/arch`: "amd64",
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("%s: want %v, got %v", k, v, vars[k])
+		}
+	}
+	if _, ok := vars[constant.LogConfigVar]; !ok {
+		t.Error("want the sealed log config kept")
+	}
+	if len(vars) != len(want)+1 {
+		t.Errorf("want only the values of the revision, got %v", vars)
+	}
+}
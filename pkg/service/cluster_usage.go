@@ -0,0 +1,355 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
+	"github.com/jinzhu/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var UsageScopeInvalid = "USAGE_SCOPE_INVALID"
+
+const usageDateLayout = "2006-01-02"
+
+type ClusterUsageService interface {
+	Get(clusterName string) (*dto.ClusterUsage, error)
+	Collect(clusterName string) (*dto.ClusterUsage, error)
+	Report(query dto.UsageReportQuery) ([]dto.UsageReportItem, error)
+	ExportCSV(query dto.UsageReportQuery) ([]byte, error)
+}
+
+type clusterUsageService struct {
+	clusterService ClusterService
+}
+
+func NewClusterUsageService() ClusterUsageService {
+	return &clusterUsageService{
+		clusterService: NewClusterService(),
+	}
+}
+
+// Get reads the requests and the allocatable resources from the cluster and the usage from the
+// metrics API, without metrics-server only the usage is missing and without access to the claims
+// only the storage.
+func (c clusterUsageService) Get(clusterName string) (*dto.ClusterUsage, error) {
+	secret, err := c.clusterService.GetSecrets(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := c.clusterService.GetApiServerEndpoints(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubernetesUtil.NewKubernetesClient(&kubernetesUtil.Config{
+		Hosts: endpoints,
+		Token: secret.KubernetesToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := kubeClient.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := dto.ClusterUsage{ClusterName: clusterName, MetricsAvailable: true, StorageAvailable: true}
+	var messages []string
+	metrics, err := kubernetesUtil.GetMetricsUsage(kubeClient)
+	if err != nil {
+		result.MetricsAvailable = false
+		messages = append(messages, fmt.Sprintf("metrics API is not available: %s", err.Error()))
+	}
+	result.Nodes, result.Namespaces, result.Total = kubernetesUtil.AggregateUsage(nodes.Items, pods.Items, metrics)
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		result.StorageAvailable = false
+		messages = append(messages, fmt.Sprintf("persistent volume claims are not available: %s", err.Error()))
+	} else {
+		result.Storage = kubernetesUtil.AggregateStorage(pvcs.Items)
+	}
+	result.Message = strings.Join(messages, "; ")
+	return &result, nil
+}
+
+// Collect adds the current usage to the aggregates of the day.
+func (c clusterUsageService) Collect(clusterName string) (*dto.ClusterUsage, error) {
+	var cluster model.Cluster
+	if err := db.DB.Where("name = ?", clusterName).Preload("Spec").Preload("Plan").Preload("Nodes").Preload("Nodes.Host").First(&cluster).Error; err != nil {
+		return nil, err
+	}
+	usage, err := c.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	date := time.Now().Format(usageDateLayout)
+	samples := map[string][]kubernetesUtil.ResourceUsage{
+		constant.UsageScopeCluster:   {usage.Total},
+		constant.UsageScopeNode:      usage.Nodes,
+		constant.UsageScopeNamespace: usage.Namespaces,
+	}
+	capacities := nodeCapacities(cluster)
+	project, err := clusterProject(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.DB.Begin()
+	for scope, items := range samples {
+		for _, item := range items {
+			var capacity *nodeCapacity
+			if scope == constant.UsageScopeNode {
+				if nc, ok := capacities[item.Name]; ok {
+					capacity = &nc
+				}
+			}
+			if err := addUsageSample(tx, cluster, project, date, scope, item, capacity); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	for _, item := range usage.Storage {
+		if err := addStorageSample(tx, cluster, project, date, item); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	tx.Commit()
+	return usage, nil
+}
+
+// nodeCapacity is what a node is priced by, it is kept with the usage of the day so the cost of
+// past days does not follow later changes of the nodes.
+type nodeCapacity struct {
+	hostName string
+	vmConfig string
+	cpuCore  int
+	memory   int
+	gpuNum   int
+}
+
+// nodeCapacities returns the capacity of the nodes of the cluster by node name. The nodes of plan
+// clusters also carry the vm config of their role, its size stands in for hosts not yet gathered.
+func nodeCapacities(cluster model.Cluster) map[string]nodeCapacity {
+	vars := map[string]string{}
+	if cluster.Spec.Provider == constant.ClusterProviderPlan {
+		_ = json.Unmarshal([]byte(cluster.Plan.Vars), &vars)
+	}
+	vmConfigs := map[string]model.VmConfig{}
+	result := map[string]nodeCapacity{}
+	for _, node := range cluster.Nodes {
+		capacity := nodeCapacity{hostName: node.Host.Name, cpuCore: node.Host.CpuCore, memory: node.Host.Memory, gpuNum: node.Host.GpuNum}
+		capacity.vmConfig = vars["workerModel"]
+		if node.Role == constant.NodeRoleNameMaster {
+			capacity.vmConfig = vars["masterModel"]
+		}
+		if capacity.vmConfig != "" && capacity.cpuCore == 0 {
+			vmConfig, ok := vmConfigs[capacity.vmConfig]
+			if !ok {
+				db.DB.Where("name = ?", capacity.vmConfig).First(&vmConfig)
+				vmConfigs[capacity.vmConfig] = vmConfig
+			}
+			capacity.cpuCore, capacity.memory = vmConfig.Cpu, vmConfig.Memory*1024
+		}
+		result[node.Name] = capacity
+	}
+	return result
+}
+
+func addUsageSample(tx *gorm.DB, cluster model.Cluster, project, date, scope string, item kubernetesUtil.ResourceUsage, capacity *nodeCapacity) error {
+	var u model.ClusterUsage
+	if tx.Where("date = ? AND cluster_id = ? AND scope = ? AND name = ?", date, cluster.ID, scope, item.Name).First(&u).RecordNotFound() {
+		u = model.ClusterUsage{Date: date, ClusterID: cluster.ID, ClusterName: cluster.Name, Scope: scope, Name: item.Name}
+	}
+	u.ProjectName = project
+	n := int64(u.Samples)
+	avg := func(old, v int64) int64 { return (old*n + v) / (n + 1) }
+	u.CpuRequested = avg(u.CpuRequested, item.CpuRequested)
+	u.CpuAllocatable = avg(u.CpuAllocatable, item.CpuAllocatable)
+	u.CpuUsed = avg(u.CpuUsed, item.CpuUsed)
+	u.MemoryRequested = avg(u.MemoryRequested, item.MemoryRequested)
+	u.MemoryAllocatable = avg(u.MemoryAllocatable, item.MemoryAllocatable)
+	u.MemoryUsed = avg(u.MemoryUsed, item.MemoryUsed)
+	if item.CpuUsed > u.CpuUsedPeak {
+		u.CpuUsedPeak = item.CpuUsed
+	}
+	if item.MemoryUsed > u.MemoryUsedPeak {
+		u.MemoryUsedPeak = item.MemoryUsed
+	}
+	if capacity != nil {
+		u.HostName, u.VmConfig = capacity.hostName, capacity.vmConfig
+		u.CpuCore, u.Memory, u.GpuNum = capacity.cpuCore, capacity.memory, capacity.gpuNum
+	}
+	u.Samples++
+	return tx.Save(&u).Error
+}
+
+func addStorageSample(tx *gorm.DB, cluster model.Cluster, project, date string, item kubernetesUtil.StorageUsage) error {
+	var u model.ClusterUsage
+	if tx.Where("date = ? AND cluster_id = ? AND scope = ? AND name = ? AND storage_class = ?", date, cluster.ID, constant.UsageScopeStorage, item.Namespace, item.StorageClass).First(&u).RecordNotFound() {
+		u = model.ClusterUsage{Date: date, ClusterID: cluster.ID, ClusterName: cluster.Name, Scope: constant.UsageScopeStorage, Name: item.Namespace, StorageClass: item.StorageClass}
+	}
+	u.ProjectName = project
+	n := int64(u.Samples)
+	u.StorageRequested = (u.StorageRequested*n + item.Requested) / (n + 1)
+	u.Samples++
+	return tx.Save(&u).Error
+}
+
+// Report averages the daily aggregates between start and end, by default the last 30 days. The
+// project scope sums the clusters of each project day by day, by the project recorded with the
+// usage so deleted and moved clusters keep their history.
+func (c clusterUsageService) Report(query dto.UsageReportQuery) ([]dto.UsageReportItem, error) {
+	if query.Scope == "" {
+		query.Scope = constant.UsageScopeCluster
+	}
+	scope := query.Scope
+	switch scope {
+	case constant.UsageScopeCluster, constant.UsageScopeNode, constant.UsageScopeNamespace:
+	case constant.UsageScopeProject:
+		scope = constant.UsageScopeCluster
+	default:
+		return nil, errors.New(UsageScopeInvalid)
+	}
+	if query.End == "" {
+		query.End = time.Now().Format(usageDateLayout)
+	}
+	if query.Start == "" {
+		end, err := time.Parse(usageDateLayout, query.End)
+		if err != nil {
+			return nil, err
+		}
+		query.Start = end.AddDate(0, 0, -29).Format(usageDateLayout)
+	}
+
+	tx := db.DB.Where("scope = ? AND date >= ? AND date <= ?", scope, query.Start, query.End)
+	if query.ClusterName != "" {
+		tx = tx.Where("cluster_name = ?", query.ClusterName)
+	}
+	if query.ProjectName != "" {
+		tx = tx.Where("project_name = ?", query.ProjectName)
+	}
+	var usages []model.ClusterUsage
+	if err := tx.Order("date").Find(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	type day struct {
+		key  string
+		date string
+	}
+	items := map[string]*dto.UsageReportItem{}
+	daily := map[day]*model.ClusterUsage{}
+	for i := range usages {
+		u := usages[i]
+		project := u.ProjectName
+		key := u.ClusterName + "/" + u.Name
+		item := dto.UsageReportItem{ProjectName: project, ClusterName: u.ClusterName, Scope: query.Scope, Name: u.Name}
+		if query.Scope == constant.UsageScopeProject {
+			if project == "" {
+				continue
+			}
+			key = project
+			item = dto.UsageReportItem{ProjectName: project, Scope: query.Scope, Name: project}
+		}
+		if _, ok := items[key]; !ok {
+			items[key] = &item
+		}
+		d, ok := daily[day{key, u.Date}]
+		if !ok {
+			daily[day{key, u.Date}] = &u
+			continue
+		}
+		d.CpuRequested += u.CpuRequested
+		d.CpuAllocatable += u.CpuAllocatable
+		d.CpuUsed += u.CpuUsed
+		d.CpuUsedPeak += u.CpuUsedPeak
+		d.MemoryRequested += u.MemoryRequested
+		d.MemoryAllocatable += u.MemoryAllocatable
+		d.MemoryUsed += u.MemoryUsed
+		d.MemoryUsedPeak += u.MemoryUsedPeak
+	}
+	for k, d := range daily {
+		item := items[k.key]
+		item.Days++
+		item.CpuRequested += d.CpuRequested
+		item.CpuAllocatable += d.CpuAllocatable
+		item.CpuUsed += d.CpuUsed
+		item.MemoryRequested += d.MemoryRequested
+		item.MemoryAllocatable += d.MemoryAllocatable
+		item.MemoryUsed += d.MemoryUsed
+		if d.CpuUsedPeak > item.CpuUsedPeak {
+			item.CpuUsedPeak = d.CpuUsedPeak
+		}
+		if d.MemoryUsedPeak > item.MemoryUsedPeak {
+			item.MemoryUsedPeak = d.MemoryUsedPeak
+		}
+	}
+	result := []dto.UsageReportItem{}
+	for _, item := range items {
+		days := int64(item.Days)
+		item.CpuRequested /= days
+		item.CpuAllocatable /= days
+		item.CpuUsed /= days
+		item.MemoryRequested /= days
+		item.MemoryAllocatable /= days
+		item.MemoryUsed /= days
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ClusterName != result[j].ClusterName {
+			return result[i].ClusterName < result[j].ClusterName
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// clusterProject returns the name of the project of the cluster.
+func clusterProject(clusterID string) (string, error) {
+	var resource model.ProjectResource
+	err := db.DB.Where("resource_id = ? AND resource_type = ?", clusterID, constant.ResourceCluster).Preload("Project").First(&resource).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", nil
+	}
+	return resource.Project.Name, err
+}
+
+func (c clusterUsageService) ExportCSV(query dto.UsageReportQuery) ([]byte, error) {
+	items, err := c.Report(query)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"project", "cluster", "scope", "name", "days",
+		"cpu_requested_m", "cpu_allocatable_m", "cpu_used_m", "cpu_used_peak_m",
+		"memory_requested_bytes", "memory_allocatable_bytes", "memory_used_bytes", "memory_used_peak_bytes"})
+	for _, i := range items {
+		_ = w.Write([]string{i.ProjectName, i.ClusterName, i.Scope, i.Name, fmt.Sprint(i.Days),
+			fmt.Sprint(i.CpuRequested), fmt.Sprint(i.CpuAllocatable), fmt.Sprint(i.CpuUsed), fmt.Sprint(i.CpuUsedPeak),
+			fmt.Sprint(i.MemoryRequested), fmt.Sprint(i.MemoryAllocatable), fmt.Sprint(i.MemoryUsed), fmt.Sprint(i.MemoryUsedPeak)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.Log.Errorf("write usage report failed: %s", err.Error())
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	CostRateVmConfigNotFound = "COST_RATE_VM_CONFIG_NOT_FOUND"
+	CostRateSplitMissing     = "COST_RATE_SPLIT_MISSING"
+	CostMonthInvalid         = "COST_MONTH_INVALID"
+	CostScopeInvalid         = "COST_SCOPE_INVALID"
+)
+
+const (
+	costMonthLayout = "2006-01"
+	gib             = 1 << 30
+)
+
+type CostService interface {
+	ListRates() ([]dto.CostRate, error)
+	SaveRate(req dto.CostRateCreate) (*dto.CostRate, error)
+	DeleteRate(id string) error
+	Report(query dto.CostReportQuery) ([]dto.CostReportItem, error)
+	ExportCSV(query dto.CostReportQuery) ([]byte, error)
+}
+
+type costService struct {
+}
+
+func NewCostService() CostService {
+	return &costService{}
+}
+
+func (c costService) ListRates() ([]dto.CostRate, error) {
+	var rates []model.CostRate
+	if err := db.DB.Order("type, name").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	result := []dto.CostRate{}
+	for _, r := range rates {
+		result = append(result, dto.CostRate{CostRate: r})
+	}
+	return result, nil
+}
+
+// SaveRate creates the rate of the type and name or replaces the prices of the existing one. The
+// instance price of a vm config is split by cpu and memory prices, its own or the default host's.
+func (c costService) SaveRate(req dto.CostRateCreate) (*dto.CostRate, error) {
+	if req.Type == constant.CostRateVmConfig && req.InstanceMonth > 0 && req.CpuMonth == 0 && req.MemoryMonth == 0 {
+		var count int
+		if err := db.DB.Model(&model.CostRate{}).Where("type = ? AND name = ?", constant.CostRateHost, "").Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New(CostRateSplitMissing)
+		}
+	}
+	if req.Type == constant.CostRateVmConfig && req.Name != "" {
+		var count int
+		if err := db.DB.Model(&model.VmConfig{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errorf.New(CostRateVmConfigNotFound, req.Name)
+		}
+	}
+	var rate model.CostRate
+	if err := db.DB.Where("type = ? AND name = ?", req.Type, req.Name).First(&rate).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+	rate.Type = req.Type
+	rate.Name = req.Name
+	rate.CpuMonth = req.CpuMonth
+	rate.MemoryMonth = req.MemoryMonth
+	rate.GpuMonth = req.GpuMonth
+	rate.StorageMonth = req.StorageMonth
+	rate.InstanceMonth = req.InstanceMonth
+	if err := db.DB.Save(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &dto.CostRate{CostRate: rate}, nil
+}
+
+func (c costService) DeleteRate(id string) error {
+	return db.DB.Where("id = ?", id).Delete(&model.CostRate{}).Error
+}
+
+// costRates holds the rates by type and name.
+type costRates map[string]map[string]model.CostRate
+
+func (r costRates) get(rateType, name string) (model.CostRate, bool) {
+	if rate, ok := r[rateType][name]; ok && name != "" {
+		return rate, true
+	}
+	rate, ok := r[rateType][""]
+	return rate, ok
+}
+
+// nodeCost is the monthly cost of a node, split into the cpu and the memory pool shared by the
+// namespaces. The node is priced by the host, vm config and capacity recorded with its usage of the
+// day. Gpus are charged to the cpu pool.
+func (r costRates) nodeCost(u model.ClusterUsage) (cpu float64, memory float64) {
+	if rate, ok := r[constant.CostRateHost][u.HostName]; ok && u.HostName != "" {
+		return hostCost(rate, u)
+	}
+	if u.VmConfig != "" {
+		if rate, ok := r.get(constant.CostRateVmConfig, u.VmConfig); ok {
+			return r.instanceCost(rate, u)
+		}
+	}
+	rate, ok := r.get(constant.CostRateHost, "")
+	if !ok {
+		return 0, 0
+	}
+	if u.CpuCore > 0 {
+		return hostCost(rate, u)
+	}
+	return float64(u.CpuAllocatable) / 1000 * rate.CpuMonth, float64(u.MemoryAllocatable) / gib * rate.MemoryMonth
+}
+
+func hostCost(rate model.CostRate, u model.ClusterUsage) (cpu float64, memory float64) {
+	return float64(u.CpuCore)*rate.CpuMonth + float64(u.GpuNum)*rate.GpuMonth, float64(u.Memory) / 1024 * rate.MemoryMonth
+}
+
+// instanceCost splits the instance price of a vm config between the pools in proportion to the
+// cores and memory of the node valued at the cpu and memory prices of the vm config rate, or of
+// the default host rate when the vm config rate has none. Without any price it all goes to cpu.
+func (r costRates) instanceCost(rate model.CostRate, u model.ClusterUsage) (cpu float64, memory float64) {
+	weights := rate
+	if weights.CpuMonth == 0 && weights.MemoryMonth == 0 {
+		weights, _ = r.get(constant.CostRateHost, "")
+	}
+	cores, memoryGib := float64(u.CpuCore), float64(u.Memory)/1024
+	if cores == 0 && memoryGib == 0 {
+		cores, memoryGib = float64(u.CpuAllocatable)/1000, float64(u.MemoryAllocatable)/gib
+	}
+	cpuWeight, memoryWeight := cores*weights.CpuMonth, memoryGib*weights.MemoryMonth
+	if cpuWeight+memoryWeight == 0 {
+		return rate.InstanceMonth, 0
+	}
+	cpu = rate.InstanceMonth * cpuWeight / (cpuWeight + memoryWeight)
+	return cpu, rate.InstanceMonth - cpu
+}
+
+type costItem struct {
+	dto.CostReportItem
+	days map[string]bool
+}
+
+func (i *costItem) add(o *costItem) {
+	i.Compute += o.Compute
+	i.Storage += o.Storage
+	for d := range o.days {
+		i.days[d] = true
+	}
+}
+
+// Report prices the daily usage of the month, by default the current month. The usage is reported
+// under the cluster and project names recorded with it, so deleted and moved clusters keep their
+// history.
+func (c costService) Report(query dto.CostReportQuery) ([]dto.CostReportItem, error) {
+	if query.Scope == "" {
+		query.Scope = constant.UsageScopeNamespace
+	}
+	switch query.Scope {
+	case constant.UsageScopeProject, constant.UsageScopeCluster, constant.UsageScopeNamespace:
+	default:
+		return nil, errors.New(CostScopeInvalid)
+	}
+	if query.Month == "" {
+		query.Month = time.Now().Format(costMonthLayout)
+	}
+	month, err := time.Parse(costMonthLayout, query.Month)
+	if err != nil {
+		return nil, errorf.New(CostMonthInvalid, query.Month)
+	}
+	daysInMonth := float64(month.AddDate(0, 1, -1).Day())
+
+	var rateList []model.CostRate
+	if err := db.DB.Find(&rateList).Error; err != nil {
+		return nil, err
+	}
+	rates := costRates{}
+	for _, r := range rateList {
+		if rates[r.Type] == nil {
+			rates[r.Type] = map[string]model.CostRate{}
+		}
+		rates[r.Type][r.Name] = r
+	}
+	currency := constant.DefaultCostCurrency
+	var setting model.SystemSetting
+	if err := db.DB.Where(map[string]interface{}{"key": constant.CostCurrencyKey}).First(&setting).Error; err == nil && setting.Value != "" {
+		currency = setting.Value
+	}
+	var usages []model.ClusterUsage
+	start := month.Format(usageDateLayout)
+	end := month.AddDate(0, 1, -1).Format(usageDateLayout)
+	tx := db.DB.Where("scope IN (?) AND date >= ? AND date <= ?", []string{constant.UsageScopeNode, constant.UsageScopeNamespace, constant.UsageScopeStorage}, start, end)
+	if query.ClusterName != "" {
+		tx = tx.Where("cluster_name = ?", query.ClusterName)
+	}
+	if query.ProjectName != "" {
+		tx = tx.Where("project_name = ?", query.ProjectName)
+	}
+	if err := tx.Find(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	items := map[string]*costItem{}
+	for _, ns := range priceUsages(usages, rates, daysInMonth) {
+		key := ns.ProjectName + "/" + ns.ClusterName + "/" + ns.Name
+		item := &costItem{CostReportItem: dto.CostReportItem{ProjectName: ns.ProjectName, ClusterName: ns.ClusterName, Name: ns.Name}, days: map[string]bool{}}
+		switch query.Scope {
+		case constant.UsageScopeCluster:
+			key = ns.ProjectName + "/" + ns.ClusterName
+			item.Name = ns.ClusterName
+		case constant.UsageScopeProject:
+			if ns.ProjectName == "" {
+				continue
+			}
+			key = ns.ProjectName
+			item = &costItem{CostReportItem: dto.CostReportItem{ProjectName: ns.ProjectName, Name: ns.ProjectName}, days: map[string]bool{}}
+		}
+		if _, ok := items[key]; !ok {
+			items[key] = item
+		}
+		items[key].add(ns)
+	}
+	result := []dto.CostReportItem{}
+	for _, item := range items {
+		item.Month = query.Month
+		item.Scope = query.Scope
+		item.Currency = currency
+		item.Days = len(item.days)
+		item.Compute = roundCost(item.Compute)
+		item.Storage = roundCost(item.Storage)
+		item.Total = roundCost(item.Compute + item.Storage)
+		result = append(result, item.CostReportItem)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ClusterName != result[j].ClusterName {
+			return result[i].ClusterName < result[j].ClusterName
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ProjectName < result[j].ProjectName
+	})
+	return result, nil
+}
+
+// priceUsages prices the daily usage of the nodes, namespaces and storage of the month by
+// namespace. The cost of the nodes of a cluster is shared by its namespaces on the larger of their
+// requests and their usage, what is left is reported as the idle namespace. Storage is charged to
+// the namespace of the claims.
+func priceUsages(usages []model.ClusterUsage, rates costRates, daysInMonth float64) []*costItem {
+	type clusterDay struct {
+		clusterID string
+		date      string
+	}
+	type dayUsage struct {
+		cpuPool, memoryPool               float64
+		cpuAllocatable, memoryAllocatable int64
+		node                              model.ClusterUsage
+		namespaces                        []model.ClusterUsage
+		storage                           []model.ClusterUsage
+	}
+	days := map[clusterDay]*dayUsage{}
+	for _, u := range usages {
+		key := clusterDay{u.ClusterID, u.Date}
+		d, ok := days[key]
+		if !ok {
+			d = &dayUsage{}
+			days[key] = d
+		}
+		switch u.Scope {
+		case constant.UsageScopeNode:
+			cpu, memory := rates.nodeCost(u)
+			d.cpuPool += cpu / daysInMonth
+			d.memoryPool += memory / daysInMonth
+			d.cpuAllocatable += u.CpuAllocatable
+			d.memoryAllocatable += u.MemoryAllocatable
+			d.node = u
+		case constant.UsageScopeNamespace:
+			d.namespaces = append(d.namespaces, u)
+		case constant.UsageScopeStorage:
+			d.storage = append(d.storage, u)
+		}
+	}
+
+	namespaces := map[[3]string]*costItem{}
+	namespaceItem := func(u model.ClusterUsage, name string) *costItem {
+		key := [3]string{u.ProjectName, u.ClusterName, name}
+		if _, ok := namespaces[key]; !ok {
+			namespaces[key] = &costItem{
+				CostReportItem: dto.CostReportItem{ProjectName: u.ProjectName, ClusterName: u.ClusterName, Name: name},
+				days:           map[string]bool{},
+			}
+		}
+		return namespaces[key]
+	}
+	share := func(requested, used, allocatable int64) float64 {
+		if allocatable == 0 {
+			return 0
+		}
+		if used > requested {
+			requested = used
+		}
+		return float64(requested) / float64(allocatable)
+	}
+	for key, d := range days {
+		cpuShares, memoryShares := map[string]float64{}, map[string]float64{}
+		var cpuTotal, memoryTotal float64
+		for _, u := range d.namespaces {
+			cpuShares[u.Name] = share(u.CpuRequested, u.CpuUsed, d.cpuAllocatable)
+			memoryShares[u.Name] = share(u.MemoryRequested, u.MemoryUsed, d.memoryAllocatable)
+			cpuTotal += cpuShares[u.Name]
+			memoryTotal += memoryShares[u.Name]
+		}
+		// namespaces may use more than allocatable when the nodes are overcommitted
+		cpuScale, memoryScale := 1.0, 1.0
+		if cpuTotal > 1 {
+			cpuScale, cpuTotal = 1/cpuTotal, 1
+		}
+		if memoryTotal > 1 {
+			memoryScale, memoryTotal = 1/memoryTotal, 1
+		}
+		for _, u := range d.namespaces {
+			item := namespaceItem(u, u.Name)
+			item.Compute += d.cpuPool*cpuShares[u.Name]*cpuScale + d.memoryPool*memoryShares[u.Name]*memoryScale
+			item.days[key.date] = true
+		}
+		if d.cpuPool > 0 || d.memoryPool > 0 {
+			idle := namespaceItem(d.node, constant.CostIdleNamespace)
+			idle.Compute += d.cpuPool*(1-cpuTotal) + d.memoryPool*(1-memoryTotal)
+			idle.days[key.date] = true
+		}
+		for _, u := range d.storage {
+			rate, _ := rates.get(constant.CostRateStorageClass, u.StorageClass)
+			item := namespaceItem(u, u.Name)
+			item.Storage += float64(u.StorageRequested) / gib * rate.StorageMonth / daysInMonth
+			item.days[key.date] = true
+		}
+	}
+	result := make([]*costItem, 0, len(namespaces))
+	for _, ns := range namespaces {
+		result = append(result, ns)
+	}
+	return result
+}
+
+func roundCost(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func (c costService) ExportCSV(query dto.CostReportQuery) ([]byte, error) {
+	items, err := c.Report(query)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"month", "project", "cluster", "scope", "name", "days", "currency", "compute", "storage", "total"})
+	for _, i := range items {
+		_ = w.Write([]string{i.Month, i.ProjectName, i.ClusterName, i.Scope, i.Name, fmt.Sprint(i.Days), i.Currency,
+			fmt.Sprintf("%.2f", i.Compute), fmt.Sprintf("%.2f", i.Storage), fmt.Sprintf("%.2f", i.Total)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.Log.Errorf("write cost report failed: %s", err.Error())
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
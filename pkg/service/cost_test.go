@@ -0,0 +1,132 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+)
+
+func testCostRates(rates ...model.CostRate) costRates {
+	result := costRates{}
+	for _, r := range rates {
+		if result[r.Type] == nil {
+			result[r.Type] = map[string]model.CostRate{}
+		}
+		result[r.Type][r.Name] = r
+	}
+	return result
+}
+
+func costEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNodeCost(t *testing.T) {
+	defaultHost := model.CostRate{Type: constant.CostRateHost, CpuMonth: 2, MemoryMonth: 1}
+	host := model.CostRate{Type: constant.CostRateHost, Name: "host1", CpuMonth: 10, MemoryMonth: 5, GpuMonth: 100}
+	vmConfig := model.CostRate{Type: constant.CostRateVmConfig, Name: "small", CpuMonth: 10, MemoryMonth: 5, InstanceMonth: 300}
+	tests := []struct {
+		name   string
+		rates  costRates
+		usage  model.ClusterUsage
+		cpu    float64
+		memory float64
+	}{
+		{name: "host rate", rates: testCostRates(defaultHost, host), usage: model.ClusterUsage{HostName: "host1", CpuCore: 4, Memory: 8192, GpuNum: 1}, cpu: 140, memory: 40},
+		{name: "vm config rate", rates: testCostRates(defaultHost, vmConfig), usage: model.ClusterUsage{HostName: "host2", VmConfig: "small", CpuCore: 4, Memory: 8192}, cpu: 150, memory: 150},
+		{name: "default host rate", rates: testCostRates(defaultHost, host), usage: model.ClusterUsage{HostName: "host2", CpuCore: 2, Memory: 4096}, cpu: 4, memory: 4},
+		{name: "allocatable", rates: testCostRates(defaultHost), usage: model.ClusterUsage{CpuAllocatable: 1500, MemoryAllocatable: 2 * gib}, cpu: 3, memory: 2},
+		{name: "no rate", rates: testCostRates(vmConfig), usage: model.ClusterUsage{CpuCore: 2, Memory: 4096}},
+	}
+	for _, tt := range tests {
+		cpu, memory := tt.rates.nodeCost(tt.usage)
+		if !costEqual(cpu, tt.cpu) || !costEqual(memory, tt.memory) {
+			t.Errorf("%s: want %v/%v, got %v/%v", tt.name, tt.cpu, tt.memory, cpu, memory)
+		}
+	}
+}
+
+func TestInstanceCost(t *testing.T) {
+	defaultHost := model.CostRate{Type: constant.CostRateHost, CpuMonth: 10, MemoryMonth: 5}
+	instance := model.CostRate{Type: constant.CostRateVmConfig, Name: "small", InstanceMonth: 100}
+	tests := []struct {
+		name   string
+		rates  costRates
+		rate   model.CostRate
+		usage  model.ClusterUsage
+		cpu    float64
+		memory float64
+	}{
+		{name: "own prices", rates: testCostRates(defaultHost), rate: model.CostRate{InstanceMonth: 100, CpuMonth: 1, MemoryMonth: 3}, usage: model.ClusterUsage{CpuCore: 2, Memory: 2048}, cpu: 25, memory: 75},
+		{name: "default host prices", rates: testCostRates(defaultHost), rate: instance, usage: model.ClusterUsage{CpuCore: 2, Memory: 4096}, cpu: 50, memory: 50},
+		{name: "allocatable", rates: testCostRates(defaultHost), rate: instance, usage: model.ClusterUsage{CpuAllocatable: 1000, MemoryAllocatable: 6 * gib}, cpu: 25, memory: 75},
+		{name: "no prices", rates: testCostRates(), rate: instance, usage: model.ClusterUsage{CpuCore: 2, Memory: 4096}, cpu: 100},
+	}
+	for _, tt := range tests {
+		cpu, memory := tt.rates.instanceCost(tt.rate, tt.usage)
+		if !costEqual(cpu, tt.cpu) || !costEqual(memory, tt.memory) {
+			t.Errorf("%s: want %v/%v, got %v/%v", tt.name, tt.cpu, tt.memory, cpu, memory)
+		}
+	}
+}
+
+func TestPriceUsages(t *testing.T) {
+	rates := testCostRates(
+		model.CostRate{Type: constant.CostRateHost, CpuMonth: 100, MemoryMonth: 100},
+		model.CostRate{Type: constant.CostRateStorageClass, StorageMonth: 10},
+	)
+	usage := func(scope, name string, u model.ClusterUsage) model.ClusterUsage {
+		u.ClusterID, u.ClusterName, u.ProjectName, u.Date, u.Scope, u.Name = "deleted-id", "c1", "p1", "2026-10-01", scope, name
+		return u
+	}
+	node := usage(constant.UsageScopeNode, "node1", model.ClusterUsage{CpuCore: 1, Memory: 1024, CpuAllocatable: 1000, MemoryAllocatable: gib})
+	type cost struct{ compute, storage float64 }
+	tests := []struct {
+		name   string
+		usages []model.ClusterUsage
+		want   map[string]cost
+	}{
+		{
+			name: "share and idle",
+			usages: []model.ClusterUsage{node,
+				usage(constant.UsageScopeNamespace, "a", model.ClusterUsage{CpuRequested: 200, CpuUsed: 100, MemoryUsed: gib / 4}),
+				usage(constant.UsageScopeStorage, "a", model.ClusterUsage{StorageRequested: 2 * gib}),
+			},
+			want: map[string]cost{"a": {compute: 45, storage: 20}, constant.CostIdleNamespace: {compute: 155}},
+		},
+		{
+			name: "overcommitted",
+			usages: []model.ClusterUsage{node,
+				usage(constant.UsageScopeNamespace, "a", model.ClusterUsage{CpuRequested: 800}),
+				usage(constant.UsageScopeNamespace, "b", model.ClusterUsage{CpuUsed: 800}),
+			},
+			want: map[string]cost{"a": {compute: 50}, "b": {compute: 50}, constant.CostIdleNamespace: {compute: 100}},
+		},
+		{
+			name:   "no nodes",
+			usages: []model.ClusterUsage{usage(constant.UsageScopeNamespace, "a", model.ClusterUsage{CpuRequested: 200})},
+			want:   map[string]cost{"a": {}},
+		},
+	}
+	for _, tt := range tests {
+		items := priceUsages(tt.usages, rates, 1)
+		if len(items) != len(tt.want) {
+			t.Errorf("%s: want %d namespaces, got %d", tt.name, len(tt.want), len(items))
+		}
+		for _, item := range items {
+			want, ok := tt.want[item.Name]
+			if !ok {
+				t.Errorf("%s: unexpected namespace %s", tt.name, item.Name)
+				continue
+			}
+			if item.ProjectName != "p1" || item.ClusterName != "c1" {
+				t.Errorf("%s: %s is not reported under the recorded cluster and project", tt.name, item.Name)
+			}
+			if !costEqual(item.Compute, want.compute) || !costEqual(item.Storage, want.storage) {
+				t.Errorf("%s: %s want %v/%v, got %v/%v", tt.name, item.Name, want.compute, want.storage, item.Compute, item.Storage)
+			}
+		}
+	}
+}
@@ -1,21 +1,31 @@
 package service
 
 import (
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
 	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
-	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
 )
 
-var CredentialNameExist = "NAME_EXISTS"
+var (
+	CredentialNameExist     = "NAME_EXISTS"
+	CredentialRotateKeyBits = 4096
+	// CredentialRotatePasswordLength is the length of the passwords generated by a rotation.
+	CredentialRotatePasswordLength = 24
+)
 
 type CredentialService interface {
 	Get(name string) (dto.Credential, error)
@@ -26,6 +36,7 @@ type CredentialService interface {
 	Batch(op dto.CredentialBatchOp) error
 	GetById(id string) (dto.Credential, error)
 	Update(name string, update dto.CredentialUpdate) (*dto.Credential, error)
+	Rotate(name string) (*dto.CredentialRotate, error)
 }
 
 type credentialService struct {
@@ -115,20 +126,17 @@ func (c credentialService) Create(creation dto.CredentialCreate) (*dto.Credentia
 	if old.ID != "" {
 		return nil, errors.New(CredentialNameExist)
 	}
-	password, err := encrypt.StringEncrypt(creation.Password)
-	if err != nil {
-		return nil, err
-	}
 
 	credential := model.Credential{
-		BaseModel:  common.BaseModel{},
-		Name:       creation.Name,
-		Password:   password,
-		Username:   creation.Username,
-		PrivateKey: creation.PrivateKey,
-		Type:       creation.Type,
-	}
-	err = c.credentialRepo.Save(&credential)
+		BaseModel: common.BaseModel{},
+		Name:      creation.Name,
+		Username:  creation.Username,
+		Type:      creation.Type,
+	}
+	if err := credential.SetSecret(creation.Password, creation.PrivateKey); err != nil {
+		return nil, err
+	}
+	err := c.credentialRepo.Save(&credential)
 	if err != nil {
 		return nil, err
 	}
@@ -148,20 +156,15 @@ func (c credentialService) Update(name string, update dto.CredentialUpdate) (*dt
 	if update.Type == constant.Password {
 		if update.Password == "" {
 			return nil, errors.New("PASSWORD_CAN_NOT_NULL")
-		} else {
-			credential.Password, err = encrypt.StringEncrypt(update.Password)
-			credential.PrivateKey = ""
-			if err != nil {
-				return nil, err
-			}
+		} else if err := credential.SetSecret(update.Password, ""); err != nil {
+			return nil, err
 		}
 	}
 	if update.Type == constant.PrivateKey {
 		if update.PrivateKey == "" {
 			return nil, errors.New("PRIVATE_KEY_CAN_NOT_NULL")
-		} else {
-			credential.PrivateKey = update.PrivateKey
-			credential.Password = ""
+		} else if err := credential.SetSecret("", update.PrivateKey); err != nil {
+			return nil, err
 		}
 	}
 	if err := db.DB.Save(&credential).Error; err != nil {
@@ -193,3 +196,247 @@ func (c credentialService) Batch(op dto.CredentialBatchOp) error {
 	}
 	return nil
 }
+
+type rotateTarget struct {
+	result *dto.CredentialRotateHost
+	config *ssh.Config
+}
+
+// Rotate replaces the secret of the credential on every host and bastion using it and keeps
+// the type of the credential. The credential is swapped only once all of them accept a login
+// with the new secret, otherwise the ones already changed are restored.
+func (c credentialService) Rotate(name string) (*dto.CredentialRotate, error) {
+	credential, err := c.credentialRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	password, privateKey, err := credential.GetSecret()
+	if err != nil {
+		return nil, err
+	}
+	targets, err := c.rotateTargets(credential)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Type == constant.Password {
+		return c.rotatePassword(credential, password, targets)
+	}
+	return c.rotateKey(credential, privateKey, targets)
+}
+
+// rotateKey pushes a newly generated key and removes the old one once the credential is swapped
+// to the new key. Nothing is swapped when one of the targets fails, the pushed key is removed again.
+func (c credentialService) rotateKey(credential model.Credential, privateKey string, targets []rotateTarget) (*dto.CredentialRotate, error) {
+	oldKey, err := ssh.AuthorizedKey([]byte(privateKey))
+	if err != nil {
+		return nil, err
+	}
+	newPrivateKey, newKey, err := ssh.GenerateKeyPair(CredentialRotateKeyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.CredentialRotate{Name: credential.Name, Status: constant.CredentialRotateSuccess}
+	var pushed []rotateTarget
+	for _, t := range targets {
+		t.config.PrivateKey = []byte(privateKey)
+		if err := runOnTarget(t.config, authorizeKeyCmd(newKey)); err != nil {
+			failRotateTarget(&result, t, err)
+			continue
+		}
+		pushed = append(pushed, t)
+	}
+	if result.Status == constant.CredentialRotateSuccess {
+		for _, t := range pushed {
+			verify := *t.config
+			verify.PrivateKey = []byte(newPrivateKey)
+			if err := runOnTarget(&verify, "true"); err != nil {
+				failRotateTarget(&result, t, err)
+			}
+		}
+	}
+	if result.Status == constant.CredentialRotateFailed {
+		for _, t := range pushed {
+			if err := runOnTarget(t.config, revokeKeyCmd(newKey)); err != nil {
+				logger.Log.Errorf("revoke rotated key of %s error: %s", t.result.Name, err.Error())
+			}
+		}
+		return rotateResult(&result, targets), nil
+	}
+
+	// both keys are authorized until here, a failed swap leaves every host reachable
+	if err := credential.SetSecret("", newPrivateKey); err != nil {
+		return nil, err
+	}
+	if err := c.credentialRepo.Save(&credential); err != nil {
+		return nil, err
+	}
+
+	for _, t := range targets {
+		t.result.Status = constant.CredentialRotateSuccess
+		if oldKey != newKey {
+			t.config.PrivateKey = []byte(newPrivateKey)
+			if err := runOnTarget(t.config, revokeKeyCmd(oldKey)); err != nil {
+				t.result.Message = err.Error()
+			}
+		}
+	}
+	return rotateResult(&result, targets), nil
+}
+
+// rotatePassword sets a newly generated password on the targets after checking all of them
+// accept the old one, the targets already changed get the old password back when one fails.
+func (c credentialService) rotatePassword(credential model.Credential, password string, targets []rotateTarget) (*dto.CredentialRotate, error) {
+	newPassword, err := generatePassword(CredentialRotatePasswordLength)
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.CredentialRotate{Name: credential.Name, Status: constant.CredentialRotateSuccess}
+	for _, t := range targets {
+		t.config.Password = password
+		if err := runOnTarget(t.config, "true"); err != nil {
+			failRotateTarget(&result, t, err)
+		}
+	}
+	var changed []rotateTarget
+	if result.Status == constant.CredentialRotateSuccess {
+		for _, t := range targets {
+			if err := runOnTarget(t.config, changePasswordCmd(credential.Username, password, newPassword)); err != nil {
+				failRotateTarget(&result, t, err)
+				break
+			}
+			changed = append(changed, t)
+			verify := *t.config
+			verify.Password = newPassword
+			if err := runOnTarget(&verify, "true"); err != nil {
+				failRotateTarget(&result, t, err)
+				break
+			}
+		}
+	}
+	if result.Status == constant.CredentialRotateFailed {
+		for _, t := range changed {
+			restore := *t.config
+			restore.Password = newPassword
+			if err := runOnTarget(&restore, changePasswordCmd(credential.Username, newPassword, password)); err != nil {
+				logger.Log.Errorf("restore password of %s error: %s", t.result.Name, err.Error())
+			}
+		}
+		return rotateResult(&result, targets), nil
+	}
+
+	if err := credential.SetSecret(newPassword, ""); err != nil {
+		return nil, err
+	}
+	if err := c.credentialRepo.Save(&credential); err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		t.result.Status = constant.CredentialRotateSuccess
+	}
+	return rotateResult(&result, targets), nil
+}
+
+// rotateTargets lists the hosts before the bastions, the old key of a bastion has to be
+// removed last as the hosts behind it are still reached with it.
+func (c credentialService) rotateTargets(credential model.Credential) ([]rotateTarget, error) {
+	var (
+		hosts    []model.Host
+		bastions []model.Bastion
+		targets  []rotateTarget
+	)
+	if err := db.DB.Where("credential_id = ?", credential.ID).Preload("Credential").Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Where("credential_id = ?", credential.ID).Preload("Credential").Find(&bastions).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		bastion, err := h.BastionSSHConfig()
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, rotateTarget{
+			result: &dto.CredentialRotateHost{Name: h.Name, Ip: h.Ip},
+			config: &ssh.Config{
+				User:        credential.Username,
+				Host:        h.Ip,
+				Port:        h.Port,
+				DialTimeOut: 5 * time.Second,
+				Retry:       1,
+				HostKey:     h.HostKey,
+				Bastion:     bastion,
+			},
+		})
+	}
+	for _, b := range bastions {
+		config, err := b.ToSSHConfig()
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, rotateTarget{
+			result: &dto.CredentialRotateHost{Name: b.Name, Ip: b.Ip},
+			config: config,
+		})
+	}
+	return targets, nil
+}
+
+func failRotateTarget(result *dto.CredentialRotate, t rotateTarget, err error) {
+	t.result.Status = constant.CredentialRotateFailed
+	t.result.Message = err.Error()
+	result.Status = constant.CredentialRotateFailed
+}
+
+func rotateResult(result *dto.CredentialRotate, targets []rotateTarget) *dto.CredentialRotate {
+	for _, t := range targets {
+		result.Hosts = append(result.Hosts, *t.result)
+	}
+	return result
+}
+
+func runOnTarget(config *ssh.Config, cmd string) error {
+	client, err := ssh.New(config)
+	if err != nil {
+		return err
+	}
+	return client.Run(cmd)
+}
+
+// changePasswordCmd changes the password of the user, a user other than root needs sudo with
+// its current password.
+func changePasswordCmd(user, oldPassword, newPassword string) string {
+	change := fmt.Sprintf("echo %s | chpasswd", shellQuote(user+":"+newPassword))
+	if user == "root" {
+		return change
+	}
+	return fmt.Sprintf("echo %s | sudo -S -p '' sh -c %s", shellQuote(oldPassword), shellQuote(change))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// generatePassword returns a random password of letters and digits.
+func generatePassword(length int) (string, error) {
+	const letters = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = letters[int(buf[i])%len(letters)]
+	}
+	return string(buf), nil
+}
+
+func authorizeKeyCmd(key string) string {
+	return fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && touch ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys && "+
+		"(grep -qF '%s' ~/.ssh/authorized_keys || echo '%s' >> ~/.ssh/authorized_keys)", key, key)
+}
+
+func revokeKeyCmd(key string) string {
+	return fmt.Sprintf("grep -vF '%s' ~/.ssh/authorized_keys > ~/.ssh/authorized_keys.ko; "+
+		"cat ~/.ssh/authorized_keys.ko > ~/.ssh/authorized_keys && rm -f ~/.ssh/authorized_keys.ko", key)
+}
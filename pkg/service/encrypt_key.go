@@ -0,0 +1,240 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/jinzhu/gorm"
+)
+
+// encryptedColumn is a column holding values encrypted with the master key, legacy marks the
+// ones that were always encrypted, the others may still hold values saved before in plain text.
+// where limits the column to the rows holding secrets, like the system settings of passwords.
+type encryptedColumn struct {
+	table  string
+	column string
+	where  string
+	legacy bool
+}
+
+var encryptedColumns = []encryptedColumn{
+	{table: "ko_credential", column: "password", legacy: true},
+	{table: "ko_credential", column: "private_key"},
+	{table: "ko_user", column: "password", legacy: true},
+	{table: "ko_system_registry", column: "nexus_password", legacy: true},
+	{table: "ko_backup_account", column: "credential"},
+	{table: "ko_cluster_secret", column: "kubeadm_token"},
+	{table: "ko_cluster_secret", column: "kubernetes_token"},
+	{table: "ko_cluster_secret", column: "alert_token"},
+	{table: "ko_f5_setting", column: "password", legacy: true},
+	{table: "ko_cluster_load_balancer", column: "password", legacy: true},
+	{table: "ko_system_setting", column: "value", where: fmt.Sprintf("`key` IN ('%s')", constant.MetricsRemoteWritePasswordKey), legacy: true},
+}
+
+// encryptedJSONField is a value encrypted with the master key inside the json objects of a column,
+// path leads to it through the objects.
+type encryptedJSONField struct {
+	table  string
+	column string
+	path   []string
+}
+
+var encryptedJSONFields = []encryptedJSONField{
+	{table: "ko_cluster_tool", column: "vars", path: []string{constant.LogConfigVar, "target", "encryptedPassword"}},
+}
+
+type EncryptKeyService interface {
+	Rotate() (*dto.EncryptKeyRotate, error)
+	Load() error
+}
+
+type encryptKeyService struct {
+}
+
+func NewEncryptKeyService() EncryptKeyService {
+	return &encryptKeyService{}
+}
+
+// Rotate adds a new master key and re-encrypts every encrypted column with it in one transaction,
+// the key is stored wrapped with the config key and made active in the same transaction, so a
+// failed rotation leaves the old key active and every value as it was. Old keys stay stored so
+// values encrypted with them elsewhere, like the config, can still be decrypted.
+func (e encryptKeyService) Rotate() (*dto.EncryptKeyRotate, error) {
+	key, err := encrypt.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := encrypt.WrapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.DB.Begin()
+	version := 1
+	var last model.EncryptKey
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Order("version desc").First(&last).Error; err == nil {
+		version = last.Version + 1
+	} else if !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Model(&model.EncryptKey{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Create(&model.EncryptKey{Version: version, Key: wrapped, Active: true}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	result := dto.EncryptKeyRotate{Version: version}
+	for _, c := range encryptedColumns {
+		count, err := reEncryptColumn(tx, c, version, key)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("re-encrypt %s.%s failed: %s", c.table, c.column, err.Error())
+		}
+		result.Columns = append(result.Columns, dto.EncryptColumnRotated{Table: c.table, Column: c.column, Count: count})
+	}
+	for _, f := range encryptedJSONFields {
+		column := f.column + "." + strings.Join(f.path, ".")
+		count, err := reEncryptJSONField(tx, f, version, key)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("re-encrypt %s.%s failed: %s", f.table, column, err.Error())
+		}
+		result.Columns = append(result.Columns, dto.EncryptColumnRotated{Table: f.table, Column: column, Count: count})
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	if err := e.Load(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Load makes the stored keys the ones in use, it runs at startup and after every rotation.
+func (e encryptKeyService) Load() error {
+	var keys []model.EncryptKey
+	if err := db.DB.Find(&keys).Error; err != nil {
+		return err
+	}
+	current := 0
+	wrapped := map[int]string{}
+	for _, k := range keys {
+		wrapped[k.Version] = k.Key
+		if k.Active {
+			current = k.Version
+		}
+	}
+	return encrypt.SetKeyring(current, wrapped)
+}
+
+func reEncryptColumn(tx *gorm.DB, c encryptedColumn, version int, key []byte) (int, error) {
+	type row struct {
+		ID    string
+		Value string
+	}
+	var rows []row
+	where := fmt.Sprintf("`%s` IS NOT NULL AND `%s` <> ''", c.column, c.column)
+	if c.where != "" {
+		where += " AND " + c.where
+	}
+	if err := tx.Raw(fmt.Sprintf("SELECT id, `%s` AS value FROM `%s` WHERE %s FOR UPDATE", c.column, c.table, where)).
+		Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	for _, r := range rows {
+		plain := r.Value
+		if c.legacy || encrypt.IsEnvelope(r.Value) {
+			p, err := encrypt.StringDecrypt(r.Value)
+			if err != nil {
+				return 0, err
+			}
+			plain = p
+		}
+		value, err := encrypt.StringEncryptWithKey(plain, version, key)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Table(c.table).Where("id = ?", r.ID).UpdateColumn(c.column, value).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), nil
+}
+
+// reEncryptJSONField re-encrypts the field in the json of every row holding it, the rest of the json
+// is written back as it was read.
+func reEncryptJSONField(tx *gorm.DB, f encryptedJSONField, version int, key []byte) (int, error) {
+	type row struct {
+		ID    string
+		Value string
+	}
+	var rows []row
+	field := f.path[len(f.path)-1]
+	if err := tx.Raw(fmt.Sprintf("SELECT id, `%s` AS value FROM `%s` WHERE `%s` LIKE ? FOR UPDATE", f.column, f.table, f.column), "%\""+field+"\"%").
+		Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, r := range rows {
+		value, changed, err := reEncryptJSON(r.Value, f.path, func(cipher string) (string, error) {
+			plain, err := encrypt.StringDecrypt(cipher)
+			if err != nil {
+				return "", err
+			}
+			return encrypt.StringEncryptWithKey(plain, version, key)
+		})
+		if err != nil {
+			return 0, err
+		}
+		if !changed {
+			continue
+		}
+		if err := tx.Table(f.table).Where("id = ?", r.ID).UpdateColumn(f.column, value).Error; err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// reEncryptJSON replaces the string at path of the json document with what reEncrypt makes of it,
+// numbers are kept as they were written. Documents without the string are left unchanged.
+func reEncryptJSON(doc string, path []string, reEncrypt func(string) (string, error)) (string, bool, error) {
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return "", false, err
+	}
+	obj := root
+	for _, p := range path[:len(path)-1] {
+		next, ok := obj[p].(map[string]interface{})
+		if !ok {
+			return doc, false, nil
+		}
+		obj = next
+	}
+	field := path[len(path)-1]
+	cipher, ok := obj[field].(string)
+	if !ok || cipher == "" {
+		return doc, false, nil
+	}
+	value, err := reEncrypt(cipher)
+	if err != nil {
+		return "", false, err
+	}
+	obj[field] = value
+	buf, err := json.Marshal(root)
+	if err != nil {
+		return "", false, err
+	}
+	return string(buf), true, nil
+}
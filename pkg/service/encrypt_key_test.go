@@ -0,0 +1,52 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReEncryptJSON(t *testing.T) {
+	path := []string{"logConfig", "target", "encryptedPassword"}
+	reEncrypt := func(cipher string) (string, error) { return "v2:" + cipher, nil }
+	tests := []struct {
+		name    string
+		doc     string
+		changed bool
+		want    string
+	}{
+		{name: "sealed", doc: `{"namespace":"kube-operator","replicas":1000000,"logConfig":{"target":{"type":"elasticsearch","encryptedPassword":"v1:abc"}}}`, changed: true, want: "v2:v1:abc"},
+		{name: "no password", doc: `{"logConfig":{"target":{"type":"elasticsearch"}}}`},
+		{name: "empty password", doc: `{"logConfig":{"target":{"encryptedPassword":""}}}`},
+		{name: "no log config", doc: `{"encryptedPassword":"v1:abc"}`},
+	}
+	for _, tt := range tests {
+		doc, changed, err := reEncryptJSON(tt.doc, path, reEncrypt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if changed != tt.changed {
+			t.Fatalf("%s: want changed %v, got %v", tt.name, tt.changed, changed)
+		}
+		if !changed {
+			if doc != tt.doc {
+				t.Errorf("%s: want the document untouched, got %s", tt.name, doc)
+			}
+			continue
+		}
+		var vars struct {
+			Replicas  json.Number `json:"replicas"`
+			LogConfig struct {
+				Target struct {
+					Type              string `json:"type"`
+					EncryptedPassword string `json:"encryptedPassword"`
+				} `json:"target"`
+			} `json:"logConfig"`
+		}
+		if err := json.Unmarshal([]byte(doc), &vars); err != nil {
+			t.Fatal(err)
+		}
+		if vars.LogConfig.Target.EncryptedPassword != tt.want || vars.LogConfig.Target.Type != "elasticsearch" || vars.Replicas != "1000000" {
+			t.Errorf("%s: got %s", tt.name, doc)
+		}
+	}
+}
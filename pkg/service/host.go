@@ -13,7 +13,6 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
 	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
-	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
@@ -51,6 +50,7 @@ type hostService struct {
 	credentialRepo    repository.CredentialRepository
 	credentialService CredentialService
 	projectRepository repository.ProjectRepository
+	hostKeyService    HostKeyService
 }
 
 func NewHostService() HostService {
@@ -59,6 +59,7 @@ func NewHostService() HostService {
 		credentialRepo:    repository.NewCredentialRepository(),
 		credentialService: NewCredentialService(),
 		projectRepository: repository.NewProjectRepository(),
+		hostKeyService:    NewHostKeyService(),
 	}
 }
 
@@ -254,20 +255,13 @@ func (h *hostService) Create(creation dto.HostCreate) (*dto.Host, error) {
 			return nil, err
 		}
 	} else {
-		var password string
-		if creation.Credential.Password != "" {
-			p, err := encrypt.StringEncrypt(creation.Credential.Password)
-			if err != nil {
-				return nil, err
-			}
-			password = p
-		}
 		c := model.Credential{
-			Name:       creation.Credential.Name,
-			Password:   password,
-			Username:   creation.Credential.Username,
-			PrivateKey: creation.Credential.PrivateKey,
-			Type:       creation.Credential.Type,
+			Name:     creation.Credential.Name,
+			Username: creation.Credential.Username,
+			Type:     creation.Credential.Type,
+		}
+		if err := c.SetSecret(creation.Credential.Password, creation.Credential.PrivateKey); err != nil {
+			return nil, err
 		}
 		if err := tx.Create(&c).Error; err != nil {
 			tx.Rollback()
@@ -283,6 +277,7 @@ func (h *hostService) Create(creation dto.HostCreate) (*dto.Host, error) {
 		Port:         creation.Port,
 		CredentialID: credential.ID,
 		Credential:   credential,
+		BastionID:    creation.BastionID,
 		Status:       constant.ClusterInitializing,
 	}
 	if err := tx.Create(&host).Error; err != nil {
@@ -339,20 +334,13 @@ func (h *hostService) Update(host dto.HostUptate) (*dto.Host, error) {
 			return nil, err
 		}
 	} else {
-		var password string
-		if host.Credential.Password != "" {
-			p, err := encrypt.StringEncrypt(host.Credential.Password)
-			if err != nil {
-				return nil, err
-			}
-			password = p
-		}
 		c := model.Credential{
-			Name:       host.Credential.Name,
-			Password:   password,
-			Username:   host.Credential.Username,
-			PrivateKey: host.Credential.PrivateKey,
-			Type:       host.Credential.Type,
+			Name:     host.Credential.Name,
+			Username: host.Credential.Username,
+			Type:     host.Credential.Type,
+		}
+		if err := c.SetSecret(host.Credential.Password, host.Credential.PrivateKey); err != nil {
+			return nil, err
 		}
 		if err := tx.Create(&c).Error; err != nil {
 			tx.Rollback()
@@ -364,23 +352,38 @@ func (h *hostService) Update(host dto.HostUptate) (*dto.Host, error) {
 	if err := tx.Where("name = ?", host.Name).First(&oldHost).Error; err != nil {
 		return nil, err
 	}
+	// a new address means a new machine, its host key is recorded again on sync
+	if oldHost.Ip != host.Ip || oldHost.Port != host.Port {
+		oldHost.HostKey = ""
+		oldHost.HostKeyStatus = ""
+		oldHost.PendingHostKey = ""
+	}
 	if err := tx.Model(&model.Host{}).Where("id = ?", oldHost.ID).Updates(map[string]interface{}{
-		"Ip":           host.Ip,
-		"Port":         host.Port,
-		"CredentialID": credential.ID,
-		"Status":       constant.ClusterInitializing,
+		"Ip":             host.Ip,
+		"Port":           host.Port,
+		"CredentialID":   credential.ID,
+		"Status":         constant.ClusterInitializing,
+		"HostKey":        oldHost.HostKey,
+		"HostKeyStatus":  oldHost.HostKeyStatus,
+		"PendingHostKey": oldHost.PendingHostKey,
+		"BastionID":      host.BastionID,
 	}).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 	newHost := model.Host{
-		ID:           oldHost.ID,
-		Name:         host.Name,
-		Ip:           host.Ip,
-		Port:         host.Port,
-		CredentialID: credential.ID,
-		Credential:   credential,
-		Status:       constant.ClusterInitializing,
+		ID:             oldHost.ID,
+		Name:           host.Name,
+		ZoneID:         oldHost.ZoneID,
+		Ip:             host.Ip,
+		Port:           host.Port,
+		CredentialID:   credential.ID,
+		Credential:     credential,
+		Status:         constant.ClusterInitializing,
+		HostKey:        oldHost.HostKey,
+		HostKeyStatus:  oldHost.HostKeyStatus,
+		PendingHostKey: oldHost.PendingHostKey,
+		BastionID:      host.BastionID,
 	}
 
 	tx.Commit()
@@ -455,6 +458,10 @@ func (h *hostService) GetHostGpu(host *model.Host) error {
 	if err != nil {
 		return err
 	}
+	bastion, err := host.BastionSSHConfig()
+	if err != nil {
+		return err
+	}
 	client, err := ssh.New(&ssh.Config{
 		User:        host.Credential.Username,
 		Host:        host.Ip,
@@ -464,6 +471,8 @@ func (h *hostService) GetHostGpu(host *model.Host) error {
 		PassPhrase:  nil,
 		DialTimeOut: 5 * time.Second,
 		Retry:       3,
+		HostKey:     host.HostKey,
+		Bastion:     bastion,
 	})
 	if err != nil {
 		host.Status = model.SshError
@@ -497,6 +506,10 @@ func (h *hostService) GetHostMem(host *model.Host) error {
 	if err != nil {
 		return err
 	}
+	bastion, err := host.BastionSSHConfig()
+	if err != nil {
+		return err
+	}
 	client, err := ssh.New(&ssh.Config{
 		User:        host.Credential.Username,
 		Host:        host.Ip,
@@ -506,6 +519,8 @@ func (h *hostService) GetHostMem(host *model.Host) error {
 		PassPhrase:  nil,
 		DialTimeOut: 5 * time.Second,
 		Retry:       3,
+		HostKey:     host.HostKey,
+		Bastion:     bastion,
 	})
 	if err != nil {
 		host.Status = model.SshError
@@ -558,6 +573,12 @@ func (h *hostService) GetHostConfig(host *model.Host) error {
 		}
 	}()
 
+	if err := h.hostKeyService.Verify(host); err != nil {
+		return err
+	}
+	if err := host.WriteConnectionFiles(); err != nil {
+		return err
+	}
 	password, privateKey, err := host.GetHostPasswordAndPrivateKey()
 	if err != nil {
 		return err
@@ -572,7 +593,7 @@ func (h *hostService) GetHostConfig(host *model.Host) error {
 					User:       host.Credential.Username,
 					Password:   password,
 					PrivateKey: string(privateKey),
-					Vars:       map[string]string{},
+					Vars:       host.ConnectionVars(),
 				},
 			},
 			Groups: []*api.Group{
@@ -783,8 +804,7 @@ func (h *hostService) ImportHosts(file []byte) error {
 			continue
 		}
 		if ip.ID != "" {
-			ip.Status = constant.IpUsed
-			if err := db.DB.Save(&ip).Error; err != nil {
+			if err := model.AllocateIp(db.DB, &ip, "", host.Name); err != nil {
 				continue
 			}
 		}
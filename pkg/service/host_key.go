@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	HostKeyNotTrusted  = "HOST_KEY_NOT_TRUSTED"
+	HostKeyNotPending  = "HOST_KEY_NOT_PENDING"
+	HostKeyScanTimeout = 5 * time.Second
+	// HostKeyWaitTimeout is how long the keys of hosts just created are waited for.
+	HostKeyWaitTimeout = 3 * time.Minute
+)
+
+type HostKeyService interface {
+	Verify(host *model.Host) error
+	VerifyCreated(hosts []*model.Host) error
+	Accept(name string) error
+	Reset(name string) error
+}
+
+type hostKeyService struct {
+	hostRepo          repository.HostRepository
+	systemSettingRepo repository.SystemSettingRepository
+	messageService    MessageService
+}
+
+func NewHostKeyService() HostKeyService {
+	return &hostKeyService{
+		hostRepo:          repository.NewHostRepository(),
+		systemSettingRepo: repository.NewSystemSettingRepository(),
+		messageService:    NewMessageService(),
+	}
+}
+
+// Verify compares the key presented by the host with the recorded one, the first key is
+// trusted directly or left for admin approval according to HOST_KEY_POLICY.
+func (h hostKeyService) Verify(host *model.Host) error {
+	bastion, err := host.BastionSSHConfig()
+	if err != nil {
+		return err
+	}
+	key, err := ssh.ScanHostKey(&ssh.Config{
+		User:        host.Credential.Username,
+		Host:        host.Ip,
+		Port:        host.Port,
+		DialTimeOut: HostKeyScanTimeout,
+		Bastion:     bastion,
+	})
+	if err != nil {
+		return err
+	}
+	if host.HostKey == "" {
+		if h.policy() == constant.HostKeyPolicyApproval {
+			host.HostKeyStatus = constant.HostKeyStatusPending
+			host.PendingHostKey = key
+			if err := h.save(host); err != nil {
+				return err
+			}
+			return errors.New(HostKeyNotTrusted)
+		}
+		host.HostKey = key
+		host.HostKeyStatus = constant.HostKeyStatusTrusted
+		host.PendingHostKey = ""
+		return h.save(host)
+	}
+	if host.HostKey == key {
+		return nil
+	}
+
+	expected, _ := ssh.HostKeyFingerprint(host.HostKey)
+	actual, _ := ssh.HostKeyFingerprint(key)
+	alerted := host.HostKeyStatus == constant.HostKeyStatusChanged && host.PendingHostKey == key
+	host.HostKeyStatus = constant.HostKeyStatusChanged
+	host.PendingHostKey = key
+	if err := h.save(host); err != nil {
+		return err
+	}
+	if !alerted {
+		go h.alert(*host, expected, actual)
+	}
+	return &ssh.HostKeyMismatchError{Host: host.Ip, Expected: expected, Actual: actual}
+}
+
+// VerifyCreated verifies the keys of the hosts KubeOperator has just created, the scan is retried
+// while their sshd is still starting. A rejected or changed key is not retried.
+func (h hostKeyService) VerifyCreated(hosts []*model.Host) error {
+	for _, host := range hosts {
+		var lastErr error
+		err := wait.PollImmediate(5*time.Second, HostKeyWaitTimeout, func() (bool, error) {
+			lastErr = h.Verify(host)
+			if lastErr == nil {
+				return true, nil
+			}
+			if _, ok := lastErr.(*ssh.HostKeyMismatchError); ok || lastErr.Error() == HostKeyNotTrusted {
+				return false, lastErr
+			}
+			return false, nil
+		})
+		if err == wait.ErrWaitTimeout {
+			err = lastErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Accept trusts the pending key of a host, used for approval and after a legitimate key change.
+func (h hostKeyService) Accept(name string) error {
+	host, err := h.hostRepo.Get(name)
+	if err != nil {
+		return err
+	}
+	if host.PendingHostKey == "" {
+		return errors.New(HostKeyNotPending)
+	}
+	host.HostKey = host.PendingHostKey
+	host.HostKeyStatus = constant.HostKeyStatusTrusted
+	host.PendingHostKey = ""
+	return h.save(&host)
+}
+
+// Reset forgets the recorded key, the next sync records it again.
+func (h hostKeyService) Reset(name string) error {
+	host, err := h.hostRepo.Get(name)
+	if err != nil {
+		return err
+	}
+	host.HostKey = ""
+	host.HostKeyStatus = ""
+	host.PendingHostKey = ""
+	return h.save(&host)
+}
+
+func (h hostKeyService) policy() string {
+	setting, err := h.systemSettingRepo.Get(constant.HostKeyPolicyKey)
+	if err != nil || setting.Value == "" {
+		return constant.HostKeyPolicyTOFU
+	}
+	return setting.Value
+}
+
+func (h hostKeyService) save(host *model.Host) error {
+	return db.DB.Model(&model.Host{}).Where("id = ?", host.ID).Updates(map[string]interface{}{
+		"host_key":         host.HostKey,
+		"host_key_status":  host.HostKeyStatus,
+		"pending_host_key": host.PendingHostKey,
+	}).Error
+}
+
+func (h hostKeyService) alert(host model.Host, expected, actual string) {
+	msg := fmt.Sprintf("host %s(%s) key changed, expected %s but got %s", host.Name, host.Ip, expected, actual)
+	logger.Log.Warn(msg)
+	if host.ClusterID == "" {
+		return
+	}
+	var cluster model.Cluster
+	if err := db.DB.Where("id = ?", host.ClusterID).First(&cluster).Error; err != nil {
+		logger.Log.Errorf("get cluster of host %s error: %s", host.Name, err.Error())
+		return
+	}
+	content, _ := json.Marshal(map[string]string{"message": msg})
+	if err := h.messageService.SendMessage(constant.System, false, string(content), cluster.Name, constant.HostKeyChanged); err != nil {
+		logger.Log.Errorf("send host key changed message error: %s", err.Error())
+	}
+}
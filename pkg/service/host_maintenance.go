@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/adm/phases"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ansible"
+	"github.com/KubeOperator/KubeOperator/pkg/util/kobe"
+	kubernetesUtil "github.com/KubeOperator/KubeOperator/pkg/util/kubernetes"
+	"github.com/KubeOperator/kobe/api"
+	"github.com/jinzhu/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	HostInMaintenance              = "HOST_IN_MAINTENANCE"
+	HostNotInMaintenance           = "HOST_NOT_IN_MAINTENANCE"
+	HostMaintenanceNothingToDo     = "HOST_MAINTENANCE_NOTHING_TO_DO"
+	HostMaintenanceRunning         = "HOST_MAINTENANCE_RUNNING"
+	ClusterMaintenanceRunning      = "CLUSTER_MAINTENANCE_RUNNING"
+	MaintenanceMinAvailableInvalid = "MAINTENANCE_MIN_AVAILABLE_INVALID"
+)
+
+type HostMaintenanceService interface {
+	Start(name string, req dto.HostMaintenanceRequest) (*dto.HostMaintenance, error)
+	Exit(name string) error
+	List(name string) ([]dto.HostMaintenance, error)
+	Rolling(clusterName string, req dto.ClusterMaintenanceRequest) error
+}
+
+type hostMaintenanceService struct {
+	hostRepo          repository.HostRepository
+	nodeRepo          repository.ClusterNodeRepository
+	clusterService    ClusterService
+	clusterLogService ClusterLogService
+}
+
+func NewHostMaintenanceService() HostMaintenanceService {
+	return &hostMaintenanceService{
+		hostRepo:          repository.NewHostRepository(),
+		nodeRepo:          repository.NewClusterNodeRepository(),
+		clusterService:    NewClusterService(),
+		clusterLogService: NewClusterLogService(),
+	}
+}
+
+// Start puts the host in maintenance, its node is cordoned and drained, the host patched or
+// rebooted and the node uncordoned once Ready again. A failed step leaves the host in maintenance.
+func (h hostMaintenanceService) Start(name string, req dto.HostMaintenanceRequest) (*dto.HostMaintenance, error) {
+	host, err := h.hostRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := h.prepare(host, req)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := h.run(host, m, req); err != nil {
+			logger.Log.Errorf("host %s maintenance failed: %s", host.Name, err.Error())
+		}
+	}()
+	return &dto.HostMaintenance{HostMaintenance: *m, HostName: host.Name}, nil
+}
+
+// Exit uncordons the node of a host left in maintenance by a failed run.
+func (h hostMaintenanceService) Exit(name string) error {
+	host, err := h.hostRepo.Get(name)
+	if err != nil {
+		return err
+	}
+	if !host.Maintenance {
+		return errors.New(HostNotInMaintenance)
+	}
+	var running int
+	if err := db.DB.Model(&model.HostMaintenance{}).
+		Where("host_id = ? AND status IN (?)", host.ID, []string{constant.ClusterLogStatusWaiting, constant.ClusterLogStatusRunning}).
+		Count(&running).Error; err != nil {
+		return err
+	}
+	if running > 0 {
+		return errors.New(HostMaintenanceRunning)
+	}
+	var node model.ClusterNode
+	if err := db.DB.Where("host_id = ?", host.ID).First(&node).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+	if node.ID != "" {
+		client, err := h.kubeClient(node.ClusterID)
+		if err != nil {
+			return err
+		}
+		if err := kubernetesUtil.CordonNode(client, node.Name, false); err != nil {
+			return err
+		}
+	}
+	return db.DB.Model(&model.Host{}).Where("id = ?", host.ID).UpdateColumn("maintenance", false).Error
+}
+
+func (h hostMaintenanceService) List(name string) ([]dto.HostMaintenance, error) {
+	host, err := h.hostRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	var mos []model.HostMaintenance
+	if err := db.DB.Where("host_id = ?", host.ID).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_num")
+		}).
+		Order("created_at desc").
+		Find(&mos).Error; err != nil {
+		return nil, err
+	}
+	var items []dto.HostMaintenance
+	for _, mo := range mos {
+		items = append(items, dto.HostMaintenance{HostMaintenance: mo, HostName: host.Name})
+	}
+	return items, nil
+}
+
+// Rolling runs the maintenance on the nodes of the cluster batch by batch, masters one at a time,
+// a batch holds no more nodes than can be taken out while MinAvailable nodes stay ready and schedulable.
+func (h hostMaintenanceService) Rolling(clusterName string, req dto.ClusterMaintenanceRequest) error {
+	running, err := h.clusterLogService.GetRunningLogWithClusterNameAndType(clusterName, constant.ClusterLogTypeMaintain)
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+	if running.ID != "" {
+		return errors.New(ClusterMaintenanceRunning)
+	}
+	nodes, err := h.nodeRepo.List(clusterName)
+	if err != nil {
+		return err
+	}
+	if req.MinAvailable < 1 || req.MinAvailable >= len(nodes) {
+		return errors.New(MaintenanceMinAvailableInvalid)
+	}
+	var masters, workers []model.ClusterNode
+	for _, n := range nodes {
+		if len(req.Nodes) > 0 && !containsString(req.Nodes, n.Name) {
+			continue
+		}
+		if n.Host.Maintenance {
+			return errors.New(HostInMaintenance)
+		}
+		if n.Role == constant.NodeRoleNameMaster {
+			masters = append(masters, n)
+		} else {
+			workers = append(workers, n)
+		}
+	}
+
+	clog := model.ClusterLog{Type: constant.ClusterLogTypeMaintain}
+	if err := h.clusterLogService.Save(clusterName, &clog); err != nil {
+		return err
+	}
+	if err := h.clusterLogService.Start(&clog); err != nil {
+		return err
+	}
+	go func() {
+		err := h.rolling(masters, workers, req)
+		if err != nil {
+			logger.Log.Errorf("cluster %s rolling maintenance failed: %s", clusterName, err.Error())
+			_ = h.clusterLogService.End(&clog, false, err.Error())
+			return
+		}
+		_ = h.clusterLogService.End(&clog, true, "")
+	}()
+	return nil
+}
+
+func (h hostMaintenanceService) rolling(masters, workers []model.ClusterNode, req dto.ClusterMaintenanceRequest) error {
+	pending := append(append([]model.ClusterNode{}, masters...), workers...)
+	if len(pending) == 0 {
+		return nil
+	}
+	client, err := h.kubeClient(pending[0].ClusterID)
+	if err != nil {
+		return err
+	}
+	for len(pending) > 0 {
+		size, err := availableForMaintenance(client, req.MinAvailable)
+		if err != nil {
+			return err
+		}
+		if size < 1 {
+			return fmt.Errorf("less than %d nodes are available, stop the rolling maintenance", req.MinAvailable+1)
+		}
+		if pending[0].Role == constant.NodeRoleNameMaster {
+			size = 1
+		}
+		var batch []model.ClusterNode
+		for len(pending) > 0 && len(batch) < size {
+			batch = append(batch, pending[0])
+			pending = pending[1:]
+		}
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed []string
+		)
+		for i := range batch {
+			host := batch[i].Host
+			m, err := h.prepare(host, req.HostMaintenanceRequest)
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %s", batch[i].Name, err.Error()))
+				mu.Unlock()
+				continue
+			}
+			wg.Add(1)
+			go func(host model.Host, m *model.HostMaintenance) {
+				defer wg.Done()
+				if err := h.run(host, m, req.HostMaintenanceRequest); err != nil {
+					mu.Lock()
+					failed = append(failed, fmt.Sprintf("%s: %s", m.NodeName, err.Error()))
+					mu.Unlock()
+				}
+			}(host, m)
+		}
+		wg.Wait()
+		if len(failed) > 0 {
+			return errors.New(strings.Join(failed, "; "))
+		}
+	}
+	return nil
+}
+
+// prepare records the maintenance with the steps it runs and marks the host in maintenance.
+func (h hostMaintenanceService) prepare(host model.Host, req dto.HostMaintenanceRequest) (*model.HostMaintenance, error) {
+	if host.Maintenance {
+		return nil, errors.New(HostInMaintenance)
+	}
+	var node model.ClusterNode
+	if err := db.DB.Where("host_id = ?", host.ID).First(&node).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+	var names []string
+	if node.ID != "" {
+		names = append(names, constant.HostMaintenanceStepCordon, constant.HostMaintenanceStepDrain)
+	}
+	if req.Patch || req.Reboot {
+		names = append(names, constant.HostMaintenanceStepPatch)
+	}
+	if node.ID != "" {
+		names = append(names, constant.HostMaintenanceStepWait, constant.HostMaintenanceStepUncordon)
+	}
+	if len(names) == 0 {
+		return nil, errors.New(HostMaintenanceNothingToDo)
+	}
+
+	m := model.HostMaintenance{
+		HostID:    host.ID,
+		ClusterID: node.ClusterID,
+		NodeName:  node.Name,
+		Patch:     req.Patch,
+		Reboot:    req.Reboot,
+		Status:    constant.ClusterLogStatusWaiting,
+	}
+	tx := db.DB.Begin()
+	// only one maintenance gets the host, the check above may have read a stale flag
+	update := tx.Model(&model.Host{}).Where("id = ? AND maintenance = ?", host.ID, false).UpdateColumn("maintenance", true)
+	if update.Error != nil {
+		tx.Rollback()
+		return nil, update.Error
+	}
+	if update.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, errors.New(HostInMaintenance)
+	}
+	if err := tx.Create(&m).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for i, name := range names {
+		step := model.HostMaintenanceStep{
+			MaintenanceID: m.ID,
+			Name:          name,
+			Status:        constant.ClusterLogStatusWaiting,
+			OrderNum:      i,
+		}
+		if err := tx.Create(&step).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		m.Steps = append(m.Steps, step)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (h hostMaintenanceService) run(host model.Host, m *model.HostMaintenance, req dto.HostMaintenanceRequest) error {
+	m.Status = constant.ClusterLogStatusRunning
+	m.StartTime = time.Now()
+	db.DB.Save(m)
+
+	var client kubernetes.Interface
+	if m.ClusterID != "" {
+		c, err := h.kubeClient(m.ClusterID)
+		if err != nil {
+			h.end(m, err)
+			return err
+		}
+		client = c
+	}
+	drainTimeout := req.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = constant.DefaultDrainTimeout
+	}
+	var bootID string
+	if client != nil && m.Reboot {
+		id, err := kubernetesUtil.NodeBootID(client, m.NodeName)
+		if err != nil {
+			h.end(m, err)
+			return err
+		}
+		bootID = id
+	}
+	for i := range m.Steps {
+		step := &m.Steps[i]
+		step.Status = constant.ClusterLogStatusRunning
+		step.StartTime = time.Now()
+		db.DB.Save(step)
+
+		var err error
+		switch step.Name {
+		case constant.HostMaintenanceStepCordon:
+			err = kubernetesUtil.CordonNode(client, m.NodeName, true)
+		case constant.HostMaintenanceStepDrain:
+			step.Message, err = kubernetesUtil.DrainNode(client, m.NodeName, time.Duration(drainTimeout)*time.Minute)
+		case constant.HostMaintenanceStepPatch:
+			err = h.runPatch(host, m)
+		case constant.HostMaintenanceStepWait:
+			err = kubernetesUtil.WaitNodeReady(client, m.NodeName, bootID, constant.DefaultNodeReadyTimeout*time.Minute)
+		case constant.HostMaintenanceStepUncordon:
+			err = kubernetesUtil.CordonNode(client, m.NodeName, false)
+		}
+
+		step.EndTime = time.Now()
+		if err != nil {
+			step.Status = constant.ClusterLogStatusFailed
+			step.Message = strings.TrimSpace(step.Message + "\n" + err.Error())
+			db.DB.Save(step)
+			h.end(m, fmt.Errorf("%s: %s", step.Name, err.Error()))
+			return err
+		}
+		step.Status = constant.ClusterLogStatusSuccess
+		db.DB.Save(step)
+	}
+	h.end(m, nil)
+	return db.DB.Model(&model.Host{}).Where("id = ?", host.ID).UpdateColumn("maintenance", false).Error
+}
+
+func (h hostMaintenanceService) end(m *model.HostMaintenance, err error) {
+	m.EndTime = time.Now()
+	if err != nil {
+		m.Status = constant.ClusterLogStatusFailed
+		m.Message = err.Error()
+	} else {
+		m.Status = constant.ClusterLogStatusSuccess
+	}
+	if err := db.DB.Save(m).Error; err != nil {
+		logger.Log.Errorf("save host maintenance %s error: %s", m.ID, err.Error())
+	}
+}
+
+// runPatch upgrades the packages and reboots the host with ad-hoc modules, the reboot module
+// returns once the host answers ssh again.
+func (h hostMaintenanceService) runPatch(host model.Host, m *model.HostMaintenance) error {
+	password, privateKey, err := host.GetHostPasswordAndPrivateKey()
+	if err != nil {
+		return err
+	}
+	logDir := host.Name
+	if m.ClusterID != "" {
+		var cluster model.Cluster
+		if err := db.DB.Where("id = ?", m.ClusterID).First(&cluster).Error; err != nil {
+			return err
+		}
+		logDir = cluster.Name
+	}
+	_, writer, err := ansible.CreateAnsibleLogWriter(logDir)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	if err := host.WriteConnectionFiles(); err != nil {
+		return err
+	}
+	groupVars := map[string]string{}
+	if host.Credential.Username != "root" {
+		groupVars["ansible_become"] = "true"
+		if password != "" {
+			groupVars["ansible_become_pass"] = password
+		}
+	}
+	k := kobe.NewAnsible(&kobe.Config{
+		Inventory: &api.Inventory{
+			Hosts: []*api.Host{
+				{
+					Ip:         host.Ip,
+					Name:       host.Name,
+					Port:       int32(host.Port),
+					User:       host.Credential.Username,
+					Password:   password,
+					PrivateKey: string(privateKey),
+					Vars:       host.ConnectionVars(),
+				},
+			},
+			Groups: []*api.Group{
+				{
+					Name:     constant.HostMaintenanceGroup,
+					Children: []string{},
+					Vars:     groupVars,
+					Hosts:    []string{host.Name},
+				},
+			},
+		},
+	})
+	if m.Patch {
+		if err := phases.RunAdhocAndGetResult(k, constant.HostMaintenanceGroup, "shell", constant.HostMaintenancePatchCommand, writer); err != nil {
+			return err
+		}
+	}
+	if m.Reboot {
+		param := fmt.Sprintf("reboot_timeout=%d", constant.HostRebootTimeout)
+		if err := phases.RunAdhocAndGetResult(k, constant.HostMaintenanceGroup, "reboot", param, writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h hostMaintenanceService) kubeClient(clusterID string) (*kubernetes.Clientset, error) {
+	var cluster model.Cluster
+	if err := db.DB.Where("id = ?", clusterID).First(&cluster).Error; err != nil {
+		return nil, err
+	}
+	secret, err := h.clusterService.GetSecrets(cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := h.clusterService.GetApiServerEndpoints(cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetesUtil.NewKubernetesClient(&kubernetesUtil.Config{
+		Hosts: endpoints,
+		Token: secret.KubernetesToken,
+	})
+}
+
+// availableForMaintenance is how many nodes can be taken out while minAvailable stay ready and schedulable.
+func availableForMaintenance(client kubernetes.Interface, minAvailable int) (int, error) {
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+	available := 0
+	for i := range nodes.Items {
+		if !nodes.Items[i].Spec.Unschedulable && kubernetesUtil.IsNodeReady(&nodes.Items[i]) {
+			available++
+		}
+	}
+	return available - minAvailable, nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
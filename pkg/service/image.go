@@ -0,0 +1,434 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider"
+	"github.com/KubeOperator/KubeOperator/pkg/cloud_storage"
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
+	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	ImageNameExist       = "NAME_EXISTS"
+	ImageArtifactMissing = "IMAGE_ARTIFACT_MISSING"
+)
+
+// zoneImageVars are the zone vars a provider needs to know where to import an image.
+var zoneImageVars = []string{"cluster", "resourcePool", "datastore", "network", "resourceType", "hostSystem", "portgroup"}
+
+type ImageService interface {
+	Get(name string) (*dto.Image, error)
+	List(conditions condition.Conditions) ([]dto.Image, error)
+	Page(num, size int, conditions condition.Conditions) (*page.Page, error)
+	Create(creation dto.ImageCreate) (*dto.Image, error)
+	Update(name string, update dto.ImageUpdate) (*dto.Image, error)
+	Delete(name string) error
+	Batch(op dto.ImageOp) error
+}
+
+type imageService struct {
+	imageRepo repository.ImageRepository
+}
+
+func NewImageService() ImageService {
+	return &imageService{
+		imageRepo: repository.NewImageRepository(),
+	}
+}
+
+func (i imageService) Get(name string) (*dto.Image, error) {
+	image, err := i.imageRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return toImageDTO(image), nil
+}
+
+func (i imageService) List(conditions condition.Conditions) ([]dto.Image, error) {
+	var (
+		imageDTOs []dto.Image
+		images    []model.Image
+	)
+	d := db.DB.Model(model.Image{})
+	if err := dbUtil.WithConditions(&d, model.Image{}, conditions); err != nil {
+		return nil, err
+	}
+	if err := d.Order("name").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		imageDTOs = append(imageDTOs, *toImageDTO(image))
+	}
+	return imageDTOs, nil
+}
+
+func (i imageService) Page(num, size int, conditions condition.Conditions) (*page.Page, error) {
+	var (
+		p         page.Page
+		imageDTOs []dto.Image
+		images    []model.Image
+	)
+	d := db.DB.Model(model.Image{})
+	if err := dbUtil.WithConditions(&d, model.Image{}, conditions); err != nil {
+		return nil, err
+	}
+	if err := d.Count(&p.Total).Order("name").Offset((num - 1) * size).Limit(size).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		imageDTOs = append(imageDTOs, *toImageDTO(image))
+	}
+	p.Items = imageDTOs
+	return &p, nil
+}
+
+func (i imageService) Create(creation dto.ImageCreate) (*dto.Image, error) {
+	old, err := i.imageRepo.Get(creation.Name)
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+	if old.ID != "" {
+		return nil, errors.New(ImageNameExist)
+	}
+	artifacts, _ := json.Marshal(creation.ImageArtifacts)
+	image := model.Image{
+		Name:         creation.Name,
+		OsFamily:     creation.OsFamily,
+		OsVersion:    creation.OsVersion,
+		Architecture: creation.Architecture,
+		Checksum:     creation.Checksum,
+		Artifacts:    string(artifacts),
+	}
+	if err := i.imageRepo.Save(&image); err != nil {
+		return nil, err
+	}
+	return toImageDTO(image), nil
+}
+
+func (i imageService) Update(name string, update dto.ImageUpdate) (*dto.Image, error) {
+	image, err := i.imageRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if update.OsFamily != "" {
+		image.OsFamily = update.OsFamily
+	}
+	if update.OsVersion != "" {
+		image.OsVersion = update.OsVersion
+	}
+	if update.Architecture != "" {
+		image.Architecture = update.Architecture
+	}
+	if update.Checksum != "" {
+		image.Checksum = update.Checksum
+	}
+	if update.ImageArtifacts != nil {
+		artifacts, _ := json.Marshal(update.ImageArtifacts)
+		image.Artifacts = string(artifacts)
+	}
+	if err := i.imageRepo.Save(&image); err != nil {
+		return nil, err
+	}
+	return toImageDTO(image), nil
+}
+
+func (i imageService) Delete(name string) error {
+	return i.imageRepo.Delete(name)
+}
+
+func (i imageService) Batch(op dto.ImageOp) error {
+	var items []model.Image
+	for _, item := range op.Items {
+		items = append(items, model.Image{Name: item.Name})
+	}
+	return i.imageRepo.Batch(op.Operation, items)
+}
+
+func toImageDTO(image model.Image) *dto.Image {
+	artifacts := map[string]map[string]string{}
+	_ = json.Unmarshal([]byte(image.Artifacts), &artifacts)
+	return &dto.Image{Image: image, ImageArtifacts: artifacts}
+}
+
+// getImageByName returns the catalog image picked by name, or nil when no image is picked.
+func getImageByName(name string) (*model.Image, error) {
+	if name == "" {
+		return nil, nil
+	}
+	image, err := repository.NewImageRepository().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// resolveHostImage picks the image of the vm config first and the one of the plan otherwise,
+// nil means the host is cloned from the template of its zone.
+func resolveHostImage(plan model.Plan, vmConfigName string) (*model.Image, error) {
+	imageRepo := repository.NewImageRepository()
+	if vmConfigName != "" && !useFlavor(plan.Region.Provider) {
+		vmConfig, err := repository.NewVmConfigRepository().Get(vmConfigName)
+		if err != nil {
+			return nil, err
+		}
+		if vmConfig.ImageID != "" {
+			image, err := imageRepo.GetById(vmConfig.ImageID)
+			if err != nil {
+				return nil, err
+			}
+			return &image, nil
+		}
+	}
+	if plan.ImageID != "" {
+		image, err := imageRepo.GetById(plan.ImageID)
+		if err != nil {
+			return nil, err
+		}
+		return &image, nil
+	}
+	return nil, nil
+}
+
+// assignHostImages records the template each host is created from, the images are imported by importHostImages.
+func assignHostImages(plan model.Plan, hosts []*model.Host) error {
+	planVars := map[string]string{}
+	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
+	for _, h := range hosts {
+		image, err := resolveHostImage(plan, planVars[fmt.Sprintf("%sModel", getHostRole(h.Name))])
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			h.Image = image.TemplateName(plan.Region.Provider)
+		}
+	}
+	return nil
+}
+
+// imageImports holds the imports in progress keyed by zone and image, so an image is imported once per zone.
+var imageImports sync.Map
+
+type imageImport struct {
+	done chan struct{}
+	err  error
+}
+
+// importImage starts importing the image into the zone in the background, or joins the import already in progress.
+func importImage(region model.Region, zone model.Zone, image model.Image) *imageImport {
+	key := zone.ID + "/" + image.ID
+	im := &imageImport{done: make(chan struct{})}
+	if running, loaded := imageImports.LoadOrStore(key, im); loaded {
+		return running.(*imageImport)
+	}
+	go func() {
+		zoneVars := map[string]interface{}{}
+		_ = json.Unmarshal([]byte(zone.Vars), &zoneVars)
+		im.err = uploadImageToZone(region, zoneVars, image)
+		if im.err != nil {
+			logger.Log.Errorf("import image %s into zone %s failed: %s", image.Name, zone.Name, im.err.Error())
+		}
+		imageImports.Delete(key)
+		close(im.done)
+	}()
+	return im
+}
+
+// importPlanImages imports the images of the plan into its zones ahead of cluster creation.
+func importPlanImages(plan model.Plan) {
+	planVars := map[string]string{}
+	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
+	images := map[string]model.Image{}
+	for _, role := range []string{constant.NodeRoleNameMaster, constant.NodeRoleNameWorker} {
+		image, err := resolveHostImage(plan, planVars[fmt.Sprintf("%sModel", role)])
+		if err != nil {
+			logger.Log.Errorf("resolve image of plan %s failed: %s", plan.Name, err.Error())
+			return
+		}
+		if image != nil {
+			images[image.ID] = *image
+		}
+	}
+	for _, zone := range plan.Zones {
+		for _, image := range images {
+			importImage(plan.Region, zone, image)
+		}
+	}
+}
+
+// importHostImages waits until the images of the hosts are imported into their zones, starting the imports still missing.
+func importHostImages(plan model.Plan, hosts []*model.Host) error {
+	planVars := map[string]string{}
+	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
+	zones := map[string]model.Zone{}
+	for _, zone := range plan.Zones {
+		zones[zone.ID] = zone
+	}
+	var imports []*imageImport
+	started := map[string]bool{}
+	for _, h := range hosts {
+		if h.Image == "" {
+			continue
+		}
+		image, err := resolveHostImage(plan, planVars[fmt.Sprintf("%sModel", getHostRole(h.Name))])
+		if err != nil {
+			return err
+		}
+		zone, ok := zones[h.ZoneID]
+		if image == nil || !ok || started[zone.ID+"/"+image.ID] {
+			continue
+		}
+		started[zone.ID+"/"+image.ID] = true
+		imports = append(imports, importImage(plan.Region, zone, *image))
+	}
+	for _, im := range imports {
+		<-im.done
+		if im.err != nil {
+			return im.err
+		}
+	}
+	return nil
+}
+
+// uploadImageToZone imports the catalog image into the zone unless the provider already has it.
+func uploadImageToZone(region model.Region, zoneVars map[string]interface{}, image model.Image) error {
+	var repo model.SystemRegistry
+	architecture := image.Architecture
+	if architecture == "" {
+		architecture = constant.ArchitectureOfAMD64
+	}
+	if err := db.DB.Where("architecture = ?", architecture).First(&repo).Error; err != nil {
+		return fmt.Errorf("can't find local ip from system setting, err %s", err.Error())
+	}
+	repoAddr := fmt.Sprintf("http://%s:%d", repo.Hostname, repo.RepoPort)
+
+	regionVars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(region.Vars), &regionVars); err != nil {
+		return err
+	}
+	regionVars["datacenter"] = region.Datacenter
+	for _, key := range zoneImageVars {
+		if zoneVars[key] != nil {
+			regionVars[key] = zoneVars[key]
+		}
+	}
+	regionVars["imageName"] = image.TemplateName(region.Provider)
+	regionVars["checksum"] = image.Checksum
+
+	artifacts := image.ArtifactsOf(region.Provider)
+	artifact := func(kind string) (string, error) {
+		u := artifacts[kind]
+		if u == "" {
+			return "", errors.New(ImageArtifactMissing)
+		}
+		if strings.HasPrefix(u, "/") {
+			u = repoAddr + u
+		}
+		return u, nil
+	}
+	var err error
+	switch region.Provider {
+	case constant.VSphere:
+		if regionVars["ovfPath"], err = artifact("ovf"); err != nil {
+			return err
+		}
+		if regionVars["vmdkPath"], err = artifact("vmdk"); err != nil {
+			return err
+		}
+	case constant.OpenStack, constant.Proxmox, constant.Libvirt:
+		if regionVars["imagePath"], err = artifact("qcow2"); err != nil {
+			return err
+		}
+	}
+
+	cloudClient := cloud_provider.NewCloudClient(regionVars)
+	if cloudClient == nil {
+		return nil
+	}
+	exist, err := cloudClient.DefaultImageExist()
+	if err != nil {
+		return err
+	}
+	if exist {
+		return nil
+	}
+	if region.Provider == constant.FusionCompute {
+		ovfPath, err := artifact("ovf")
+		if err != nil {
+			return err
+		}
+		vhdPath, err := artifact("vhd")
+		if err != nil {
+			return err
+		}
+		ovfName, err := uploadToNfs(zoneVars, ovfPath, vhdPath)
+		if err != nil {
+			return err
+		}
+		regionVars["ovfPath"] = zoneVars["nfsAddress"].(string) + ":" + zoneVars["nfsFolder"].(string) + "/" + ovfName
+		cloudClient = cloud_provider.NewCloudClient(regionVars)
+	}
+	return cloudClient.UploadImage()
+}
+
+// uploadToNfs stages the FusionCompute artifacts on the nfs share of the zone and returns the ovf file name.
+func uploadToNfs(zoneVars map[string]interface{}, urls ...string) (string, error) {
+	nfsVars := make(map[string]interface{})
+	nfsVars["type"] = "SFTP"
+	nfsVars["address"] = zoneVars["nfsAddress"]
+	nfsVars["port"] = zoneVars["nfsPort"]
+	nfsVars["username"] = zoneVars["nfsUsername"]
+	nfsVars["password"] = zoneVars["nfsPassword"]
+	nfsVars["bucket"] = zoneVars["nfsFolder"]
+	client, err := cloud_storage.NewCloudStorageClient(nfsVars)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, u := range urls {
+		name := path.Base(u)
+		local := "./" + name
+		if err := downloadFile(u, local); err != nil {
+			return "", err
+		}
+		if _, err := client.Upload(local, name); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+	return names[0], nil
+}
+
+func downloadFile(url string, dst string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.New(path.Base(url) + " not found")
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
 	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
@@ -12,11 +16,22 @@ import (
 	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/util/ipaddr"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
 	"github.com/jinzhu/gorm"
 )
 
+var (
+	IpRangeRequired = "IP_RANGE_REQUIRED"
+	IpRangeTooLarge = "IP_RANGE_TOO_LARGE"
+)
+
+// MaxIpRangeSize caps the addresses a single request adds to a pool.
+const MaxIpRangeSize = 1024
+
 type IpService interface {
 	Get(ip string) (dto.Ip, error)
 	Create(create dto.IpCreate, tx *gorm.DB) error
@@ -26,6 +41,8 @@ type IpService interface {
 	Sync(ipPoolName string) error
 	Delete(address string) error
 	List(ipPoolName string, conditions condition.Conditions) ([]dto.Ip, error)
+	History(address string) ([]dto.IpHistory, error)
+	DetectConflicts() error
 }
 
 type ipService struct {
@@ -72,30 +89,78 @@ func (i ipService) List(ipPoolName string, conditions condition.Conditions) ([]d
 }
 
 func (i ipService) Create(create dto.IpCreate, tx *gorm.DB) error {
-	if tx == nil {
+	// a transaction of the caller is rolled back by the caller
+	began := tx == nil
+	if began {
 		tx = db.DB.Begin()
 	}
+	fail := func(err error) error {
+		if began {
+			tx.Rollback()
+		}
+		return err
+	}
 	var ipPool model.IpPool
 	if err := tx.Where("name = ?", create.IpPoolName).First(&ipPool).Error; err != nil {
-		return err
+		return fail(err)
+	}
+	_, subnet, err := net.ParseCIDR(ipPool.Subnet)
+	if err != nil {
+		return fail(errors.New("IP_INVALID"))
+	}
+	// the pool exclusions and the gateway are never handed out
+	poolRange := ipaddr.NewCIDRRange(subnet)
+	exclusions := append(splitExclusions(ipPool.Exclusions), create.Exclusions...)
+	if create.Gateway != "" {
+		exclusions = append(exclusions, create.Gateway)
 	}
-	cs := strings.Split(ipPool.Subnet, "/")
-	mask, _ := strconv.Atoi(cs[1])
-	startIp := strings.Replace(create.IpStart, " ", "", -1)
-	endIp := strings.Replace(create.IpEnd, " ", "", -1)
-	if !(ipaddr.CheckIP(startIp) && ipaddr.CheckIP(endIp)) {
-		return errors.New("IP_INVALID")
+	if err := poolRange.Exclude(exclusions...); err != nil {
+		return fail(errors.New("IP_EXCLUSION_INVALID"))
+	}
+	var ips []string
+	if create.IpStart == "" && create.IpEnd == "" {
+		if create.Cidr == "" {
+			return fail(errors.New(IpRangeRequired))
+		}
+		_, ipNet, err := net.ParseCIDR(create.Cidr)
+		if err != nil {
+			return fail(errors.New("IP_INVALID"))
+		}
+		ones, _ := ipNet.Mask.Size()
+		poolOnes, _ := subnet.Mask.Size()
+		if !subnet.Contains(ipNet.IP) || ones < poolOnes {
+			return fail(errors.New("IP_CIDR_OUT_OF_SUBNET"))
+		}
+		for _, ip := range poolRange.FreeIPs() {
+			if ipNet.Contains(ip) {
+				ips = append(ips, ip.String())
+			}
+		}
+	} else {
+		cs := strings.Split(ipPool.Subnet, "/")
+		mask, _ := strconv.Atoi(cs[1])
+		startIp := strings.Replace(create.IpStart, " ", "", -1)
+		endIp := strings.Replace(create.IpEnd, " ", "", -1)
+		if !(ipaddr.CheckIP(startIp) && ipaddr.CheckIP(endIp)) {
+			return fail(errors.New("IP_INVALID"))
+		}
+		for _, ip := range ipaddr.GenerateIps(cs[0], mask, startIp, endIp) {
+			if !poolRange.Has(net.ParseIP(ip)) {
+				ips = append(ips, ip)
+			}
+		}
 	}
-	ips := ipaddr.GenerateIps(cs[0], mask, startIp, endIp)
 	if len(ips) == 0 {
-		return errors.New("IP_NULL")
+		return fail(errors.New("IP_NULL"))
+	}
+	if len(ips) > MaxIpRangeSize {
+		return fail(errorf.New(IpRangeTooLarge, len(ips), MaxIpRangeSize))
 	}
 	for _, ip := range ips {
 		var old model.Ip
 		tx.Where("address = ?", ip).First(&old)
 		if old.ID != "" {
-			tx.Rollback()
-			return errors.New("IP_EXISTS")
+			return fail(errors.New("IP_EXISTS"))
 		}
 		insert := model.Ip{
 			Address:  ip,
@@ -107,8 +172,7 @@ func (i ipService) Create(create dto.IpCreate, tx *gorm.DB) error {
 		}
 		err := tx.Create(&insert).Error
 		if err != nil {
-			tx.Rollback()
-			return err
+			return fail(err)
 		}
 		go func() {
 			err := ipaddr.Ping(insert.Address)
@@ -118,7 +182,9 @@ func (i ipService) Create(create dto.IpCreate, tx *gorm.DB) error {
 			}
 		}()
 	}
-	tx.Commit()
+	if began {
+		tx.Commit()
+	}
 	return nil
 }
 
@@ -188,11 +254,28 @@ func (i ipService) Update(address string, update dto.IpUpdate) (*dto.Ip, error)
 	if err != nil {
 		return nil, err
 	}
+	action := ""
 	switch update.Operation {
-	case "LOCK":
+	case constant.IpOperationLock:
 		ip.Status = constant.IpLock
-	case "UNLOCK":
+		action = constant.IpActionLock
+	case constant.IpOperationUnlock:
 		ip.Status = constant.IpAvailable
+		action = constant.IpActionUnlock
+	case constant.IpOperationReserve:
+		if ip.Status == constant.IpUsed {
+			tx.Rollback()
+			return nil, errors.New("IP_RESERVE_USED")
+		}
+		ip.Status = constant.IpReserved
+		ip.ReservedFor = update.ReservedFor
+		action = constant.IpActionReserve
+	case constant.IpOperationUnreserve:
+		if ip.Status == constant.IpReserved {
+			ip.Status = constant.IpAvailable
+			action = constant.IpActionUnreserve
+		}
+		ip.ReservedFor = ""
 	default:
 		break
 	}
@@ -200,6 +283,12 @@ func (i ipService) Update(address string, update dto.IpUpdate) (*dto.Ip, error)
 	if err != nil {
 		return nil, err
 	}
+	if action != "" {
+		if err := model.RecordIpHistory(tx, ip, action, update.ReservedFor); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
 	tx.Commit()
 	return &dto.Ip{Ip: ip}, err
 }
@@ -226,32 +315,175 @@ func (i ipService) Sync(ipPoolName string) error {
 	if err != nil {
 		return err
 	}
+	go detectPoolConflicts(ips)
+	return nil
+}
+
+func (i ipService) History(address string) ([]dto.IpHistory, error) {
+	var histories []model.IpHistory
+	if err := db.DB.Where("address = ?", address).Order("created_at desc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	var historyDTOs []dto.IpHistory
+	for _, h := range histories {
+		historyDTOs = append(historyDTOs, dto.IpHistory{IpHistory: h})
+	}
+	return historyDTOs, nil
+}
+
+// DetectConflicts checks every pool ip against what answers on it, pool by pool.
+func (i ipService) DetectConflicts() error {
+	var ips []model.Ip
+	if err := db.DB.Where("status in (?)", []string{constant.IpAvailable, constant.IpReachable, constant.IpUsed}).Find(&ips).Error; err != nil {
+		return err
+	}
+	pools := map[string][]model.Ip{}
 	for i := range ips {
-		if ips[i].Status == constant.IpLock {
+		pools[ips[i].IpPoolID] = append(pools[ips[i].IpPoolID], ips[i])
+	}
+	for _, poolIps := range pools {
+		detectPoolConflicts(poolIps)
+	}
+	return nil
+}
+
+// detectPoolConflicts checks the ips of one pool. The macs come from the arp table of a host of the pool,
+// only a host on the subnet of the pool sees which mac answers on an address.
+func detectPoolConflicts(ips []model.Ip) {
+	var addresses, used []string
+	for i := range ips {
+		addresses = append(addresses, ips[i].Address)
+		if ips[i].Status == constant.IpUsed {
+			used = append(used, ips[i].Address)
+		}
+	}
+	var hosts []model.Host
+	if err := db.DB.Where("ip in (?)", addresses).Preload("Credential").Find(&hosts).Error; err != nil {
+		logger.Log.Errorf("load hosts of ips error: %s", err.Error())
+		return
+	}
+	holders := map[string]model.Host{}
+	for i := range hosts {
+		holders[hosts[i].Ip] = hosts[i]
+	}
+	var macs map[string]string
+	if len(used) > 0 {
+		macs = probeArpMacs(hosts, used)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for i := range ips {
+		wg.Add(1)
+		go func(ip model.Ip) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			checkIpConflict(ip, holders[ip.Address], macs)
+		}(ips[i])
+	}
+	wg.Wait()
+}
+
+// probeArpMacs reads the macs answering on the addresses from the first pool host reachable over ssh,
+// nil when none is and the mac check is skipped.
+func probeArpMacs(hosts []model.Host, addresses []string) map[string]string {
+	for _, host := range hosts {
+		password, privateKey, err := host.GetHostPasswordAndPrivateKey()
+		if err != nil {
 			continue
 		}
-		var host model.Host
-		db.DB.Model(model.Host{}).Where("ip = ?", ips[i].Address).Find(&host)
-		if host.ID != "" {
-			if ips[i].Status == constant.IpUsed {
-				continue
-			} else {
-				ips[i].Status = constant.IpUsed
-				db.DB.Save(&ips[i])
-				continue
-			}
+		bastion, err := host.BastionSSHConfig()
+		if err != nil {
+			continue
 		}
-		go func(i int) {
-			err := ipaddr.Ping(ips[i].Address)
-			if err == nil && ips[i].Status != constant.IpReachable {
-				ips[i].Status = constant.IpReachable
-				db.DB.Save(&ips[i])
-			}
-			if err != nil && ips[i].Status == constant.IpReachable {
-				ips[i].Status = constant.IpAvailable
-				db.DB.Save(&ips[i])
-			}
-		}(i)
+		client, err := ssh.New(&ssh.Config{
+			User:        host.Credential.Username,
+			Host:        host.Ip,
+			Port:        host.Port,
+			Password:    password,
+			PrivateKey:  privateKey,
+			DialTimeOut: 5 * time.Second,
+			Retry:       1,
+			HostKey:     host.HostKey,
+			Bastion:     bastion,
+		})
+		if err != nil {
+			continue
+		}
+		stdout, _, _, err := client.Exec(ipaddr.ArpProbeCommand(addresses))
+		if err != nil {
+			logger.Log.Debugf("probe arp table on host %s error: %s", host.Name, err.Error())
+			continue
+		}
+		return ipaddr.ParseArpTable(stdout)
 	}
+	logger.Log.Debugf("no host reachable to probe the macs of %s", strings.Join(addresses, ","))
 	return nil
 }
+
+// checkIpConflict compares what answers on a pool ip with what the pool expects of it:
+// an available ip must stay silent and an ip held by a host must keep answering with the same mac.
+// An ip a host holds but the pool does not know as used is a conflict and is allocated to that host.
+// Locked and reserved ips are left alone, a reserved vip may float between hosts.
+func checkIpConflict(ip model.Ip, holder model.Host, macs map[string]string) {
+	if ip.Status == constant.IpLock || ip.Status == constant.IpReserved {
+		return
+	}
+	if holder.Name != "" && (ip.Status != constant.IpUsed || ip.HostName != holder.Name) {
+		message := fmt.Sprintf("held by host %s", holder.Name)
+		if err := model.RecordIpHistory(db.DB, ip, constant.IpActionConflict, message); err != nil {
+			logger.Log.Errorf("save ip %s history error: %s", ip.Address, err.Error())
+		}
+		if err := model.AllocateIp(db.DB, &ip, holder.ClusterID, holder.Name); err != nil {
+			logger.Log.Errorf("save ip %s error: %s", ip.Address, err.Error())
+		}
+		return
+	}
+	reachable := ipaddr.Ping(ip.Address) == nil
+	var message string
+	switch ip.Status {
+	case constant.IpAvailable:
+		if !reachable {
+			return
+		}
+		ip.Status = constant.IpReachable
+		message = "answers while available"
+	case constant.IpReachable:
+		if reachable {
+			return
+		}
+		ip.Status = constant.IpAvailable
+	case constant.IpUsed:
+		mac := macs[ip.Address]
+		switch {
+		case !reachable || mac == "" || mac == ip.Mac && !ip.Conflict:
+			return
+		case ip.Mac == "":
+			ip.Mac = mac
+		case mac == ip.Mac:
+			ip.Conflict = false
+		case ip.Conflict:
+			return
+		default:
+			ip.Conflict = true
+			message = fmt.Sprintf("%s answers instead of %s", mac, ip.Mac)
+		}
+	}
+	if err := db.DB.Save(&ip).Error; err != nil {
+		logger.Log.Errorf("save ip %s error: %s", ip.Address, err.Error())
+		return
+	}
+	if message != "" {
+		if err := model.RecordIpHistory(db.DB, ip, constant.IpActionConflict, message); err != nil {
+			logger.Log.Errorf("save ip %s history error: %s", ip.Address, err.Error())
+		}
+	}
+}
+
+func splitExclusions(exclusions string) []string {
+	if exclusions == "" {
+		return nil
+	}
+	return strings.Split(exclusions, ",")
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"strings"
+
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
@@ -106,6 +108,7 @@ func (i ipPoolService) Create(creation dto.IpPoolCreate) (dto.IpPool, error) {
 		Name:        creation.Name,
 		Description: creation.Description,
 		Subnet:      creation.Subnet,
+		Exclusions:  strings.Join(creation.Exclusions, ","),
 	}
 	tx := db.DB.Begin()
 	err := tx.Create(&ipPool).Error
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/tools"
+	"github.com/KubeOperator/KubeOperator/pkg/util/logsearch"
+)
+
+var (
+	LogSearchClusterForbidden = "LOG_SEARCH_CLUSTER_FORBIDDEN"
+	LogSearchTimeInvalid      = "LOG_SEARCH_TIME_INVALID"
+)
+
+const defaultLogSearchLimit = 100
+
+type LogSearchService interface {
+	Search(user dto.SessionUser, search dto.LogSearch) (*dto.LogSearchResult, error)
+}
+
+type logSearchService struct {
+	clusterService ClusterService
+}
+
+func NewLogSearchService() LogSearchService {
+	return &logSearchService{
+		clusterService: NewClusterService(),
+	}
+}
+
+// Search queries the log store of every cluster in parallel, the logging tool wins when both tools
+// are enabled. The entries are merged newest first, a cluster which fails is reported in errors.
+func (l logSearchService) Search(user dto.SessionUser, search dto.LogSearch) (*dto.LogSearchResult, error) {
+	clusters, err := userClusters(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(search.Clusters) > 0 {
+		allowed := map[string]model.Cluster{}
+		for _, c := range clusters {
+			allowed[c.Name] = c
+		}
+		clusters = nil
+		for _, name := range search.Clusters {
+			c, ok := allowed[name]
+			if !ok {
+				return nil, errorf.New(LogSearchClusterForbidden, name)
+			}
+			clusters = append(clusters, c)
+		}
+	}
+	if search.End.IsZero() {
+		search.End = time.Now()
+	}
+	if search.Start.IsZero() {
+		search.Start = search.End.Add(-time.Hour)
+	}
+	if !search.Start.Before(search.End) {
+		return nil, errors.New(LogSearchTimeInvalid)
+	}
+	if search.Limit == 0 {
+		search.Limit = defaultLogSearchLimit
+	}
+	q := logsearch.Query{
+		Namespace: search.Namespace,
+		Pod:       search.Pod,
+		Text:      search.Query,
+		Start:     search.Start,
+		End:       search.End,
+		Limit:     search.Limit,
+	}
+
+	result := dto.LogSearchResult{Items: []dto.LogEntry{}, Errors: []dto.LogSearchError{}}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, c := range clusters {
+		wg.Add(1)
+		go func(c model.Cluster) {
+			defer wg.Done()
+			source, client, err := l.logStore(c)
+			var entries []logsearch.Entry
+			if err == nil {
+				entries, err = client.Search(q)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors = append(result.Errors, dto.LogSearchError{ClusterName: c.Name, Message: err.Error()})
+				return
+			}
+			for _, e := range entries {
+				result.Items = append(result.Items, dto.LogEntry{ClusterName: c.Name, Source: source, Entry: e})
+			}
+		}(c)
+	}
+	wg.Wait()
+	sort.SliceStable(result.Items, func(i, j int) bool { return result.Items[i].Time.After(result.Items[j].Time) })
+	if len(result.Items) > search.Limit {
+		result.Items = result.Items[:search.Limit]
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].ClusterName < result.Errors[j].ClusterName })
+	return &result, nil
+}
+
+// logStore returns the client of the store the logs of the cluster are shipped to: the one of the
+// tool behind the ingress of the cluster or the external target of the log settings of the tool.
+func (l logSearchService) logStore(cluster model.Cluster) (string, logsearch.Interface, error) {
+	var toolList []model.ClusterTool
+	if err := db.DB.Where("cluster_id = ? AND name IN (?) AND status = ?", cluster.ID, []string{"logging", "loki"}, constant.ClusterRunning).
+		Order("name").Find(&toolList).Error; err != nil {
+		return "", nil, err
+	}
+	if len(toolList) == 0 {
+		return "", nil, errors.New("neither logging nor loki is running in the cluster")
+	}
+	tool := toolList[0]
+	config, err := tools.ParseLogConfig(tool.Name, toolVars(tool))
+	if err != nil {
+		return "", nil, err
+	}
+	if config != nil && config.Target.Type != "" {
+		t := config.Target
+		user, password, err := t.Credentials()
+		if err != nil {
+			return "", nil, err
+		}
+		switch t.Type {
+		case constant.LogTargetElasticsearch:
+			scheme := t.Scheme
+			if scheme == "" {
+				scheme = "http"
+			}
+			return t.Type, logsearch.NewElasticsearch(logsearch.Config{Url: fmt.Sprintf("%s://%s:%d", scheme, t.Host, t.Port), Username: user, Password: password}), nil
+		case constant.LogTargetLoki:
+			return t.Type, logsearch.NewLoki(logsearch.Config{Url: strings.TrimSuffix(t.Url, "/loki/api/v1/push"), Username: user, Password: password, Tenants: config.LokiTenants()}), nil
+		default:
+			return "", nil, fmt.Errorf("logs are forwarded to %s %s:%d and can not be searched", t.Type, t.Host, t.Port)
+		}
+	}
+	endpoint, err := l.clusterService.GetRouterEndpoint(cluster.Name)
+	if err != nil {
+		return "", nil, err
+	}
+	url := fmt.Sprintf("http://%s", endpoint.Address)
+	if tool.Name == "logging" {
+		return constant.LogTargetElasticsearch, logsearch.NewElasticsearch(logsearch.Config{Url: url, Host: constant.DefaultLoggingIngress}), nil
+	}
+	var tenants []string
+	if config != nil {
+		tenants = config.LokiTenants()
+	}
+	return constant.LogTargetLoki, logsearch.NewLoki(logsearch.Config{Url: url, Host: constant.DefaultLokiIngress, Tenants: tenants}), nil
+}
+
+// userClusters returns the clusters the user may access: all for admins, the clusters of the
+// projects managed by the user and the ones the user is a member of.
+func userClusters(user dto.SessionUser) ([]model.Cluster, error) {
+	var clusters []model.Cluster
+	if user.IsAdmin {
+		if err := db.DB.Order("name").Find(&clusters).Error; err != nil {
+			return nil, err
+		}
+		return clusters, nil
+	}
+	var projectMembers []model.ProjectMember
+	if err := db.DB.Where("user_id = ? AND role = ?", user.UserId, constant.ProjectRoleProjectManager).Find(&projectMembers).Error; err != nil {
+		return nil, err
+	}
+	var projectIDs []string
+	for _, m := range projectMembers {
+		projectIDs = append(projectIDs, m.ProjectID)
+	}
+	var resources []model.ProjectResource
+	if err := db.DB.Where("resource_type = ? AND project_id IN (?)", constant.ResourceCluster, projectIDs).Find(&resources).Error; err != nil {
+		return nil, err
+	}
+	var clusterMembers []model.ClusterMember
+	if err := db.DB.Where("user_id = ?", user.UserId).Find(&clusterMembers).Error; err != nil {
+		return nil, err
+	}
+	var clusterIDs []string
+	for _, r := range resources {
+		clusterIDs = append(clusterIDs, r.ResourceID)
+	}
+	for _, m := range clusterMembers {
+		clusterIDs = append(clusterIDs, m.ClusterID)
+	}
+	if err := db.DB.Where("id IN (?)", clusterIDs).Order("name").Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
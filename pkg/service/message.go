@@ -117,6 +117,22 @@ func (m messageService) GetContentByTitleAndType(content, title, sendType, clust
 				"<tr><td align=\"left\">告警时间: " + date + " </td></tr>" +
 				"<tr><td align=\"left\">详情: " + detail["message"] + "</td>/tr></table>" +
 				"<p>此邮件为KubeOperator平台自动发送，请勿回复!</p></div></body></html>"
+		} else if title == constant.ClusterAlert {
+			result = "<html>" +
+				"<head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=utf-8\"></head>" +
+				"<body><style> table { font-size: 14px; table-layout:fixed;border:5px solid #F2F2F2;}td { font-family: Arial; WORD-WRAP: break-word }</style>" +
+				"<div align=\"center\"> <table border=\"0\" cellspacing=\"2\" cellpadding=\"2\" width=\"900\"> <tr bgcolor=\"#D1D1D1\"> " +
+				"<th align=\"left\" style=\"font-size:23px;\">" + Tr(title) + "</th></tr><tr><td align=\"left\">" +
+				"项目:" + project.Name + "</td></tr>" +
+				"<tr><td align=\"left\">集群:" + clusterName + "</td></tr>" +
+				"<tr><td align=\"left\">告警:" + detail["name"] + "</td></tr>" +
+				"<tr><td align=\"left\">级别:" + detail["severity"] + "</td></tr>" +
+				"<tr><td align=\"left\">状态:" + detail["status"] + "</td></tr>" +
+				"<tr><td align=\"left\">实例:" + detail["instance"] + "</td></tr>" +
+				"<tr><td align=\"left\">概要:" + detail["message"] + "</td></tr>" +
+				"<tr><td align=\"left\">开始时间: " + detail["startsAt"] + " </td></tr>" +
+				"<tr><td align=\"left\">详情: " + detail["description"] + "</td></tr></table>" +
+				"<p>此邮件为KubeOperator平台自动发送，请勿回复!</p></div></body></html>"
 		} else {
 			result = "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=utf-8\"></head><body>" +
 				"<style>table {font-size: 14px;able-layout:fixed;border:5px solid #F2F2F2;}td {font-family: Arial; WORD-WRAP: break-word }</style>" +
@@ -144,6 +160,18 @@ func (m messageService) GetContentByTitleAndType(content, title, sendType, clust
 				"> **详情**:" + detail["message"] + "\n\n" +
 				"> **时间**:" + date + "\n\n" +
 				"<font color=\"info\">本消息由KubeOperator自动发送</font>"
+		} else if title == constant.ClusterAlert {
+			result = "### " + Tr(title) + "\n\n" +
+				"> **项目**:" + project.Name + "\n\n" +
+				"> **集群**:" + clusterName + "\n\n" +
+				"> **告警**:" + detail["name"] + " \n\n " +
+				"> **级别**:" + detail["severity"] + " \n\n " +
+				"> **状态**:" + detail["status"] + " \n\n " +
+				"> **实例**:" + detail["instance"] + " \n\n " +
+				"> **概要**:" + detail["message"] + " \n\n " +
+				"> **详情**:" + detail["description"] + "\n\n" +
+				"> **开始时间**:" + detail["startsAt"] + "\n\n" +
+				"<font color=\"info\">本消息由KubeOperator自动发送</font>"
 		} else {
 			result = "### " + Tr(title) + "\n\n" +
 				"> **项目**:" + project.Name + "\n\n" +
@@ -180,6 +208,10 @@ func Tr(title string) string {
 		result = "集群备份"
 	case constant.ClusterEventWarning:
 		result = "集群事件告警"
+	case constant.HostKeyChanged:
+		result = "主机密钥变更告警"
+	case constant.ClusterAlert:
+		result = "集群监控告警"
 	}
 	return result
 }
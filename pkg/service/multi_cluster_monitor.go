@@ -0,0 +1,207 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/service/cluster/tools"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/KubeOperator/KubeOperator/pkg/util/grafana"
+)
+
+var (
+	MetricsNotConfigured = "METRICS_NOT_CONFIGURED"
+	MetricsAuthInvalid   = "METRICS_AUTH_INVALID"
+)
+
+type MultiClusterMonitorService interface {
+	Get() (*dto.MultiClusterMonitor, error)
+	Config(config dto.MultiClusterMonitorConfig) (*dto.MultiClusterMonitor, error)
+	Sync() (*dto.MultiClusterMonitor, error)
+}
+
+type multiClusterMonitorService struct {
+	clusterToolService   ClusterToolService
+	systemSettingService SystemSettingService
+}
+
+func NewMultiClusterMonitorService() MultiClusterMonitorService {
+	return &multiClusterMonitorService{
+		clusterToolService:   NewClusterToolService(),
+		systemSettingService: NewSystemSettingService(),
+	}
+}
+
+// Get lists the clusters with prometheus, a cluster is reporting once the central metrics store
+// holds series labeled with it.
+func (m multiClusterMonitorService) Get() (*dto.MultiClusterMonitor, error) {
+	store, err := tools.GetMetricsStore()
+	if err != nil {
+		return nil, err
+	}
+	result := dto.MultiClusterMonitor{
+		DataSource:   constant.MetricsDataSourceName,
+		DashboardUrl: "/d/" + constant.MetricsDashboardUid,
+		Clusters:     []dto.MultiClusterMonitorCluster{},
+	}
+	if store != nil {
+		result.RemoteWriteUrl, result.QueryUrl, result.Auth, result.User = store.WriteUrl, store.QueryUrl, store.Auth, store.User
+	}
+	prometheusTools, err := m.prometheusTools()
+	if err != nil {
+		return nil, err
+	}
+	reporting := map[string]bool{}
+	var queryErr error
+	if store != nil {
+		reporting, queryErr = reportingClusters(*store)
+		if queryErr != nil {
+			logger.Log.Errorf("query central metrics store failed: %s", queryErr.Error())
+		}
+	}
+	for name, tool := range prometheusTools {
+		item := dto.MultiClusterMonitorCluster{Name: name, PrometheusStatus: tool.Status, Reporting: reporting[name]}
+		if queryErr != nil {
+			item.Message = queryErr.Error()
+		}
+		result.Clusters = append(result.Clusters, item)
+	}
+	return &result, nil
+}
+
+// Config saves the addresses and the credentials of the central metrics store, the password is kept
+// encrypted and an empty one keeps the saved password. An empty query url is derived from the write url.
+func (m multiClusterMonitorService) Config(config dto.MultiClusterMonitorConfig) (*dto.MultiClusterMonitor, error) {
+	vars := map[string]string{
+		constant.MetricsRemoteWriteUrlKey:  config.RemoteWriteUrl,
+		constant.MetricsQueryUrlKey:        config.QueryUrl,
+		constant.MetricsRemoteWriteAuthKey: config.Auth,
+		constant.MetricsRemoteWriteUserKey: config.User,
+	}
+	switch {
+	case config.Auth == constant.MetricsAuthNone:
+		vars[constant.MetricsRemoteWriteUserKey] = ""
+		vars[constant.MetricsRemoteWritePasswordKey] = ""
+	case config.Auth == constant.MetricsAuthBasic && config.User == "":
+		return nil, errors.New(MetricsAuthInvalid)
+	case config.Password != "":
+		password, err := encrypt.StringEncrypt(config.Password)
+		if err != nil {
+			return nil, err
+		}
+		vars[constant.MetricsRemoteWritePasswordKey] = password
+	default:
+		store, err := tools.GetMetricsStore()
+		if err != nil {
+			return nil, err
+		}
+		if store == nil || store.Password == "" {
+			return nil, errors.New(MetricsAuthInvalid)
+		}
+	}
+	if _, err := m.systemSettingService.Create(dto.SystemSettingCreate{Vars: vars, Tab: "METRICS"}); err != nil {
+		return nil, err
+	}
+	return m.Get()
+}
+
+// Sync registers the central metrics store and the cluster dashboard in grafana and upgrades the
+// prometheus of the clusters to write to the store.
+func (m multiClusterMonitorService) Sync() (*dto.MultiClusterMonitor, error) {
+	store, err := tools.GetMetricsStore()
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, errors.New(MetricsNotConfigured)
+	}
+	source := grafana.NewDataSource(constant.MetricsDataSourceName, store.QueryUrl)
+	switch store.Auth {
+	case constant.MetricsAuthBasic:
+		source.WithBasicAuth(store.User, store.Password)
+	case constant.MetricsAuthBearer:
+		source.WithBearerToken(store.Password)
+	}
+	g := grafana.NewClient()
+	_ = g.DeleteDataSource(constant.MetricsDataSourceName)
+	if err := g.AddDataSource(source); err != nil {
+		return nil, err
+	}
+	if _, err := g.CreateClusterCompareDashboard(constant.MetricsDataSourceName); err != nil {
+		return nil, err
+	}
+
+	prometheusTools, err := m.prometheusTools()
+	if err != nil {
+		return nil, err
+	}
+	for name, tool := range prometheusTools {
+		if isToolBusy(tool) {
+			continue
+		}
+		if _, err := m.clusterToolService.UpdateValues(name, tool.Name, nil); err != nil {
+			logger.Log.Errorf("configure remote write of cluster %s failed: %s", name, err.Error())
+		}
+	}
+	return m.Get()
+}
+
+// prometheusTools returns the enabled prometheus tools by the name of their cluster.
+func (m multiClusterMonitorService) prometheusTools() (map[string]model.ClusterTool, error) {
+	var tools []model.ClusterTool
+	if err := db.DB.Where("name = ? AND status <> ?", "prometheus", constant.ClusterWaiting).Find(&tools).Error; err != nil {
+		return nil, err
+	}
+	result := map[string]model.ClusterTool{}
+	for _, tool := range tools {
+		var cluster model.Cluster
+		if err := db.DB.Where("id = ?", tool.ClusterID).First(&cluster).Error; err != nil {
+			continue
+		}
+		result[cluster.Name] = tool
+	}
+	return result, nil
+}
+
+// reportingClusters asks the store which clusters have sent samples in the last minutes.
+func reportingClusters(store tools.MetricsStore) (map[string]bool, error) {
+	query := fmt.Sprintf("count by (%s) (up)", constant.MetricsClusterLabel)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/query?query=%s", store.QueryUrl, url.QueryEscape(query)), nil)
+	if err != nil {
+		return nil, err
+	}
+	store.Authorize(req)
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query %s returned %s", store.QueryUrl, resp.Status)
+	}
+	var body struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	result := map[string]bool{}
+	for _, r := range body.Data.Result {
+		result[r.Metric[constant.MetricsClusterLabel]] = true
+	}
+	return result, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/logger"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/bundle"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+	"github.com/spf13/viper"
+)
+
+var (
+	BundlePublicKeyNotSet = "BUNDLE_PUBLIC_KEY_NOT_SET"
+	BundleManifestInvalid = "BUNDLE_MANIFEST_INVALID"
+	BundleImporting       = "BUNDLE_IMPORTING"
+	BundleRegistryNotSet  = "BUNDLE_REGISTRY_NOT_SET"
+)
+
+const (
+	bundleNexusUser      = "admin"
+	bundleChartRepo      = "applications"
+	bundleBinaryRepo     = "binary-k8s-raw"
+	bundleImportFailures = 3
+)
+
+type OfflineBundleService interface {
+	List() ([]dto.OfflineBundle, error)
+	Get(id string) (*dto.OfflineBundle, error)
+	Import(path string, removeAfter bool) (*dto.OfflineBundle, error)
+}
+
+type offlineBundleService struct{}
+
+func NewOfflineBundleService() OfflineBundleService {
+	return &offlineBundleService{}
+}
+
+func (o offlineBundleService) List() ([]dto.OfflineBundle, error) {
+	var mos []model.OfflineBundle
+	if err := db.DB.Order("created_at desc").Find(&mos).Error; err != nil {
+		return nil, err
+	}
+	var result []dto.OfflineBundle
+	for _, mo := range mos {
+		result = append(result, toOfflineBundleDTO(mo))
+	}
+	return result, nil
+}
+
+func (o offlineBundleService) Get(id string) (*dto.OfflineBundle, error) {
+	var mo model.OfflineBundle
+	if err := db.DB.Where("id = ?", id).First(&mo).Error; err != nil {
+		return nil, err
+	}
+	result := toOfflineBundleDTO(mo)
+	return &result, nil
+}
+
+func toOfflineBundleDTO(mo model.OfflineBundle) dto.OfflineBundle {
+	result := dto.OfflineBundle{OfflineBundle: mo, Manifests: []string{}}
+	if mo.Manifests != "" {
+		result.Manifests = strings.Split(mo.Manifests, ",")
+	}
+	return result
+}
+
+// Import verifies the bundle at path before accepting it, the content is then pushed to the
+// system registry of the bundle architecture in the background. removeAfter deletes the file
+// once the import is done, it is set for uploaded bundles.
+func (o offlineBundleService) Import(path string, removeAfter bool) (*dto.OfflineBundle, error) {
+	var b *bundle.Bundle
+	cleanup := func() {
+		if b != nil {
+			b.Close()
+		}
+		if removeAfter {
+			_ = os.Remove(path)
+		}
+	}
+	started := false
+	defer func() {
+		if !started {
+			cleanup()
+		}
+	}()
+
+	publicKey := viper.GetString("bundle.public_key")
+	if publicKey == "" {
+		return nil, errors.New(BundlePublicKeyNotSet)
+	}
+	b, err := bundle.Open(path, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := bundleClusterManifests(b)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := model.GetSystemRegistry(b.Architecture)
+	if err != nil {
+		return nil, errors.New(BundleRegistryNotSet)
+	}
+	password, err := encrypt.StringDecrypt(registry.NexusPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	mo := model.OfflineBundle{
+		Name:         b.Name,
+		Architecture: b.Architecture,
+		Images:       len(b.Images),
+		Charts:       len(b.Charts),
+		Binaries:     len(b.Binaries),
+		Status:       constant.StatusRunning,
+	}
+	// the registry row is locked while the running imports are counted, a concurrent import of the
+	// bundle waits and sees this one
+	tx := db.DB.Begin()
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", registry.ID).First(&model.SystemRegistry{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var running int
+	if err := tx.Model(&model.OfflineBundle{}).Where("name = ? AND status = ?", b.Name, constant.StatusRunning).Count(&running).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if running > 0 {
+		tx.Rollback()
+		return nil, errors.New(BundleImporting)
+	}
+	if err := tx.Create(&mo).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	nexus := bundle.NewNexus(
+		fmt.Sprintf("%s://%s:%d", registry.Protocol, registry.Hostname, registry.RegistryHostedPort),
+		fmt.Sprintf("%s://%s:%d", registry.Protocol, registry.Hostname, registry.RepoPort),
+		bundleNexusUser, password)
+	started = true
+	go func() {
+		defer cleanup()
+		importOfflineBundle(mo, b, nexus, manifests)
+	}()
+	result := toOfflineBundleDTO(mo)
+	return &result, nil
+}
+
+func bundleClusterManifests(b *bundle.Bundle) ([]dto.ClusterManifest, error) {
+	var manifests []dto.ClusterManifest
+	for _, raw := range b.ClusterManifests {
+		var m dto.ClusterManifest
+		if err := json.Unmarshal(raw, &m); err != nil || m.Name == "" || m.Version == "" {
+			return nil, errors.New(BundleManifestInvalid)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+func importOfflineBundle(mo model.OfflineBundle, b *bundle.Bundle, nexus *bundle.Nexus, manifests []dto.ClusterManifest) {
+	err := pushOfflineBundle(b, nexus)
+	if err == nil {
+		var names []string
+		names, err = activateClusterManifests(manifests)
+		mo.Manifests = strings.Join(names, ",")
+	}
+	mo.Status = constant.StatusSuccess
+	mo.Message = ""
+	if err != nil {
+		logger.Log.Errorf("import offline bundle %s failed: %s", mo.Name, err.Error())
+		mo.Status = constant.StatusFailed
+		mo.Message = err.Error()
+	}
+	if err := db.DB.Save(&mo).Error; err != nil {
+		logger.Log.Errorf("save offline bundle %s failed: %s", mo.Name, err.Error())
+	}
+}
+
+// pushOfflineBundle retries every item a few times, nexus drops connections of large uploads now
+// and then.
+func pushOfflineBundle(b *bundle.Bundle, nexus *bundle.Nexus) error {
+	retry := func(name string, f func() error) error {
+		var err error
+		for i := 0; i < bundleImportFailures; i++ {
+			if err = f(); err == nil {
+				return nil
+			}
+			logger.Log.Warnf("import %s of offline bundle %s failed: %s", name, b.Name, err.Error())
+		}
+		return err
+	}
+	for i := range b.Images {
+		image := b.Images[i]
+		if err := retry(image.Repository+":"+image.Tag, func() error { return nexus.PushImage(b, image) }); err != nil {
+			return err
+		}
+	}
+	for _, chart := range b.Charts {
+		path := b.Path(chart)
+		if err := retry(chart, func() error { return nexus.UploadChart(bundleChartRepo, path) }); err != nil {
+			return err
+		}
+	}
+	for _, binary := range b.Binaries {
+		repo := binary.Repository
+		if repo == "" {
+			repo = bundleBinaryRepo
+		}
+		path, directory := b.Path(binary.Path), binary.Directory
+		if directory == "" {
+			directory = filepath.Dir(binary.Path)
+		}
+		if err := retry(binary.Path, func() error { return nexus.UploadRaw(repo, directory, path) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activateClusterManifests adds the manifests of the bundle, manifests already known are only
+// activated so a bundle can be imported again after a failure.
+func activateClusterManifests(manifests []dto.ClusterManifest) ([]string, error) {
+	var names []string
+	tx := db.DB.Begin()
+	for _, m := range manifests {
+		var exist model.ClusterManifest
+		if err := tx.Where("name = ?", m.Name).First(&exist).Error; err == nil {
+			if err := tx.Model(&exist).Update("is_active", true).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			names = append(names, m.Name)
+			continue
+		}
+		mo := model.ClusterManifest{Name: m.Name, Version: m.Version, IsActive: true}
+		for _, v := range []struct {
+			target *string
+			vars   []dto.NameVersion
+		}{
+			{&mo.CoreVars, m.CoreVars},
+			{&mo.NetworkVars, m.NetworkVars},
+			{&mo.ToolVars, m.ToolVars},
+			{&mo.StorageVars, m.StorageVars},
+			{&mo.OtherVars, m.OtherVars},
+		} {
+			vars := v.vars
+			if vars == nil {
+				vars = []dto.NameVersion{}
+			}
+			content, _ := json.Marshal(vars)
+			*v.target = string(content)
+		}
+		if err := tx.Create(&mo).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		names = append(names, m.Name)
+	}
+	tx.Commit()
+	return names, nil
+}
@@ -60,7 +60,8 @@ func (p planService) Get(name string) (*dto.Plan, error) {
 	)
 	if err := db.DB.Where("name = ?", name).
 		Preload("Zones").
-		Preload("Region").First(&plan).Error; err != nil {
+		Preload("Region").
+		Preload("Image").First(&plan).Error; err != nil {
 		return nil, err
 	}
 	r := make(map[string]interface{})
@@ -74,6 +75,7 @@ func (p planService) Get(name string) (*dto.Plan, error) {
 	planDTO.Plan = plan
 	planDTO.Region = plan.Region.Name
 	planDTO.Provider = plan.Region.Provider
+	planDTO.Image = plan.Image.Name
 	if err := db.DB.Where("resource_id = ?", plan.ID).Preload("Project").Find(&projectResources).Error; err != nil {
 		return nil, err
 	}
@@ -114,7 +116,7 @@ func (p planService) Page(num, size int, projectName string, conditions conditio
 		}
 	}
 
-	if err := d.Preload("Region").Preload("Zones").Order("CONVERT(name using gbk) asc").Count(&pa.Total).Offset((num - 1) * size).Limit(size).Find(&plans).Error; err != nil {
+	if err := d.Preload("Region").Preload("Zones").Preload("Image").Order("CONVERT(name using gbk) asc").Count(&pa.Total).Offset((num - 1) * size).Limit(size).Find(&plans).Error; err != nil {
 		return nil, err
 	}
 	for _, p := range plans {
@@ -132,6 +134,7 @@ func (p planService) Page(num, size int, projectName string, conditions conditio
 		planDTO.Plan = p
 		planDTO.Region = p.Region.Name
 		planDTO.Zones = zoneNames
+		planDTO.Image = p.Image.Name
 		var projectResources []model.ProjectResource
 		if err := db.DB.Where("resource_id = ?", p.ID).Preload("Project").Find(&projectResources).Error; err != nil {
 			return nil, err
@@ -167,6 +170,10 @@ func (p planService) Create(creation dto.PlanCreate) (*dto.Plan, error) {
 	if err := db.DB.Where("name = ?", creation.Region).First(&region).Error; err != nil {
 		return nil, err
 	}
+	image, err := getImageByName(creation.Image)
+	if err != nil {
+		return nil, err
+	}
 	tx := db.DB.Begin()
 	plan := model.Plan{
 		BaseModel:      common.BaseModel{},
@@ -175,7 +182,10 @@ func (p planService) Create(creation dto.PlanCreate) (*dto.Plan, error) {
 		RegionID:       region.ID,
 		DeployTemplate: creation.DeployTemplate,
 	}
-	err := tx.Create(&plan).Error
+	if image != nil {
+		plan.ImageID = image.ID
+	}
+	err = tx.Create(&plan).Error
 	if err != nil {
 		tx.Rollback()
 		return nil, err
@@ -214,6 +224,9 @@ func (p planService) Create(creation dto.PlanCreate) (*dto.Plan, error) {
 		}
 	}
 	tx.Commit()
+	plan.Region = region
+	plan.Zones = zones
+	importPlanImages(plan)
 	return &dto.Plan{Plan: plan}, err
 }
 
@@ -224,6 +237,13 @@ func (p planService) PatchBy(name string, update dto.PlanUpdate) (*dto.Plan, err
 	}
 	vars, _ := json.Marshal(update.PlanVars)
 	plan.Vars = string(vars)
+	if update.Image != "" {
+		image, err := getImageByName(update.Image)
+		if err != nil {
+			return nil, err
+		}
+		plan.ImageID = image.ID
+	}
 	var projects []model.Project
 	tx := db.DB.Begin()
 	if err := tx.Where("name in (?)", update.Projects).Find(&projects).Error; err != nil {
@@ -247,6 +267,9 @@ func (p planService) PatchBy(name string, update dto.PlanUpdate) (*dto.Plan, err
 	}
 	tx.Save(&plan)
 	tx.Commit()
+	if loaded, err := p.planRepo.GetById(plan.ID); err == nil {
+		importPlanImages(loaded)
+	}
 	return &dto.Plan{Plan: plan}, nil
 }
 
@@ -272,7 +295,7 @@ func (p planService) GetConfigs(regionName string) ([]dto.PlanVmConfig, error) {
 		return nil, err
 	}
 	var configs []dto.PlanVmConfig
-	if region.Provider == constant.OpenStack {
+	if useFlavor(region.Provider) {
 		vars := region.RegionVars.(map[string]interface{})
 		vars["datacenter"] = region.Datacenter
 		cloudClient := cloud_provider.NewCloudClient(vars)
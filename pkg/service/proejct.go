@@ -33,6 +33,7 @@ type projectService struct {
 	projectRepo       repository.ProjectRepository
 	userService       UserService
 	projectMemberRepo repository.ProjectMemberRepository
+	bastionRepo       repository.BastionRepository
 }
 
 func NewProjectService() ProjectService {
@@ -40,6 +41,7 @@ func NewProjectService() ProjectService {
 		projectRepo:       repository.NewProjectRepository(),
 		userService:       NewUserService(),
 		projectMemberRepo: repository.NewProjectMemberRepository(),
+		bastionRepo:       repository.NewBastionRepository(),
 	}
 }
 
@@ -50,7 +52,15 @@ func (p *projectService) Get(name string) (*dto.Project, error) {
 		return nil, err
 	}
 	projectDTO.Project = mo
-	return &projectDTO, err
+	projectDTO.Quota, err = getProjectQuota(db.DB, mo.ID)
+	if err != nil {
+		return nil, err
+	}
+	projectDTO.Usage, err = getProjectUsage(db.DB, mo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &projectDTO, nil
 }
 
 func (p *projectService) List(user dto.SessionUser, conditions condition.Conditions) ([]dto.Project, error) {
@@ -118,9 +128,19 @@ func (p *projectService) Create(creation dto.ProjectCreate) (*dto.Project, error
 		Name:        creation.Name,
 		Description: creation.Description,
 	}
+	if creation.BastionName != "" {
+		bastion, err := p.bastionRepo.Get(creation.BastionName)
+		if err != nil {
+			return nil, err
+		}
+		project.BastionID = bastion.ID
+	}
 	if err := db.DB.Create(&project).Error; err != nil {
 		return nil, err
 	}
+	if err := saveProjectQuota(project.ID, creation.Quota); err != nil {
+		return nil, err
+	}
 	return &dto.Project{Project: project}, nil
 }
 
@@ -131,10 +151,21 @@ func (p *projectService) Update(name string, update dto.ProjectUpdate) (*dto.Pro
 		return nil, err
 	}
 	mo.Description = update.Description
+	mo.BastionID = ""
+	if update.BastionName != "" {
+		bastion, err := p.bastionRepo.Get(update.BastionName)
+		if err != nil {
+			return nil, err
+		}
+		mo.BastionID = bastion.ID
+	}
 	err := p.projectRepo.Save(&mo)
 	if err := db.DB.Save(&mo).Error; err != nil {
 		return nil, err
 	}
+	if err := saveProjectQuota(mo.ID, update.Quota); err != nil {
+		return nil, err
+	}
 
 	return &dto.Project{Project: mo}, err
 }
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	ProjectQuotaClustersExceeded = "PROJECT_QUOTA_CLUSTERS_EXCEEDED"
+	ProjectQuotaNodesExceeded    = "PROJECT_QUOTA_NODES_EXCEEDED"
+	ProjectQuotaCpuExceeded      = "PROJECT_QUOTA_CPU_EXCEEDED"
+	ProjectQuotaMemoryExceeded   = "PROJECT_QUOTA_MEMORY_EXCEEDED"
+	ProjectQuotaIpsExceeded      = "PROJECT_QUOTA_IPS_EXCEEDED"
+)
+
+func getProjectQuota(tx *gorm.DB, projectID string) (*model.ProjectQuota, error) {
+	var quota model.ProjectQuota
+	if err := tx.Where("project_id = ?", projectID).First(&quota).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func saveProjectQuota(projectID string, request *dto.ProjectQuota) error {
+	if request == nil {
+		return nil
+	}
+	quota, err := getProjectQuota(db.DB, projectID)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		quota = &model.ProjectQuota{ProjectID: projectID}
+	}
+	quota.MaxClusters = request.MaxClusters
+	quota.MaxNodes = request.MaxNodes
+	quota.MaxCpu = request.MaxCpu
+	quota.MaxMemory = request.MaxMemory
+	quota.MaxIps = request.MaxIps
+	return db.DB.Save(quota).Error
+}
+
+// getProjectUsage counts the clusters of the project with their nodes, the cpu and memory of their hosts
+// and the pool ips they hold.
+func getProjectUsage(tx *gorm.DB, projectID string) (*dto.ProjectUsage, error) {
+	var usage dto.ProjectUsage
+	var clusterIDs []string
+	if err := tx.Model(model.ProjectResource{}).
+		Where("project_id = ? AND resource_type = ?", projectID, constant.ResourceCluster).
+		Pluck("resource_id", &clusterIDs).Error; err != nil {
+		return nil, err
+	}
+	usage.Clusters = len(clusterIDs)
+	if len(clusterIDs) == 0 {
+		return &usage, nil
+	}
+	var nodes []model.ClusterNode
+	if err := tx.Where("cluster_id in (?)", clusterIDs).Preload("Host").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	usage.Nodes = len(nodes)
+	memory := 0
+	for _, n := range nodes {
+		usage.Cpu += n.Host.CpuCore
+		memory += n.Host.Memory
+	}
+	usage.Memory = memory / 1024
+	if err := tx.Model(model.Ip{}).
+		Where("cluster_id in (?) AND status = ?", clusterIDs, constant.IpUsed).
+		Count(&usage.Ips).Error; err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// checkProjectQuota refuses a request which takes the project over its quota. It runs in the transaction
+// creating the resources and locks the project row, concurrent creations in the project wait for it to end
+// and count what it created.
+func checkProjectQuota(tx *gorm.DB, projectID string, request dto.ProjectUsage) error {
+	var project model.Project
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", projectID).First(&project).Error; err != nil {
+		return err
+	}
+	quota, err := getProjectQuota(tx, projectID)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return nil
+	}
+	usage, err := getProjectUsage(tx, projectID)
+	if err != nil {
+		return err
+	}
+	var errs errorf.CErrFs
+	exceeded := func(key string, max, used, requested int) {
+		if max > 0 && used+requested > max {
+			errs = errs.Add(errorf.New(key, max, used, requested))
+		}
+	}
+	exceeded(ProjectQuotaClustersExceeded, quota.MaxClusters, usage.Clusters, request.Clusters)
+	exceeded(ProjectQuotaNodesExceeded, quota.MaxNodes, usage.Nodes, request.Nodes)
+	exceeded(ProjectQuotaCpuExceeded, quota.MaxCpu, usage.Cpu, request.Cpu)
+	exceeded(ProjectQuotaMemoryExceeded, quota.MaxMemory, usage.Memory, request.Memory)
+	exceeded(ProjectQuotaIpsExceeded, quota.MaxIps, usage.Ips, request.Ips)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// planQuotaRequest is what provisioning the masters and workers from the plan takes, every host holds a pool ip.
+func planQuotaRequest(plan model.Plan, masterAmount, workerAmount int) (dto.ProjectUsage, error) {
+	request := dto.ProjectUsage{Nodes: masterAmount + workerAmount, Ips: masterAmount + workerAmount}
+	if err := db.DB.Where("id = ?", plan.ID).Preload("Region").First(&plan).Error; err != nil {
+		return request, err
+	}
+	sizes, err := planHostSizes(plan)
+	if err != nil {
+		return request, err
+	}
+	request.Cpu = sizes[constant.NodeRoleNameMaster].Cpu*masterAmount + sizes[constant.NodeRoleNameWorker].Cpu*workerAmount
+	request.Memory = sizes[constant.NodeRoleNameMaster].Memory*masterAmount + sizes[constant.NodeRoleNameWorker].Memory*workerAmount
+	return request, nil
+}
+
+// hostQuotaRequest is what adding the existing hosts takes, they do not come from an ip pool.
+func hostQuotaRequest(hostNames []string) (dto.ProjectUsage, error) {
+	request := dto.ProjectUsage{Nodes: len(hostNames)}
+	if len(hostNames) == 0 {
+		return request, nil
+	}
+	var hosts []model.Host
+	if err := db.DB.Where("name in (?)", hostNames).Find(&hosts).Error; err != nil {
+		return request, err
+	}
+	memory := 0
+	for _, h := range hosts {
+		request.Cpu += h.CpuCore
+		memory += h.Memory
+	}
+	request.Memory = memory / 1024
+	return request, nil
+}
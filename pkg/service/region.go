@@ -11,11 +11,14 @@ import (
 	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
 
 	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider"
+	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider/client"
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
 )
 
 var (
@@ -142,6 +145,9 @@ func (r regionService) Create(creation dto.RegionCreate) (*dto.Region, error) {
 		return nil, errors.New(RegionNameExist)
 	}
 
+	if err := trustLibvirtHost(creation.RegionVars); err != nil {
+		return nil, err
+	}
 	vars, _ := json.Marshal(creation.RegionVars)
 	region := model.Region{
 		BaseModel:  common.BaseModel{},
@@ -163,6 +169,9 @@ func (r regionService) Update(name string, update dto.RegionUpdate) (*dto.Region
 	if err := db.DB.Where("name = ?", name).First(&region).Error; err != nil {
 		return nil, err
 	}
+	if err := trustLibvirtHost(update.RegionVars); err != nil {
+		return nil, err
+	}
 	vars, _ := json.Marshal(update.RegionVars)
 	region.Vars = string(vars)
 	region.Datacenter = update.Datacenter
@@ -187,6 +196,9 @@ func (r regionService) Batch(op dto.RegionOp) error {
 }
 
 func (r regionService) ListDatacenter(creation dto.RegionDatacenterRequest) ([]string, error) {
+	if err := trustLibvirtHost(creation.RegionVars); err != nil {
+		return nil, err
+	}
 	cloudClient := cloud_provider.NewCloudClient(creation.RegionVars.(map[string]interface{}))
 	var result []string
 	if cloudClient != nil {
@@ -198,3 +210,26 @@ func (r regionService) ListDatacenter(creation dto.RegionDatacenterRequest) ([]s
 	}
 	return result, nil
 }
+
+// trustLibvirtHost records the key of the kvm host of a libvirt region in its vars, the admin adding
+// the region is the one approving it like for bastions.
+func trustLibvirtHost(regionVars interface{}) error {
+	vars, ok := regionVars.(map[string]interface{})
+	if !ok || vars["provider"] != constant.Libvirt {
+		return nil
+	}
+	if key, _ := vars["hostKey"].(string); key != "" {
+		return nil
+	}
+	config, err := client.LibvirtSSHConfig(vars)
+	if err != nil {
+		return err
+	}
+	config.DialTimeOut = HostKeyScanTimeout
+	key, err := ssh.ScanHostKey(config)
+	if err != nil {
+		return err
+	}
+	vars["hostKey"] = key
+	return nil
+}
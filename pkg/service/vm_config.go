@@ -48,12 +48,13 @@ func (v vmConfigService) Page(num, size int, conditions condition.Conditions) (*
 	if err := dbUtil.WithConditions(&d, model.VmConfig{}, conditions); err != nil {
 		return nil, err
 	}
-	if err := d.Count(&p.Total).Order("cpu").Offset((num - 1) * size).Limit(size).Find(&vmConfigs).Error; err != nil {
+	if err := d.Count(&p.Total).Preload("Image").Order("cpu").Offset((num - 1) * size).Limit(size).Find(&vmConfigs).Error; err != nil {
 		return nil, err
 	}
 	for _, mo := range vmConfigs {
 		vmConfigDTO := new(dto.VmConfig)
 		vmConfigDTO.VmConfig = mo
+		vmConfigDTO.Image = mo.Image.Name
 		vmConfigDTOs = append(vmConfigDTOs, *vmConfigDTO)
 	}
 	p.Items = vmConfigDTOs
@@ -67,6 +68,13 @@ func (v vmConfigService) Get(name string) (*dto.VmConfig, error) {
 		return nil, err
 	}
 	vmConfigDTO.VmConfig = vmConfig
+	if vmConfig.ImageID != "" {
+		var image model.Image
+		if err := db.DB.Where("id = ?", vmConfig.ImageID).First(&image).Error; err != nil {
+			return nil, err
+		}
+		vmConfigDTO.Image = image.Name
+	}
 	return &vmConfigDTO, nil
 }
 
@@ -77,12 +85,13 @@ func (v vmConfigService) List(conditions condition.Conditions) ([]dto.VmConfig,
 	if err := dbUtil.WithConditions(&d, model.VmConfig{}, conditions); err != nil {
 		return nil, err
 	}
-	if err := d.Order("cpu").Find(&configs).Error; err != nil {
+	if err := d.Preload("Image").Order("cpu").Find(&configs).Error; err != nil {
 		return nil, err
 	}
 	for _, config := range configs {
 		configDTO := new(dto.VmConfig)
 		configDTO.VmConfig = config
+		configDTO.Image = config.Image.Name
 		configDTOS = append(configDTOS, *configDTO)
 	}
 	return configDTOS, nil
@@ -112,6 +121,10 @@ func (v vmConfigService) Create(creation dto.VmConfigCreate) (*dto.VmConfig, err
 	if config.ID != "" {
 		return nil, errors.New(ConfigExist)
 	}
+	image, err := getImageByName(creation.Image)
+	if err != nil {
+		return nil, err
+	}
 	vmConfig := model.VmConfig{
 		Name:     creation.Name,
 		Cpu:      creation.Cpu,
@@ -119,11 +132,14 @@ func (v vmConfigService) Create(creation dto.VmConfigCreate) (*dto.VmConfig, err
 		Disk:     50,
 		Provider: creation.Provider,
 	}
+	if image != nil {
+		vmConfig.ImageID = image.ID
+	}
 	err = v.vmConfigRepo.Save(&vmConfig)
 	if err != nil {
 		return nil, err
 	}
-	return &dto.VmConfig{VmConfig: vmConfig}, err
+	return &dto.VmConfig{VmConfig: vmConfig, Image: creation.Image}, err
 }
 
 func (v vmConfigService) Update(name string, update dto.VmConfigUpdate) (*dto.VmConfig, error) {
@@ -141,7 +157,15 @@ func (v vmConfigService) Update(name string, update dto.VmConfigUpdate) (*dto.Vm
 	if update.Cpu != 0 {
 		vmConfig.Cpu = update.Cpu
 	}
-	if err := db.DB.Save(&vmConfig).Error; err != nil {
+	if update.Image != "" {
+		image, err := getImageByName(update.Image)
+		if err != nil {
+			return nil, err
+		}
+		vmConfig.ImageID = image.ID
+		vmConfig.Image = update.Image
+	}
+	if err := db.DB.Save(&vmConfig.VmConfig).Error; err != nil {
 		return nil, err
 	}
 	return vmConfig, err
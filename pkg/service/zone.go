@@ -4,9 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/KubeOperator/KubeOperator/pkg/controller/condition"
@@ -14,14 +13,22 @@ import (
 	dbUtil "github.com/KubeOperator/KubeOperator/pkg/util/db"
 
 	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider"
-	"github.com/KubeOperator/KubeOperator/pkg/cloud_storage"
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/controller/page"
 	"github.com/KubeOperator/KubeOperator/pkg/db"
 	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
 	"github.com/KubeOperator/KubeOperator/pkg/model"
 	"github.com/KubeOperator/KubeOperator/pkg/model/common"
 	"github.com/KubeOperator/KubeOperator/pkg/repository"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ssh"
+)
+
+var (
+	ZoneSubnetRequired        = "ZONE_SUBNET_REQUIRED"
+	ZoneCredentialKeyRequired = "ZONE_CREDENTIAL_KEY_REQUIRED"
+	ZoneAllowedCidrInvalid    = "ZONE_ALLOWED_CIDR_INVALID"
+	ZoneIpPoolRequired        = "ZONE_IP_POOL_REQUIRED"
 )
 
 type ZoneService interface {
@@ -36,13 +43,16 @@ type ZoneService interface {
 	ListTemplates(creation dto.CloudZoneRequest) ([]interface{}, error)
 	ListByRegionName(regionName string) ([]dto.Zone, error)
 	ListDatastores(creation dto.CloudZoneRequest) ([]dto.CloudDatastore, error)
+	Capacity(name string) (*dto.ZoneCapacity, error)
 }
 
 type zoneService struct {
 	zoneRepo             repository.ZoneRepository
 	regionRepo           repository.RegionRepository
+	bastionRepo          repository.BastionRepository
 	systemSettingService SystemSettingService
 	ipPoolService        IpPoolService
+	imageRepo            repository.ImageRepository
 }
 
 func NewZoneService() ZoneService {
@@ -50,7 +60,9 @@ func NewZoneService() ZoneService {
 		zoneRepo:             repository.NewZoneRepository(),
 		systemSettingService: NewSystemSettingService(),
 		regionRepo:           repository.NewRegionRepository(),
+		bastionRepo:          repository.NewBastionRepository(),
 		ipPoolService:        NewIpPoolService(),
+		imageRepo:            repository.NewImageRepository(),
 	}
 }
 
@@ -59,7 +71,7 @@ func (z zoneService) Get(name string) (*dto.Zone, error) {
 		zoneDTO dto.Zone
 		zone    model.Zone
 	)
-	if err := db.DB.Model(model.Zone{}).Where("name = ?", name).Preload("Region").Preload("IpPool").Preload("Credential").Preload("IpPool.Ips").Find(&zone).Error; err != nil {
+	if err := db.DB.Model(model.Zone{}).Where("name = ?", name).Preload("Region").Preload("IpPool").Preload("Credential").Preload("Bastion").Preload("IpPool.Ips").Find(&zone).Error; err != nil {
 		return nil, err
 	}
 	zoneDTO.Zone = zone
@@ -82,6 +94,7 @@ func (z zoneService) Get(name string) (*dto.Zone, error) {
 	}
 	zoneDTO.IpPoolName = zone.IpPool.Name
 	zoneDTO.CredentialName = zone.Credential.Name
+	zoneDTO.BastionName = zone.Bastion.Name
 	return &zoneDTO, nil
 }
 
@@ -95,7 +108,7 @@ func (z zoneService) List(conditions condition.Conditions) ([]dto.Zone, error) {
 	if err := dbUtil.WithConditions(&d, model.Zone{}, conditions); err != nil {
 		return nil, err
 	}
-	err := d.Preload("IpPool").Preload("Region").Preload("Credential").Find(&zones).Error
+	err := d.Preload("IpPool").Preload("Region").Preload("Credential").Preload("Bastion").Find(&zones).Error
 	if err != nil {
 		return zoneDTOs, err
 	}
@@ -120,6 +133,7 @@ func (z zoneService) List(conditions condition.Conditions) ([]dto.Zone, error) {
 			IpPool: mo.IpPool,
 		}
 		zoneDTO.CredentialName = mo.Credential.Name
+		zoneDTO.BastionName = mo.Bastion.Name
 		zoneDTO.IpPoolName = mo.IpPool.Name
 		zoneDTOs = append(zoneDTOs, *zoneDTO)
 	}
@@ -145,6 +159,7 @@ func (z zoneService) Page(num, size int, conditions condition.Conditions) (*page
 		Preload("Region").
 		Preload("IpPool").
 		Preload("Credential").
+		Preload("Bastion").
 		Preload("IpPool.Ips").
 		Order("CONVERT(name using gbk) asc").
 		Find(&zones).
@@ -171,6 +186,7 @@ func (z zoneService) Page(num, size int, conditions condition.Conditions) (*page
 			IpPool: mo.IpPool,
 		}
 		zoneDTO.CredentialName = mo.Credential.Name
+		zoneDTO.BastionName = mo.Bastion.Name
 		zoneDTO.IpPoolName = mo.IpPool.Name
 		zoneDTOs = append(zoneDTOs, *zoneDTO)
 	}
@@ -211,16 +227,17 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 		return nil, err
 	}
 	if param["templateType"] != nil && param["templateType"].(string) == "default" {
+		image, err := z.defaultImage(param)
+		if err != nil {
+			return nil, err
+		}
 		switch region.Provider {
-		case constant.OpenStack:
-			param["imageName"] = constant.OpenStackImageName
-		case constant.VSphere:
-			param["imageName"] = constant.VSphereImageName
-		case constant.FusionCompute:
-			param["template"] = constant.FusionComputeImageName
+		case constant.FusionCompute, constant.Proxmox:
+			param["template"] = image.TemplateName(region.Provider)
 		default:
-			param["imageName"] = constant.VSphereImageName
+			param["imageName"] = image.TemplateName(region.Provider)
 		}
+		param["image"] = image.Name
 		credentialService := NewCredentialService()
 		credential, err = credentialService.Get(constant.ImageCredentialName)
 		if err != nil {
@@ -234,6 +251,14 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 		}
 	}
 
+	var bastion model.Bastion
+	if creation.BastionName != "" {
+		bastion, err = z.bastionRepo.Get(creation.BastionName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if region.Provider == constant.VSphere {
 		regionVars := region.RegionVars.(map[string]interface{})
 		regionVars["datacenter"] = region.Datacenter
@@ -244,19 +269,29 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 		}
 	}
 
-	ipPool, err := z.ipPoolService.Get(creation.IpPoolName)
-	if err != nil {
-		return nil, err
-	}
-	if len(ipPool.Ips) == 0 {
-		return nil, errors.New("IP_SHORT")
+	var ipPool dto.IpPool
+	if cloud_provider.IsPublicCloud(region.Provider) {
+		if err := z.preparePublicZone(region.Region, creation.Name, credential.Credential, param); err != nil {
+			return nil, err
+		}
+	} else {
+		if creation.IpPoolName == "" {
+			return nil, errors.New(ZoneIpPoolRequired)
+		}
+		ipPool, err = z.ipPoolService.Get(creation.IpPoolName)
+		if err != nil {
+			return nil, err
+		}
+		if len(ipPool.Ips) == 0 {
+			return nil, errors.New("IP_SHORT")
+		}
+		index := strings.Index(ipPool.Subnet, "/")
+		networkCidr := ipPool.Subnet
+		param["netMask"] = networkCidr[index+1:]
+		param["gateway"] = ipPool.Ips[0].Gateway
+		param["dns1"] = ipPool.Ips[0].DNS1
+		param["dns2"] = ipPool.Ips[0].DNS2
 	}
-	index := strings.Index(ipPool.Subnet, "/")
-	networkCidr := ipPool.Subnet
-	param["netMask"] = networkCidr[index+1:]
-	param["gateway"] = ipPool.Ips[0].Gateway
-	param["dns1"] = ipPool.Ips[0].DNS1
-	param["dns2"] = ipPool.Ips[0].DNS2
 
 	vars, _ := json.Marshal(creation.CloudVars)
 	zone := model.Zone{
@@ -266,6 +301,7 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 		RegionID:     region.ID,
 		CredentialID: credential.ID,
 		IpPoolID:     ipPool.ID,
+		BastionID:    bastion.ID,
 		Status:       constant.Ready,
 	}
 
@@ -285,37 +321,65 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 func (z zoneService) Update(name string, update dto.ZoneUpdate) (*dto.Zone, error) {
 
 	param := update.CloudVars.(map[string]interface{})
-	ipPool, err := z.ipPoolService.Get(update.IpPoolName)
+	zone, err := z.zoneRepo.Get(name)
 	if err != nil {
 		return nil, err
 	}
-	if len(ipPool.Ips) == 0 {
-		return nil, errors.New("IP_SHORT")
+	var region model.Region
+	if err := db.DB.Where("id = ?", update.RegionID).First(&region).Error; err != nil {
+		return nil, err
+	}
+
+	if update.CredentialName != "" {
+		credentialService := NewCredentialService()
+		credential, err := credentialService.Get(update.CredentialName)
+		if err != nil {
+			return nil, err
+		}
+		zone.CredentialID = credential.ID
 	}
 
-	index := strings.Index(ipPool.Subnet, "/")
-	networkCidr := ipPool.Subnet
-	param["netMask"] = networkCidr[index+1:]
-	param["gateway"] = ipPool.Ips[0].Gateway
-	param["dns1"] = ipPool.Ips[0].DNS1
-	param["dns2"] = ipPool.Ips[0].DNS2
+	var ipPool dto.IpPool
+	if cloud_provider.IsPublicCloud(region.Provider) {
+		var credential model.Credential
+		if err := db.DB.Where("id = ?", zone.CredentialID).First(&credential).Error; err != nil {
+			return nil, err
+		}
+		if err := z.preparePublicZone(region, zone.Name, credential, param); err != nil {
+			return nil, err
+		}
+	} else {
+		if update.IpPoolName == "" {
+			return nil, errors.New(ZoneIpPoolRequired)
+		}
+		ipPool, err = z.ipPoolService.Get(update.IpPoolName)
+		if err != nil {
+			return nil, err
+		}
+		if len(ipPool.Ips) == 0 {
+			return nil, errors.New("IP_SHORT")
+		}
 
-	vars, _ := json.Marshal(update.CloudVars)
-	zone, err := z.zoneRepo.Get(name)
-	if err != nil {
-		return nil, err
+		index := strings.Index(ipPool.Subnet, "/")
+		networkCidr := ipPool.Subnet
+		param["netMask"] = networkCidr[index+1:]
+		param["gateway"] = ipPool.Ips[0].Gateway
+		param["dns1"] = ipPool.Ips[0].DNS1
+		param["dns2"] = ipPool.Ips[0].DNS2
 	}
+
+	vars, _ := json.Marshal(update.CloudVars)
 	zone.Vars = string(vars)
 	zone.RegionID = update.RegionID
 	zone.IpPoolID = ipPool.ID
 
-	if update.CredentialName != "" {
-		credentialService := NewCredentialService()
-		credential, err := credentialService.Get(update.CredentialName)
+	zone.BastionID = ""
+	if update.BastionName != "" {
+		bastion, err := z.bastionRepo.Get(update.BastionName)
 		if err != nil {
 			return nil, err
 		}
-		zone.CredentialID = credential.ID
+		zone.BastionID = bastion.ID
 	}
 
 	if err := db.DB.Save(&zone).Error; err != nil {
@@ -324,6 +388,75 @@ func (z zoneService) Update(name string, update dto.ZoneUpdate) (*dto.Zone, erro
 	return &dto.Zone{Zone: zone}, err
 }
 
+// preparePublicZone fills the network vars of the zone from its vpc subnet, and creates the security group and the
+// key pair of the zone credential when the user did not pick existing ones.
+func (z zoneService) preparePublicZone(region model.Region, zoneName string, credential model.Credential, param map[string]interface{}) error {
+	regionVars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(region.Vars), &regionVars); err != nil {
+		return err
+	}
+	regionVars["datacenter"] = region.Datacenter
+	cloudClient, ok := cloud_provider.NewCloudClient(regionVars).(cloud_provider.PublicCloudClient)
+	if !ok {
+		return fmt.Errorf("provider %s has no vpc support", region.Provider)
+	}
+	subnetID, _ := param["subnet"].(string)
+	if subnetID == "" {
+		return errors.New(ZoneSubnetRequired)
+	}
+	subnet, err := cloudClient.GetSubnet(subnetID)
+	if err != nil {
+		return err
+	}
+	param["vpc"] = subnet.VpcID
+	param["subnetCidr"] = subnet.Cidr
+	param["reservedHead"] = strconv.Itoa(subnet.ReservedHead)
+	param["reservedTail"] = strconv.Itoa(subnet.ReservedTail)
+	param["netMask"] = subnet.Cidr[strings.Index(subnet.Cidr, "/")+1:]
+	param["gateway"] = subnet.Gateway
+	param["dns1"] = subnet.DNS
+	param["dns2"] = ""
+
+	if group, _ := param["securityGroup"].(string); group == "" {
+		// ssh and the apiserver are reachable from the subnet only unless the zone allows more
+		allowedCidrs := []string{subnet.Cidr}
+		if allowed, _ := param["allowedCidr"].(string); strings.TrimSpace(allowed) != "" {
+			allowedCidrs = nil
+			for _, cidr := range strings.Split(allowed, ",") {
+				_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+				if err != nil {
+					return errorf.New(ZoneAllowedCidrInvalid, cidr)
+				}
+				allowedCidrs = append(allowedCidrs, ipNet.String())
+			}
+		}
+		groupID, err := cloudClient.CreateSecurityGroup(subnet.VpcID, fmt.Sprintf("kubeoperator-%s", formatZoneName(zoneName)), allowedCidrs)
+		if err != nil {
+			return err
+		}
+		param["securityGroup"] = groupID
+	}
+	if keyName, _ := param["keyName"].(string); keyName == "" {
+		if credential.Type != constant.PrivateKey {
+			return errors.New(ZoneCredentialKeyRequired)
+		}
+		_, privateKey, err := credential.GetSecret()
+		if err != nil {
+			return err
+		}
+		publicKey, err := ssh.AuthorizedKey([]byte(privateKey))
+		if err != nil {
+			return err
+		}
+		keyName = fmt.Sprintf("kubeoperator-%s", credential.Name)
+		if err := cloudClient.ImportKeyPair(keyName, publicKey); err != nil {
+			return err
+		}
+		param["keyName"] = keyName
+	}
+	return nil
+}
+
 func (z zoneService) Batch(op dto.ZoneOp) error {
 	var deleteItems []model.Zone
 	for _, item := range op.Items {
@@ -410,131 +543,25 @@ func (z zoneService) uploadZoneImage(creation dto.ZoneCreate) {
 }
 
 func (z zoneService) uploadImage(creation dto.ZoneCreate) error {
-	region, err := NewRegionService().Get(creation.RegionName)
+	region, err := z.regionRepo.Get(creation.RegionName)
 	if err != nil {
 		return err
 	}
-	var repo model.SystemRegistry
-	if err := db.DB.Where("architecture = ?", constant.ArchitectureOfAMD64).First(&repo).Error; err != nil {
-		return fmt.Errorf("can't find local ip from system setting, err %s", err.Error())
-	}
-	ip := repo.Hostname
-	port := repo.RepoPort
-
-	regionVars := region.RegionVars.(map[string]interface{})
-	regionVars["datacenter"] = region.Datacenter
-	if region.Provider == constant.VSphere {
-		zoneVars := creation.CloudVars.(map[string]interface{})
-		if zoneVars["cluster"] != nil {
-			regionVars["cluster"] = zoneVars["cluster"]
-		}
-		if zoneVars["resourcePool"] != nil {
-			regionVars["resourcePool"] = zoneVars["resourcePool"]
-		}
-		if zoneVars["datastore"] != nil {
-			regionVars["datastore"] = zoneVars["datastore"]
-		}
-		if zoneVars["network"] != nil {
-			regionVars["network"] = zoneVars["network"]
-		}
-		if zoneVars["resourceType"] != nil {
-			regionVars["resourceType"] = zoneVars["resourceType"]
-		}
-		if zoneVars["hostSystem"] != nil {
-			regionVars["hostSystem"] = zoneVars["hostSystem"]
-		}
-		regionVars["ovfPath"] = fmt.Sprintf(constant.VSphereImageOvfPath, ip, port)
-		regionVars["vmdkPath"] = fmt.Sprintf(constant.VSphereImageVMDkPath, ip, port)
-	}
-	if region.Provider == constant.OpenStack {
-		regionVars["imagePath"] = fmt.Sprintf(constant.OpenStackImagePath, ip, port)
-	}
-	if region.Provider == constant.FusionCompute {
-		zoneVars := creation.CloudVars.(map[string]interface{})
-		if zoneVars["cluster"] != nil {
-			regionVars["cluster"] = zoneVars["cluster"]
-		}
-		if zoneVars["datastore"] != nil {
-			regionVars["datastore"] = zoneVars["datastore"]
-		}
-		if zoneVars["portgroup"] != nil {
-			regionVars["portgroup"] = zoneVars["portgroup"]
-		}
+	zoneVars := creation.CloudVars.(map[string]interface{})
+	image, err := z.defaultImage(zoneVars)
+	if err != nil {
+		return err
 	}
+	return uploadImageToZone(region, zoneVars, image)
+}
 
-	cloudClient := cloud_provider.NewCloudClient(regionVars)
-	if cloudClient != nil {
-		result, err := cloudClient.DefaultImageExist()
-		if err != nil {
-			return err
-		}
-		if result {
-			return nil
-		}
-		if region.Provider == constant.FusionCompute {
-			zoneVars := creation.CloudVars.(map[string]interface{})
-			nfsVars := make(map[string]interface{})
-			nfsVars["type"] = "SFTP"
-			nfsVars["address"] = zoneVars["nfsAddress"]
-			nfsVars["port"] = zoneVars["nfsPort"]
-			nfsVars["username"] = zoneVars["nfsUsername"]
-			nfsVars["password"] = zoneVars["nfsPassword"]
-			nfsVars["bucket"] = zoneVars["nfsFolder"]
-			client, err := cloud_storage.NewCloudStorageClient(nfsVars)
-			if err != nil {
-				return err
-			}
-			ovfResp, err := http.Get(fmt.Sprintf(constant.FusionComputeOvfPath, ip, port))
-			if err != nil {
-				return err
-			}
-			if ovfResp.StatusCode == 404 {
-				return errors.New(constant.FusionComputeOvfName + "not found")
-			}
-			defer ovfResp.Body.Close()
-			ovfOut, err := os.Create(constant.FusionComputeOvfLocal)
-			if err != nil {
-				return err
-			}
-			defer ovfOut.Close()
-			_, err = io.Copy(ovfOut, ovfResp.Body)
-			if err != nil {
-				return err
-			}
-			vhdResp, err := http.Get(fmt.Sprintf(constant.FusionComputeVhdPath, ip, port))
-			if err != nil {
-				return err
-			}
-			if vhdResp.StatusCode == 404 {
-				return errors.New(constant.FusionComputeVhdName + "not found")
-			}
-			defer vhdResp.Body.Close()
-			vhdOut, err := os.Create(constant.FusionComputeVhdLocal)
-			if err != nil {
-				return err
-			}
-			defer vhdOut.Close()
-			_, err = io.Copy(vhdOut, vhdResp.Body)
-			if err != nil {
-				return err
-			}
-			_, err = client.Upload(constant.FusionComputeOvfLocal, constant.FusionComputeOvfName)
-			if err != nil {
-				return err
-			}
-			_, err = client.Upload(constant.FusionComputeVhdLocal, constant.FusionComputeVhdName)
-			if err != nil {
-				return err
-			}
-			regionVars["ovfPath"] = zoneVars["nfsAddress"].(string) + ":" + zoneVars["nfsFolder"].(string) + "/" + constant.FusionComputeOvfName
-			cloudClient = cloud_provider.NewCloudClient(regionVars)
-		}
-		err = cloudClient.UploadImage()
-		if err != nil {
-			return err
-		}
+// defaultImage is the catalog image a zone with the default template is initialized with.
+func (z zoneService) defaultImage(param map[string]interface{}) (model.Image, error) {
+	name := constant.DefaultImageName
+	if param["image"] != nil && param["image"].(string) != "" {
+		name = param["image"].(string)
 	}
-	return nil
+	return z.imageRepo.Get(name)
 }
 
 func (z zoneService) ListByRegionName(regionName string) ([]dto.Zone, error) {
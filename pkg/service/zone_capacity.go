@@ -0,0 +1,233 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/KubeOperator/KubeOperator/pkg/cloud_provider"
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/db"
+	"github.com/KubeOperator/KubeOperator/pkg/dto"
+	"github.com/KubeOperator/KubeOperator/pkg/errorf"
+	"github.com/KubeOperator/KubeOperator/pkg/model"
+	"github.com/KubeOperator/KubeOperator/pkg/util/ipaddr"
+)
+
+var (
+	ZoneCapacityIpShort     = "ZONE_CAPACITY_IP_SHORT"
+	ZoneCapacityCpuShort    = "ZONE_CAPACITY_CPU_SHORT"
+	ZoneCapacityMemoryShort = "ZONE_CAPACITY_MEMORY_SHORT"
+	ZoneCapacityDiskShort   = "ZONE_CAPACITY_DISK_SHORT"
+)
+
+func (z zoneService) Capacity(name string) (*dto.ZoneCapacity, error) {
+	var zone model.Zone
+	if err := db.DB.Where("name = ?", name).Preload("Region").First(&zone).Error; err != nil {
+		return nil, err
+	}
+	return getZoneCapacity(zone.Region, zone)
+}
+
+// zoneCloudClient connects to the provider of the region, scoped to the compute cluster of the zone.
+func zoneCloudClient(region model.Region, zone model.Zone) (cloud_provider.CloudClient, map[string]interface{}, error) {
+	zoneVars := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(zone.Vars), &zoneVars)
+	providerVars := map[string]interface{}{}
+	providerVars["provider"] = region.Provider
+	providerVars["datacenter"] = region.Datacenter
+	_ = json.Unmarshal([]byte(region.Vars), &providerVars)
+	for _, key := range []string{"cluster", "resourceType", "hostSystem"} {
+		if zoneVars[key] != nil {
+			providerVars[key] = zoneVars[key]
+		}
+	}
+	cloudClient := cloud_provider.NewCloudClient(providerVars)
+	if cloudClient == nil {
+		return nil, nil, fmt.Errorf("unsupported provider %s", region.Provider)
+	}
+	return cloudClient, zoneVars, nil
+}
+
+// getZoneCapacity collects the free ips, the datastores and the compute resources a zone has left.
+func getZoneCapacity(region model.Region, zone model.Zone) (*dto.ZoneCapacity, error) {
+	cloudClient, zoneVars, err := zoneCloudClient(region, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := dto.ZoneCapacity{Name: zone.Name}
+	var hosts []model.Host
+	if err := db.DB.Select("ip").Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	var hostIps []string
+	for i := range hosts {
+		hostIps = append(hostIps, hosts[i].Ip)
+	}
+	if zone.IpPoolID == "" && zoneVars["subnetCidr"] != nil {
+		_, cidr, err := net.ParseCIDR(zoneVars["subnetCidr"].(string))
+		if err != nil {
+			return nil, err
+		}
+		inUsed, err := cloudClient.GetIpInUsed(fmt.Sprintf("%v", zoneVars["subnet"]))
+		if err != nil {
+			return nil, err
+		}
+		head, _ := strconv.Atoi(fmt.Sprintf("%v", zoneVars["reservedHead"]))
+		tail, _ := strconv.Atoi(fmt.Sprintf("%v", zoneVars["reservedTail"]))
+		used := map[string]bool{}
+		for _, ip := range append(inUsed, hostIps...) {
+			if parsed := net.ParseIP(ip); parsed != nil && cidr.Contains(parsed) {
+				used[ip] = true
+			}
+		}
+		capacity.IpTotal = int(ipaddr.RangeSize(cidr)) - head - tail
+		capacity.IpFree = capacity.IpTotal - len(used)
+	} else {
+		var ips []model.Ip
+		if err := db.DB.Where("ip_pool_id = ?", zone.IpPoolID).Find(&ips).Error; err != nil {
+			return nil, err
+		}
+		capacity.IpTotal = len(ips)
+		for i := range ips {
+			if ips[i].Status == constant.IpAvailable && !exists(ips[i].Address, hostIps) {
+				capacity.IpFree++
+			}
+		}
+	}
+
+	datastores, err := cloudClient.ListDatastores()
+	if err != nil {
+		return nil, err
+	}
+	zoneDatastores := zoneDatastoreNames(zoneVars["datastore"])
+	for i := range datastores {
+		if len(zoneDatastores) > 0 && !exists(datastores[i].Name, zoneDatastores) {
+			continue
+		}
+		capacity.Datastores = append(capacity.Datastores, dto.CloudDatastore{
+			Name:      datastores[i].Name,
+			Capacity:  datastores[i].Capacity,
+			FreeSpace: datastores[i].FreeSpace,
+		})
+	}
+
+	compute, err := cloudClient.GetCompute()
+	if err != nil {
+		return nil, err
+	}
+	if compute != nil {
+		capacity.Compute = &dto.CloudCompute{
+			CpuTotal:    compute.CpuTotal,
+			CpuFree:     compute.CpuFree,
+			MemoryTotal: compute.MemoryTotal,
+			MemoryFree:  compute.MemoryFree,
+		}
+	}
+	return &capacity, nil
+}
+
+func zoneDatastoreNames(v interface{}) []string {
+	switch d := v.(type) {
+	case string:
+		return []string{d}
+	case []interface{}:
+		var names []string
+		for _, n := range d {
+			names = append(names, fmt.Sprintf("%v", n))
+		}
+		return names
+	}
+	return nil
+}
+
+// planHostSizes returns the vm size of each node role of the plan, memory and disk in GB.
+func planHostSizes(plan model.Plan) (map[string]constant.VmConfig, error) {
+	configs, err := NewPlanService().GetConfigs(plan.Region.Name)
+	if err != nil {
+		return nil, err
+	}
+	planVars := map[string]string{}
+	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
+	sizes := map[string]constant.VmConfig{}
+	for _, role := range []string{constant.NodeRoleNameMaster, constant.NodeRoleNameWorker} {
+		for _, c := range configs {
+			if c.Name == planVars[fmt.Sprintf("%sModel", role)] {
+				sizes[role] = c.Config
+			}
+		}
+	}
+	return sizes, nil
+}
+
+// checkPlanCapacity refuses to provision hosts which the zones of the plan can not hold,
+// the hosts are spread over the zones the same way createHosts does.
+func checkPlanCapacity(plan model.Plan, masterAmount, workerAmount int) error {
+	if err := db.DB.Where("id = ?", plan.ID).Preload("Zones").Preload("Region").First(&plan).Error; err != nil {
+		return err
+	}
+	if len(plan.Zones) == 0 {
+		return nil
+	}
+	sizes, err := planHostSizes(plan)
+	if err != nil {
+		return err
+	}
+
+	var hosts []*model.Host
+	for i := 0; i < masterAmount; i++ {
+		hosts = append(hosts, &model.Host{Name: fmt.Sprintf("%s-master-%d", plan.Name, i+1)})
+	}
+	for i := 0; i < workerAmount; i++ {
+		hosts = append(hosts, &model.Host{Name: fmt.Sprintf("%s-worker-%d", plan.Name, i+1)})
+	}
+	group := allocateZone(plan.Zones, hosts)
+
+	var errs errorf.CErrFs
+	for i := range plan.Zones {
+		zoneHosts := group[&plan.Zones[i]]
+		if len(zoneHosts) == 0 {
+			continue
+		}
+		var cpu, memory, disk int
+		for _, h := range zoneHosts {
+			size := sizes[getHostRole(h.Name)]
+			cpu += size.Cpu
+			memory += size.Memory
+			disk += size.Disk
+		}
+		capacity, err := getZoneCapacity(plan.Region, plan.Zones[i])
+		if err != nil {
+			return err
+		}
+		if capacity.IpFree < len(zoneHosts) {
+			errs = errs.Add(errorf.New(ZoneCapacityIpShort, capacity.Name, len(zoneHosts), capacity.IpFree))
+		}
+		if capacity.Compute != nil {
+			if capacity.Compute.CpuFree < cpu {
+				errs = errs.Add(errorf.New(ZoneCapacityCpuShort, capacity.Name, cpu, capacity.Compute.CpuFree))
+			}
+			if capacity.Compute.MemoryFree < memory {
+				errs = errs.Add(errorf.New(ZoneCapacityMemoryShort, capacity.Name, memory, capacity.Compute.MemoryFree))
+			}
+		}
+		if len(capacity.Datastores) > 0 {
+			// allocateDatastore puts every host of a zone on the same datastore
+			free := 0
+			for _, d := range capacity.Datastores {
+				if d.FreeSpace > free {
+					free = d.FreeSpace
+				}
+			}
+			if free < disk {
+				errs = errs.Add(errorf.New(ZoneCapacityDiskShort, capacity.Name, disk, free))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -3,8 +3,10 @@ package ansible
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/KubeOperator/KubeOperator/pkg/constant"
 	"github.com/KubeOperator/KubeOperator/pkg/util/file"
@@ -61,3 +63,43 @@ func GetNodeAnsibleLogReader(clusterName string, nodeName string, logId string)
 	}
 	return result, nil
 }
+
+// KnownHostsPath is the known_hosts file of name as kobe sees it.
+func KnownHostsPath(name string) string {
+	return path.Join(constant.DefaultKnownHostsDir, name)
+}
+
+// BastionKeyPath is the private key file of the bastion name as kobe sees it.
+func BastionKeyPath(name string) string {
+	return path.Join(constant.DefaultBastionKeyDir, name)
+}
+
+// WriteKnownHosts writes a known_hosts file for ansible, the directory must be shared with kobe.
+func WriteKnownHosts(name string, lines ...string) (string, error) {
+	if !file.Exists(constant.DefaultKnownHostsDir) {
+		err := os.MkdirAll(constant.DefaultKnownHostsDir, 0755)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("create known hosts dir failed: %v", err))
+		}
+	}
+	fileName := KnownHostsPath(name)
+	if err := ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("write known hosts file failed: %v", err))
+	}
+	return fileName, nil
+}
+
+// WriteBastionKey writes the private key ansible uses to jump through a bastion, the directory must be shared with kobe.
+func WriteBastionKey(name string, privateKey string) (string, error) {
+	if !file.Exists(constant.DefaultBastionKeyDir) {
+		err := os.MkdirAll(constant.DefaultBastionKeyDir, 0700)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("create bastion key dir failed: %v", err))
+		}
+	}
+	fileName := BastionKeyPath(name)
+	if err := ioutil.WriteFile(fileName, []byte(privateKey), 0600); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("write bastion key file failed: %v", err))
+	}
+	return fileName, nil
+}
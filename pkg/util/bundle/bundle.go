@@ -0,0 +1,203 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ManifestFile  = "bundle.json"
+	SignatureFile = "bundle.json.sig"
+	BlobDir       = "blobs/sha256"
+)
+
+var (
+	ErrSignature = errors.New("BUNDLE_SIGNATURE_INVALID")
+	ErrChecksum  = errors.New("BUNDLE_CHECKSUM_INVALID")
+)
+
+// Manifest describes the content of an offline bundle. Files holds the sha256 of every file of the
+// bundle but the manifest and its signature, anything not listed there is rejected.
+type Manifest struct {
+	Name             string            `json:"name"`
+	Architecture     string            `json:"architecture"`
+	Images           []Image           `json:"images"`
+	Charts           []string          `json:"charts"`
+	Binaries         []Binary          `json:"binaries"`
+	ClusterManifests []json.RawMessage `json:"clusterManifests"`
+	Files            map[string]string `json:"files"`
+}
+
+// Image is an image manifest of the bundle, its config and layers are read from blobs/sha256.
+type Image struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Manifest   string `json:"manifest"`
+	MediaType  string `json:"mediaType"`
+}
+
+// Binary is uploaded to Directory of the raw Repository of nexus.
+type Binary struct {
+	Path       string `json:"path"`
+	Repository string `json:"repository"`
+	Directory  string `json:"directory"`
+}
+
+type Bundle struct {
+	Manifest
+	dir string
+}
+
+// Open extracts the bundle at path and verifies the signature of its manifest against publicKey,
+// a base64 ed25519 public key, and the checksum of every file. The caller must Close the bundle.
+func Open(path, publicKey string) (*Bundle, error) {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid bundle public key")
+	}
+	dir, err := ioutil.TempDir("", "ko-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{dir: dir}
+	if err := extract(path, dir); err != nil {
+		b.Close()
+		return nil, err
+	}
+	if err := b.verify(ed25519.PublicKey(key)); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bundle) verify(key ed25519.PublicKey) error {
+	content, err := ioutil.ReadFile(filepath.Join(b.dir, ManifestFile))
+	if err != nil {
+		return fmt.Errorf("read %s failed: %v", ManifestFile, err)
+	}
+	sig, err := ioutil.ReadFile(filepath.Join(b.dir, SignatureFile))
+	if err != nil {
+		return fmt.Errorf("read %s failed: %v", SignatureFile, err)
+	}
+	sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(key, content, sig) {
+		return ErrSignature
+	}
+	if err := json.Unmarshal(content, &b.Manifest); err != nil {
+		return fmt.Errorf("parse %s failed: %v", ManifestFile, err)
+	}
+	for name, sum := range b.Files {
+		actual, err := checksum(b.Path(name))
+		if err != nil {
+			return err
+		}
+		if actual != sum {
+			return ErrChecksum
+		}
+	}
+	return filepath.Walk(b.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(b.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile || rel == SignatureFile {
+			return nil
+		}
+		if _, ok := b.Files[rel]; !ok {
+			return ErrChecksum
+		}
+		return nil
+	})
+}
+
+// Path returns the location of a file of the bundle.
+func (b *Bundle) Path(name string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(name))
+}
+
+// Blob returns the location of the blob with digest, e.g. sha256:abc.
+func (b *Bundle) Blob(digest string) string {
+	return b.Path(BlobDir + "/" + strings.TrimPrefix(digest, "sha256:"))
+}
+
+func (b *Bundle) Close() {
+	_ = os.RemoveAll(b.dir)
+}
+
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func extract(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("bundle is not a tar.gz: %v", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("bundle entry %s escapes the bundle", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		default:
+			return fmt.Errorf("bundle entry %s is not a regular file", header.Name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBundle(t *testing.T, files map[string][]byte, sign ed25519.PrivateKey, tamper bool) string {
+	m := Manifest{Name: "v1.20.6", Architecture: "amd64", Charts: []string{"charts/a.tgz"}, Files: map[string]string{}}
+	for name, content := range files {
+		sum := sha256.Sum256(content)
+		m.Files[name] = hex.EncodeToString(sum[:])
+	}
+	manifest, _ := json.Marshal(m)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(sign, manifest))
+	all := map[string][]byte{ManifestFile: manifest, SignatureFile: []byte(sig)}
+	for name, content := range files {
+		if tamper {
+			content = append(content, '!')
+		}
+		all[name] = content
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range all {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write(content)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	_, other, _ := ed25519.GenerateKey(nil)
+	key := base64.StdEncoding.EncodeToString(pub)
+	files := map[string][]byte{"charts/a.tgz": []byte("chart")}
+
+	b, err := Open(writeBundle(t, files, priv, false), key)
+	if err != nil {
+		t.Fatalf("open signed bundle: %v", err)
+	}
+	if b.Name != "v1.20.6" || len(b.Charts) != 1 {
+		t.Errorf("unexpected manifest %+v", b.Manifest)
+	}
+	if _, err := os.Stat(b.Path("charts/a.tgz")); err != nil {
+		t.Errorf("chart not extracted: %v", err)
+	}
+	b.Close()
+
+	if _, err := Open(writeBundle(t, files, other, false), key); err != ErrSignature {
+		t.Errorf("expected %v, got %v", ErrSignature, err)
+	}
+	if _, err := Open(writeBundle(t, files, priv, true), key); err != ErrChecksum {
+		t.Errorf("expected %v, got %v", ErrChecksum, err)
+	}
+	if _, err := Open(writeBundle(t, map[string][]byte{"../evil": []byte("x")}, priv, false), key); err == nil {
+		t.Error("expected entries outside the bundle to be rejected")
+	}
+}
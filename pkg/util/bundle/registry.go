@@ -0,0 +1,186 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+// Nexus imports the content of a bundle into the nexus of a system registry, images are pushed
+// through the docker hosted repository and charts and binaries through the components api.
+type Nexus struct {
+	// Registry is the endpoint of the docker hosted repository, e.g. http://registry.local:8083.
+	Registry string
+	// Repo is the endpoint of nexus itself, e.g. http://registry.local:8081.
+	Repo     string
+	User     string
+	Password string
+	client   *http.Client
+}
+
+func NewNexus(registry, repo, user, password string) *Nexus {
+	return &Nexus{
+		Registry: strings.TrimSuffix(registry, "/"),
+		Repo:     strings.TrimSuffix(repo, "/"),
+		User:     user,
+		Password: password,
+		client: &http.Client{
+			Timeout:   30 * time.Minute,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}
+}
+
+type imageManifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+type descriptor struct {
+	Digest string `json:"digest"`
+}
+
+// PushImage uploads the blobs of image which are missing in the registry and tags its manifest.
+func (n *Nexus) PushImage(b *Bundle, image Image) error {
+	content, err := ioutil.ReadFile(b.Path(image.Manifest))
+	if err != nil {
+		return err
+	}
+	var m imageManifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return fmt.Errorf("parse manifest of %s:%s failed: %v", image.Repository, image.Tag, err)
+	}
+	for _, d := range append([]descriptor{m.Config}, m.Layers...) {
+		if err := n.pushBlob(b, image.Repository, d.Digest); err != nil {
+			return err
+		}
+	}
+	mediaType := image.MediaType
+	if mediaType == "" {
+		mediaType = defaultMediaType
+	}
+	resp, err := n.do(http.MethodPut, fmt.Sprintf("%s/v2/%s/manifests/%s", n.Registry, image.Repository, image.Tag), mediaType, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp, "push manifest of "+image.Repository+":"+image.Tag)
+}
+
+func (n *Nexus) pushBlob(b *Bundle, repository, digest string) error {
+	resp, err := n.do(http.MethodHead, fmt.Sprintf("%s/v2/%s/blobs/%s", n.Registry, repository, digest), "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	resp, err = n.do(http.MethodPost, fmt.Sprintf("%s/v2/%s/blobs/uploads/", n.Registry, repository), "", nil)
+	if err != nil {
+		return err
+	}
+	location := resp.Header.Get("Location")
+	if err := checkResponse(resp, "start upload of "+digest); err != nil {
+		return err
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		base, _ := url.Parse(n.Registry)
+		u = base.ResolveReference(u)
+	}
+	q := u.Query()
+	q.Set("digest", digest)
+	u.RawQuery = q.Encode()
+	f, err := os.Open(b.Blob(digest))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	resp, err = n.do(http.MethodPut, u.String(), "application/octet-stream", f)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp, "upload of "+digest)
+}
+
+// UploadChart uploads the chart package at path to the helm hosted repository.
+func (n *Nexus) UploadChart(repository, path string) error {
+	return n.upload(repository, path, "helm.asset", nil)
+}
+
+// UploadRaw uploads the file at path to directory of the raw hosted repository.
+func (n *Nexus) UploadRaw(repository, directory, path string) error {
+	return n.upload(repository, path, "raw.asset1", map[string]string{
+		"raw.directory":       directory,
+		"raw.asset1.filename": filepath.Base(path),
+	})
+}
+
+func (n *Nexus) upload(repository, path, field string, fields map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		for k, v := range fields {
+			if err := writer.WriteField(k, v); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		part, err := writer.CreateFormFile(field, filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	resp, err := n.do(http.MethodPost, fmt.Sprintf("%s/service/rest/v1/components?repository=%s", n.Repo, url.QueryEscape(repository)), writer.FormDataContentType(), pr)
+	if err != nil {
+		// unblock the writer, it may still wait for the request to read the body
+		pr.CloseWithError(err)
+		return err
+	}
+	return checkResponse(resp, "upload of "+filepath.Base(path))
+}
+
+func (n *Nexus) do(method, u, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(n.User, n.Password)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return n.client.Do(req)
+}
+
+func checkResponse(resp *http.Response, action string) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s failed: %s %s", action, resp.Status, string(body))
+	}
+	return nil
+}
@@ -7,8 +7,9 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"github.com/spf13/viper"
 	"io"
+	"strconv"
+	"strings"
 )
 
 func padding(plaintext []byte, blockSize int) []byte {
@@ -71,28 +72,54 @@ func aesDecryptWithSalt(key, ciphertext []byte) ([]byte, error) {
 	return ciphertext, nil
 }
 
+// StringEncrypt encrypts with the current key, the result is an envelope carrying the key version.
 func StringEncrypt(text string) (string, error) {
-	key := viper.GetString("encrypt.key")
-	pass := []byte(text)
-	xpass, err := aesEncryptWithSalt([]byte(key), pass)
-	if err == nil {
-		pass64 := base64.StdEncoding.EncodeToString(xpass)
-		return pass64, err
+	version := CurrentKeyVersion()
+	key, err := getKey(version)
+	if err != nil {
+		return "", err
 	}
-	return "", err
+	return StringEncryptWithKey(text, version, key)
+}
+
+// StringEncryptWithKey encrypts with a key that may not be in the keyring yet, like the one being rotated to.
+func StringEncryptWithKey(text string, version int, key []byte) (string, error) {
+	xpass, err := aesEncryptWithSalt(key, []byte(text))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d:%s", envelopePrefix, version, base64.StdEncoding.EncodeToString(xpass)), nil
 }
 
+// StringDecrypt decrypts envelopes of every key version as well as values written before
+// the key was versioned, those belong to the encrypt.key of the config.
 func StringDecrypt(text string) (string, error) {
-	key := viper.GetString("encrypt.key")
+	version := 0
+	if IsEnvelope(text) {
+		parts := strings.SplitN(strings.TrimPrefix(text, envelopePrefix), ":", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid encrypted value")
+		}
+		v, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return "", fmt.Errorf("invalid key version %s", parts[0])
+		}
+		version = v
+		text = parts[1]
+	}
+	key, err := getKey(version)
+	if err != nil {
+		return "", err
+	}
 	bytesPass, err := base64.StdEncoding.DecodeString(text)
 	if err != nil {
 		return "", err
 	}
 	var tpass []byte
 	if isSaltPass(bytesPass) {
-		tpass, err = aesDecryptWithSalt([]byte(key), bytesPass)
+		tpass, err = aesDecryptWithSalt(key, bytesPass)
 	} else {
-		tpass, err = aesDecrypt([]byte(key), bytesPass)
+		tpass, err = aesDecrypt(key, bytesPass)
 	}
 	if err == nil {
 		result := string(tpass[:])
@@ -102,6 +129,9 @@ func StringDecrypt(text string) (string, error) {
 }
 
 func isSaltPass(pass []byte) bool {
+	if len(pass) < 8 {
+		return false
+	}
 	for i := 2; i < 8; i++ {
 		if pass[i] != 1 {
 			return false
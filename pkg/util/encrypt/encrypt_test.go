@@ -2,8 +2,10 @@ package encrypt
 
 import (
 	"fmt"
-	"github.com/KubeOperator/KubeOperator/pkg/config"
 	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/config"
+	"github.com/spf13/viper"
 )
 
 func TestStringEncrypt(t *testing.T) {
@@ -22,3 +24,49 @@ func TestStringDecrypt(t *testing.T) {
 	}
 	fmt.Println(p)
 }
+
+func TestKeyRotation(t *testing.T) {
+	viper.Set("encrypt.key", "kubeoperator1234")
+	defer SetKeyring(0, nil)
+
+	old, err := StringEncrypt("Calong@2015")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyVersion(old) != 0 {
+		t.Fatalf("expected version 0, got %s", old)
+	}
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := WrapKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reEncrypted, err := StringEncryptWithKey("Calong@2015", 1, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetKeyring(1, map[int]string{1: wrapped}); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{old, reEncrypted} {
+		p, err := StringDecrypt(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != "Calong@2015" {
+			t.Fatalf("unexpected plain text %s", p)
+		}
+	}
+	current, _ := StringEncrypt("Calong@2015")
+	if KeyVersion(current) != 1 {
+		t.Fatalf("expected version 1, got %s", current)
+	}
+
+	viper.Set("encrypt.key", "kubeoperator4321")
+	if err := SetKeyring(1, map[int]string{1: wrapped}); err == nil {
+		t.Fatal("expected unwrap with another config key to fail")
+	}
+}
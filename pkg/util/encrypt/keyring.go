@@ -0,0 +1,131 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// envelopePrefix marks a value encrypted with a versioned key, "ko:v<version>:<base64>".
+const envelopePrefix = "ko:v"
+
+// keySize is the size of the keys added by rotation, they are used as AES-256 keys.
+const keySize = 32
+
+var errInvalidWrappedKey = errors.New("invalid wrapped key, the encrypt.key of the config may have changed")
+
+// keyring holds the keys added by rotation, version 0 is always the encrypt.key of the config.
+// The keys are stored in the database wrapped with the encrypt.key of the config, so they are
+// backed up with the values they encrypt, and loaded with SetKeyring at startup.
+type keyring struct {
+	current int
+	keys    map[int][]byte
+}
+
+var (
+	ring     = &keyring{keys: map[int][]byte{}}
+	ringLock sync.RWMutex
+)
+
+func IsEnvelope(text string) bool {
+	return strings.HasPrefix(text, envelopePrefix)
+}
+
+// KeyVersion returns the version of the key the value is encrypted with.
+func KeyVersion(text string) int {
+	if !IsEnvelope(text) {
+		return 0
+	}
+	v, _ := strconv.Atoi(strings.SplitN(strings.TrimPrefix(text, envelopePrefix), ":", 2)[0])
+	return v
+}
+
+func CurrentKeyVersion() int {
+	ringLock.RLock()
+	defer ringLock.RUnlock()
+	return ring.current
+}
+
+// SetKeyring replaces the keys in use, wrapped maps every version added by rotation to its key
+// wrapped with WrapKey and current is the version new values are encrypted with.
+func SetKeyring(current int, wrapped map[int]string) error {
+	keys := map[int][]byte{}
+	for v, w := range wrapped {
+		key, err := UnwrapKey(w)
+		if err != nil {
+			return fmt.Errorf("unwrap key version %d failed: %s", v, err.Error())
+		}
+		keys[v] = key
+	}
+	if _, ok := keys[current]; !ok && current != 0 {
+		return fmt.Errorf("key version %d not found", current)
+	}
+	ringLock.Lock()
+	defer ringLock.Unlock()
+	ring = &keyring{current: current, keys: keys}
+	return nil
+}
+
+// NewKey generates a key for rotation, values can be encrypted with it by StringEncryptWithKey
+// before it is stored and made current.
+func NewKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WrapKey encrypts a key with the encrypt.key of the config so it can be stored with the data.
+func WrapKey(key []byte) (string, error) {
+	xpass, err := aesEncryptWithSalt([]byte(viper.GetString("encrypt.key")), key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(xpass), nil
+}
+
+// UnwrapKey checks the whole padding, so a key wrapped with another encrypt.key is refused
+// instead of being used to encrypt.
+func UnwrapKey(text string) ([]byte, error) {
+	bs, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) != aes.BlockSize+keySize+aes.BlockSize {
+		return nil, errInvalidWrappedKey
+	}
+	block, err := aes.NewCipher([]byte(viper.GetString("encrypt.key")))
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, keySize+aes.BlockSize)
+	cipher.NewCBCDecrypter(block, bs[:aes.BlockSize]).CryptBlocks(plain, bs[aes.BlockSize:])
+	for _, b := range plain[keySize:] {
+		if int(b) != aes.BlockSize {
+			return nil, errInvalidWrappedKey
+		}
+	}
+	return plain[:keySize], nil
+}
+
+func getKey(version int) ([]byte, error) {
+	if version == 0 {
+		return []byte(viper.GetString("encrypt.key")), nil
+	}
+	ringLock.RLock()
+	defer ringLock.RUnlock()
+	key, ok := ring.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("key version %d not found", version)
+	}
+	return key, nil
+}
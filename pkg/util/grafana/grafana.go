@@ -13,8 +13,10 @@ import (
 
 type Interface interface {
 	CreateDataSource(name string, url string) error
+	AddDataSource(source *DataSource) error
 	DeleteDataSource(name string) error
 	CreateDashboard(dataSourceName string) (string, error)
+	CreateClusterCompareDashboard(dataSourceName string) (string, error)
 	DeleteDashboard(name string) error
 }
 
@@ -42,8 +44,11 @@ func NewClient() *Client {
 }
 
 func (c Client) CreateDataSource(name string, prometheusUrl string) error {
+	return c.AddDataSource(NewDataSource(name, prometheusUrl))
+}
+
+func (c Client) AddDataSource(source *DataSource) error {
 	url := fmt.Sprintf("http://%s:%s@%s:%d/api/datasources/", c.Username, c.Password, c.Host, c.Port)
-	source := NewDataSource(name, prometheusUrl)
 	data, err := json.Marshal(&source)
 	if err != nil {
 		return err
@@ -80,7 +85,14 @@ func (c Client) DeleteDataSource(name string) error {
 }
 
 func (c Client) CreateDashboard(dataSourceName string) (string, error) {
-	dashboard := NewDashboard(dataSourceName)
+	return c.createDashboard(NewDashboard(dataSourceName))
+}
+
+func (c Client) CreateClusterCompareDashboard(dataSourceName string) (string, error) {
+	return c.createDashboard(NewClusterCompareDashboard(dataSourceName))
+}
+
+func (c Client) createDashboard(dashboard *Dashboard) (string, error) {
 	req := CreateDashboardRequest{
 		Dashboard: *dashboard,
 		Overwrite: true,
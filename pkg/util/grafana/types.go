@@ -23,8 +23,23 @@ type Dashboard struct {
 }
 
 func NewDashboard(dataSourceName string) *Dashboard {
+	dashboard := newDashboard(constant.DefaultDashboardTemplate, dataSourceName)
+	dashboard.Title = dataSourceName
+	dashboard.Uid = uuid.NewV4().String()
+	return dashboard
+}
+
+// NewClusterCompareDashboard returns the dashboard comparing the clusters side by side, it queries
+// the central metrics store where every series has the label of its cluster.
+func NewClusterCompareDashboard(dataSourceName string) *Dashboard {
+	dashboard := newDashboard(constant.DefaultClusterCompareDashboardTemplate, dataSourceName)
+	dashboard.Uid = constant.MetricsDashboardUid
+	return dashboard
+}
+
+func newDashboard(template string, dataSourceName string) *Dashboard {
 	var dashboard Dashboard
-	_ = json.Unmarshal([]byte(constant.DefaultDashboardTemplate), &dashboard)
+	_ = json.Unmarshal([]byte(template), &dashboard)
 	for i := range dashboard.Panels {
 		dashboard.Panels[i]["datasource"] = dataSourceName
 	}
@@ -33,17 +48,18 @@ func NewDashboard(dataSourceName string) *Dashboard {
 			v[i]["datasource"] = dataSourceName
 		}
 	}
-	dashboard.Title = dataSourceName
-	dashboard.Uid = uuid.NewV4().String()
 	return &dashboard
 }
 
 type DataSource struct {
-	Name      string `json:"name"`
-	Type      string `json:"type"`
-	Url       string `json:"url"`
-	Access    string `json:"access"`
-	BasicAuth bool   `json:"basic_auth"`
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	Url            string            `json:"url"`
+	Access         string            `json:"access"`
+	BasicAuth      bool              `json:"basicAuth"`
+	BasicAuthUser  string            `json:"basicAuthUser,omitempty"`
+	JsonData       map[string]string `json:"jsonData,omitempty"`
+	SecureJsonData map[string]string `json:"secureJsonData,omitempty"`
 }
 
 func NewDataSource(name string, url string) *DataSource {
@@ -56,6 +72,21 @@ func NewDataSource(name string, url string) *DataSource {
 	}
 }
 
+// WithBasicAuth makes grafana send the user and password to the data source.
+func (d *DataSource) WithBasicAuth(user, password string) *DataSource {
+	d.BasicAuth = true
+	d.BasicAuthUser = user
+	d.SecureJsonData = map[string]string{"basicAuthPassword": password}
+	return d
+}
+
+// WithBearerToken makes grafana send the token in the authorization header to the data source.
+func (d *DataSource) WithBearerToken(token string) *DataSource {
+	d.JsonData = map[string]string{"httpHeaderName1": "Authorization"}
+	d.SecureJsonData = map[string]string{"httpHeaderValue1": "Bearer " + token}
+	return d
+}
+
 type CreateDashboardRequest struct {
 	Dashboard Dashboard
 	Overwrite bool
@@ -9,3 +9,18 @@ func TestNewDashboard(t *testing.T) {
 	//fmt.Println(dash)
 
 }
+
+func TestNewClusterCompareDashboard(t *testing.T) {
+	dash := NewClusterCompareDashboard("metrics")
+	if len(dash.Panels) == 0 {
+		t.Fatal("dashboard template has no panels")
+	}
+	for _, p := range dash.Panels {
+		if p["datasource"] != "metrics" {
+			t.Errorf("panel %v does not use the datasource", p["title"])
+		}
+	}
+	if len(dash.Templating["list"]) != 1 || dash.Templating["list"][0]["datasource"] != "metrics" {
+		t.Errorf("cluster variable does not use the datasource: %v", dash.Templating)
+	}
+}
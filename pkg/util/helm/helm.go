@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"github.com/gofrs/flock"
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
@@ -42,6 +44,9 @@ type Interface interface {
 	Upgrade(name string, chartName string, chartVersion string, values map[string]interface{}) (*release.Release, error)
 	Uninstall(name string) (*release.UninstallReleaseResponse, error)
 	List() ([]*release.Release, error)
+	History(name string) ([]*release.Release, error)
+	Rollback(name string, revision int) (*release.Release, error)
+	LoadChart(chartName string, chartVersion string) (*chart.Chart, error)
 	GetRepoIP(arch string) (string, string, int, int, error)
 	SyncRepoCharts(arch string) error
 }
@@ -167,6 +172,50 @@ func (c Client) List() ([]*release.Release, error) {
 	return release, nil
 }
 
+// History returns the revisions of release name kept by helm, the oldest first.
+func (c Client) History(name string) ([]*release.Release, error) {
+	client := action.NewHistory(c.installActionConfig)
+	client.Max = 256
+	rs, err := client.Run(name)
+	if err != nil {
+		return rs, fmt.Errorf("get history of %s failed: %v", name, err)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Version < rs[j].Version })
+	return rs, nil
+}
+
+// Rollback rolls release name back to revision and returns the release created by the rollback.
+func (c Client) Rollback(name string, revision int) (*release.Release, error) {
+	client := action.NewRollback(c.installActionConfig)
+	client.Version = revision
+	client.Timeout = 5 * time.Minute
+	if err := client.Run(name); err != nil {
+		return nil, fmt.Errorf("rollback %s to revision %d failed: %v", name, revision, err)
+	}
+	rel, err := action.NewGet(c.installActionConfig).Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("get release %s failed: %v", name, err)
+	}
+	return rel, nil
+}
+
+// LoadChart loads chartName of chartVersion from the repositories without installing it.
+func (c Client) LoadChart(chartName, chartVersion string) (*chart.Chart, error) {
+	if err := updateRepo(c.Architectures); err != nil {
+		return nil, err
+	}
+	options := action.ChartPathOptions{InsecureSkipTLSverify: true, Version: chartVersion}
+	p, err := options.LocateChart(chartName, c.settings)
+	if err != nil {
+		return nil, fmt.Errorf("locate chart %s failed: %v", chartName, err)
+	}
+	ct, err := loader.Load(p)
+	if err != nil {
+		return nil, fmt.Errorf("load chart %s failed: %v", chartName, err)
+	}
+	return ct, nil
+}
+
 func GetSettings() *cli.EnvSettings {
 	return &cli.EnvSettings{
 		PluginsDirectory: helmpath.DataPath("plugins"),
@@ -0,0 +1,88 @@
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const (
+	ValueAdded   = "added"
+	ValueRemoved = "removed"
+	ValueChanged = "changed"
+)
+
+type ValueChange struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// DiffValues compares the leaves of two helm value trees, lists are compared as a whole.
+func DiffValues(current, proposed map[string]interface{}) []ValueChange {
+	old, new := FlattenValues(current), FlattenValues(proposed)
+	var changes []ValueChange
+	for path, o := range old {
+		n, ok := new[path]
+		if !ok {
+			changes = append(changes, ValueChange{Path: path, Type: ValueRemoved, Old: o})
+			continue
+		}
+		if !reflect.DeepEqual(o, n) && fmt.Sprint(o) != fmt.Sprint(n) {
+			changes = append(changes, ValueChange{Path: path, Type: ValueChanged, Old: o, New: n})
+		}
+	}
+	for path, n := range new {
+		if _, ok := old[path]; !ok {
+			changes = append(changes, ValueChange{Path: path, Type: ValueAdded, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// FlattenValues returns the leaves of a helm value tree by their dotted path, dots of keys are
+// escaped like helm --set expects.
+func FlattenValues(values map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	flattenValues("", values, result)
+	return result
+}
+
+func flattenValues(prefix string, values map[string]interface{}, result map[string]interface{}) {
+	for k, v := range values {
+		path := strings.ReplaceAll(k, ".", "\\.")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			flattenValues(path, m, result)
+			continue
+		}
+		result[path] = v
+	}
+}
+
+// ValidateValues validates values merged with the defaults of ct against the values.schema.json
+// of the chart and its dependencies, it returns one message per violation.
+func ValidateValues(ct *chart.Chart, values map[string]interface{}) []string {
+	merged, err := chartutil.CoalesceValues(ct, values)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if err := chartutil.ValidateAgainstSchema(ct, merged); err != nil {
+		var messages []string
+		for _, line := range strings.Split(err.Error(), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasSuffix(line, ":") {
+				messages = append(messages, strings.TrimPrefix(line, "- "))
+			}
+		}
+		return messages
+	}
+	return nil
+}
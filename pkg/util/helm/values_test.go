@@ -0,0 +1,57 @@
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestDiffValues(t *testing.T) {
+	current := map[string]interface{}{
+		"server": map[string]interface{}{"retention": "10d", "replicas": 1},
+		"nodeSelector": map[string]interface{}{
+			"kubernetes.io/arch": "amd64",
+		},
+	}
+	proposed := map[string]interface{}{
+		"server":       map[string]interface{}{"retention": "15d", "replicas": float64(1)},
+		"alertmanager": map[string]interface{}{"enabled": true},
+	}
+	changes := DiffValues(current, proposed)
+	expect := []ValueChange{
+		{Path: "alertmanager.enabled", Type: ValueAdded, New: true},
+		{Path: "nodeSelector.kubernetes\\.io/arch", Type: ValueRemoved, Old: "amd64"},
+		{Path: "server.retention", Type: ValueChanged, Old: "10d", New: "15d"},
+	}
+	if len(changes) != len(expect) {
+		t.Fatalf("expected %d changes, got %+v", len(expect), changes)
+	}
+	for i := range expect {
+		if changes[i] != expect[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, expect[i], changes[i])
+		}
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	ct := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "prometheus", Version: "11.12.1"},
+		Values:   map[string]interface{}{"server": map[string]interface{}{"retention": "15d"}},
+		Schema: []byte(`{
+			"type": "object",
+			"properties": {
+				"server": {
+					"type": "object",
+					"properties": {"retention": {"type": "string", "pattern": "^[0-9]+[dhm]$"}}
+				}
+			}
+		}`),
+	}
+	if errs := ValidateValues(ct, map[string]interface{}{"server": map[string]interface{}{"retention": "30d"}}); len(errs) != 0 {
+		t.Errorf("expected valid values, got %v", errs)
+	}
+	errs := ValidateValues(ct, map[string]interface{}{"server": map[string]interface{}{"retention": "30 days"}})
+	if len(errs) != 1 {
+		t.Fatalf("expected one violation, got %v", errs)
+	}
+}
@@ -0,0 +1,26 @@
+package ipaddr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArpProbeCommand pings the addresses from a host on their subnet so its kernel resolves them,
+// then prints the arp table of that host for ParseArpTable.
+func ArpProbeCommand(addresses []string) string {
+	return fmt.Sprintf("for a in %s; do ping -c 1 -W 1 $a >/dev/null 2>&1; done; cat /proc/net/arp", strings.Join(addresses, " "))
+}
+
+// ParseArpTable maps the addresses of /proc/net/arp to their mac, incomplete entries are left out.
+func ParseArpTable(content string) map[string]string {
+	macs := map[string]string{}
+	lines := strings.Split(content, "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] == "0x0" || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		macs[fields[0]] = fields[3]
+	}
+	return macs
+}
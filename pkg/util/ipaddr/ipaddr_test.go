@@ -2,6 +2,8 @@ package ipaddr
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"testing"
 )
 
@@ -11,3 +13,43 @@ func TestGenerateIps(t *testing.T) {
 		fmt.Println(ip)
 	}
 }
+
+func TestRangeExclude(t *testing.T) {
+	_, cidr, _ := net.ParseCIDR("172.16.10.0/28")
+	r := NewCIDRRange(cidr)
+	if err := r.Exclude("172.16.10.1", "172.16.10.5-172.16.10.8", "172.16.9.250-172.16.10.2"); err != nil {
+		t.Fatal(err)
+	}
+	var ips []string
+	for _, ip := range r.FreeIPs() {
+		ips = append(ips, ip.String())
+	}
+	want := "172.16.10.3,172.16.10.4,172.16.10.9,172.16.10.10,172.16.10.11,172.16.10.12,172.16.10.13,172.16.10.14"
+	if strings.Join(ips, ",") != want {
+		t.Errorf("free ips %v, want %s", ips, want)
+	}
+	if err := r.Exclude("172.16.10.8-172.16.10.5"); err == nil {
+		t.Error("reversed exclusion accepted")
+	}
+}
+
+func TestParseArpTable(t *testing.T) {
+	content := `IP address       HW type     Flags       HW address            Mask     Device
+172.16.10.1      0x1         0x2         52:54:00:12:34:56     *        eth0
+172.16.10.7      0x1         0x0         00:00:00:00:00:00     *        eth0
+`
+	macs := ParseArpTable(content)
+	if macs["172.16.10.1"] != "52:54:00:12:34:56" {
+		t.Errorf("mac of 172.16.10.1 is %s", macs["172.16.10.1"])
+	}
+	if _, ok := macs["172.16.10.7"]; ok {
+		t.Error("incomplete entry parsed")
+	}
+}
+
+func TestArpProbeCommand(t *testing.T) {
+	cmd := ArpProbeCommand([]string{"172.16.10.1", "172.16.10.2"})
+	if !strings.HasPrefix(cmd, "for a in 172.16.10.1 172.16.10.2; do ping") || !strings.HasSuffix(cmd, "cat /proc/net/arp") {
+		t.Errorf("unexpected probe command %s", cmd)
+	}
+}
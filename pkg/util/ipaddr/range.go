@@ -0,0 +1,52 @@
+package ipaddr
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Exclude marks the exclusions, single addresses or start-end ranges, as allocated.
+// The parts of an exclusion outside of the range are skipped.
+func (r *Range) Exclude(exclusions ...string) error {
+	for _, e := range exclusions {
+		e = strings.Replace(e, " ", "", -1)
+		if e == "" {
+			continue
+		}
+		start, end := e, e
+		if parts := strings.SplitN(e, "-", 2); len(parts) == 2 {
+			start, end = parts[0], parts[1]
+		}
+		startIP, endIP := net.ParseIP(start), net.ParseIP(end)
+		if startIP == nil || endIP == nil || bytes.Compare(startIP.To16(), endIP.To16()) > 0 {
+			return fmt.Errorf("invalid exclusion %s", e)
+		}
+		first := calculateIPOffset(r.base, startIP)
+		last := calculateIPOffset(r.base, endIP)
+		if first < 0 {
+			first = 0
+		}
+		if last >= r.max {
+			last = r.max - 1
+		}
+		for offset := first; offset <= last; offset++ {
+			if _, err := r.alloc.Allocate(offset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FreeIPs returns the addresses of the range which are not allocated, in order.
+func (r *Range) FreeIPs() []net.IP {
+	var ips []net.IP
+	for offset := 0; offset < r.max; offset++ {
+		if !r.alloc.Has(offset) {
+			ips = append(ips, addIPOffset(r.base, offset))
+		}
+	}
+	return ips
+}
@@ -12,6 +12,7 @@ import (
 
 type Interface interface {
 	RunPlaybook(name, tag string) (string, error)
+	RunAdhoc(pattern, module, param string) (string, error)
 	Watch(writer io.Writer, taskId string) error
 	GetResult(taskId string) (*api.Result, error)
 	SetVar(key string, value string)
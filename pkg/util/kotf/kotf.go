@@ -2,6 +2,8 @@ package kotf
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 
 	"github.com/KubeOperator/kotf/api"
 	kotfClient "github.com/KubeOperator/kotf/pkg/client"
@@ -50,3 +52,26 @@ func (k *Kotf) Destroy(regionVars string) (*api.KotfResult, error) {
 	}
 	return result, nil
 }
+
+var summaryRegexp = regexp.MustCompile(`Resources: (?:(\d+) added, (\d+) changed, )?(\d+) destroyed`)
+
+// Summary is the resource count terraform prints at the end of an apply or a destroy.
+type Summary struct {
+	Added     int
+	Changed   int
+	Destroyed int
+}
+
+// ParseSummary reads the last resource count of the output, all zero when terraform printed none.
+func ParseSummary(output string) Summary {
+	var summary Summary
+	matches := summaryRegexp.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return summary
+	}
+	m := matches[len(matches)-1]
+	summary.Added, _ = strconv.Atoi(m[1])
+	summary.Changed, _ = strconv.Atoi(m[2])
+	summary.Destroyed, _ = strconv.Atoi(m[3])
+	return summary
+}
@@ -148,3 +148,17 @@ func TestKotfIint(t *testing.T) {
 	// 	//}
 
 }
+
+func TestParseSummary(t *testing.T) {
+	output := "vsphere_virtual_machine.demo-worker-3: Creation complete after 1m2s\n\n" +
+		"Apply complete! Resources: 2 added, 1 changed, 0 destroyed.\n"
+	if s := ParseSummary(output); s.Added != 2 || s.Changed != 1 || s.Destroyed != 0 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if s := ParseSummary("Destroy complete! Resources: 4 destroyed."); s.Destroyed != 4 || s.Added != 0 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if s := ParseSummary("Error: timeout while waiting for state"); s != (Summary{}) {
+		t.Errorf("unexpected summary %+v", s)
+	}
+}
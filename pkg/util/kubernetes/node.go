@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// CordonNode marks the node unschedulable, or schedulable again when desired is false.
+func CordonNode(client kubernetes.Interface, name string, desired bool) error {
+	node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	helper := &drain.Helper{Ctx: context.TODO(), Client: client}
+	if err := drain.RunCordonOrUncordon(helper, node, desired); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("cordon node %s failed: %v", name, err))
+	}
+	return nil
+}
+
+// DrainNode evicts the pods of the node the way kubectl drain --ignore-daemonsets --delete-local-data does,
+// the output of the drain is returned for the record.
+func DrainNode(client kubernetes.Interface, name string, timeout time.Duration) (string, error) {
+	var out bytes.Buffer
+	helper := &drain.Helper{
+		Ctx:                 context.TODO(),
+		Client:              client,
+		Force:               true,
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: true,
+		DeleteLocalData:     true,
+		Timeout:             timeout,
+		Out:                 &out,
+		ErrOut:              &out,
+	}
+	if err := drain.RunNodeDrain(helper, name); err != nil {
+		return out.String(), errors.Wrap(err, fmt.Sprintf("drain node %s failed: %v", name, err))
+	}
+	return out.String(), nil
+}
+
+// NodeBootID returns the boot id the kubelet of the node reports, it changes on every reboot.
+func NodeBootID(client kubernetes.Interface, name string) (string, error) {
+	node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return node.Status.NodeInfo.BootID, nil
+}
+
+// WaitNodeReady waits until the Ready condition of the node is true. When bootID is not empty
+// the node has to report another boot id first, so a node still Ready before it goes down for
+// a reboot is not taken as back.
+func WaitNodeReady(client kubernetes.Interface, name, bootID string, timeout time.Duration) error {
+	return wait.Poll(10*time.Second, timeout, func() (bool, error) {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			// the apiserver may be unreachable while a master reboots
+			return false, nil
+		}
+		if bootID != "" && node.Status.NodeInfo.BootID == bootID {
+			return false, nil
+		}
+		return IsNodeReady(node), nil
+	})
+}
+
+func IsNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ResourceUsage is the cpu in millicores and the memory in bytes requested, allocatable and used
+// on a node or in a namespace. Namespaces have no allocatable resources.
+type ResourceUsage struct {
+	Name              string `json:"name"`
+	CpuRequested      int64  `json:"cpuRequested"`
+	CpuAllocatable    int64  `json:"cpuAllocatable"`
+	CpuUsed           int64  `json:"cpuUsed"`
+	MemoryRequested   int64  `json:"memoryRequested"`
+	MemoryAllocatable int64  `json:"memoryAllocatable"`
+	MemoryUsed        int64  `json:"memoryUsed"`
+}
+
+func (r *ResourceUsage) add(o ResourceUsage) {
+	r.CpuRequested += o.CpuRequested
+	r.CpuAllocatable += o.CpuAllocatable
+	r.CpuUsed += o.CpuUsed
+	r.MemoryRequested += o.MemoryRequested
+	r.MemoryAllocatable += o.MemoryAllocatable
+	r.MemoryUsed += o.MemoryUsed
+}
+
+// MetricsUsage is the usage reported by the metrics API by node and by namespace.
+type MetricsUsage struct {
+	Nodes      map[string]ResourceUsage
+	Namespaces map[string]ResourceUsage
+}
+
+type metricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Usage      v1.ResourceList   `json:"usage"`
+		Containers []struct {
+			Usage v1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// GetMetricsUsage reads the metrics API served by metrics-server.
+func GetMetricsUsage(client kubernetes.Interface) (*MetricsUsage, error) {
+	result := &MetricsUsage{Nodes: map[string]ResourceUsage{}, Namespaces: map[string]ResourceUsage{}}
+	var nodes metricsList
+	if err := getMetrics(client, "/apis/metrics.k8s.io/v1beta1/nodes", &nodes); err != nil {
+		return nil, err
+	}
+	for _, n := range nodes.Items {
+		result.Nodes[n.Metadata.Name] = ResourceUsage{Name: n.Metadata.Name, CpuUsed: n.Usage.Cpu().MilliValue(), MemoryUsed: n.Usage.Memory().Value()}
+	}
+	var pods metricsList
+	if err := getMetrics(client, "/apis/metrics.k8s.io/v1beta1/pods", &pods); err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		usage := result.Namespaces[p.Metadata.Namespace]
+		usage.Name = p.Metadata.Namespace
+		for _, c := range p.Containers {
+			usage.CpuUsed += c.Usage.Cpu().MilliValue()
+			usage.MemoryUsed += c.Usage.Memory().Value()
+		}
+		result.Namespaces[p.Metadata.Namespace] = usage
+	}
+	return result, nil
+}
+
+func getMetrics(client kubernetes.Interface, path string, into interface{}) error {
+	data, err := client.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(context.TODO())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+// PodRequests returns the effective requests of pod, the init containers run one after another
+// before the containers.
+func PodRequests(pod v1.Pod) (cpu int64, memory int64) {
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().MilliValue()
+		memory += c.Resources.Requests.Memory().Value()
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if v := c.Resources.Requests.Cpu().MilliValue(); v > cpu {
+			cpu = v
+		}
+		if v := c.Resources.Requests.Memory().Value(); v > memory {
+			memory = v
+		}
+	}
+	return cpu, memory
+}
+
+// AggregateUsage sums the requests of the pods which are not terminated by node and by namespace
+// and adds the allocatable resources of the nodes and the usage of metrics, which may be nil.
+// The total of the cluster is returned last.
+func AggregateUsage(nodes []v1.Node, pods []v1.Pod, metrics *MetricsUsage) (nodeUsages []ResourceUsage, namespaceUsages []ResourceUsage, total ResourceUsage) {
+	byNode := map[string]*ResourceUsage{}
+	for _, n := range nodes {
+		u := &ResourceUsage{
+			Name:              n.Name,
+			CpuAllocatable:    n.Status.Allocatable.Cpu().MilliValue(),
+			MemoryAllocatable: n.Status.Allocatable.Memory().Value(),
+		}
+		if metrics != nil {
+			u.CpuUsed = metrics.Nodes[n.Name].CpuUsed
+			u.MemoryUsed = metrics.Nodes[n.Name].MemoryUsed
+		}
+		byNode[n.Name] = u
+	}
+	byNamespace := map[string]*ResourceUsage{}
+	for _, p := range pods {
+		if p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+			continue
+		}
+		cpu, memory := PodRequests(p)
+		if u, ok := byNode[p.Spec.NodeName]; ok {
+			u.CpuRequested += cpu
+			u.MemoryRequested += memory
+		}
+		u, ok := byNamespace[p.Namespace]
+		if !ok {
+			u = &ResourceUsage{Name: p.Namespace}
+			byNamespace[p.Namespace] = u
+		}
+		u.CpuRequested += cpu
+		u.MemoryRequested += memory
+	}
+	if metrics != nil {
+		for name, m := range metrics.Namespaces {
+			u, ok := byNamespace[name]
+			if !ok {
+				u = &ResourceUsage{Name: name}
+				byNamespace[name] = u
+			}
+			u.CpuUsed = m.CpuUsed
+			u.MemoryUsed = m.MemoryUsed
+		}
+	}
+
+	total.Name = "cluster"
+	for _, u := range byNode {
+		nodeUsages = append(nodeUsages, *u)
+		total.add(*u)
+	}
+	for _, u := range byNamespace {
+		namespaceUsages = append(namespaceUsages, *u)
+	}
+	sort.Slice(nodeUsages, func(i, j int) bool { return nodeUsages[i].Name < nodeUsages[j].Name })
+	sort.Slice(namespaceUsages, func(i, j int) bool { return namespaceUsages[i].Name < namespaceUsages[j].Name })
+	return nodeUsages, namespaceUsages, total
+}
+
+// StorageUsage is the storage in bytes claimed by the bound claims of a namespace on a storage class.
+type StorageUsage struct {
+	Namespace    string `json:"namespace"`
+	StorageClass string `json:"storageClass"`
+	Requested    int64  `json:"requested"`
+}
+
+// AggregateStorage sums the capacity of the bound claims by namespace and storage class.
+func AggregateStorage(pvcs []v1.PersistentVolumeClaim) []StorageUsage {
+	byKey := map[[2]string]*StorageUsage{}
+	var result []StorageUsage
+	for _, pvc := range pvcs {
+		if pvc.Status.Phase != v1.ClaimBound {
+			continue
+		}
+		class := ""
+		if pvc.Spec.StorageClassName != nil {
+			class = *pvc.Spec.StorageClassName
+		}
+		size := pvc.Status.Capacity.Storage().Value()
+		if size == 0 {
+			size = pvc.Spec.Resources.Requests.Storage().Value()
+		}
+		key := [2]string{pvc.Namespace, class}
+		u, ok := byKey[key]
+		if !ok {
+			u = &StorageUsage{Namespace: pvc.Namespace, StorageClass: class}
+			byKey[key] = u
+		}
+		u.Requested += size
+	}
+	for _, u := range byKey {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].StorageClass < result[j].StorageClass
+	})
+	return result
+}
@@ -0,0 +1,85 @@
+package kubernetes
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(namespace, node string, phase v1.PodPhase, cpu, memory string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		Spec: v1.PodSpec{
+			NodeName: node,
+			Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse(memory),
+			}}}},
+		},
+		Status: v1.PodStatus{Phase: phase},
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	nodes := []v1.Node{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		}},
+	}}
+	pods := []v1.Pod{
+		testPod("default", "node1", v1.PodRunning, "500m", "1Gi"),
+		testPod("default", "node1", v1.PodPending, "250m", "512Mi"),
+		testPod("kube-system", "node1", v1.PodSucceeded, "1", "1Gi"),
+	}
+	metrics := &MetricsUsage{
+		Nodes:      map[string]ResourceUsage{"node1": {CpuUsed: 1200, MemoryUsed: 2 << 30}},
+		Namespaces: map[string]ResourceUsage{"default": {CpuUsed: 300, MemoryUsed: 1 << 30}},
+	}
+	nodeUsages, namespaceUsages, total := AggregateUsage(nodes, pods, metrics)
+	if len(nodeUsages) != 1 || nodeUsages[0].CpuRequested != 750 || nodeUsages[0].CpuAllocatable != 4000 || nodeUsages[0].CpuUsed != 1200 {
+		t.Errorf("unexpected node usage %+v", nodeUsages)
+	}
+	if len(namespaceUsages) != 1 || namespaceUsages[0].MemoryRequested != 1536<<20 || namespaceUsages[0].CpuUsed != 300 {
+		t.Errorf("unexpected namespace usage %+v", namespaceUsages)
+	}
+	if total.MemoryAllocatable != 8<<30 || total.MemoryUsed != 2<<30 {
+		t.Errorf("unexpected total %+v", total)
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	pod := testPod("default", "node1", v1.PodRunning, "100m", "128Mi")
+	pod.Spec.InitContainers = []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+		v1.ResourceCPU: resource.MustParse("1"),
+	}}}}
+	cpu, memory := PodRequests(pod)
+	if cpu != 1000 || memory != 128<<20 {
+		t.Errorf("unexpected requests cpu %d memory %d", cpu, memory)
+	}
+}
+
+func TestAggregateStorage(t *testing.T) {
+	class := "nfs"
+	pvc := func(namespace string, phase v1.PersistentVolumeClaimPhase, size string) v1.PersistentVolumeClaim {
+		return v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+			Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &class},
+			Status: v1.PersistentVolumeClaimStatus{
+				Phase:    phase,
+				Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			},
+		}
+	}
+	usages := AggregateStorage([]v1.PersistentVolumeClaim{
+		pvc("default", v1.ClaimBound, "10Gi"),
+		pvc("default", v1.ClaimBound, "5Gi"),
+		pvc("default", v1.ClaimPending, "1Gi"),
+	})
+	if len(usages) != 1 || usages[0].StorageClass != "nfs" || usages[0].Requested != 15<<30 {
+		t.Errorf("unexpected storage usage %+v", usages)
+	}
+}
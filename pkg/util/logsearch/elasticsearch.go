@@ -0,0 +1,106 @@
+package logsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// elasticsearchIndex matches the indices written by fluentd, the hidden system indices excluded.
+const elasticsearchIndex = "*,-.*"
+
+type Elasticsearch struct {
+	Config
+}
+
+func NewElasticsearch(config Config) *Elasticsearch {
+	return &Elasticsearch{Config: config}
+}
+
+func (e Elasticsearch) Search(q Query) ([]Entry, error) {
+	data, err := json.Marshal(ElasticsearchQuery(q))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(e.Url, "/")+"/"+elasticsearchIndex+"/_search?ignore_unavailable=true", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := e.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return parseElasticsearch(body)
+}
+
+// ElasticsearchQuery translates q to the search of the records of fluentd, the message is the log
+// field with docker and the message field with cri.
+func ElasticsearchQuery(q Query) map[string]interface{} {
+	filters := []interface{}{
+		map[string]interface{}{"range": map[string]interface{}{"@timestamp": map[string]interface{}{
+			"gte": q.Start.Format(time.RFC3339Nano), "lte": q.End.Format(time.RFC3339Nano),
+		}}},
+	}
+	if q.Namespace != "" {
+		filters = append(filters, map[string]interface{}{"match_phrase": map[string]interface{}{"kubernetes.namespace_name": q.Namespace}})
+	}
+	if q.Pod != "" {
+		filters = append(filters, map[string]interface{}{"match_phrase": map[string]interface{}{"kubernetes.pod_name": q.Pod}})
+	}
+	query := map[string]interface{}{"filter": filters}
+	if q.Text != "" {
+		query["should"] = []interface{}{
+			map[string]interface{}{"match_phrase": map[string]interface{}{"log": q.Text}},
+			map[string]interface{}{"match_phrase": map[string]interface{}{"message": q.Text}},
+		}
+		query["minimum_should_match"] = 1
+	}
+	return map[string]interface{}{
+		"size":  q.Limit,
+		"sort":  []interface{}{map[string]interface{}{"@timestamp": map[string]interface{}{"order": "desc"}}},
+		"query": map[string]interface{}{"bool": query},
+	}
+}
+
+type elasticsearchResult struct {
+	Hits struct {
+		Hits []struct {
+			Source struct {
+				Timestamp  time.Time `json:"@timestamp"`
+				Log        string    `json:"log"`
+				Message    string    `json:"message"`
+				Kubernetes struct {
+					NamespaceName string `json:"namespace_name"`
+					PodName       string `json:"pod_name"`
+					ContainerName string `json:"container_name"`
+				} `json:"kubernetes"`
+			} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func parseElasticsearch(body []byte) ([]Entry, error) {
+	var result elasticsearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, h := range result.Hits.Hits {
+		s := h.Source
+		message := s.Log
+		if message == "" {
+			message = s.Message
+		}
+		entries = append(entries, Entry{
+			Time:      s.Timestamp,
+			Namespace: s.Kubernetes.NamespaceName,
+			Pod:       s.Kubernetes.PodName,
+			Container: s.Kubernetes.ContainerName,
+			Message:   strings.TrimRight(message, "\n"),
+		})
+	}
+	return entries, nil
+}
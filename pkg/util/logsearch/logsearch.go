@@ -0,0 +1,64 @@
+package logsearch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Query selects the logs of a namespace and pod between Start and End whose message contains
+// Text, the newest Limit entries are returned.
+type Query struct {
+	Namespace string
+	Pod       string
+	Text      string
+	Start     time.Time
+	End       time.Time
+	Limit     int
+}
+
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Message   string    `json:"message"`
+}
+
+type Interface interface {
+	Search(q Query) ([]Entry, error)
+}
+
+// Config is the address of the store. Host overrides the host header for stores behind the
+// ingress of a cluster. Tenants are the loki tenants searched, none for loki without auth.
+type Config struct {
+	Url      string
+	Host     string
+	Username string
+	Password string
+	Tenants  []string
+}
+
+func (c Config) do(req *http.Request) ([]byte, error) {
+	if c.Host != "" {
+		req.Host = c.Host
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d: %s", req.URL.Path, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,98 @@
+package logsearch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogQL(t *testing.T) {
+	cases := map[string]Query{
+		`{namespace=~".+"}`:                             {},
+		`{namespace="app", pod="web-1"} |= "timed out"`: {Namespace: "app", Pod: "web-1", Text: "timed out"},
+		`{namespace=~".+"} |= "say \"hi\""`:             {Text: `say "hi"`},
+	}
+	for want, q := range cases {
+		if got := LogQL(q); got != want {
+			t.Errorf("LogQL(%+v) = %s, want %s", q, got, want)
+		}
+	}
+}
+
+func TestElasticsearchQuery(t *testing.T) {
+	q := ElasticsearchQuery(Query{Namespace: "app", Text: "error", Limit: 10})
+	b := q["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	if len(b["filter"].([]interface{})) != 2 {
+		t.Errorf("want the range and the namespace filter, got %v", b["filter"])
+	}
+	if len(b["should"].([]interface{})) != 2 || b["minimum_should_match"] != 1 {
+		t.Errorf("want the text matched in log or message, got %v", b)
+	}
+	if q["size"] != 10 {
+		t.Errorf("size = %v", q["size"])
+	}
+}
+
+func TestSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "logs.example.com" {
+			t.Errorf("host header = %s", r.Host)
+		}
+		switch r.URL.Path {
+		case "/loki/api/v1/query_range":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"namespace":"app","pod":"web-1","container":"web"},"values":[["1600000000000000000","boom\n"]]}]}}`))
+		default:
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"hits":{"hits":[{"_source":{"@timestamp":"2020-09-13T12:26:40Z","message":"boom","kubernetes":{"namespace_name":"app","pod_name":"web-1","container_name":"web"}}}]}}`))
+		}
+	}))
+	defer server.Close()
+	want := Entry{Time: time.Unix(1600000000, 0).UTC(), Namespace: "app", Pod: "web-1", Container: "web", Message: "boom"}
+	config := Config{Url: server.URL, Host: "logs.example.com"}
+	for name, client := range map[string]Interface{"loki": NewLoki(config), "elasticsearch": NewElasticsearch(config)} {
+		entries, err := client.Search(Query{Start: time.Unix(0, 0), End: time.Now(), Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(entries) != 1 || !entries[0].Time.Equal(want.Time) || entries[0].Message != want.Message || entries[0].Pod != want.Pod {
+			t.Errorf("%s: got %+v, want %+v", name, entries, want)
+		}
+	}
+}
+
+func TestLokiTenants(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Scope-OrgID") {
+		case "fake":
+			_, _ = w.Write([]byte(`{"data":{"resultType":"streams","result":[{"stream":{"namespace":"app"},"values":[["1600000000000000000","old"]]}]}}`))
+		case "team-a":
+			_, _ = w.Write([]byte(`{"data":{"resultType":"streams","result":[{"stream":{"namespace":"billing"},"values":[["1600000001000000000","new"]]}]}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	entries, err := NewLoki(Config{Url: server.URL, Tenants: []string{"fake", "team-a"}}).Search(Query{Start: time.Unix(0, 0), End: time.Now(), Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Message != "new" {
+		t.Errorf("want the newest entry of both tenants, got %+v", entries)
+	}
+	if _, err := NewLoki(Config{Url: server.URL}).Search(Query{Start: time.Unix(0, 0), End: time.Now(), Limit: 1}); err == nil {
+		t.Error("want the search without tenant rejected")
+	}
+}
+
+func TestVerifyCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"resultType":"streams","result":[]}}`))
+	}))
+	defer server.Close()
+	if _, err := NewLoki(Config{Url: server.URL}).Search(Query{Start: time.Unix(0, 0), End: time.Now(), Limit: 1}); err == nil {
+		t.Error("want the self signed certificate rejected")
+	}
+}
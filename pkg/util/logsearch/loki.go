@@ -0,0 +1,117 @@
+package logsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Loki struct {
+	Config
+}
+
+func NewLoki(config Config) *Loki {
+	return &Loki{Config: config}
+}
+
+// Search queries every tenant of the config, the newest Limit entries of all of them are returned.
+func (l Loki) Search(q Query) ([]Entry, error) {
+	if len(l.Tenants) == 0 {
+		return l.search(q, "")
+	}
+	entries := []Entry{}
+	for _, tenant := range l.Tenants {
+		tenantEntries, err := l.search(q, tenant)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %s", tenant, err.Error())
+		}
+		entries = append(entries, tenantEntries...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (l Loki) search(q Query, tenant string) ([]Entry, error) {
+	params := url.Values{}
+	params.Set("query", LogQL(q))
+	params.Set("start", strconv.FormatInt(q.Start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(q.End.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(q.Limit))
+	params.Set("direction", "backward")
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(l.Url, "/")+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != "" {
+		req.Header.Set("X-Scope-OrgID", tenant)
+	}
+	body, err := l.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return parseLoki(body)
+}
+
+// LogQL translates q to a query of the streams labeled by promtail, a stream selector needs one
+// matcher which is not empty.
+func LogQL(q Query) string {
+	var matchers []string
+	if q.Namespace != "" {
+		matchers = append(matchers, fmt.Sprintf("namespace=%s", strconv.Quote(q.Namespace)))
+	} else {
+		matchers = append(matchers, `namespace=~".+"`)
+	}
+	if q.Pod != "" {
+		matchers = append(matchers, fmt.Sprintf("pod=%s", strconv.Quote(q.Pod)))
+	}
+	query := "{" + strings.Join(matchers, ", ") + "}"
+	if q.Text != "" {
+		query += " |= " + strconv.Quote(q.Text)
+	}
+	return query
+}
+
+type lokiResult struct {
+	Data struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func parseLoki(body []byte) ([]Entry, error) {
+	var result lokiResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Data.ResultType != "" && result.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("result type %s is not a log stream", result.Data.ResultType)
+	}
+	entries := []Entry{}
+	for _, s := range result.Data.Result {
+		for _, v := range s.Values {
+			ns, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, Entry{
+				Time:      time.Unix(0, ns).UTC(),
+				Namespace: s.Stream["namespace"],
+				Pod:       s.Stream["pod"],
+				Container: s.Stream["container"],
+				Message:   strings.TrimRight(v[1], "\n"),
+			})
+		}
+	}
+	return entries, nil
+}
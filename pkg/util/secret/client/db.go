@@ -0,0 +1,62 @@
+package client
+
+import (
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/util/encrypt"
+)
+
+// dbClient keeps the secret in the credential row encrypted with the master key,
+// private keys saved before they were encrypted are read as is.
+type dbClient struct {
+	Vars map[string]interface{}
+}
+
+func NewDBClient(vars map[string]interface{}) (*dbClient, error) {
+	return &dbClient{
+		Vars: vars,
+	}, nil
+}
+
+func (d dbClient) Put(path string, secret map[string]string) (map[string]string, error) {
+	stored := map[string]string{}
+	for k, v := range secret {
+		stored[k] = v
+	}
+	for _, k := range []string{constant.Password, constant.PrivateKey} {
+		if secret[k] == "" {
+			continue
+		}
+		v, err := encrypt.StringEncrypt(secret[k])
+		if err != nil {
+			return nil, err
+		}
+		stored[k] = v
+	}
+	return stored, nil
+}
+
+func (d dbClient) Get(path string, stored map[string]string) (map[string]string, error) {
+	secret := map[string]string{}
+	for k, v := range stored {
+		secret[k] = v
+	}
+	if stored[constant.Password] != "" {
+		p, err := encrypt.StringDecrypt(stored[constant.Password])
+		if err != nil {
+			return nil, err
+		}
+		secret[constant.Password] = p
+	}
+	if encrypt.IsEnvelope(stored[constant.PrivateKey]) {
+		k, err := encrypt.StringDecrypt(stored[constant.PrivateKey])
+		if err != nil {
+			return nil, err
+		}
+		secret[constant.PrivateKey] = k
+	}
+	return secret, nil
+}
+
+func (d dbClient) Delete(path string) error {
+	return nil
+}
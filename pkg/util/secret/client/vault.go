@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ParamEmpty = "PARAM_EMPTY"
+
+	defaultVaultMount  = "secret"
+	defaultVaultPrefix = "kubeoperator"
+)
+
+// vaultClient talks to the KV version 2 secrets engine of HashiCorp Vault,
+// nothing is kept in the credential row.
+type vaultClient struct {
+	Vars    map[string]interface{}
+	address string
+	token   string
+	mount   string
+	prefix  string
+	client  *http.Client
+}
+
+func NewVaultClient(vars map[string]interface{}) (*vaultClient, error) {
+	address, _ := vars["address"].(string)
+	token, _ := vars["token"].(string)
+	if address == "" || token == "" {
+		return nil, errors.New(ParamEmpty)
+	}
+	mount, _ := vars["mount"].(string)
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+	prefix, _ := vars["prefix"].(string)
+	if prefix == "" {
+		prefix = defaultVaultPrefix
+	}
+	return &vaultClient{
+		Vars:    vars,
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		prefix:  strings.Trim(prefix, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v vaultClient) Put(path string, secret map[string]string) (map[string]string, error) {
+	body, err := json.Marshal(map[string]interface{}{"data": secret})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := v.do(http.MethodPost, v.url("data", path), body); err != nil {
+		return nil, err
+	}
+	return map[string]string{}, nil
+}
+
+func (v vaultClient) Get(path string, stored map[string]string) (map[string]string, error) {
+	body, err := v.do(http.MethodGet, v.url("data", path), nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data.Data, nil
+}
+
+// Delete removes every version of the secret.
+func (v vaultClient) Delete(path string) error {
+	_, err := v.do(http.MethodDelete, v.url("metadata", path), nil)
+	return err
+}
+
+func (v vaultClient) url(kind, path string) string {
+	return fmt.Sprintf("%s/v1/%s/%s/%s/%s", v.address, v.mount, kind, v.prefix, strings.Trim(path, "/"))
+}
+
+func (v vaultClient) do(method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("vault %s %s: %d %s", method, url, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
@@ -0,0 +1,30 @@
+package secret
+
+import (
+	"errors"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+	"github.com/KubeOperator/KubeOperator/pkg/util/secret/client"
+)
+
+var (
+	NotSupport = "NOT_SUPPORT"
+)
+
+// Backend keeps the sensitive part of a credential, the values it returns from Put
+// are the ones kept in the credential row and handed back to Get.
+type Backend interface {
+	Put(path string, secret map[string]string) (map[string]string, error)
+	Get(path string, stored map[string]string) (map[string]string, error)
+	Delete(path string) error
+}
+
+func NewSecretBackend(vars map[string]interface{}) (Backend, error) {
+	if vars["type"] == nil || vars["type"] == "" || vars["type"] == constant.SecretBackendDB {
+		return client.NewDBClient(vars)
+	}
+	if vars["type"] == constant.SecretBackendVault {
+		return client.NewVaultClient(vars)
+	}
+	return nil, errors.New(NotSupport)
+}
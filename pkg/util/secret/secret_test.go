@@ -0,0 +1,92 @@
+package secret
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KubeOperator/KubeOperator/pkg/constant"
+)
+
+// newVaultStandIn serves the subset of the KV v2 api the vault backend uses.
+func newVaultStandIn(t *testing.T, token string) *httptest.Server {
+	var mu sync.Mutex
+	store := map[string]map[string]string{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v1/secret/data/"), "/v1/secret/metadata/")
+		switch r.Method {
+		case http.MethodPost:
+			var body struct {
+				Data map[string]string `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			store[key] = body.Data
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			data, ok := store[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+		case http.MethodDelete:
+			delete(store, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestVaultBackend(t *testing.T) {
+	server := newVaultStandIn(t, "root")
+	defer server.Close()
+
+	backend, err := NewSecretBackend(map[string]interface{}{
+		"type":    constant.SecretBackendVault,
+		"address": server.URL,
+		"token":   "root",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := backend.Put("credentials/1", map[string]string{constant.Password: "Calong@2015"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 0 {
+		t.Fatalf("vault backend should keep nothing in the row, got %v", stored)
+	}
+	secret, err := backend.Get("credentials/1", stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret[constant.Password] != "Calong@2015" {
+		t.Fatalf("unexpected secret %v", secret)
+	}
+	if err := backend.Delete("credentials/1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Get("credentials/1", stored); err == nil {
+		t.Fatal("expected error reading deleted secret")
+	}
+
+	denied, _ := NewSecretBackend(map[string]interface{}{
+		"type":    constant.SecretBackendVault,
+		"address": server.URL,
+		"token":   "wrong",
+	})
+	if _, err := denied.Put("credentials/1", map[string]string{}); err == nil {
+		t.Fatal("expected error with a wrong token")
+	}
+}
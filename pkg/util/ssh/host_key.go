@@ -0,0 +1,133 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var errHostKeyScanned = errors.New("host key scanned")
+
+type HostKeyMismatchError struct {
+	Host     string
+	Expected string
+	Actual   string
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key of %s changed, expected %s but got %s", e.Host, e.Expected, e.Actual)
+}
+
+// HostKeyNotPinnedError is returned for a connection to a host whose key is not recorded yet.
+type HostKeyNotPinnedError struct {
+	Host string
+}
+
+func (e *HostKeyNotPinnedError) Error() string {
+	return fmt.Sprintf("host key of %s is not verified", e.Host)
+}
+
+// MarshalHostKey returns the key in authorized_keys format without the trailing newline.
+func MarshalHostKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+func ParseHostKey(key string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing host key: '%v'", err)
+	}
+	return pub, nil
+}
+
+func HostKeyFingerprint(key string) (string, error) {
+	pub, err := ParseHostKey(key)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(pub), nil
+}
+
+// FixedHostKey accepts only the given key, which is in authorized_keys format.
+func FixedHostKey(expected string) (ssh.HostKeyCallback, error) {
+	pub, err := ParseHostKey(expected)
+	if err != nil {
+		return nil, err
+	}
+	want := MarshalHostKey(pub)
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if got := MarshalHostKey(key); got != want {
+			return &HostKeyMismatchError{
+				Host:     hostname,
+				Expected: ssh.FingerprintSHA256(pub),
+				Actual:   ssh.FingerprintSHA256(key),
+			}
+		}
+		return nil
+	}, nil
+}
+
+// KnownHostsLine renders a known_hosts entry for host:port.
+func KnownHostsLine(host string, port int, key string) (string, error) {
+	pub, err := ParseHostKey(key)
+	if err != nil {
+		return "", err
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	return knownhosts.Line([]string{knownhosts.Normalize(addr)}, pub), nil
+}
+
+// ScanHostKey fetches the key presented by the host of c without authenticating,
+// through the bastion of c when there is one.
+func ScanHostKey(c *Config) (string, error) {
+	if c.DialTimeOut == 0 {
+		c.DialTimeOut = 5 * time.Second
+	}
+	var scanned string
+	config := &ssh.ClientConfig{
+		User: c.User,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			scanned = MarshalHostKey(key)
+			return errHostKeyScanned
+		},
+		Timeout: c.DialTimeOut,
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.Bastion != nil {
+		bastion, err := New(c.Bastion)
+		if err != nil {
+			return "", err
+		}
+		bastionClient, err := bastion.dial()
+		if err != nil {
+			return "", fmt.Errorf("error getting SSH client to bastion %s: '%v'", bastion.addr, err)
+		}
+		defer bastionClient.Close()
+		conn, err = bastionClient.Dial("tcp", addr)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, c.DialTimeOut)
+		if err != nil {
+			return "", err
+		}
+		_ = conn.SetDeadline(time.Now().Add(c.DialTimeOut))
+	}
+	defer conn.Close()
+	_, _, _, err = ssh.NewClientConn(conn, addr, config)
+	if scanned == "" {
+		return "", fmt.Errorf("error scanning host key of %s: '%v'", addr, err)
+	}
+	return scanned, nil
+}
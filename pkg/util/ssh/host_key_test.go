@@ -0,0 +1,77 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestFixedHostKey(t *testing.T) {
+	pinned := newTestHostKey(t)
+	other := newTestHostKey(t)
+
+	callback, err := FixedHostKey(MarshalHostKey(pinned))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callback("172.16.10.210:22", nil, pinned); err != nil {
+		t.Fatalf("pinned key rejected: %v", err)
+	}
+	err = callback("172.16.10.210:22", nil, other)
+	if _, ok := err.(*HostKeyMismatchError); !ok {
+		t.Fatalf("expected HostKeyMismatchError, got %v", err)
+	}
+}
+
+func TestKnownHostsLine(t *testing.T) {
+	key := MarshalHostKey(newTestHostKey(t))
+
+	line, err := KnownHostsLine("172.16.10.210", 22, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "172.16.10.210 "+key {
+		t.Fatalf("unexpected line %q", line)
+	}
+	line, err = KnownHostsLine("172.16.10.210", 2222, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "[172.16.10.210]:2222 ") {
+		t.Fatalf("unexpected line %q", line)
+	}
+}
+
+func TestNewRequiresHostKey(t *testing.T) {
+	config := &Config{User: "root", Host: "172.16.10.210", Port: 22, Password: "KubeOperator@2021"}
+	_, err := New(config)
+	if _, ok := err.(*HostKeyNotPinnedError); !ok {
+		t.Fatalf("expected HostKeyNotPinnedError, got %v", err)
+	}
+
+	config.HostKey = MarshalHostKey(newTestHostKey(t))
+	if _, err := New(config); err != nil {
+		t.Fatalf("pinned host rejected: %v", err)
+	}
+
+	config.HostKey = ""
+	config.AcceptUnknownHostKey = true
+	if _, err := New(config); err != nil {
+		t.Fatalf("explicitly accepted host rejected: %v", err)
+	}
+}
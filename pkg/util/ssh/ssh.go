@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -31,6 +32,8 @@ type SSH struct {
 	authMethods []ssh.AuthMethod
 	dialer      sshDialer
 	Retry       int
+
+	hostKeyCallback ssh.HostKeyCallback
 }
 
 type Config struct {
@@ -42,6 +45,15 @@ type Config struct {
 	PassPhrase  []byte
 	DialTimeOut time.Duration
 	Retry       int
+	// HostKey pins the connection to a key in authorized_keys format,
+	// HostKeyCallback takes precedence over it when both are set.
+	HostKey         string
+	HostKeyCallback ssh.HostKeyCallback
+	// AcceptUnknownHostKey trusts whatever key the host presents, a connection without a
+	// pinned key is refused unless it is set.
+	AcceptUnknownHostKey bool
+	// Bastion is the jump host the connection is tunneled through
+	Bastion *Config
 }
 
 type Interface interface {
@@ -96,14 +108,41 @@ func New(c *Config) (*SSH, error) {
 		c.DialTimeOut = 5 * time.Second
 	}
 
+	hostKeyCallback := c.HostKeyCallback
+	if hostKeyCallback == nil {
+		switch {
+		case c.HostKey != "":
+			callback, err := FixedHostKey(c.HostKey)
+			if err != nil {
+				return nil, err
+			}
+			hostKeyCallback = callback
+		case c.AcceptUnknownHostKey:
+			hostKeyCallback = ssh.InsecureIgnoreHostKey()
+		default:
+			return nil, &HostKeyNotPinnedError{Host: addr}
+		}
+	}
+
+	var dialer sshDialer = &realSSHDialer{}
+	if c.Bastion != nil {
+		bastion, err := New(c.Bastion)
+		if err != nil {
+			return nil, fmt.Errorf("error creating SSH client of bastion %s: '%v'", c.Bastion.Host, err)
+		}
+		dialer = &bastionDialer{bastion: bastion}
+	}
+
 	return &SSH{
 		User:        c.User,
 		Host:        c.Host,
 		Port:        c.Port,
 		addr:        addr,
 		authMethods: authMethods,
-		dialer:      &timeoutDialer{&realSSHDialer{}, c.DialTimeOut},
+		dialer:      &timeoutDialer{dialer, c.DialTimeOut},
 		Retry:       c.Retry,
+
+		hostKeyCallback: hostKeyCallback,
 	}, nil
 }
 
@@ -139,7 +178,7 @@ func (s *SSH) Exec(cmd ...string) (stdout string, stderr string, exit int, err e
 	config := &ssh.ClientConfig{
 		User:            s.User,
 		Auth:            s.authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: s.hostKeyCallback,
 	}
 	client, err := s.dialer.Dial("tcp", s.addr, config)
 	if err != nil && s.Retry > 0 {
@@ -195,7 +234,7 @@ func (s *SSH) CopyFile(src, dst string) error {
 	config := &ssh.ClientConfig{
 		User:            s.User,
 		Auth:            s.authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: s.hostKeyCallback,
 	}
 	client, err := s.dialer.Dial("tcp", s.addr, config)
 	if err != nil {
@@ -241,7 +280,7 @@ func (s *SSH) WriteFile(src io.Reader, dst string) error {
 	config := &ssh.ClientConfig{
 		User:            s.User,
 		Auth:            s.authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: s.hostKeyCallback,
 	}
 	client, err := s.dialer.Dial("tcp", s.addr, config)
 	if err != nil {
@@ -281,7 +320,7 @@ func (s *SSH) Stat(p string) (os.FileInfo, error) {
 	config := &ssh.ClientConfig{
 		User:            s.User,
 		Auth:            s.authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: s.hostKeyCallback,
 	}
 	client, err := s.dialer.Dial("tcp", s.addr, config)
 	if err != nil {
@@ -310,7 +349,7 @@ func (s *SSH) ReadFile(filename string) ([]byte, error) {
 	config := &ssh.ClientConfig{
 		User:            s.User,
 		Auth:            s.authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: s.hostKeyCallback,
 	}
 	client, err := s.dialer.Dial("tcp", s.addr, config)
 	if err != nil {
@@ -341,6 +380,15 @@ func (s *SSH) ReadFile(filename string) ([]byte, error) {
 	return data.Bytes(), err
 }
 
+func (s *SSH) dial() (*ssh.Client, error) {
+	config := &ssh.ClientConfig{
+		User:            s.User,
+		Auth:            s.authMethods,
+		HostKeyCallback: s.hostKeyCallback,
+	}
+	return s.dialer.Dial("tcp", s.addr, config)
+}
+
 func (s *SSH) LookPath(file string) (string, error) {
 	data, err := s.CombinedOutput(fmt.Sprintf("which %s", file))
 	return string(data), err
@@ -368,6 +416,34 @@ func (d *realSSHDialer) Dial(network, addr string, config *ssh.ClientConfig) (*s
 	return ssh.NewClient(c, chans, reqs), nil
 }
 
+// bastionDialer opens the connection to the target from inside a client connected to the bastion.
+type bastionDialer struct {
+	bastion *SSH
+}
+
+func (d *bastionDialer) Dial(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	bastionClient, err := d.bastion.dial()
+	if err != nil {
+		return nil, fmt.Errorf("error getting SSH client to bastion %s: '%v'", d.bastion.addr, err)
+	}
+	conn, err := bastionClient.Dial(network, addr)
+	if err != nil {
+		bastionClient.Close()
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		bastionClient.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	go func() {
+		_ = client.Wait()
+		bastionClient.Close()
+	}()
+	return client, nil
+}
+
 type timeoutDialer struct {
 	dialer  sshDialer
 	timeout time.Duration
@@ -424,3 +500,26 @@ func ParsePublicKeyFromFile(keyFile string) (*rsa.PublicKey, error) {
 	}
 	return rsaKey, nil
 }
+
+// GenerateKeyPair returns a new rsa private key in PEM format and its public key in authorized_keys format.
+func GenerateKeyPair(bits int) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", err
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return string(privateKey), MarshalHostKey(publicKey), nil
+}
+
+// AuthorizedKey returns the public key of a private key in authorized_keys format.
+func AuthorizedKey(privateKey []byte) (string, error) {
+	signer, err := MakePrivateKeySigner(privateKey, nil)
+	if err != nil {
+		return "", err
+	}
+	return MarshalHostKey(signer.PublicKey()), nil
+}
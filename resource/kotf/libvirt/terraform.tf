@@ -0,0 +1,78 @@
+{{ $provider := .provider }}
+{{ $region := .cloudRegion }}
+{{ $hosts := .hosts }}
+
+variable "username" {
+  type = string
+}
+
+variable "password" {
+  type    = string
+  default = ""
+}
+
+terraform {
+  required_providers {
+    libvirt = {
+      source  = "dmacvicar/libvirt"
+      version = "0.6.14"
+    }
+  }
+}
+
+# the key of the kvm host has to be in the known_hosts of kotf, it is not verified otherwise
+provider "libvirt" {
+  uri = "qemu+ssh://${var.username}:${var.password}@{{ $provider.host }}:{{ if $provider.port }}{{ $provider.port }}{{ else }}22{{ end }}/system?sshauth=ssh-password"
+}
+
+{{ range $hosts }}
+resource "libvirt_volume" "{{ .shortName }}" {
+  name             = "{{ .name }}.qcow2"
+  pool             = "{{ .pool }}"
+  base_volume_name = "{{ .imageName }}"
+  base_volume_pool = "{{ .pool }}"
+  size             = 53687091200
+}
+
+resource "libvirt_cloudinit_disk" "{{ .shortName }}" {
+  name      = "{{ .name }}-cloudinit.iso"
+  pool      = "{{ .pool }}"
+  user_data = join("\n", ["#cloud-config", "hostname: {{ .shortName }}"])
+  network_config = yamlencode({
+    version = 2
+    ethernets = {
+      eth0 = {
+        addresses   = ["{{ .ip }}/{{ .zone.netMask }}"]
+        gateway4    = "{{ .zone.gateway }}"
+        nameservers = { addresses = ["{{ .zone.dns1 }}"{{ if .zone.dns2 }}, "{{ .zone.dns2 }}"{{ end }}] }
+      }
+    }
+  })
+}
+
+resource "libvirt_domain" "{{ .shortName }}" {
+  name       = "{{ .name }}"
+  vcpu       = {{ .cpu }}
+  memory     = {{ .memory }}
+  qemu_agent = true
+  cloudinit  = libvirt_cloudinit_disk.{{ .shortName }}.id
+
+  disk {
+    volume_id = libvirt_volume.{{ .shortName }}.id
+  }
+
+  network_interface {
+{{ if .zone.bridge }}
+    bridge = "{{ .zone.bridge }}"
+{{ else }}
+    network_name = "{{ .zone.network }}"
+{{ end }}
+  }
+
+  console {
+    type        = "pty"
+    target_type = "serial"
+    target_port = "0"
+  }
+}
+{{ end }}
@@ -0,0 +1,63 @@
+{{ $provider := .provider }}
+{{ $region := .cloudRegion }}
+{{ $hosts := .hosts }}
+
+variable "username" {
+  type = string
+}
+
+variable "password" {
+  type    = string
+  default = ""
+}
+
+terraform {
+  required_providers {
+    proxmox = {
+      source  = "Telmate/proxmox"
+      version = "2.9.3"
+    }
+  }
+}
+
+provider "proxmox" {
+  pm_api_url      = "https://{{ $provider.host }}:{{ if $provider.port }}{{ $provider.port }}{{ else }}8006{{ end }}/api2/json"
+{{ if $provider.tokenId }}
+  pm_api_token_id     = "{{ $provider.username }}!{{ $provider.tokenId }}"
+  pm_api_token_secret = "{{ $provider.tokenSecret }}"
+{{ else }}
+  pm_user     = var.username
+  pm_password = var.password
+{{ end }}
+  pm_tls_insecure = true
+}
+
+{{ range $hosts }}
+resource "proxmox_vm_qemu" "{{ .shortName }}" {
+  name        = "{{ .name }}"
+  target_node = "{{ .node }}"
+  clone       = "{{ .template }}"
+  full_clone  = true
+  agent       = 1
+  os_type     = "cloud-init"
+  cores       = {{ .cpu }}
+  memory      = {{ .memory }}
+  scsihw      = "virtio-scsi-pci"
+  boot        = "order=scsi0"
+
+  disk {
+    type    = "scsi"
+    storage = "{{ .datastore }}"
+    size    = "50G"
+  }
+
+  network {
+    model  = "virtio"
+    bridge = "{{ .zone.network }}"
+  }
+
+  ipconfig0    = "ip={{ .ip }}/{{ .zone.netMask }},gw={{ .zone.gateway }}"
+  nameserver   = "{{ .zone.dns1 }}{{ if .zone.dns2 }} {{ .zone.dns2 }}{{ end }}"
+  searchdomain = "."
+}
+{{ end }}